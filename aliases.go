@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// maxAliases is how many aliases one Recurser can have.
+const maxAliases = 20
+
+// expandAlias replaces the first word of a command with the command it's an
+// alias for, keeping the rest. Only the first word is expanded, and only once:
+// checkAlias makes sure every alias stands for a built-in command, so there's
+// nothing further to expand.
+func expandAlias(cmdStr string, aliases map[string]string) string {
+	name, rest, _ := strings.Cut(strings.TrimSpace(cmdStr), " ")
+	expansion, ok := aliases[strings.ToLower(name)]
+	if !ok {
+		return cmdStr
+	}
+	if rest = strings.TrimSpace(rest); rest != "" {
+		return expansion + " " + rest
+	}
+	return expansion
+}
+
+// checkAlias returns why the alias can't be added to the existing ones, or
+// "" if it can. Aliases can't shadow a built-in command (admin commands
+// included) or replace another alias, and they have to start with a built-in
+// command, not another alias. The rest of the command can be left for later,
+// like `alias s = skip` for `s tomorrow`.
+func checkAlias(aliases map[string]string, name, expansion string) string {
+	if _, _, err := parseCmd(name); !errors.Is(err, ErrUnknownCommand) {
+		return fmt.Sprintf("`%s` is already a command, so it can't be an alias.", name)
+	}
+	if existing, ok := aliases[name]; ok {
+		return fmt.Sprintf("You already have an alias called `%s` (for `%s`). Send `unalias %s` first to replace it.", name, existing, name)
+	}
+
+	first, _, _ := strings.Cut(expansion, " ")
+	if _, ok := aliases[strings.ToLower(first)]; ok || strings.EqualFold(first, name) {
+		return "Aliases can only stand for built-in commands, not other aliases."
+	}
+	if _, _, err := parseCmd(expansion); errors.Is(err, ErrUnknownCommand) {
+		return fmt.Sprintf("`%s` isn't a command I know, so it can't be an alias. Send `help` to see what I can do.", first)
+	}
+
+	if len(aliases) >= maxAliases {
+		return fmt.Sprintf("You can only have %d aliases. Send `unalias <name>` to make room.", maxAliases)
+	}
+	return ""
+}
+
+// AddAlias saves a personal shortcut for a command, like `alias sk = skip
+// tomorrow`. Anything after the alias is added to the end of the command.
+func (pl *PairingLogic) AddAlias(ctx context.Context, rec *store.Recurser, name, expansion string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+	if reason := checkAlias(rec.Aliases, name, expansion); reason != "" {
+		return reason, nil
+	}
+
+	if rec.Aliases == nil {
+		rec.Aliases = make(map[string]string)
+	}
+	rec.Aliases[name] = expansion
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Done. Sending `%s` is the same as `%s` now. (`unalias %s` undoes this.)", name, expansion, name), nil
+}
+
+// RemoveAlias deletes one of the Recurser's aliases.
+func (pl *PairingLogic) RemoveAlias(ctx context.Context, rec *store.Recurser, name string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+	if _, ok := rec.Aliases[name]; !ok {
+		return fmt.Sprintf("You don't have an alias called `%s`.", name), nil
+	}
+
+	delete(rec.Aliases, name)
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Done. `%s` isn't an alias anymore.", name), nil
+}
+
+// ListAliases shows the Recurser their aliases, in alphabetical order.
+func (pl *PairingLogic) ListAliases(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+	if len(rec.Aliases) == 0 {
+		return "You don't have any aliases. Add one like `alias sk = skip tomorrow`.", nil
+	}
+
+	names := make([]string, 0, len(rec.Aliases))
+	for name := range rec.Aliases {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	b.WriteString("Your aliases:")
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n* `%s` = `%s`", name, rec.Aliases[name])
+	}
+	return b.String(), nil
+}
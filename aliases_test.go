@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_expandAlias(t *testing.T) {
+	aliases := map[string]string{"sk": "skip tomorrow", "s": "skip"}
+
+	for _, tc := range []struct {
+		Cmd      string
+		Expected string
+	}{
+		{"sk", "skip tomorrow"},
+		{"  SK  ", "skip tomorrow"},
+		{"s 2024-03-14", "skip 2024-03-14"},
+		{"s   3 days", "skip 3 days"},
+		{"status", "status"},
+		// Only the first word is an alias.
+		{"unskip sk", "unskip sk"},
+	} {
+		assert.Equal(t, expandAlias(tc.Cmd, aliases), tc.Expected)
+	}
+
+	assert.Equal(t, expandAlias("sk", nil), "sk")
+}
+
+func Test_checkAlias(t *testing.T) {
+	aliases := map[string]string{"sk": "skip tomorrow"}
+
+	assert.Equal(t, checkAlias(aliases, "n", "next"), "")
+	assert.Equal(t, checkAlias(aliases, "s", "skip"), "")
+
+	t.Run("conflicts", func(t *testing.T) {
+		for _, tc := range []struct {
+			Name, Expansion string
+			Reason          string
+		}{
+			{"skip", "status", "already a command"},
+			{"thank", "status", "already a command"},
+			{"maintenance", "status", "already a command"},
+			{"alias", "status", "already a command"},
+			{"sk", "skip 3 days", "already have an alias"},
+			{"s", "sk", "not other aliases"},
+			{"s", "SK now", "not other aliases"},
+			{"loop", "loop", "not other aliases"},
+			{"x", "skp tomorrow", "isn't a command"},
+		} {
+			reason := checkAlias(aliases, tc.Name, tc.Expansion)
+			if !strings.Contains(reason, tc.Reason) {
+				t.Errorf("checkAlias(%q, %q) = %q, wanted it to say %q", tc.Name, tc.Expansion, reason, tc.Reason)
+			}
+		}
+	})
+
+	t.Run("too many", func(t *testing.T) {
+		full := make(map[string]string)
+		for i := range maxAliases {
+			full[fmt.Sprintf("n%d", i)] = "next"
+		}
+		assert.Equal(t, strings.Contains(checkAlias(full, "sk", "skip tomorrow"), "only have"), true)
+	})
+}
+
+func TestPairingLogic_aliases(t *testing.T) {
+	ctx := context.Background()
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx)}
+
+	rec := &store.Recurser{ID: 1, Name: "Ada", IsSubscribed: true, Schedule: store.DefaultSchedule()}
+	assert.NoError(t, store.Recursers(pl.db).Set(ctx, rec))
+
+	_, err := pl.dispatch(ctx, "alias", []string{"sk", "skip tomorrow"}, rec)
+	assert.NoError(t, err)
+	_, err = pl.dispatch(ctx, "alias", []string{"n", "next"}, rec)
+	assert.NoError(t, err)
+
+	saved, err := store.Recursers(pl.db).GetByUserID(ctx, store.DefaultRealm, rec.ID, "", rec.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, saved.Aliases, map[string]string{"sk": "skip tomorrow", "n": "next"})
+
+	msg, err := pl.dispatch(ctx, "aliases", nil, saved)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, "Your aliases:\n* `n` = `next`\n* `sk` = `skip tomorrow`")
+
+	t.Run("removal", func(t *testing.T) {
+		_, err := pl.dispatch(ctx, "unalias", []string{"sk"}, saved)
+		assert.NoError(t, err)
+
+		saved, err := store.Recursers(pl.db).GetByUserID(ctx, store.DefaultRealm, rec.ID, "", rec.Name)
+		assert.NoError(t, err)
+		assert.Equal(t, saved.Aliases, map[string]string{"n": "next"})
+
+		msg, err := pl.dispatch(ctx, "unalias", []string{"sk"}, saved)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "You don't have an alias called `sk`.")
+	})
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// Availability shows how many other people will be matched on each of the
+// Recurser's remaining days this week, to help them pick a day to add.
+func (pl *PairingLogic) Availability(ctx context.Context, rec *store.Recurser) (string, error) {
+	now := time.Now()
+	days := rec.RestOfWeek(now)
+
+	counts, err := store.Recursers(pl.db).CountAvailable(ctx, now, days, rec.ID)
+	if err != nil {
+		return "", readError(err)
+	}
+	return formatAvailability(days, counts), nil
+}
+
+// formatAvailability lists the count (from CountAvailable) for each day, in
+// order.
+func formatAvailability(days []string, counts map[string]int) string {
+	var b strings.Builder
+	b.WriteString("Here's how many other people are pairing on each day for the rest of the week:")
+	for _, day := range days {
+		fmt.Fprintf(&b, "\n* %s: **%d**", dayName(day), counts[day])
+	}
+	b.WriteString("\n\nThis counts everyone's skips and pauses. `thisweek add <day>` adds a day for just this week.")
+	return b.String()
+}
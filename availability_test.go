@@ -0,0 +1,15 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+)
+
+func Test_formatAvailability(t *testing.T) {
+	got := formatAvailability([]string{"thursday", "friday"}, map[string]int{"thursday": 3})
+	assert.Equal(t, got, "Here's how many other people are pairing on each day for the rest of the week:\n"+
+		"* Thursday: **3**\n"+
+		"* Friday: **0**\n\n"+
+		"This counts everyone's skips and pauses. `thisweek add <day>` adds a day for just this week.")
+}
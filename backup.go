@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+	"google.golang.org/api/option"
+	"google.golang.org/api/storage/v1"
+)
+
+// A backupWriter saves the files of a backup somewhere safe.
+type backupWriter interface {
+	Write(ctx context.Context, name string, data []byte) error
+}
+
+// gcsBackupWriter writes backup files to a Cloud Storage bucket.
+type gcsBackupWriter struct {
+	objects *storage.ObjectsService
+	bucket  string
+}
+
+func newGCSBackupWriter(ctx context.Context, bucket string) (*gcsBackupWriter, error) {
+	service, err := storage.NewService(ctx, option.WithScopes(storage.DevstorageReadWriteScope))
+	if err != nil {
+		return nil, err
+	}
+	return &gcsBackupWriter{objects: service.Objects, bucket: bucket}, nil
+}
+
+func (g *gcsBackupWriter) Write(ctx context.Context, name string, data []byte) error {
+	object := &storage.Object{Name: name, ContentType: "application/json"}
+	_, err := g.objects.Insert(g.bucket, object).Media(bytes.NewReader(data)).Context(ctx).Do()
+	return err
+}
+
+// A backupCollection is one Firestore collection to back up, read through its
+// store client.
+type backupCollection struct {
+	name  string
+	fetch func(context.Context) (any, error)
+}
+
+// backupCollections are the collections that Backup saves.
+func (pl *PairingLogic) backupCollections() []backupCollection {
+	return []backupCollection{
+		{"recursers", func(ctx context.Context) (any, error) { return store.Recursers(pl.db).GetAll(ctx) }},
+		{"reviews", func(ctx context.Context) (any, error) { return store.Reviews(pl.db).GetAll(ctx) }},
+		{"pairings", func(ctx context.Context) (any, error) { return store.Pairings(pl.db).GetAllPairings(ctx) }},
+	}
+}
+
+// Backup saves a JSON copy of the main Firestore collections, so they can be
+// restored if the database is lost. Any collections that fail are reported
+// to the admin stream.
+func (pl *PairingLogic) Backup(ctx context.Context) error {
+	if pl.backups == nil {
+		logger(ctx).Warn("Skipping the backup, since PB_BACKUP_BUCKET isn't set")
+		return nil
+	}
+
+	err := writeBackup(ctx, pl.backups, time.Now(), pl.backupCollections())
+	if err != nil {
+		message := fmt.Sprintf("The nightly backup didn't finish:\n```\n%s\n```", err)
+		if err := pl.zulip.PostToTopic(ctx, pl.adminStream, "backup failures", message); err != nil {
+			logger(ctx).Error("Could not report the backup failure", slog.Any("error", err))
+		}
+	}
+	return err
+}
+
+// writeBackup writes each collection to its own file, named like
+// "2024-03-14T040000Z/recursers.json". It keeps going after a failure, so
+// one bad collection doesn't stop the others from being saved, and returns
+// every failure.
+func writeBackup(ctx context.Context, w backupWriter, now time.Time, collections []backupCollection) error {
+	prefix := now.UTC().Format("2006-01-02T150405Z")
+
+	var errs []error
+	for _, c := range collections {
+		name := prefix + "/" + c.name + ".json"
+		if err := backupOne(ctx, w, name, c.fetch); err != nil {
+			logger(ctx).Error("Could not back up a collection", slog.String("collection", c.name), slog.Any("error", err))
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+			continue
+		}
+		logger(ctx).Info("Backed up a collection", slog.String("collection", c.name), slog.String("file", name))
+	}
+	return errors.Join(errs...)
+}
+
+func backupOne(ctx context.Context, w backupWriter, name string, fetch func(context.Context) (any, error)) error {
+	docs, err := fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+
+	data, err := json.Marshal(docs)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	if err := w.Write(ctx, name, data); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// fakeBackupWriter keeps backup files in memory.
+type fakeBackupWriter struct {
+	files map[string][]byte
+	err   error
+}
+
+func (f *fakeBackupWriter) Write(ctx context.Context, name string, data []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.files == nil {
+		f.files = make(map[string][]byte)
+	}
+	f.files[name] = data
+	return nil
+}
+
+func Test_writeBackup(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.March, 14, 4, 0, 0, 0, time.UTC)
+
+	collections := []backupCollection{
+		{"recursers", func(context.Context) (any, error) {
+			return []store.Recurser{{ID: 1, Name: "Ada"}}, nil
+		}},
+		{"reviews", func(context.Context) (any, error) {
+			return []store.Review{{Content: "Love it", Timestamp: 100}}, nil
+		}},
+		{"pairings", func(context.Context) (any, error) {
+			return []store.Pairing{{Value: 3, Timestamp: 200, NumRecursers: 6}}, nil
+		}},
+	}
+
+	w := &fakeBackupWriter{}
+	assert.NoError(t, writeBackup(ctx, w, now, collections))
+	assert.Equal(t, len(w.files), 3)
+
+	var recursers []store.Recurser
+	assert.NoError(t, json.Unmarshal(w.files["2024-03-14T040000Z/recursers.json"], &recursers))
+	assert.Equal(t, recursers, []store.Recurser{{ID: 1, Name: "Ada"}})
+
+	var reviews []store.Review
+	assert.NoError(t, json.Unmarshal(w.files["2024-03-14T040000Z/reviews.json"], &reviews))
+	assert.Equal(t, reviews, []store.Review{{Content: "Love it", Timestamp: 100}})
+
+	var pairings []store.Pairing
+	assert.NoError(t, json.Unmarshal(w.files["2024-03-14T040000Z/pairings.json"], &pairings))
+	assert.Equal(t, pairings, []store.Pairing{{Value: 3, Timestamp: 200, NumRecursers: 6}})
+
+	t.Run("read failure", func(t *testing.T) {
+		failing := slices.Clone(collections)
+		failing[1].fetch = func(context.Context) (any, error) {
+			return nil, errors.New("unavailable")
+		}
+
+		w := &fakeBackupWriter{}
+		err := writeBackup(ctx, w, now, failing)
+		if err == nil || !strings.Contains(err.Error(), "reviews: read: unavailable") {
+			t.Errorf("got error %v, wanted the reviews to fail", err)
+		}
+
+		// The other collections are still saved.
+		assert.Equal(t, len(w.files), 2)
+	})
+
+	t.Run("write failure", func(t *testing.T) {
+		w := &fakeBackupWriter{err: errors.New("bucket not found")}
+		err := writeBackup(ctx, w, now, collections)
+		for _, name := range []string{"recursers", "reviews", "pairings"} {
+			if err == nil || !strings.Contains(err.Error(), name+": write") {
+				t.Errorf("got error %v, wanted %s to fail", err, name)
+			}
+		}
+	})
+}
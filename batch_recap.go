@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// batchRecapWindow is how far back the end-of-batch recap looks. It's as long
+// as a full batch, so it covers everyone who's leaving, including half-batchers
+// (who just weren't around for the first half).
+const batchRecapWindow = 12 * 7 * 24 * time.Hour
+
+// A batchRecap sums up a Recurser's pairing over their batch.
+type batchRecap struct {
+	// Pairings is how many times they were matched.
+	Pairings int
+
+	// Partners is how many different people they were matched with.
+	Partners int
+}
+
+// recapBatch counts rec's matches and the different partners in them.
+func recapBatch(rec *store.Recurser, matches []store.Match) batchRecap {
+	partners := make(map[int64]bool)
+	for _, match := range matches {
+		for _, id := range match.Recursers {
+			// Partners who deleted their matches are all 0, so they can't be
+			// told apart.
+			if id != rec.ID && id != 0 {
+				partners[id] = true
+			}
+		}
+	}
+	return batchRecap{Pairings: len(matches), Partners: len(partners)}
+}
+
+// batchRecapFor looks up rec's matches from the batch that's ending at `now`
+// and sums them up.
+func (pl *PairingLogic) batchRecapFor(ctx context.Context, rec *store.Recurser, now time.Time) (batchRecap, error) {
+	matches, err := store.Pairings(pl.db).GetMatchesForBetween(ctx, rec.Realm, rec.ID, now.Add(-batchRecapWindow), now)
+	if err != nil {
+		return batchRecap{}, err
+	}
+	return recapBatch(rec, matches), nil
+}
+
+// sendBatchRecap thanks a Recurser who's leaving at the end of their batch
+// for pairing, with how much they paired. Anyone who never got matched gets a
+// note saying they're welcome back instead.
+func (pl *PairingLogic) sendBatchRecap(ctx context.Context, rec *store.Recurser, now time.Time) {
+	recLog := logger(ctx).With(slog.Int64("recurserId", rec.ID))
+
+	recap, err := pl.batchRecapFor(ctx, rec, now)
+	if err != nil {
+		recLog.Error("Could not count matches for the batch recap", slog.Any("error", err))
+		return
+	}
+
+	message, err := renderBatchRecap(recap)
+	if err != nil {
+		recLog.Error("Could not render the batch recap", slog.Any("error", err))
+		return
+	}
+
+	if err := pl.zulipFor(rec.Realm).SendUserMessage(ctx, []int64{rec.ID}, message); err != nil {
+		recLog.Error("Could not send the batch recap", slog.Any("error", err))
+		return
+	}
+	recLog.Info("Sent the batch recap", slog.Int("pairings", recap.Pairings), slog.Int("partners", recap.Partners))
+}
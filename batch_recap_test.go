@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_recapBatch(t *testing.T) {
+	rec := &store.Recurser{ID: 1}
+
+	assert.Equal(t, recapBatch(rec, []store.Match{
+		{Recursers: []int64{1, 2}},
+		{Recursers: []int64{3, 1}},
+		{Recursers: []int64{1, 2, 4}},
+		{Recursers: []int64{1, 0}},
+	}), batchRecap{Pairings: 4, Partners: 3})
+
+	assert.Equal(t, recapBatch(rec, nil), batchRecap{})
+}
+
+func Test_renderBatchRecap(t *testing.T) {
+	recap, err := renderBatchRecap(batchRecap{Pairings: 5, Partners: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Contains(recap, "You paired 5 times, with 1 different Recurser."), true)
+
+	recap, err = renderBatchRecap(batchRecap{})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Contains(recap, "You didn't get matched"), true)
+}
+
+func TestPairingLogic_batchRecapFor(t *testing.T) {
+	ctx := context.Background()
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx)}
+
+	id := pbtest.RandInt64(t)
+	rec := &store.Recurser{ID: id}
+	now := time.Now()
+	daysAgo := func(n int) time.Time { return now.AddDate(0, 0, -n) }
+
+	pairings := store.Pairings(pl.db)
+	for _, match := range []store.Match{
+		{Recursers: []int64{id, id + 1}, Timestamp: daysAgo(1).Unix()},
+		{Recursers: []int64{id + 1, id}, Timestamp: daysAgo(20).Unix()},
+		{Recursers: []int64{id, id + 2, id + 3}, Timestamp: daysAgo(60).Unix()},
+		// Before the batch.
+		{Recursers: []int64{id, id + 4}, Timestamp: daysAgo(100).Unix()},
+		// Someone else's match.
+		{Recursers: []int64{id + 5, id + 6}, Timestamp: daysAgo(2).Unix()},
+	} {
+		assert.NoError(t, pairings.AddMatch(ctx, match))
+	}
+
+	recap, err := pl.batchRecapFor(ctx, rec, now)
+	assert.NoError(t, err)
+	assert.Equal(t, recap, batchRecap{Pairings: 3, Partners: 3})
+
+	t.Run("never matched", func(t *testing.T) {
+		recap, err := pl.batchRecapFor(ctx, &store.Recurser{ID: id + 7}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, recap, batchRecap{})
+	})
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// Blind intros are a community-wide setting (see store.BlindIntroConfig). Pairs
+// get a match message that doesn't say who their partner is, and are introduced
+// once they've both sent Pairing Bot a hello, or after blindIntroTimeout,
+// whichever comes first.
+
+// blindIntroTimeout is how long a blind pair has to both say hi, starting from
+// when they're told they've been matched. After that, they're introduced
+// anyway.
+const blindIntroTimeout = 3 * time.Hour
+
+// blindIntrosOn returns whether pairs are matched blind. If the setting can't
+// be read, they aren't.
+func (pl *PairingLogic) blindIntrosOn(ctx context.Context) bool {
+	config, err := store.BlindIntroConfigs(pl.db).Get(ctx)
+	if err != nil {
+		logger(ctx).Warn("Could not get the blind intro setting, so introducing pairs right away", slog.Any("error", err))
+		return false
+	}
+	return config.On
+}
+
+// holdForBlindIntro saves the pair's match message as a BlindIntro, to send
+// once they've both said hi. It returns whether it did, in which case they
+// should each get blindIntroMessage instead. Groups of three always get the
+// usual message.
+func (pl *PairingLogic) holdForBlindIntro(ctx context.Context, group []store.Recurser, sendAt time.Time, message string) bool {
+	if len(group) != 2 {
+		return false
+	}
+
+	intro := store.BlindIntro{
+		Content:  message,
+		SendAt:   sendAt.Unix(),
+		RevealAt: sendAt.Add(blindIntroTimeout).Unix(),
+		Realm:    group[0].Realm,
+	}
+	for _, rec := range group {
+		intro.Recursers = append(intro.Recursers, rec.ID)
+	}
+
+	if err := store.BlindIntros(pl.db).Add(ctx, intro); err != nil {
+		logger(ctx).Error("Could not save the blind intro, so introducing the pair now", slog.Any("recurserIds", intro.Recursers), slog.Any("error", err))
+		return false
+	}
+	return true
+}
+
+// blindIntroHello treats the message as the Recurser's hello, if they're in a
+// blind pair and haven't said hi yet. It returns the reply, and whether the
+// message was a hello. Once both of the pair have said hi, they're introduced.
+func (pl *PairingLogic) blindIntroHello(ctx context.Context, rec *store.Recurser, message string) (string, bool) {
+	intro, err := store.BlindIntros(pl.db).GetFor(ctx, rec.Realm, rec.ID, time.Now())
+	if err != nil {
+		logger(ctx).Warn("Could not look up the Recurser's blind intro", slog.Any("error", err))
+		return "", false
+	}
+	if intro == nil {
+		return "", false
+	}
+	if intro.Hello(rec.ID) != "" {
+		return fmt.Sprintf("I've already got your hello! I'll introduce you as soon as your partner says hi too, or at %s at the latest.", time.Unix(intro.RevealAt, 0).In(rec.Location()).Format("15:04")), true
+	}
+
+	intro, err = store.BlindIntros(pl.db).SayHello(ctx, intro.ID, rec.ID, strings.TrimSpace(message))
+	if err != nil {
+		logger(ctx).Error("Could not record the Recurser's hello", slog.Any("error", err))
+		return writeErrorMessage, true
+	}
+	if !intro.AllSaidHello() {
+		return "Thanks! I'll pass that along once your partner says hi too.", true
+	}
+
+	if !pl.revealBlindIntro(ctx, intro) {
+		return "Thanks! You've both said hi, but I couldn't introduce you just now. I'll keep trying.", true
+	}
+	return "You've both said hi, so I've introduced you. Have fun pairing :)", true
+}
+
+// RevealBlindIntros introduces the blind pairs who didn't both say hi in time.
+func (pl *PairingLogic) RevealBlindIntros(ctx context.Context) error {
+	due, err := store.BlindIntros(pl.db).ListDue(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("get due blind intros from DB: %w", err)
+	}
+
+	for i := range due {
+		pl.revealBlindIntro(ctx, &due[i])
+	}
+
+	logger(ctx).Info("Revealed blind intros", slog.Int("count", len(due)))
+	return nil
+}
+
+// revealBlindIntro sends the pair their match message, along with the hellos
+// they sent, unless someone else already has. It returns false if the message
+// couldn't be sent, in which case the intro is put back for the reveal job to
+// try again.
+func (pl *PairingLogic) revealBlindIntro(ctx context.Context, intro *store.BlindIntro) bool {
+	groupLog := logger(ctx).With(slog.Any("recurserIds", intro.Recursers))
+
+	claimed, err := store.BlindIntros(pl.db).Claim(ctx, intro.ID)
+	if err != nil {
+		groupLog.Error("Could not claim the blind intro", slog.Any("error", err))
+		return false
+	}
+	if !claimed {
+		return true
+	}
+
+	if err := pl.zulipFor(intro.Realm).SendUserMessage(ctx, intro.Recursers, revealMessage(intro)); err != nil {
+		groupLog.Error("Could not introduce the blind pair", slog.Any("error", err))
+		if err := store.BlindIntros(pl.db).Unclaim(ctx, *intro); err != nil {
+			groupLog.Error("Could not put back the blind intro, so the pair won't be introduced", slog.Any("error", err))
+		}
+		return false
+	}
+	groupLog.Info("Introduced a blind pair", slog.Bool("allSaidHello", intro.AllSaidHello()))
+	return true
+}
+
+// revealMessage is the match message for a blind pair, followed by the hellos
+// they sent before they knew who they were talking to.
+func revealMessage(intro *store.BlindIntro) string {
+	var b strings.Builder
+	b.WriteString(intro.Content)
+	b.WriteString("\n\n:see_no_evil: **Before you knew who you were talking to, you said:**")
+	for _, id := range intro.Recursers {
+		hello := intro.Hello(id)
+		if hello == "" {
+			hello = "*(didn't say hi in time)*"
+		}
+		fmt.Fprintf(&b, "\n* @_**|%d**: %s", id, hello)
+	}
+	return b.String()
+}
+
+// BlindIntrosStatus shows whether pairs are matched blind.
+func (pl *PairingLogic) BlindIntrosStatus(ctx context.Context, rec *store.Recurser) (string, error) {
+	config, err := store.BlindIntroConfigs(pl.db).Get(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+
+	var changed string
+	if config.UpdatedBy != 0 {
+		changed = fmt.Sprintf(" (since @_**|%d** changed it at %s)", config.UpdatedBy, time.Unix(config.Timestamp, 0).In(rec.Location()).Format("2006-01-02 15:04"))
+	}
+
+	if config.On {
+		return fmt.Sprintf("Blind intros are **on**%s, so pairs find out who they're matched with once they've both said hi. Send `blindintros off` to stop.", changed), nil
+	}
+	return fmt.Sprintf("Blind intros are **off**%s, so match messages say who's in the match. Send `blindintros on` to start.", changed), nil
+}
+
+// SetBlindIntros turns blind intros on or off. Pairs who are already waiting
+// to be introduced still are.
+func (pl *PairingLogic) SetBlindIntros(ctx context.Context, rec *store.Recurser, on bool) (string, error) {
+	err := store.BlindIntroConfigs(pl.db).Set(ctx, store.BlindIntroConfig{
+		On:        on,
+		UpdatedBy: rec.ID,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		return "", writeError(err)
+	}
+
+	if on {
+		return fmt.Sprintf("Blind intros are on. From the next match run, pairs will find out who they're matched with once they've both said hi (or after %d hours).", int(blindIntroTimeout.Hours())), nil
+	}
+	return "Blind intros are off. From the next match run, match messages will say who's in the match again.", nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_revealMessage(t *testing.T) {
+	t.Run("both said hi", func(t *testing.T) {
+		intro := store.BlindIntro{
+			Recursers: []int64{1, 2},
+			Content:   "Hi you two!",
+			Hellos:    map[string]string{"1": "hi!", "2": "hello :wave:"},
+		}
+		assert.Equal(t, revealMessage(&intro), "Hi you two!\n\n"+
+			":see_no_evil: **Before you knew who you were talking to, you said:**\n"+
+			"* @_**|1**: hi!\n"+
+			"* @_**|2**: hello :wave:")
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		intro := store.BlindIntro{
+			Recursers: []int64{1, 2},
+			Content:   "Hi you two!",
+			Hellos:    map[string]string{"2": "hello"},
+		}
+		assert.Equal(t, strings.HasSuffix(revealMessage(&intro), "\n"+
+			"* @_**|1**: *(didn't say hi in time)*\n"+
+			"* @_**|2**: hello"), true)
+	})
+}
+
+func TestPairingLogic_blindIntros(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*PairingLogic, *pbtest.FakeZulip) {
+		fake := pbtest.NewFakeZulip(t)
+		return &PairingLogic{db: pbtest.FirestoreClient(t, ctx), zulip: fake.Client}, fake
+	}
+
+	ada := store.Recurser{ID: 1, Name: "Ada", IsSubscribed: true}
+	grace := store.Recurser{ID: 2, Name: "Grace", IsSubscribed: true}
+	pair := []store.Recurser{ada, grace}
+
+	t.Run("off by default", func(t *testing.T) {
+		pl, _ := setup(t)
+		assert.Equal(t, pl.blindIntrosOn(ctx), false)
+
+		_, err := pl.SetBlindIntros(ctx, &ada, true)
+		assert.NoError(t, err)
+		assert.Equal(t, pl.blindIntrosOn(ctx), true)
+	})
+
+	t.Run("only pairs", func(t *testing.T) {
+		pl, _ := setup(t)
+
+		group := []store.Recurser{ada, grace, {ID: 3, Name: "Alan"}}
+		assert.Equal(t, pl.holdForBlindIntro(ctx, group, time.Now(), "Hi all!"), false)
+	})
+
+	t.Run("reveal after both say hi", func(t *testing.T) {
+		pl, fake := setup(t)
+
+		assert.Equal(t, pl.holdForBlindIntro(ctx, pair, time.Now(), "Hi you two!"), true)
+
+		msg, ok := pl.blindIntroHello(ctx, &ada, "hi there!")
+		assert.Equal(t, ok, true)
+		assert.Equal(t, msg, "Thanks! I'll pass that along once your partner says hi too.")
+		assert.Equal(t, len(fake.DMs("[1,2]")), 0)
+
+		// Only the first message is a hello.
+		msg, ok = pl.blindIntroHello(ctx, &ada, "still there?")
+		assert.Equal(t, ok, true)
+		assert.Equal(t, strings.HasPrefix(msg, "I've already got your hello!"), true)
+
+		msg, ok = pl.blindIntroHello(ctx, &grace, "hello")
+		assert.Equal(t, ok, true)
+		assert.Equal(t, msg, "You've both said hi, so I've introduced you. Have fun pairing :)")
+
+		assert.Equal(t, len(fake.DMs("[1,2]")), 1)
+		assert.Equal(t, strings.HasPrefix(fake.DMs("[1,2]")[0], "Hi you two!"), true)
+		assert.Equal(t, strings.Contains(fake.DMs("[1,2]")[0], "* @_**|1**: hi there!"), true)
+
+		// Once they're introduced, messages aren't hellos anymore.
+		_, ok = pl.blindIntroHello(ctx, &ada, "thanks")
+		assert.Equal(t, ok, false)
+
+		// The reveal job doesn't introduce them again.
+		assert.NoError(t, pl.RevealBlindIntros(ctx))
+		assert.Equal(t, len(fake.DMs("[1,2]")), 1)
+	})
+
+	t.Run("reveal anyway after the timeout", func(t *testing.T) {
+		pl, fake := setup(t)
+
+		// They were told about the match long enough ago that it's time.
+		sendAt := time.Now().Add(-blindIntroTimeout - time.Minute)
+		assert.Equal(t, pl.holdForBlindIntro(ctx, pair, sendAt, "Hi you two!"), true)
+
+		_, ok := pl.blindIntroHello(ctx, &grace, "hello")
+		assert.Equal(t, ok, true)
+
+		assert.NoError(t, pl.RevealBlindIntros(ctx))
+		assert.Equal(t, len(fake.DMs("[1,2]")), 1)
+		assert.Equal(t, strings.Contains(fake.DMs("[1,2]")[0], "* @_**|1**: *(didn't say hi in time)*"), true)
+		assert.Equal(t, strings.Contains(fake.DMs("[1,2]")[0], "* @_**|2**: hello"), true)
+
+		// It's only revealed once.
+		assert.NoError(t, pl.RevealBlindIntros(ctx))
+		assert.Equal(t, len(fake.DMs("[1,2]")), 1)
+	})
+
+	t.Run("tried again if Zulip fails", func(t *testing.T) {
+		pl, fake := setup(t)
+		fake.Status = func(msg pbtest.ZulipMessage) int {
+			if msg.To == "[1,2]" {
+				return http.StatusInternalServerError
+			}
+			return 0
+		}
+
+		// Old enough that the reveal job picks it up too.
+		sendAt := time.Now().Add(-blindIntroTimeout - time.Minute)
+		assert.Equal(t, pl.holdForBlindIntro(ctx, pair, sendAt, "Hi you two!"), true)
+		_, ok := pl.blindIntroHello(ctx, &ada, "hi there!")
+		assert.Equal(t, ok, true)
+		msg, ok := pl.blindIntroHello(ctx, &grace, "hello")
+		assert.Equal(t, ok, true)
+		assert.Equal(t, msg, "Thanks! You've both said hi, but I couldn't introduce you just now. I'll keep trying.")
+
+		// The intro is still there, hellos and all.
+		due, err := store.BlindIntros(pl.db).ListDue(ctx, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, len(due), 1)
+		assert.Equal(t, due[0].AllSaidHello(), true)
+
+		// Once Zulip is back, the reveal job introduces them.
+		fake.Status = nil
+		assert.NoError(t, pl.RevealBlindIntros(ctx))
+		dms := fake.DMs("[1,2]")
+		assert.Equal(t, len(dms), 2)
+		assert.Equal(t, strings.Contains(dms[1], "* @_**|1**: hi there!"), true)
+
+		due, err = store.BlindIntros(pl.db).ListDue(ctx, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, len(due), 0)
+	})
+
+	t.Run("not before the teaser goes out", func(t *testing.T) {
+		pl, _ := setup(t)
+
+		assert.Equal(t, pl.holdForBlindIntro(ctx, pair, time.Now().Add(time.Hour), "Hi you two!"), true)
+
+		_, ok := pl.blindIntroHello(ctx, &ada, "hi")
+		assert.Equal(t, ok, false)
+	})
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// resolveRecurser finds the person a command like `block` or `met` is about.
+// If the command included a Zulip user ID, that's used as-is. Otherwise the
+// name is looked up among subscribers in rec's realm, then in the RC directory
+// (which only covers the default realm). The reply is set (and the block is
+// empty) if there's no single match for the name.
+func (pl *PairingLogic) resolveRecurser(ctx context.Context, rec *store.Recurser, command string, args []string) (store.Block, string, error) {
+	name := args[0]
+	if len(args) > 1 {
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return store.Block{}, "", err
+		}
+		return store.Block{ID: id, Name: name}, "", nil
+	}
+
+	subscribers, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
+	if err != nil {
+		return store.Block{}, "", readError(err)
+	}
+
+	var found []store.Block
+	for _, sub := range subscribers {
+		if sub.Realm == rec.Realm && strings.EqualFold(sub.Name, name) {
+			found = append(found, store.Block{ID: sub.ID, Name: sub.Name})
+		}
+	}
+
+	// They might have blocked someone before that person subscribed.
+	if len(found) == 0 && rec.Realm == store.DefaultRealm && pl.recurse != nil {
+		profiles, err := pl.recurse.ActiveRecursers(ctx)
+		if err != nil {
+			logger(ctx).Warn("Could not look up the name in the RC directory", slog.Any("error", err))
+		}
+		for _, profile := range profiles {
+			if profile.ZulipID != 0 && strings.EqualFold(profile.Name, name) {
+				found = append(found, store.Block{ID: profile.ZulipID, Name: profile.Name})
+			}
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return store.Block{}, fmt.Sprintf("I couldn't find anyone called %q. Try mentioning them, like `%s @**%s**`.", name, command, name), nil
+	case 1:
+		return found[0], "", nil
+	default:
+		return store.Block{}, fmt.Sprintf("There's more than one %s! Mention the one you mean, picking them from Zulip's suggestions.", name), nil
+	}
+}
+
+// Block makes sure the Recurser is never matched with someone, whether in
+// daily matching or `match now`. The other person isn't told.
+func (pl *PairingLogic) Block(ctx context.Context, rec *store.Recurser, args []string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	block, reply, err := pl.resolveRecurser(ctx, rec, "block", args)
+	if reply != "" || err != nil {
+		return reply, err
+	}
+
+	if block.ID == rec.ID {
+		return "You can't block yourself!", nil
+	}
+	if rec.HasBlocked(block.ID) {
+		return fmt.Sprintf("You've already blocked %s.", block.Name), nil
+	}
+
+	rec.Blocks = append(rec.Blocks, block)
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Done. You'll never be matched with %s, and they won't be told. (`unblock` undoes this.)", block.Name), nil
+}
+
+// Unblock undoes a Block. The person can be given by name or mention, so it
+// only needs to match someone the Recurser has already blocked.
+func (pl *PairingLogic) Unblock(ctx context.Context, rec *store.Recurser, args []string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	i := slices.IndexFunc(rec.Blocks, func(b store.Block) bool {
+		if len(args) > 1 {
+			return strconv.FormatInt(b.ID, 10) == args[1]
+		}
+		return strings.EqualFold(b.Name, args[0])
+	})
+	if i < 0 {
+		return fmt.Sprintf("You haven't blocked %s.", args[0]), nil
+	}
+
+	name := rec.Blocks[i].Name
+	rec.Blocks = slices.Delete(rec.Blocks, i, i+1)
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Done. You can be matched with %s again.", name), nil
+}
+
+// ListBlocks shows the Recurser who they've blocked.
+func (pl *PairingLogic) ListBlocks(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+	if len(rec.Blocks) == 0 {
+		return "You haven't blocked anyone.", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("You've blocked:")
+	for _, block := range rec.Blocks {
+		fmt.Fprintf(&b, "\n* %s", block.Name)
+	}
+	return b.String(), nil
+}
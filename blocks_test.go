@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/recurse"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestPairingLogic_resolveRecurser(t *testing.T) {
+	ctx := context.Background()
+	rc := &pbtest.FakeRecurse{
+		Active: []recurse.Profile{{Name: "Grace", ZulipID: 2}},
+	}
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx), recurse: rc}
+
+	for _, rec := range []store.Recurser{
+		{ID: 1, Name: "Ada", IsSubscribed: true},
+		{ID: 3, Name: "Alan", IsSubscribed: true, Realm: "sister"},
+	} {
+		assert.NoError(t, store.Recursers(pl.db).Set(ctx, &rec))
+	}
+
+	here := &store.Recurser{ID: 10}
+	sister := &store.Recurser{ID: 10, Realm: "sister"}
+
+	t.Run("subscriber", func(t *testing.T) {
+		block, reply, err := pl.resolveRecurser(ctx, here, "block", []string{"ada"})
+		assert.NoError(t, err)
+		assert.Equal(t, reply, "")
+		assert.Equal(t, block, store.Block{ID: 1, Name: "Ada"})
+	})
+
+	t.Run("RC directory", func(t *testing.T) {
+		block, reply, err := pl.resolveRecurser(ctx, here, "block", []string{"Grace"})
+		assert.NoError(t, err)
+		assert.Equal(t, reply, "")
+		assert.Equal(t, block, store.Block{ID: 2, Name: "Grace"})
+	})
+
+	t.Run("only the same realm", func(t *testing.T) {
+		_, reply, err := pl.resolveRecurser(ctx, here, "block", []string{"Alan"})
+		assert.NoError(t, err)
+		assert.Equal(t, reply, `I couldn't find anyone called "Alan". Try mentioning them, like `+"`block @**Alan**`.")
+
+		_, reply, err = pl.resolveRecurser(ctx, sister, "block", []string{"Ada"})
+		assert.NoError(t, err)
+		assert.Equal(t, reply, `I couldn't find anyone called "Ada". Try mentioning them, like `+"`block @**Ada**`.")
+
+		block, _, err := pl.resolveRecurser(ctx, sister, "block", []string{"Alan"})
+		assert.NoError(t, err)
+		assert.Equal(t, block, store.Block{ID: 3, Name: "Alan"})
+	})
+
+	t.Run("no RC directory for other realms", func(t *testing.T) {
+		_, reply, err := pl.resolveRecurser(ctx, sister, "block", []string{"Grace"})
+		assert.NoError(t, err)
+		assert.Equal(t, reply, `I couldn't find anyone called "Grace". Try mentioning them, like `+"`block @**Grace**`.")
+	})
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// An issueFiler files bug reports somewhere the maintainers will see them.
+type issueFiler interface {
+	// FileIssue creates an issue and returns a link to it.
+	FileIssue(ctx context.Context, title, body string) (string, error)
+}
+
+// githubIssueFiler creates issues in a GitHub repository.
+type githubIssueFiler struct {
+	http *http.Client
+
+	// baseURL is the GitHub API's URL, without a trailing slash.
+	baseURL string
+
+	// repo is the repository's "owner/name".
+	repo  string
+	token TokenFunc
+}
+
+func (g *githubIssueFiler) FileIssue(ctx context.Context, title, body string) (string, error) {
+	token, err := g.token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get GitHub token: %w", err)
+	}
+
+	payload, err := json.Marshal(struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}{title, body})
+	if err != nil {
+		return "", err
+	}
+
+	// https://docs.github.com/en/rest/issues/issues#create-an-issue
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+"/repos/"+g.repo+"/issues", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("create GitHub issue: %s: %s", resp.Status, msg)
+	}
+
+	var issue struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return "", fmt.Errorf("parse created GitHub issue: %w", err)
+	}
+	return issue.HTMLURL, nil
+}
+
+// maxIssueTitle is the most characters of a bug report's first line that go
+// in its issue's title.
+const maxIssueTitle = 72
+
+// bugIssue returns the title and body of the issue for a bug report. It
+// doesn't say who reported it, since the issue is public.
+func bugIssue(report string) (string, string) {
+	title, _, _ := strings.Cut(report, "\n")
+	title = strings.TrimSpace(title)
+	if runes := []rune(title); len(runes) > maxIssueTitle {
+		title = string(runes[:maxIssueTitle-1]) + "…"
+	}
+	return title, report + "\n\n*Reported through Pairing Bot.*"
+}
+
+// ReportBug files the Recurser's bug report as a GitHub issue. If that isn't
+// configured, or it doesn't work, the report is saved as a #bug review
+// instead, so it isn't lost.
+func (pl *PairingLogic) ReportBug(ctx context.Context, rec *store.Recurser, report string) (string, error) {
+	if pl.issues != nil {
+		title, body := bugIssue(report)
+		url, err := pl.issues.FileIssue(ctx, title, body)
+		if err == nil {
+			return fmt.Sprintf("Thanks for the report! I've filed it as %s, where you can follow along.", url), nil
+		}
+		logger(ctx).Error("Could not file a bug report as an issue, so saving it as a review", slog.Any("error", err))
+	}
+
+	err := store.Reviews(pl.db).Insert(ctx, store.Review{
+		Content:   report,
+		Email:     rec.Email,
+		Timestamp: time.Now().Unix(),
+		Category:  "bug",
+	})
+	if err != nil {
+		return "", writeError(err)
+	}
+	return "Thanks for the report! I've passed it along to the maintainers.", nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_githubIssueFiler(t *testing.T) {
+	ctx := context.Background()
+
+	var method, path, auth string
+	var payload map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, auth = r.Method, r.URL.Path, r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"number": 42, "html_url": "https://github.example.net/recursecenter/pairing-bot/issues/42"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	filer := &githubIssueFiler{
+		http:    srv.Client(),
+		baseURL: srv.URL,
+		repo:    "recursecenter/pairing-bot",
+		token:   func(context.Context) (string, error) { return "fake-token", nil },
+	}
+
+	title, body := bugIssue("It matched me with myself\nTwice, even.")
+	url, err := filer.FileIssue(ctx, title, body)
+	assert.NoError(t, err)
+	assert.Equal(t, url, "https://github.example.net/recursecenter/pairing-bot/issues/42")
+
+	assert.Equal(t, method, http.MethodPost)
+	assert.Equal(t, path, "/repos/recursecenter/pairing-bot/issues")
+	assert.Equal(t, auth, "Bearer fake-token")
+	assert.Equal(t, payload, map[string]string{
+		"title": "It matched me with myself",
+		"body":  "It matched me with myself\nTwice, even.\n\n*Reported through Pairing Bot.*",
+	})
+
+	t.Run("error", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+		}))
+		t.Cleanup(srv.Close)
+
+		filer := *filer
+		filer.http, filer.baseURL = srv.Client(), srv.URL
+		_, err := filer.FileIssue(ctx, "title", "body")
+		assert.Equal(t, err != nil && strings.Contains(err.Error(), "Bad credentials"), true)
+	})
+}
+
+func Test_bugIssue(t *testing.T) {
+	long := strings.Repeat("a", 100)
+	title, _ := bugIssue(long)
+	assert.Equal(t, len([]rune(title)), maxIssueTitle)
+	assert.Equal(t, strings.HasSuffix(title, "…"), true)
+}
+
+// fakeIssueFiler records the issues it's asked to file.
+type fakeIssueFiler struct {
+	titles []string
+	err    error
+}
+
+func (f *fakeIssueFiler) FileIssue(_ context.Context, title, body string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.titles = append(f.titles, title)
+	return "https://github.example.net/issues/1", nil
+}
+
+func TestPairingLogic_ReportBug(t *testing.T) {
+	ctx := context.Background()
+	rec := &store.Recurser{ID: 1, Email: "ada@recurse.example.net"}
+
+	t.Run("filed", func(t *testing.T) {
+		issues := &fakeIssueFiler{}
+		pl := &PairingLogic{issues: issues}
+
+		msg, err := pl.ReportBug(ctx, rec, "It matched me with myself")
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Contains(msg, "https://github.example.net/issues/1"), true)
+		assert.Equal(t, issues.titles, []string{"It matched me with myself"})
+	})
+
+	t.Run("saved as a review when GitHub fails", func(t *testing.T) {
+		db := pbtest.FirestoreClient(t, ctx)
+		pl := &PairingLogic{db: db, issues: &fakeIssueFiler{err: errors.New("GitHub is down")}}
+
+		msg, err := pl.ReportBug(ctx, rec, "It matched me with myself")
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Thanks for the report! I've passed it along to the maintainers.")
+
+		reviews, err := store.Reviews(db).GetAll(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, len(reviews), 1)
+		assert.Equal(t, reviews[0].Content, "It matched me with myself")
+		assert.Equal(t, reviews[0].Category, "bug")
+	})
+}
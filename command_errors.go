@@ -0,0 +1,42 @@
+package main
+
+import "errors"
+
+// A commandError is a command that failed. Reply is what the Recurser is told,
+// and Err is the detail that only goes in the logs.
+type commandError struct {
+	Reply string
+	Err   error
+}
+
+func (e *commandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *commandError) Unwrap() error {
+	return e.Err
+}
+
+// readError is a commandError for a command that couldn't read what it needed.
+func readError(err error) error {
+	return &commandError{Reply: readErrorMessage, Err: err}
+}
+
+// writeError is a commandError for a command that couldn't save its changes.
+func writeError(err error) error {
+	return &commandError{Reply: writeErrorMessage, Err: err}
+}
+
+// replyFor returns what to tell the Recurser after a command returned response
+// and err. A commandError's reply wins. Any other error keeps the response, or
+// gets the read error reply if there isn't one, so they always hear something.
+func replyFor(response string, err error) string {
+	var cmdErr *commandError
+	switch {
+	case errors.As(err, &cmdErr):
+		return cmdErr.Reply
+	case err != nil && response == "":
+		return readErrorMessage
+	}
+	return response
+}
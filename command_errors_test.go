@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+)
+
+func Test_replyFor(t *testing.T) {
+	detail := errors.New("rpc error: code = Unavailable desc = connection refused")
+
+	for name, tc := range map[string]struct {
+		response string
+		err      error
+		reply    string
+	}{
+		"success":          {"Done!", nil, "Done!"},
+		"read error":       {"", readError(detail), readErrorMessage},
+		"write error":      {"", writeError(detail), writeErrorMessage},
+		"custom reply":     {"", &commandError{Reply: "Sorry, I couldn't do that.", Err: detail}, "Sorry, I couldn't do that."},
+		"wrapped":          {"", fmt.Errorf("subscribe: %w", writeError(detail)), writeErrorMessage},
+		"plain error":      {"It half worked.", detail, "It half worked."},
+		"plain, no answer": {"", detail, readErrorMessage},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, replyFor(tc.response, tc.err), tc.reply)
+		})
+	}
+}
+
+func Test_commandError(t *testing.T) {
+	detail := errors.New("rpc error: code = Unavailable desc = connection refused")
+	err := readError(detail)
+
+	// The detail is what's logged, and the Recurser never sees it.
+	assert.Equal(t, err.Error(), detail.Error())
+	assert.Equal(t, errors.Is(err, detail), true)
+	assert.Equal(t, replyFor("", err) == detail.Error(), false)
+}
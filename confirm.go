@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// matchConfirmWindow is how long people who asked to confirm their matches
+// (see store.Recurser.ConfirmMatches) have to do it, starting from when the
+// match message goes out.
+const matchConfirmWindow = 6 * time.Hour
+
+// noPendingMatchMessage is the reply to `confirm` or `decline` when there's
+// nothing to confirm.
+const noPendingMatchMessage = "You don't have a match waiting to be confirmed."
+
+// holdForConfirmation saves the group's match as a PendingMatch if anyone in it
+// wants to confirm their matches. It returns the note to add to the match
+// message, and whether the match is pending (instead of ready to record).
+func (pl *PairingLogic) holdForConfirmation(ctx context.Context, group []store.Recurser, sendAt time.Time, match store.Match) (string, bool) {
+	pending := store.PendingMatch{
+		Recursers: match.Recursers,
+		Timestamp: match.Timestamp,
+		ExpiresAt: sendAt.Add(matchConfirmWindow).Unix(),
+		Realm:     group[0].Realm,
+	}
+	var confirmingNames []string
+	for _, rec := range group {
+		pending.Names = append(pending.Names, rec.Name)
+		if rec.ConfirmMatches {
+			pending.Unconfirmed = append(pending.Unconfirmed, rec.ID)
+			confirmingNames = append(confirmingNames, rec.Name)
+		}
+	}
+	if len(pending.Unconfirmed) == 0 {
+		return "", false
+	}
+
+	groupLog := logger(ctx).With(slog.Any("recurserIds", match.Recursers))
+
+	note, err := renderConfirmMatch(confirmingNames, matchConfirmWindow)
+	if err != nil {
+		groupLog.Warn("Could not render the confirmation note, so recording the match now", slog.Any("error", err))
+		return "", false
+	}
+
+	if err := store.PendingMatches(pl.db).Add(ctx, pending); err != nil {
+		groupLog.Error("Could not save the pending match, so recording it now", slog.Any("error", err))
+		return "", false
+	}
+	return note, true
+}
+
+// ConfirmMatch confirms the Recurser's pending match. Once everyone who asked
+// to confirm has done so, the match is recorded.
+func (pl *PairingLogic) ConfirmMatch(ctx context.Context, rec *store.Recurser) (string, error) {
+	pending, err := store.PendingMatches(pl.db).GetUnconfirmed(ctx, rec.Realm, rec.ID, time.Now())
+	if err != nil {
+		return "", readError(err)
+	}
+	if pending == nil {
+		return noPendingMatchMessage, nil
+	}
+
+	confirmed, err := store.PendingMatches(pl.db).Confirm(ctx, pending.ID, rec.ID, time.Now())
+	if err != nil {
+		return "", writeError(err)
+	}
+	if confirmed == nil {
+		// It expired or was called off since we looked it up.
+		return noPendingMatchMessage, nil
+	}
+	if len(confirmed.Unconfirmed) > 0 {
+		return "Thanks for confirming! The match will count once everyone else has confirmed too.", nil
+	}
+
+	logger(ctx).Info("Match confirmed", slog.Any("recurserIds", pending.Recursers))
+
+	return "Thanks for confirming! Your match is on. Have fun pairing :)", nil
+}
+
+// DeclineMatch calls off the Recurser's pending match, and offers everyone
+// else who was still up for it an on-demand match instead.
+func (pl *PairingLogic) DeclineMatch(ctx context.Context, rec *store.Recurser) (string, error) {
+	pending, err := store.PendingMatches(pl.db).GetUnconfirmed(ctx, rec.Realm, rec.ID, time.Now())
+	if err != nil {
+		return "", readError(err)
+	}
+	if pending == nil {
+		return noPendingMatchMessage, nil
+	}
+
+	claimed, err := store.PendingMatches(pl.db).Claim(ctx, pending.ID)
+	if err != nil {
+		return "", writeError(err)
+	}
+	if !claimed {
+		// It was confirmed or called off since we looked it up.
+		return noPendingMatchMessage, nil
+	}
+	logger(ctx).Info("Match declined", slog.Any("recurserIds", pending.Recursers))
+
+	reason := fmt.Sprintf("**%s** can't make it after all, so today's match is off.", rec.Name)
+	pl.callOff(ctx, pending, rec.ID, reason)
+
+	return "Okay, I've called off the match and let everyone know.", nil
+}
+
+// ExpirePendingMatches calls off the pending matches that weren't confirmed in
+// time. They're never recorded.
+func (pl *PairingLogic) ExpirePendingMatches(ctx context.Context) error {
+	expired, err := store.PendingMatches(pl.db).ListExpired(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("get expired pending matches from DB: %w", err)
+	}
+
+	for i := range expired {
+		pending := &expired[i]
+		groupLog := logger(ctx).With(slog.Any("recurserIds", pending.Recursers))
+
+		claimed, err := store.PendingMatches(pl.db).Claim(ctx, pending.ID)
+		if err != nil {
+			groupLog.Error("Could not delete the expired pending match", slog.Any("error", err))
+			continue
+		}
+		if !claimed {
+			// It was confirmed or called off before it expired.
+			continue
+		}
+		groupLog.Info("Pending match expired")
+
+		pl.callOff(ctx, pending, 0, "Today's match wasn't confirmed in time, so it's off and won't count.")
+	}
+
+	logger(ctx).Info("Expired pending matches", slog.Int("count", len(expired)))
+	return nil
+}
+
+// callOff tells everyone in a pending match (except the one who called it off,
+// if anyone did) that it's off. The people who were still up for it are put in
+// the queue for an on-demand match.
+func (pl *PairingLogic) callOff(ctx context.Context, pending *store.PendingMatch, except int64, reason string) {
+	now := time.Now()
+
+	for i, id := range pending.Recursers {
+		if id == except {
+			continue
+		}
+		recLog := logger(ctx).With(slog.Int64("recurserId", id))
+
+		message := reason
+		if !slices.Contains(pending.Unconfirmed, id) {
+			var name string
+			if i < len(pending.Names) {
+				name = pending.Names[i]
+			}
+
+			// Keep their blocks, like `match now` does.
+			var blocked []int64
+			if rec, err := store.Recursers(pl.db).GetByUserID(ctx, pending.Realm, id, "", ""); err != nil {
+				recLog.Warn("Could not get the Recurser's blocks", slog.Any("error", err))
+			} else {
+				blocked = rec.BlockedIDs()
+			}
+
+			err := store.MatchRequests(pl.db).Set(ctx, store.MatchRequest{
+				ID:        id,
+				Name:      name,
+				Timestamp: now.Unix(),
+				Blocked:   blocked,
+				Realm:     pending.Realm,
+			})
+			if err != nil {
+				recLog.Error("Could not queue for an on-demand match", slog.Any("error", err))
+				message += " Send `match now` if you'd like another partner."
+			} else {
+				message += fmt.Sprintf(" I've put you in the queue for `match now`, so if someone else asks in the next %d minutes, I'll match you up! (Send `cancel match` if you'd rather not.)", int(matchNowTTL.Minutes()))
+			}
+		}
+
+		if err := pl.zulipFor(pending.Realm).SendUserMessage(ctx, []int64{id}, message); err != nil {
+			recLog.Error("Could not tell the Recurser their match is off", slog.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestPairingLogic_confirmation(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*PairingLogic, *pbtest.FakeZulip) {
+		fake := pbtest.NewFakeZulip(t)
+		return &PairingLogic{db: pbtest.FirestoreClient(t, ctx), zulip: fake.Client}, fake
+	}
+
+	ada := store.Recurser{ID: 1, Name: "Ada", ConfirmMatches: true, IsSubscribed: true}
+	grace := store.Recurser{ID: 2, Name: "Grace", IsSubscribed: true}
+	group := []store.Recurser{ada, grace}
+	match := store.Match{Recursers: []int64{1, 2}, Timestamp: time.Now().Unix()}
+
+	matchesFor := func(t *testing.T, pl *PairingLogic, id int64) int {
+		matches, err := store.Pairings(pl.db).GetAllMatchesFor(ctx, store.DefaultRealm, id)
+		assert.NoError(t, err)
+		return len(matches)
+	}
+
+	t.Run("nobody wants to confirm", func(t *testing.T) {
+		pl, _ := setup(t)
+
+		note, pending := pl.holdForConfirmation(ctx, []store.Recurser{grace, {ID: 3, Name: "Alan"}}, time.Now(), match)
+		assert.Equal(t, note, "")
+		assert.Equal(t, pending, false)
+	})
+
+	t.Run("confirm", func(t *testing.T) {
+		pl, _ := setup(t)
+
+		note, pending := pl.holdForConfirmation(ctx, group, time.Now(), match)
+		assert.Equal(t, pending, true)
+		assert.Equal(t, strings.Contains(note, "@_**Ada** asked to confirm"), true)
+		assert.Equal(t, matchesFor(t, pl, 1), 0)
+
+		// Only the people who asked to confirm need to.
+		msg, err := pl.ConfirmMatch(ctx, &grace)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, noPendingMatchMessage)
+
+		msg, err = pl.ConfirmMatch(ctx, &ada)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Thanks for confirming! Your match is on. Have fun pairing :)")
+		assert.Equal(t, matchesFor(t, pl, 1), 1)
+
+		// There's nothing left to confirm.
+		msg, err = pl.ConfirmMatch(ctx, &ada)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, noPendingMatchMessage)
+	})
+
+	t.Run("decline", func(t *testing.T) {
+		pl, fake := setup(t)
+
+		_, pending := pl.holdForConfirmation(ctx, group, time.Now(), match)
+		assert.Equal(t, pending, true)
+
+		msg, err := pl.DeclineMatch(ctx, &ada)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Okay, I've called off the match and let everyone know.")
+		assert.Equal(t, matchesFor(t, pl, 1), 0)
+
+		// Grace hears about it and is waiting for an on-demand match.
+		assert.Equal(t, len(fake.DMs("[1]")), 0)
+		assert.Equal(t, len(fake.DMs("[2]")), 1)
+		assert.Equal(t, strings.HasPrefix(fake.DMs("[2]")[0], "**Ada** can't make it"), true)
+
+		partner, err := store.MatchRequests(pl.db).ClaimOldest(ctx, store.DefaultRealm, 3, nil, time.Now().Add(-matchNowTTL))
+		assert.NoError(t, err)
+		assert.Equal(t, partner.ID, grace.ID)
+
+		msg, err = pl.ConfirmMatch(ctx, &ada)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, noPendingMatchMessage)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		pl, fake := setup(t)
+
+		// The match message went out long enough ago that the window has
+		// closed.
+		sendAt := time.Now().Add(-matchConfirmWindow - time.Minute)
+		_, pending := pl.holdForConfirmation(ctx, group, sendAt, match)
+		assert.Equal(t, pending, true)
+
+		msg, err := pl.ConfirmMatch(ctx, &ada)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, noPendingMatchMessage)
+
+		assert.NoError(t, pl.ExpirePendingMatches(ctx))
+		assert.Equal(t, matchesFor(t, pl, 1), 0)
+
+		// Both hear about it, but only Grace was still up for pairing.
+		assert.Equal(t, len(fake.DMs("[1]")), 1)
+		assert.Equal(t, len(fake.DMs("[2]")), 1)
+		assert.Equal(t, strings.Contains(fake.DMs("[1]")[0], "match now"), false)
+		assert.Equal(t, strings.Contains(fake.DMs("[2]")[0], "match now"), true)
+
+		// It's only called off once.
+		assert.NoError(t, pl.ExpirePendingMatches(ctx))
+		assert.Equal(t, len(fake.DMs("[1]")), 1)
+	})
+}
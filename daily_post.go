@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// postDailyCount publicly posts how many pairs the match run made, wherever
+// the maintainers asked for it. Days without any pairs don't get a post.
+func (pl *PairingLogic) postDailyCount(ctx context.Context, post store.DailyPost, pairing store.Pairing) {
+	if post.Stream == "" || pairing.Value == 0 {
+		return
+	}
+
+	message, err := renderDailyPost(pairing)
+	if err != nil {
+		logger(ctx).Error("Could not render the daily post", slog.Any("error", err))
+		return
+	}
+	if err := pl.zulip.PostToTopic(ctx, post.Stream, post.Topic, message); err != nil {
+		logger(ctx).Error("Could not send the daily post", slog.String("stream", post.Stream), slog.Any("error", err))
+	}
+}
+
+// DailyPostStatus shows where the daily post goes.
+func (pl *PairingLogic) DailyPostStatus(ctx context.Context, rec *store.Recurser) (string, error) {
+	post, err := store.DailyPosts(pl.db).Get(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+
+	if post.Stream == "" {
+		return "There's no daily post. Send `dailypost <stream> > <topic>` to start one.", nil
+	}
+
+	changed := ""
+	if post.UpdatedBy != 0 {
+		changed = fmt.Sprintf(" (since @_**|%d** changed it at %s)", post.UpdatedBy, time.Unix(post.Timestamp, 0).In(rec.Location()).Format("2006-01-02 15:04"))
+	}
+	return fmt.Sprintf("Each match run posts how many pairs it made to #**%s>%s**%s.", post.Stream, post.Topic, changed), nil
+}
+
+// SetDailyPost changes where the daily post goes. An empty stream turns it
+// off.
+func (pl *PairingLogic) SetDailyPost(ctx context.Context, rec *store.Recurser, stream, topic string) (string, error) {
+	err := store.DailyPosts(pl.db).Set(ctx, store.DailyPost{
+		Stream:    stream,
+		Topic:     topic,
+		UpdatedBy: rec.ID,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		return "", writeError(err)
+	}
+
+	if stream == "" {
+		return "Done. Match runs won't post how many pairs they made anymore.", nil
+	}
+	return fmt.Sprintf("Done. Match runs will post how many pairs they made to #**%s>%s**.", stream, topic), nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestPairingLogic_postDailyCount(t *testing.T) {
+	ctx := context.Background()
+
+	// Stream messages are only sent in production.
+	t.Setenv("APP_ENV", "production")
+
+	fake := pbtest.NewFakeZulip(t)
+	pl := &PairingLogic{zulip: fake.Client}
+	post := store.DailyPost{Stream: "pairing", Topic: "daily matches"}
+
+	pl.postDailyCount(ctx, post, store.Pairing{Value: 3, NumRecursers: 7})
+	assert.Equal(t, fake.Posts(), []pbtest.ZulipMessage{{
+		Type:    "stream",
+		To:      "pairing",
+		Topic:   "daily matches",
+		Content: ":pear: Pairing is happening! Today's match run made 3 matches for 7 Recursers.\n\nWant in next time? Send me a DM that says `subscribe`.\n",
+	}})
+
+	t.Run("suppressed", func(t *testing.T) {
+		pl.postDailyCount(ctx, post, store.Pairing{})
+		pl.postDailyCount(ctx, store.DailyPost{}, store.Pairing{Value: 3, NumRecursers: 7})
+		assert.Equal(t, len(fake.Posts()), 1)
+	})
+}
+
+func TestPairingLogic_match_dailyPost(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+
+	// Stream messages are only sent in production.
+	t.Setenv("APP_ENV", "production")
+
+	fake := pbtest.NewFakeZulip(t)
+	pl := &PairingLogic{db: db, zulip: fake.Client, repeatWindowDays: 7, seeds: rand.New(rand.NewSource(1))}
+	assert.NoError(t, store.DailyPosts(db).Set(ctx, store.DailyPost{Stream: "pairing", Topic: "daily matches"}))
+
+	everyDay := store.NewSchedule([]string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"})
+	for _, rec := range []store.Recurser{
+		{ID: 1, Name: "One", Schedule: everyDay},
+		{ID: 2, Name: "Two", Schedule: everyDay},
+		{ID: 3, Name: "Three", Schedule: everyDay},
+		{ID: 4, Name: "Four", Schedule: everyDay},
+		{ID: 5, Name: "Five", Schedule: everyDay},
+	} {
+		assert.NoError(t, store.Recursers(db).Set(ctx, &rec))
+	}
+
+	assert.NoError(t, pl.match(ctx, time.Now()))
+	posts := fake.Posts()
+	assert.Equal(t, len(posts), 1)
+	assert.Equal(t, posts[0].Content, ":pear: Pairing is happening! Today's match run made 2 matches for 5 Recursers.\n\nWant in next time? Send me a DM that says `subscribe`.\n")
+}
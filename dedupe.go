@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// findDuplicates groups the Recursers in the same realm who share an email
+// address (ignoring case). Only groups with more than one Recurser are
+// returned, in the order their emails first appear.
+func findDuplicates(recursers []store.Recurser) [][]store.Recurser {
+	var emails []string
+	byEmail := make(map[string][]store.Recurser)
+	for _, rec := range recursers {
+		email := strings.ToLower(strings.TrimSpace(rec.Email))
+		if email == "" {
+			continue
+		}
+		// The same person can use Pairing Bot from more than one realm.
+		email = rec.Realm + " " + email
+		if _, ok := byEmail[email]; !ok {
+			emails = append(emails, email)
+		}
+		byEmail[email] = append(byEmail[email], rec)
+	}
+
+	var groups [][]store.Recurser
+	for _, email := range emails {
+		if len(byEmail[email]) > 1 {
+			groups = append(groups, byEmail[email])
+		}
+	}
+	return groups
+}
+
+// chooseKeeper returns the index of the record to keep out of a group of
+// duplicates: the one that was matched most recently, then anyone currently at
+// RC, then the newest Zulip account (which has the highest ID).
+func chooseKeeper(group []store.Recurser, lastMatched map[recurserKey]int64) int {
+	best := 0
+	for i := 1; i < len(group); i++ {
+		if preferRecord(group[i], group[best], lastMatched) {
+			best = i
+		}
+	}
+	return best
+}
+
+// preferRecord returns whether a is a better record to keep than b. See
+// chooseKeeper.
+func preferRecord(a, b store.Recurser, lastMatched map[recurserKey]int64) bool {
+	aLast, bLast := lastMatched[recurserKey{a.Realm, a.ID}], lastMatched[recurserKey{b.Realm, b.ID}]
+	if aLast != bLast {
+		return aLast > bLast
+	}
+	if a.CurrentlyAtRC != b.CurrentlyAtRC {
+		return a.CurrentlyAtRC
+	}
+	return a.ID > b.ID
+}
+
+// mergeRecursers combines duplicate records for the same person into keep.
+//
+// The schedules, skips, and muted days are combined, since the person asked
+// for them with one account or the other, as are pauses (the later end wins)
+// and weekly limits (the lower one wins). Opt-outs from any record stick, but
+// opt-ins (like showing up on the leaderboard) need every record to agree:
+// it's better to ask again than to share something they didn't want shared.
+// Any other settings come from keep, with blanks filled in from the others.
+func mergeRecursers(keep store.Recurser, others []store.Recurser) store.Recurser {
+	merged := keep
+	merged.SkipDates = maps.Clone(keep.SkipDates)
+	merged.MutedDays = maps.Clone(keep.MutedDays)
+	merged.Aliases = maps.Clone(keep.Aliases)
+	merged.SchedulePresets = maps.Clone(keep.SchedulePresets)
+
+	days := scheduledKeys(keep.Schedule)
+	for _, other := range others {
+		days = append(days, scheduledKeys(other.Schedule)...)
+
+		for date, skip := range other.SkipDates {
+			if !skip {
+				continue
+			}
+			if merged.SkipDates == nil {
+				merged.SkipDates = make(map[string]bool)
+			}
+			merged.SkipDates[date] = true
+		}
+
+		for day, muted := range other.MutedDays {
+			if !muted {
+				continue
+			}
+			if merged.MutedDays == nil {
+				merged.MutedDays = make(map[string]bool)
+			}
+			merged.MutedDays[day] = true
+		}
+
+		merged.IsSkippingTomorrow = merged.IsSkippingTomorrow || other.IsSkippingTomorrow
+		merged.CurrentlyAtRC = merged.CurrentlyAtRC || other.CurrentlyAtRC
+		merged.WeeklySummaryOptOut = merged.WeeklySummaryOptOut || other.WeeklySummaryOptOut
+		merged.ShowOnLeaderboard = merged.ShowOnLeaderboard && other.ShowOnLeaderboard
+		merged.Discoverable = merged.Discoverable && other.Discoverable
+		merged.EmailFallback = merged.EmailFallback && other.EmailFallback
+		merged.Nudge = merged.Nudge && other.Nudge
+		merged.KeepHistory = merged.KeepHistory && other.KeepHistory
+		merged.ConfirmMatches = merged.ConfirmMatches || other.ConfirmMatches
+		merged.IsMentor = merged.IsMentor || other.IsMentor
+		merged.LastActive = max(merged.LastActive, other.LastActive)
+		merged.PausedUntil = max(merged.PausedUntil, other.PausedUntil)
+		merged.SkipUntil = max(merged.SkipUntil, other.SkipUntil)
+		if other.MaxWeekly > 0 && (merged.MaxWeekly == 0 || other.MaxWeekly < merged.MaxWeekly) {
+			merged.MaxWeekly = other.MaxWeekly
+		}
+
+		if merged.Timezone == "" {
+			merged.Timezone = other.Timezone
+		}
+		if merged.Bio == "" {
+			merged.Bio = other.Bio
+		}
+		if len(merged.Topics) == 0 {
+			merged.Topics = other.Topics
+		}
+		if merged.MatchTime == "" {
+			merged.MatchTime = other.MatchTime
+		}
+		if merged.BatchPref == "" {
+			merged.BatchPref = other.BatchPref
+		}
+		if merged.Duration == 0 {
+			merged.Duration = other.Duration
+		}
+		if merged.Verbosity == "" {
+			merged.Verbosity = other.Verbosity
+		}
+		if merged.Mode == "" {
+			merged.Mode = other.Mode
+		}
+		if merged.ThisWeek.WeekOf < other.ThisWeek.WeekOf {
+			merged.ThisWeek = other.ThisWeek
+		}
+
+		// Keep every block, since dropping one could match people who
+		// asked not to be.
+		for _, block := range other.Blocks {
+			if !merged.HasBlocked(block.ID) {
+				merged.Blocks = append(merged.Blocks, block)
+			}
+		}
+
+		for name, expansion := range other.Aliases {
+			if _, ok := merged.Aliases[name]; !ok && len(merged.Aliases) < maxAliases {
+				if merged.Aliases == nil {
+					merged.Aliases = make(map[string]string)
+				}
+				merged.Aliases[name] = expansion
+			}
+		}
+
+		for name, preset := range other.SchedulePresets {
+			if _, ok := merged.SchedulePresets[name]; !ok && len(merged.SchedulePresets) < maxSchedulePresets {
+				if merged.SchedulePresets == nil {
+					merged.SchedulePresets = make(map[string]map[string]bool)
+				}
+				merged.SchedulePresets[name] = preset
+			}
+		}
+	}
+
+	// NewSchedule also tidies up half days that add up to whole ones.
+	merged.Schedule = store.NewSchedule(days)
+	merged.Biweekly = mergeBiweekly(append([]store.Recurser{keep}, others...))
+	return merged
+}
+
+// mergeBiweekly combines the Biweekly days of duplicate records. A day that's
+// every week in any of them stays every week, like the schedules themselves.
+func mergeBiweekly(recs []store.Recurser) map[string]int {
+	var biweekly map[string]int
+	weekly := make(map[string]bool)
+	for _, rec := range recs {
+		for _, day := range scheduleShortcuts["everyday"] {
+			if !rec.IsScheduledOn(day) {
+				continue
+			}
+			parity, ok := rec.Biweekly[day]
+			if !ok {
+				weekly[day] = true
+				continue
+			}
+			if _, seen := biweekly[day]; !seen {
+				if biweekly == nil {
+					biweekly = make(map[string]int)
+				}
+				biweekly[day] = parity
+			}
+		}
+	}
+	for day := range weekly {
+		delete(biweekly, day)
+	}
+	return biweekly
+}
+
+// redirectBlocks points blocks of removed duplicate records at the record that
+// was kept instead (see Dedupe), so nobody gets matched with someone they
+// blocked under an old account. It returns whether anything changed.
+func redirectBlocks(rec *store.Recurser, kept map[recurserKey]store.Block) bool {
+	var blocks []store.Block
+	changed := false
+	for _, block := range rec.Blocks {
+		if to, ok := kept[recurserKey{rec.Realm, block.ID}]; ok {
+			block = to
+			changed = true
+		}
+		if !slices.Contains(blocks, block) && block.ID != rec.ID {
+			blocks = append(blocks, block)
+		}
+	}
+	if changed {
+		rec.Blocks = blocks
+	}
+	return changed
+}
+
+// scheduledKeys returns the days (and half days) that are on in the schedule.
+func scheduledKeys(schedule map[string]bool) []string {
+	var days []string
+	for day, on := range schedule {
+		if on {
+			days = append(days, day)
+		}
+	}
+	slices.Sort(days)
+	return days
+}
+
+// Dedupe merges subscribed Recursers who share an email address into a single
+// record each, and deletes the rest. This can happen when someone's Zulip
+// account changes.
+func (pl *PairingLogic) Dedupe(ctx context.Context) (string, error) {
+	recursers, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+
+	groups := findDuplicates(recursers)
+	if len(groups) == 0 {
+		return "No duplicate records found.", nil
+	}
+
+	matches, err := store.Pairings(pl.db).GetMatchesSince(ctx, time.Unix(0, 0))
+	if err != nil {
+		return "", readError(err)
+	}
+	lastMatched := make(map[recurserKey]int64)
+	for _, match := range matches {
+		for _, id := range match.Recursers {
+			key := recurserKey{match.Realm, id}
+			lastMatched[key] = max(lastMatched[key], match.Timestamp)
+		}
+	}
+
+	var report []string
+	kept := make(map[recurserKey]store.Block)
+	for _, group := range groups {
+		i := chooseKeeper(group, lastMatched)
+		others := slices.Delete(slices.Clone(group), i, i+1)
+		merged := mergeRecursers(group[i], others)
+
+		if err := store.Recursers(pl.db).Set(ctx, &merged); err != nil {
+			return "", writeError(err)
+		}
+
+		var removed []string
+		for _, other := range others {
+			if err := store.Recursers(pl.db).Delete(ctx, other.Realm, other.ID); err != nil {
+				return "", writeError(err)
+			}
+			removed = append(removed, fmt.Sprint(other.ID))
+			kept[recurserKey{other.Realm, other.ID}] = store.Block{ID: merged.ID, Name: merged.Name}
+		}
+
+		report = append(report, fmt.Sprintf("* %s: kept %d, merged and removed %s", merged.Email, merged.ID, strings.Join(removed, ", ")))
+	}
+
+	// Blocks work in both directions, so everyone's blocks need updating, even
+	// if they aren't subscribed right now.
+	everyone, err := store.Recursers(pl.db).GetAll(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+	for i := range everyone {
+		if !redirectBlocks(&everyone[i], kept) {
+			continue
+		}
+		if err := store.Recursers(pl.db).Set(ctx, &everyone[i]); err != nil {
+			return "", writeError(err)
+		}
+	}
+
+	return fmt.Sprintf("Merged %d set(s) of duplicate records:\n%s", len(groups), strings.Join(report, "\n")), nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_findDuplicates(t *testing.T) {
+	recursers := []store.Recurser{
+		{ID: 1, Email: "ada@example.com"},
+		{ID: 2, Email: "grace@example.com"},
+		{ID: 3, Email: "Ada@Example.com"},
+		{ID: 4, Email: ""},
+		{ID: 5, Email: ""},
+		{ID: 6, Email: "ada@example.com", Realm: "sister"},
+	}
+
+	var ids [][]int64
+	for _, group := range findDuplicates(recursers) {
+		var g []int64
+		for _, rec := range group {
+			g = append(g, rec.ID)
+		}
+		ids = append(ids, g)
+	}
+	assert.Equal(t, ids, [][]int64{{1, 3}})
+}
+
+func Test_chooseKeeper(t *testing.T) {
+	t.Run("most recently matched", func(t *testing.T) {
+		group := []store.Recurser{{ID: 1}, {ID: 2}, {ID: 3}}
+		assert.Equal(t, chooseKeeper(group, map[recurserKey]int64{{id: 1}: 300, {id: 2}: 100}), 0)
+	})
+
+	t.Run("then currently at RC", func(t *testing.T) {
+		group := []store.Recurser{{ID: 1}, {ID: 2, CurrentlyAtRC: true}, {ID: 3}}
+		assert.Equal(t, chooseKeeper(group, nil), 1)
+	})
+
+	t.Run("then the newest account", func(t *testing.T) {
+		group := []store.Recurser{{ID: 3}, {ID: 5}, {ID: 4}}
+		assert.Equal(t, chooseKeeper(group, nil), 1)
+	})
+}
+
+func Test_mergeRecursers(t *testing.T) {
+	keep := store.Recurser{
+		ID:                1,
+		Schedule:          store.NewSchedule([]string{"monday", "wednesday-am"}),
+		ShowOnLeaderboard: true,
+		KeepHistory:       true,
+		Timezone:          "Europe/Berlin",
+	}
+	other := store.Recurser{
+		ID:                  2,
+		Schedule:            store.NewSchedule([]string{"wednesday-pm", "friday"}),
+		SkipDates:           map[string]bool{"2024-03-14": true},
+		IsSkippingTomorrow:  true,
+		WeeklySummaryOptOut: true,
+		KeepHistory:         true,
+		Timezone:            "America/New_York",
+		Bio:                 "Writing a ray tracer",
+	}
+
+	merged := mergeRecursers(keep, []store.Recurser{other})
+
+	assert.Equal(t, merged, store.Recurser{
+		ID:                  1,
+		Schedule:            store.NewSchedule([]string{"monday", "wednesday", "friday"}),
+		SkipDates:           map[string]bool{"2024-03-14": true},
+		IsSkippingTomorrow:  true,
+		WeeklySummaryOptOut: true,
+		ShowOnLeaderboard:   false,
+		KeepHistory:         true,
+		Timezone:            "Europe/Berlin",
+		Bio:                 "Writing a ray tracer",
+	})
+
+	// The originals are left alone.
+	assert.Equal(t, keep.SkipDates, map[string]bool(nil))
+
+	t.Run("biweekly days", func(t *testing.T) {
+		keep := store.Recurser{Schedule: store.NewSchedule([]string{"monday", "wednesday"}), Biweekly: map[string]int{"monday": 1, "wednesday": 0}}
+		other := store.Recurser{Schedule: store.NewSchedule([]string{"monday", "friday"}), Biweekly: map[string]int{"friday": 1}}
+
+		// Monday is every week in the other record, so it stays every week.
+		merged := mergeRecursers(keep, []store.Recurser{other})
+		assert.Equal(t, merged.Biweekly, map[string]int{"wednesday": 0, "friday": 1})
+	})
+
+	t.Run("pauses and limits", func(t *testing.T) {
+		keep := store.Recurser{PausedUntil: 100, SkipUntil: 300, MaxWeekly: 0}
+		other := store.Recurser{PausedUntil: store.PausedIndefinitely, SkipUntil: 200, MaxWeekly: 2}
+		another := store.Recurser{MaxWeekly: 3}
+
+		merged := mergeRecursers(keep, []store.Recurser{other, another})
+		assert.Equal(t, merged.PausedUntil, store.PausedIndefinitely)
+		assert.Equal(t, merged.SkipUntil, int64(300))
+		assert.Equal(t, merged.MaxWeekly, 2)
+	})
+}
+
+func Test_redirectBlocks(t *testing.T) {
+	kept := map[recurserKey]store.Block{
+		{id: 2}:                  {ID: 1, Name: "Ada"},
+		{realm: "sister", id: 3}: {ID: 4, Name: "Alan"},
+	}
+
+	t.Run("removed records", func(t *testing.T) {
+		rec := store.Recurser{ID: 5, Blocks: []store.Block{{ID: 2, Name: "Old Ada"}, {ID: 6, Name: "Grace"}}}
+		assert.Equal(t, redirectBlocks(&rec, kept), true)
+		assert.Equal(t, rec.Blocks, []store.Block{{ID: 1, Name: "Ada"}, {ID: 6, Name: "Grace"}})
+	})
+
+	t.Run("both records", func(t *testing.T) {
+		rec := store.Recurser{ID: 5, Blocks: []store.Block{{ID: 1, Name: "Ada"}, {ID: 2, Name: "Old Ada"}}}
+		assert.Equal(t, redirectBlocks(&rec, kept), true)
+		assert.Equal(t, rec.Blocks, []store.Block{{ID: 1, Name: "Ada"}})
+	})
+
+	t.Run("only the same realm", func(t *testing.T) {
+		rec := store.Recurser{ID: 5, Blocks: []store.Block{{ID: 3, Name: "Alan"}}}
+		assert.Equal(t, redirectBlocks(&rec, kept), false)
+		assert.Equal(t, rec.Blocks, []store.Block{{ID: 3, Name: "Alan"}})
+	})
+}
+
+func TestPairingLogic_Dedupe(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+	pl := &PairingLogic{db: db}
+
+	recursers := []store.Recurser{
+		{ID: 1, Name: "Old Ada", Email: "ada@example.com", Schedule: store.NewSchedule([]string{"monday"})},
+		{ID: 2, Name: "New Ada", Email: "ADA@example.com", Schedule: store.NewSchedule([]string{"tuesday"})},
+		{ID: 3, Name: "Grace", Email: "grace@example.com", Schedule: store.DefaultSchedule(), Blocks: []store.Block{{ID: 2, Name: "New Ada"}}},
+	}
+	for _, rec := range recursers {
+		if err := store.Recursers(db).Set(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The old account was the last one matched, so it's the one to keep.
+	match := store.Match{Recursers: []int64{1, 3}, Timestamp: time.Now().Unix()}
+	if err := store.Pairings(db).AddMatch(ctx, match); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := pl.Dedupe(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, "Merged 1 set(s) of duplicate records:\n* ada@example.com: kept 1, merged and removed 2")
+
+	all, err := store.Recursers(db).GetAllSubscribed(ctx)
+	assert.NoError(t, err)
+
+	var ids []int64
+	for _, rec := range all {
+		ids = append(ids, rec.ID)
+	}
+	assert.Equal(t, ids, []int64{1, 3})
+	assert.Equal(t, all[0].Schedule, store.NewSchedule([]string{"monday", "tuesday"}))
+
+	// Grace blocked the removed account, so the block moves to the kept one.
+	assert.Equal(t, all[1].Blocks, []store.Block{{ID: 1, Name: "Old Ada"}})
+
+	// Running it again finds nothing to do.
+	msg, err = pl.Dedupe(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, "No duplicate records found.")
+}
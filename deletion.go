@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// deletionTTL is how long a `delete me` request waits for confirmation.
+const deletionTTL = time.Hour
+
+// RequestDeletion starts deleting all of the Recurser's data, which happens
+// once they confirm it. With anonymizeMatches, they're also removed from their
+// past matches.
+func (pl *PairingLogic) RequestDeletion(ctx context.Context, rec *store.Recurser, anonymizeMatches bool) (string, error) {
+	err := store.DeletionRequests(pl.db).Set(ctx, store.DeletionRequest{
+		UserID:           rec.ID,
+		Timestamp:        time.Now().Unix(),
+		AnonymizeMatches: anonymizeMatches,
+		Realm:            rec.Realm,
+	})
+	if err != nil {
+		return "", writeError(err)
+	}
+
+	what := "your settings, the reviews you've written (except anonymous ones), your command history, and anything waiting on you (like a match to confirm or a pair request)"
+	if anonymizeMatches {
+		what += ", and removes you from your past matches"
+	} else {
+		what += ". Your past matches are kept, so your partners' stats stay the same (send `delete me matches` instead to be removed from them too)"
+	}
+	return fmt.Sprintf("This permanently deletes %s. It can't be undone! If you just want to stop getting matched, `unsubscribe` is enough.\n\nSend `delete me confirm` within the hour to go ahead, or `delete me cancel` to keep everything.", what), nil
+}
+
+// ConfirmDeletion deletes the Recurser's data, if they asked to with
+// `delete me` recently.
+func (pl *PairingLogic) ConfirmDeletion(ctx context.Context, rec *store.Recurser) (string, error) {
+	req, err := store.DeletionRequests(pl.db).Get(ctx, rec.Realm, rec.ID)
+	if err != nil {
+		return "", readError(err)
+	}
+	if req == nil || time.Since(time.Unix(req.Timestamp, 0)) > deletionTTL {
+		return "You don't have a deletion waiting to be confirmed. Start one with `delete me`.", nil
+	}
+
+	// Every step is safe to repeat, so the request is only removed at the
+	// end. If something fails, `delete me confirm` picks up where it left off.
+	removed, err := pl.deleteUserData(ctx, rec, req.AnonymizeMatches)
+	if err != nil {
+		return "", writeError(err)
+	}
+	if err := store.DeletionRequests(pl.db).Delete(ctx, rec.Realm, rec.ID); err != nil {
+		logger(ctx).Warn("Could not delete the deletion request", slog.Any("error", err))
+	}
+	logger(ctx).Info("Deleted a Recurser's data", slog.Bool("anonymizeMatches", req.AnonymizeMatches))
+
+	return "Done. I deleted:\n* " + strings.Join(removed, "\n* ") + "\n\nThanks for pairing with us. If you ever want to come back, send `subscribe`.", nil
+}
+
+// CancelDeletion throws away the Recurser's `delete me` request.
+func (pl *PairingLogic) CancelDeletion(ctx context.Context, rec *store.Recurser) (string, error) {
+	if err := store.DeletionRequests(pl.db).Delete(ctx, rec.Realm, rec.ID); err != nil {
+		return "", writeError(err)
+	}
+	return "Okay, I won't delete anything.", nil
+}
+
+// deleteUserData removes everything (see newUserExport) that's stored about the
+// Recurser, along with anything still waiting on them: pending matches (which
+// are called off for their partners), scheduled match messages, pair requests,
+// and blind intros. It returns a description of each thing it removed.
+func (pl *PairingLogic) deleteUserData(ctx context.Context, rec *store.Recurser, anonymizeMatches bool) ([]string, error) {
+	removed := []string{"your settings"}
+
+	if err := store.Recursers(pl.db).Delete(ctx, rec.Realm, rec.ID); err != nil {
+		return nil, fmt.Errorf("delete record: %w", err)
+	}
+	// The record is gone, so don't record this command in the history.
+	rec.IsSubscribed, rec.KeepHistory = false, false
+
+	if _, err := store.MatchRequests(pl.db).Delete(ctx, rec.Realm, rec.ID); err != nil {
+		return nil, fmt.Errorf("delete match request: %w", err)
+	}
+
+	if rec.Email != "" {
+		n, err := store.Reviews(pl.db).DeleteByEmail(ctx, rec.Email)
+		if err != nil {
+			return nil, fmt.Errorf("delete reviews: %w", err)
+		}
+		removed = append(removed, fmt.Sprintf("your reviews (%d)", n))
+	}
+
+	n, err := store.History(pl.db).DeleteAllFor(ctx, rec.Realm, rec.ID)
+	if err != nil {
+		return nil, fmt.Errorf("delete history: %w", err)
+	}
+	removed = append(removed, fmt.Sprintf("your command history (%d)", n))
+
+	// The rest are only around for a day or so, so they're only mentioned if
+	// there were any.
+	pending, err := store.PendingMatches(pl.db).ListFor(ctx, rec.Realm, rec.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get pending matches: %w", err)
+	}
+	var calledOff int
+	for i := range pending {
+		claimed, err := store.PendingMatches(pl.db).Claim(ctx, pending[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("delete pending match: %w", err)
+		}
+		if claimed {
+			pl.callOff(ctx, &pending[i], rec.ID, "Someone in your match can't make it after all, so today's match is off.")
+			calledOff++
+		}
+	}
+	if calledOff > 0 {
+		removed = append(removed, fmt.Sprintf("your matches waiting to be confirmed (%d)", calledOff))
+	}
+
+	for _, step := range []struct {
+		what   string
+		delete func(context.Context, string, int64) (int, error)
+	}{
+		{"match messages waiting to be sent", store.ScheduledMessages(pl.db).DeleteAllFor},
+		{"pair requests", store.PairRequests(pl.db).DeleteAllFor},
+		{"blind intros", store.BlindIntros(pl.db).DeleteAllFor},
+	} {
+		n, err := step.delete(ctx, rec.Realm, rec.ID)
+		if err != nil {
+			return nil, fmt.Errorf("delete %s: %w", step.what, err)
+		}
+		if n > 0 {
+			removed = append(removed, fmt.Sprintf("your %s (%d)", step.what, n))
+		}
+	}
+
+	if anonymizeMatches {
+		n, err := store.Pairings(pl.db).AnonymizeMatchesFor(ctx, rec.Realm, rec.ID)
+		if err != nil {
+			return nil, fmt.Errorf("anonymize matches: %w", err)
+		}
+		removed = append(removed, fmt.Sprintf("you, from your past matches (%d)", n))
+	}
+
+	return removed, nil
+}
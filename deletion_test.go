@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestPairingLogic_deletion(t *testing.T) {
+	ctx := context.Background()
+
+	// Each test gets its own database with a Recurser who has a bit of
+	// everything, plus a partner.
+	setup := func(t *testing.T) (*PairingLogic, *store.Recurser) {
+		pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx)}
+
+		rec := &store.Recurser{ID: 1, Name: "Ada", Email: "ada@recurse.example.net", Schedule: store.DefaultSchedule(), KeepHistory: true}
+		partner := &store.Recurser{ID: 2, Name: "Grace", Email: "grace@recurse.example.net", Schedule: store.DefaultSchedule()}
+		for _, r := range []*store.Recurser{rec, partner} {
+			assert.NoError(t, store.Recursers(pl.db).Set(ctx, r))
+		}
+		rec.IsSubscribed = true
+
+		assert.NoError(t, store.Reviews(pl.db).Insert(ctx, store.Review{Content: "love it", Email: rec.Email, Timestamp: 100}))
+		assert.NoError(t, store.Reviews(pl.db).Insert(ctx, store.Review{Content: "me too", Email: partner.Email, Timestamp: 200}))
+		assert.NoError(t, store.History(pl.db).Insert(ctx, store.HistoryEntry{UserID: rec.ID, Command: "status", Timestamp: 300}))
+		assert.NoError(t, store.Pairings(pl.db).AddMatch(ctx, store.Match{Recursers: []int64{1, 2}, Timestamp: 400}))
+
+		return pl, rec
+	}
+
+	// exported returns what `export` would find for the Recurser.
+	exported := func(t *testing.T, pl *PairingLogic, rec *store.Recurser) userExport {
+		found, err := store.Recursers(pl.db).GetByUserID(ctx, rec.Realm, rec.ID, rec.Email, rec.Name)
+		assert.NoError(t, err)
+		matches, err := store.Pairings(pl.db).GetAllMatchesFor(ctx, rec.Realm, rec.ID)
+		assert.NoError(t, err)
+		reviews, err := store.Reviews(pl.db).GetByEmail(ctx, rec.Email)
+		assert.NoError(t, err)
+		history, err := store.History(pl.db).GetAllFor(ctx, rec.Realm, rec.ID)
+		assert.NoError(t, err)
+		return newUserExport(found, found.IsSubscribed, matches, reviews, history)
+	}
+
+	t.Run("needs confirmation", func(t *testing.T) {
+		pl, rec := setup(t)
+
+		msg, err := pl.ConfirmDeletion(ctx, rec)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.HasPrefix(msg, "You don't have a deletion"), true)
+
+		_, err = pl.RequestDeletion(ctx, rec, false)
+		assert.NoError(t, err)
+		_, err = pl.CancelDeletion(ctx, rec)
+		assert.NoError(t, err)
+
+		msg, err = pl.ConfirmDeletion(ctx, rec)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.HasPrefix(msg, "You don't have a deletion"), true)
+		assert.Equal(t, exported(t, pl, rec).Recurser != nil, true)
+	})
+
+	t.Run("expires", func(t *testing.T) {
+		pl, rec := setup(t)
+
+		err := store.DeletionRequests(pl.db).Set(ctx, store.DeletionRequest{
+			UserID:    rec.ID,
+			Timestamp: time.Now().Add(-deletionTTL - time.Minute).Unix(),
+		})
+		assert.NoError(t, err)
+
+		msg, err := pl.ConfirmDeletion(ctx, rec)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.HasPrefix(msg, "You don't have a deletion"), true)
+	})
+
+	t.Run("keeps matches", func(t *testing.T) {
+		pl, rec := setup(t)
+
+		_, err := pl.RequestDeletion(ctx, rec, false)
+		assert.NoError(t, err)
+		msg, err := pl.ConfirmDeletion(ctx, rec)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Contains(msg, "your reviews (1)"), true)
+		assert.Equal(t, strings.Contains(msg, "your command history (1)"), true)
+
+		got := exported(t, pl, rec)
+		assert.Equal(t, got.Recurser, nil)
+		assert.Equal(t, len(got.Reviews), 0)
+		assert.Equal(t, len(got.History), 0)
+		assert.Equal(t, len(got.Matches), 1)
+
+		// The command that deleted everything isn't recorded either.
+		assert.Equal(t, rec.KeepHistory, false)
+
+		// Nobody else's data is touched.
+		partner, err := store.Recursers(pl.db).GetByUserID(ctx, store.DefaultRealm, 2, "", "")
+		assert.NoError(t, err)
+		assert.Equal(t, partner.IsSubscribed, true)
+		reviews, err := store.Reviews(pl.db).GetAll(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, len(reviews), 1)
+	})
+
+	t.Run("removes everything waiting on them", func(t *testing.T) {
+		pl, rec := setup(t)
+		fake := pbtest.NewFakeZulip(t)
+		pl.zulip = fake.Client
+
+		now := time.Now()
+		later := now.Add(time.Hour).Unix()
+		assert.NoError(t, store.PendingMatches(pl.db).Add(ctx, store.PendingMatch{
+			Recursers:   []int64{1, 2},
+			Names:       []string{"Ada", "Grace"},
+			Timestamp:   now.Unix(),
+			Unconfirmed: []int64{1},
+			ExpiresAt:   later,
+		}))
+		assert.NoError(t, store.ScheduledMessages(pl.db).Add(ctx, store.ScheduledMessage{Recipients: []int64{1, 2}, Content: "Ada, meet Grace", SendAt: later}))
+		assert.NoError(t, store.ScheduledMessages(pl.db).Add(ctx, store.ScheduledMessage{Recipients: []int64{2, 3}, Content: "Grace, meet Alan", SendAt: later}))
+		assert.NoError(t, store.PairRequests(pl.db).Set(ctx, store.PairRequest{From: 1, FromName: "Ada", To: 2, ToName: "Grace", ExpiresAt: later}))
+		assert.NoError(t, store.PairRequests(pl.db).Set(ctx, store.PairRequest{From: 3, FromName: "Alan", To: 1, ToName: "Ada", ExpiresAt: later}))
+		assert.NoError(t, store.BlindIntros(pl.db).Add(ctx, store.BlindIntro{
+			Recursers: []int64{1, 2},
+			Content:   "Ada, meet Grace",
+			Hellos:    map[string]string{"1": "hi, it's Ada!"},
+			SendAt:    now.Add(-time.Minute).Unix(),
+			RevealAt:  later,
+		}))
+
+		_, err := pl.RequestDeletion(ctx, rec, false)
+		assert.NoError(t, err)
+		msg, err := pl.ConfirmDeletion(ctx, rec)
+		assert.NoError(t, err)
+		for _, want := range []string{
+			"your matches waiting to be confirmed (1)",
+			"your match messages waiting to be sent (1)",
+			"your pair requests (2)",
+			"your blind intros (1)",
+		} {
+			assert.Equal(t, strings.Contains(msg, want), true)
+		}
+
+		pending, err := store.PendingMatches(pl.db).ListFor(ctx, store.DefaultRealm, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, len(pending), 0)
+
+		// Grace hears that the match is off, and can't accept Ada's request.
+		assert.Equal(t, len(fake.DMs("[2]")), 1)
+		reply, err := pl.AcceptPair(ctx, &store.Recurser{ID: 2, Name: "Grace"})
+		assert.NoError(t, err)
+		assert.Equal(t, reply, noPairRequestMessage)
+		matches, err := store.Pairings(pl.db).GetAllMatchesFor(ctx, store.DefaultRealm, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, len(matches), 1)
+
+		scheduled, err := store.ScheduledMessages(pl.db).ListDue(ctx, now.Add(2*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, len(scheduled), 1)
+		assert.Equal(t, scheduled[0].Recipients, []int64{2, 3})
+
+		intro, err := store.BlindIntros(pl.db).GetFor(ctx, store.DefaultRealm, 2, now)
+		assert.NoError(t, err)
+		var none *store.BlindIntro
+		assert.Equal(t, intro, none)
+	})
+
+	t.Run("anonymizes matches", func(t *testing.T) {
+		pl, rec := setup(t)
+
+		_, err := pl.RequestDeletion(ctx, rec, true)
+		assert.NoError(t, err)
+		msg, err := pl.ConfirmDeletion(ctx, rec)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Contains(msg, "your past matches (1)"), true)
+
+		got := exported(t, pl, rec)
+		assert.Equal(t, got.Recurser, nil)
+		assert.Equal(t, len(got.Matches), 0)
+
+		// The partner's match still counts for them.
+		theirs, err := store.Pairings(pl.db).GetAllMatchesFor(ctx, store.DefaultRealm, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, theirs[0].Recursers, []int64{0, 2})
+	})
+}
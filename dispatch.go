@@ -2,22 +2,39 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/recursecenter/pairing-bot/recurse"
 	"github.com/recursecenter/pairing-bot/store"
 )
 
+// dispatch runs the command and returns the reply. If it fails, the error is
+// usually a commandError, and replyFor picks what to tell the Recurser.
 func (pl *PairingLogic) dispatch(ctx context.Context, cmd string, cmdArgs []string, rec *store.Recurser) (string, error) {
 	// here's the actual actions. command input from
 	// the user input has already been sanitized, so we can
 	// trust that cmd and cmdArgs only have valid stuff in them
 	switch cmd {
 	case "schedule":
-		return pl.SetSchedule(ctx, rec, cmdArgs)
+		days, biweekly := cmdArgs, []string(nil)
+		if i := slices.Index(cmdArgs, "biweekly"); i >= 0 {
+			days, biweekly = cmdArgs[:i], cmdArgs[i+1:]
+		}
+		return pl.SetSchedule(ctx, rec, days, biweekly)
+
+	case "thisweek":
+		return pl.ThisWeek(ctx, rec, cmdArgs[0], cmdArgs[1:])
+
+	case "mute", "unmute":
+		return pl.Mute(ctx, rec, cmdArgs, cmd == "mute")
 
 	case "subscribe":
 		return pl.Subscribe(ctx, rec)
@@ -25,112 +42,1235 @@ func (pl *PairingLogic) dispatch(ctx context.Context, cmd string, cmdArgs []stri
 	case "unsubscribe":
 		return pl.Unsubscribe(ctx, rec)
 
+	case "restore":
+		return pl.Restore(ctx, rec)
+
 	case "skip":
-		return pl.SkipTomorrow(ctx, rec)
+		switch cmdArgs[0] {
+		case "tomorrow":
+			return pl.SkipTomorrow(ctx, rec)
+		case "days":
+			days, _ := strconv.Atoi(cmdArgs[1])
+			return pl.SkipDays(ctx, rec, days)
+		}
+		return pl.SkipDate(ctx, rec, cmdArgs[0])
 
 	case "unskip":
-		return pl.UnskipTomorrow(ctx, rec)
+		switch cmdArgs[0] {
+		case "tomorrow":
+			return pl.UnskipTomorrow(ctx, rec)
+		case "days":
+			return pl.UnskipDays(ctx, rec)
+		}
+		return pl.UnskipDate(ctx, rec, cmdArgs[0])
+
+	case "pause":
+		weeks := 0
+		if len(cmdArgs) > 0 {
+			weeks, _ = strconv.Atoi(cmdArgs[0])
+		}
+		return pl.Pause(ctx, rec, weeks)
+
+	case "resume":
+		return pl.Resume(ctx, rec)
 
 	case "status":
 		return pl.Status(ctx, rec)
 
-	case "add-review":
-		content := cmdArgs[0]
-		return pl.AddReview(ctx, rec, content)
+	case "next":
+		return pl.Next(ctx, rec)
+
+	case "topics":
+		return pl.Topics(ctx, rec)
+
+	case "stats":
+		return pl.Stats(ctx, rec)
+
+	case "noshow":
+		return pl.ReportNoShow(ctx, rec)
+
+	case "rate":
+		rating, _ := strconv.Atoi(cmdArgs[0])
+		return pl.Rate(ctx, rec, rating)
+
+	case "leaderboard":
+		return pl.Leaderboard(ctx)
+
+	case "milestones":
+		return pl.Milestones(ctx)
+
+	case "history":
+		return pl.History(ctx, rec)
+
+	case "theme":
+		return pl.Theme(ctx)
+
+	case "match":
+		return pl.MatchNow(ctx, rec)
+
+	case "cancel":
+		return pl.CancelMatchNow(ctx, rec)
+
+	case "confirm":
+		return pl.ConfirmMatch(ctx, rec)
+
+	case "decline":
+		return pl.DeclineMatch(ctx, rec)
+
+	case "pair":
+		switch cmdArgs[0] {
+		case "accept":
+			return pl.AcceptPair(ctx, rec)
+		case "decline":
+			return pl.DeclinePair(ctx, rec)
+		}
+		return pl.RequestPair(ctx, rec, cmdArgs[1:])
+
+	case "block":
+		return pl.Block(ctx, rec, cmdArgs)
+
+	case "unblock":
+		return pl.Unblock(ctx, rec, cmdArgs)
+
+	case "blocks":
+		return pl.ListBlocks(ctx, rec)
+
+	case "who":
+		return pl.Who(ctx, rec, cmdArgs)
+
+	case "met":
+		return pl.Met(ctx, rec, cmdArgs)
+
+	case "availability":
+		return pl.Availability(ctx, rec)
+
+	case "alias":
+		return pl.AddAlias(ctx, rec, cmdArgs[0], cmdArgs[1])
+
+	case "unalias":
+		return pl.RemoveAlias(ctx, rec, cmdArgs[0])
+
+	case "aliases":
+		return pl.ListAliases(ctx, rec)
+
+	case "save":
+		return pl.SaveSchedule(ctx, rec, cmdArgs[0])
+
+	case "load":
+		return pl.LoadSchedule(ctx, rec, cmdArgs[0])
+
+	case "schedules":
+		return pl.ListSchedules(ctx, rec)
+
+	case "export":
+		return pl.Export(ctx, rec)
+
+	case "reset":
+		if len(cmdArgs) == 0 {
+			return pl.Reset(ctx, rec)
+		}
+		return pl.ConfirmReset(ctx, rec)
+
+	case "delete":
+		switch {
+		case cmdArgs[0] == "schedule":
+			return pl.DeleteSchedule(ctx, rec, cmdArgs[1])
+		case len(cmdArgs) == 1:
+			return pl.RequestDeletion(ctx, rec, false)
+		case cmdArgs[1] == "matches":
+			return pl.RequestDeletion(ctx, rec, true)
+		case cmdArgs[1] == "confirm":
+			return pl.ConfirmDeletion(ctx, rec)
+		}
+		return pl.CancelDeletion(ctx, rec)
+
+	case "set":
+		switch cmdArgs[0] {
+		case "timezone":
+			return pl.SetTimezone(ctx, rec, cmdArgs[1])
+		case "bio":
+			return pl.SetBio(ctx, rec, cmdArgs[1])
+		case "theme":
+			if !isMaintainer(rec.Realm, rec.ID) {
+				return "Sorry, only maintainers can set the theme.", nil
+			}
+			return pl.SetTheme(ctx, rec, cmdArgs[1])
+		case "topics":
+			return pl.SetTopics(ctx, rec, cmdArgs[1:])
+		case "weekly-summary":
+			return pl.SetWeeklySummary(ctx, rec, cmdArgs[1] == "on")
+		case "leaderboard":
+			return pl.SetShowOnLeaderboard(ctx, rec, cmdArgs[1] == "on")
+		case "discoverable":
+			return pl.SetDiscoverable(ctx, rec, cmdArgs[1] == "on")
+		case "email-fallback":
+			return pl.SetEmailFallback(ctx, rec, cmdArgs[1] == "on")
+		case "nudge":
+			return pl.SetNudge(ctx, rec, cmdArgs[1] == "on")
+		case "history":
+			return pl.SetKeepHistory(ctx, rec, cmdArgs[1] == "on")
+		case "confirm":
+			return pl.SetConfirmMatches(ctx, rec, cmdArgs[1] == "on")
+		case "mentor":
+			return pl.SetMentor(ctx, rec, cmdArgs[1] == "on")
+		case "matchtime":
+			return pl.SetMatchTime(ctx, rec, cmdArgs[1])
+		case "maxweekly":
+			maxWeekly, _ := strconv.Atoi(cmdArgs[1])
+			return pl.SetMaxWeekly(ctx, rec, maxWeekly)
+		case "duration":
+			duration, _ := strconv.Atoi(cmdArgs[1])
+			return pl.SetDuration(ctx, rec, duration)
+		case "batchpref":
+			return pl.SetBatchPref(ctx, rec, cmdArgs[1])
+		case "mode":
+			return pl.SetMode(ctx, rec, cmdArgs[1])
+		case "verbosity":
+			return pl.SetVerbosity(ctx, rec, cmdArgs[1])
+		}
+		return "", nil
+
+	case "clear":
+		switch cmdArgs[0] {
+		case "bio":
+			return pl.SetBio(ctx, rec, "")
+		case "theme":
+			if !isMaintainer(rec.Realm, rec.ID) {
+				return "Sorry, only maintainers can clear the theme.", nil
+			}
+			return pl.SetTheme(ctx, rec, "")
+		case "topics":
+			return pl.SetTopics(ctx, rec, nil)
+		case "matchtime":
+			return pl.SetMatchTime(ctx, rec, "")
+		case "duration":
+			return pl.SetDuration(ctx, rec, 0)
+		}
+		return "", nil
+
+	case "add-review", "anonymous-review":
+		content := cmdArgs[0]
+		var category string
+		if len(cmdArgs) > 1 {
+			category = cmdArgs[1]
+		}
+		return pl.AddReview(ctx, rec, content, category, cmd == "anonymous-review")
+
+	case "bug":
+		return pl.ReportBug(ctx, rec, cmdArgs[0])
+
+	case "get-reviews":
+		numReviews := 5
+		if len(cmdArgs) > 0 {
+			numReviews, _ = strconv.Atoi(cmdArgs[0])
+		}
+		return pl.GetReviews(ctx, numReviews)
+
+	case "reviews":
+		if !isMaintainer(rec.Realm, rec.ID) {
+			return "Sorry, only maintainers can page through the reviews.", nil
+		}
+		return pl.ReviewsPage(ctx, rec, len(cmdArgs) > 0)
+
+	case "announce":
+		if !isMaintainer(rec.Realm, rec.ID) {
+			return "Sorry, only maintainers can send announcements.", nil
+		}
+		switch cmdArgs[0] {
+		case "confirm":
+			return pl.ConfirmAnnouncement(ctx, rec)
+		case "cancel":
+			return pl.CancelAnnouncement(ctx, rec)
+		}
+		return pl.DraftAnnouncement(ctx, rec, cmdArgs[1])
+
+	case "dedupe":
+		if !isMaintainer(rec.Realm, rec.ID) {
+			return "Sorry, only maintainers can merge duplicate records.", nil
+		}
+		return pl.Dedupe(ctx)
+
+	case "maintenance":
+		if !isMaintainer(rec.Realm, rec.ID) {
+			return "Sorry, only maintainers can change maintenance mode.", nil
+		}
+		if len(cmdArgs) == 0 {
+			return pl.MaintenanceStatus(ctx, rec)
+		}
+		return pl.SetMaintenance(ctx, rec, cmdArgs[0] == "on")
+
+	case "blindintros":
+		if !isMaintainer(rec.Realm, rec.ID) {
+			return "Sorry, only maintainers can change blind intros.", nil
+		}
+		if len(cmdArgs) == 0 {
+			return pl.BlindIntrosStatus(ctx, rec)
+		}
+		return pl.SetBlindIntros(ctx, rec, cmdArgs[0] == "on")
+
+	case "minparticipants":
+		if !isMaintainer(rec.Realm, rec.ID) {
+			return "Sorry, only maintainers can change the minimum for a match run.", nil
+		}
+		if len(cmdArgs) == 0 {
+			return pl.MinParticipantsStatus(ctx, rec)
+		}
+		return pl.SetMinParticipants(ctx, rec, cmdArgs)
+
+	case "weekends":
+		if !isMaintainer(rec.Realm, rec.ID) {
+			return "Sorry, only maintainers can change weekend matching.", nil
+		}
+		switch len(cmdArgs) {
+		case 0:
+			return pl.WeekendsStatus(ctx, rec)
+		case 1:
+			return pl.SetWeekends(ctx, rec, cmdArgs[0] == "on", "")
+		}
+		return pl.SetWeekends(ctx, rec, cmdArgs[0] == "on", cmdArgs[1])
+
+	case "holidays":
+		if !isMaintainer(rec.Realm, rec.ID) {
+			return "Sorry, only maintainers can change the holiday calendar.", nil
+		}
+		switch {
+		case len(cmdArgs) == 0:
+			return pl.HolidaysStatus(ctx)
+		case cmdArgs[0] == "remove":
+			return pl.RemoveHoliday(ctx, cmdArgs[1])
+		case cmdArgs[0] == "timezone":
+			return pl.SetHolidayTimezone(ctx, rec, cmdArgs[1])
+		}
+		return pl.AddHoliday(ctx, rec, cmdArgs[1], cmdArgs[2], cmdArgs[3] == "announce")
+
+	case "dailypost":
+		if !isMaintainer(rec.Realm, rec.ID) {
+			return "Sorry, only maintainers can change the daily post.", nil
+		}
+		switch {
+		case len(cmdArgs) == 0:
+			return pl.DailyPostStatus(ctx, rec)
+		case len(cmdArgs) == 1:
+			return pl.SetDailyPost(ctx, rec, "", "")
+		}
+		return pl.SetDailyPost(ctx, rec, cmdArgs[0], cmdArgs[1])
+
+	case "trends":
+		if !isMaintainer(rec.Realm, rec.ID) {
+			return "Sorry, only maintainers can see the usage trends.", nil
+		}
+		days, _ := strconv.Atoi(cmdArgs[0])
+		return pl.Trends(ctx, days)
+
+	case "fun":
+		if !isMaintainer(rec.Realm, rec.ID) {
+			return "Sorry, only maintainers can change the fun responses.", nil
+		}
+		switch {
+		case len(cmdArgs) == 0:
+			return pl.FunStatus(ctx)
+		case cmdArgs[0] == "add":
+			return pl.AddFunResponse(ctx, cmdArgs[1], cmdArgs[2])
+		}
+		return pl.RemoveFunResponse(ctx, cmdArgs[1], cmdArgs[2])
+
+	case "roster":
+		if !isMaintainer(rec.Realm, rec.ID) {
+			return "Sorry, only maintainers can see the roster.", nil
+		}
+		return pl.Roster(ctx, cmdArgs[0])
+
+	case "cookie":
+		return cookieClubMessage, nil
+
+	case "help":
+		if len(cmdArgs) > 0 {
+			return helpFor(cmdArgs[0], rec.IsSubscribed), nil
+		}
+		return helpFor("", rec.IsSubscribed), nil
+
+	case "version":
+		return pl.version, nil
+
+	case "thanks":
+		return youreWelcomeMessage, nil
+
+	default:
+		// this won't execute because all input has been sanitized
+		// by parseCmd() and all cases are handled explicitly above
+		return "", nil
+	}
+}
+
+func (pl *PairingLogic) SetSchedule(ctx context.Context, rec *store.Recurser, days, biweekly []string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.Schedule = store.NewSchedule(days)
+	rec.SetBiweekly(time.Now(), biweekly)
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	// The schedule is saved either way. This is just a heads-up.
+	var warning string
+	if counts, err := store.Recursers(pl.db).CountScheduledDays(ctx, rec.ID); err != nil {
+		logger(ctx).Warn("Could not check whether anyone shares the schedule", slog.Any("error", err))
+	} else {
+		warning = noOverlapWarning(rec, counts)
+	}
+	return "Awesome, your new schedule's been set! You can check it with `status`." + warning, nil
+}
+
+// noOverlapWarning returns a note for the Recurser if nobody else is scheduled
+// on any of their days (counts are from CountScheduledDays), since they'd never
+// be matched. Otherwise it returns the empty string.
+func noOverlapWarning(rec *store.Recurser, counts map[string]int) string {
+	var days []string
+	for _, day := range scheduleShortcuts["everyday"] {
+		if !rec.IsScheduledOn(day) {
+			continue
+		}
+		if counts[day] > 0 {
+			return ""
+		}
+		days = append(days, dayName(day))
+	}
+	if len(days) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n\n:warning: Heads up: nobody else is scheduled on %s right now, so you won't be matched until someone is. Adding another day or two to your `schedule` would help.", joinAnd(days))
+}
+
+// ThisWeek adds days to (or removes them from) the Recurser's schedule for the
+// rest of this week only. "clear" undoes every change for the week.
+func (pl *PairingLogic) ThisWeek(ctx context.Context, rec *store.Recurser, action string, days []string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	now := time.Now()
+
+	if action == "clear" {
+		rec.ThisWeek = store.WeekOverride{}
+		if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+			return "", writeError(err)
+		}
+		return "Okay, you're back to your usual schedule for the rest of this week.", nil
+	}
+
+	var changed, passed []string
+	for _, day := range days {
+		// DateOnly strings sort chronologically.
+		if rec.ThisWeekDate(now, day) < rec.MatchDate(now) {
+			passed = append(passed, dayName(day))
+			continue
+		}
+		rec.OverrideThisWeek(now, day, action == "add")
+		changed = append(changed, day)
+	}
+
+	if len(changed) == 0 {
+		return fmt.Sprintf("%s already went by this week, so there's nothing to change.", joinAnd(passed)), nil
+	}
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	reply := fmt.Sprintf("Got it! This week, %s. Next week, you're back to your usual schedule.", describeWeekOverride(rec.ActiveWeekOverride(now)))
+	if len(passed) > 0 {
+		reply += fmt.Sprintf(" (%s already went by, so I left it alone.)", joinAnd(passed))
+	}
+	return reply, nil
+}
+
+// Mute stops (or, with mute false, restarts) matching the Recurser on the days
+// every week, without changing their schedule.
+func (pl *PairingLogic) Mute(ctx context.Context, rec *store.Recurser, days []string, mute bool) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	var changed []string
+	for _, day := range days {
+		if rec.MutedDays[day] == mute {
+			continue
+		}
+		if mute {
+			if rec.MutedDays == nil {
+				rec.MutedDays = make(map[string]bool)
+			}
+			rec.MutedDays[day] = true
+		} else {
+			delete(rec.MutedDays, day)
+		}
+		changed = append(changed, dayName(day)+"s")
+	}
+
+	if len(changed) == 0 {
+		if mute {
+			return "You've already muted those days. Send `unmute` and the days to be matched on them again.", nil
+		}
+		return "You haven't muted those days, so there's nothing to unmute.", nil
+	}
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if mute {
+		return fmt.Sprintf("Got it, I won't match you on **%s** until you `unmute` them. Your schedule is kept as it is.", joinAnd(changed)), nil
+	}
+	return fmt.Sprintf("Okay, **%s** are back to following your schedule.", joinAnd(changed)), nil
+}
+
+// describeWeekOverride summarizes a week's schedule changes, like "you're
+// pairing on **Thursday** and not on **Monday**".
+func describeWeekOverride(override map[string]bool) string {
+	var added, removed []string
+	for i := range 7 {
+		day := strings.ToLower(time.Weekday((i + 1) % 7).String())
+		pair, ok := override[day]
+		switch {
+		case !ok:
+		case pair:
+			added = append(added, dayName(day))
+		default:
+			removed = append(removed, dayName(day))
+		}
+	}
+
+	var parts []string
+	if len(added) > 0 {
+		parts = append(parts, fmt.Sprintf("you're pairing on **%s**", joinAnd(added)))
+	}
+	if len(removed) > 0 {
+		if len(parts) > 0 {
+			parts = append(parts, fmt.Sprintf("not on **%s**", joinAnd(removed)))
+		} else {
+			parts = append(parts, fmt.Sprintf("you're not pairing on **%s**", joinAnd(removed)))
+		}
+	}
+	return strings.Join(parts, " and ")
+}
+
+// dayName capitalizes a lowercase day name for messages.
+func dayName(day string) string {
+	return strings.ToUpper(day[:1]) + day[1:]
+}
+
+// joinAnd joins items into an English list, like "A, B, and C".
+func joinAnd(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	case 2:
+		return items[0] + " and " + items[1]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + ", and " + items[len(items)-1]
+}
+
+func (pl *PairingLogic) SetTimezone(ctx context.Context, rec *store.Recurser, zone string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	// LoadLocation treats this specially, but it isn't what anyone means by
+	// "my time zone".
+	if zone == "Local" {
+		return renderUnknownTimezone(zone)
+	}
+
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return renderUnknownTimezone(zone)
+	}
+
+	rec.Timezone = loc.String()
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Got it! I'll use **%s** to figure out which day it is for you.", rec.Timezone), nil
+}
+
+// maxBioLength is the longest bio (in characters) we'll store.
+const maxBioLength = 280
+
+// SetBio updates the bio shared with the Recurser's matches. An empty bio
+// clears it.
+func (pl *PairingLogic) SetBio(ctx context.Context, rec *store.Recurser, bio string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	if n := utf8.RuneCountInString(bio); n > maxBioLength {
+		return fmt.Sprintf("That bio is %d characters long, but the limit is %d. Could you trim it down a bit?", n, maxBioLength), nil
+	}
+
+	rec.Bio = bio
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if bio == "" {
+		return "Your bio has been cleared.", nil
+	}
+	return "Nice to meet you! I'll share your bio with your pairing partners.", nil
+}
+
+// SetTopics replaces the Recurser's topics of interest. An empty list clears
+// them.
+func (pl *PairingLogic) SetTopics(ctx context.Context, rec *store.Recurser, topics []string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.Topics = store.NewTopics(topics)
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if len(rec.Topics) == 0 {
+		return "Your topics have been cleared.", nil
+	}
+	return fmt.Sprintf("Your topics are now: %s. I'll try to match you with people who share them!", formatTopics(rec.Topics)), nil
+}
+
+// Topics shows the Recurser's current topics of interest.
+func (pl *PairingLogic) Topics(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	if len(rec.Topics) == 0 {
+		return "You haven't set any topics yet. Try `set topics go, rust, distributed-systems`!", nil
+	}
+	return fmt.Sprintf("Your topics are: %s", formatTopics(rec.Topics)), nil
+}
+
+// formatTopics renders topic tags as a list of inline code spans.
+func formatTopics(topics []string) string {
+	var formatted []string
+	for _, topic := range topics {
+		formatted = append(formatted, "`"+topic+"`")
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// maxThemeLength is the longest theme (in characters) we'll store. It's the
+// same as for bios, since both end up in match messages.
+const maxThemeLength = maxBioLength
+
+// SetTheme sets the conversation starter that goes out with every match
+// message. An empty theme clears it.
+func (pl *PairingLogic) SetTheme(ctx context.Context, rec *store.Recurser, text string) (string, error) {
+	if text == "" {
+		if err := store.Themes(pl.db).Clear(ctx); err != nil {
+			return "", writeError(err)
+		}
+		return "Okay, match messages won't have a theme anymore.", nil
+	}
+
+	if n := utf8.RuneCountInString(text); n > maxThemeLength {
+		return fmt.Sprintf("That theme is %d characters long, but the limit is %d. Could you trim it down a bit?", n, maxThemeLength), nil
+	}
+
+	err := store.Themes(pl.db).Set(ctx, store.Theme{
+		Text:      text,
+		SetBy:     rec.ID,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Got it! Match messages will suggest this theme until it's changed:%s", themeNote(text)), nil
+}
+
+// Theme shows the current theme, if there is one.
+func (pl *PairingLogic) Theme(ctx context.Context) (string, error) {
+	theme, err := store.Themes(pl.db).Get(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+	if theme == nil {
+		return "There's no theme right now. Pair on whatever you like!", nil
+	}
+	return fmt.Sprintf("This week's theme is: %s", theme.Text), nil
+}
+
+// SetWeeklySummary opts the Recurser in to (or out of) the weekly pairing
+// summary message.
+func (pl *PairingLogic) SetWeeklySummary(ctx context.Context, rec *store.Recurser, enabled bool) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.WeeklySummaryOptOut = !enabled
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if enabled {
+		return "I'll send you a summary of who you paired with at the end of each week.", nil
+	}
+	return "Okay, no more weekly summaries.", nil
+}
+
+// SetKeepHistory opts the Recurser in to (or out of) recording the commands
+// they send.
+func (pl *PairingLogic) SetKeepHistory(ctx context.Context, rec *store.Recurser, keep bool) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.KeepHistory = keep
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if keep {
+		return "I'll remember the commands you send me from now on. Use `history` to see them.", nil
+	}
+	return "Okay, I'll stop remembering your commands.", nil
+}
+
+// SetConfirmMatches opts the Recurser in to (or out of) confirming each match
+// before it's recorded.
+func (pl *PairingLogic) SetConfirmMatches(ctx context.Context, rec *store.Recurser, confirm bool) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.ConfirmMatches = confirm
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if confirm {
+		return fmt.Sprintf("From now on, I'll ask you to `confirm` each match within %d hours. Matches you don't confirm won't count.", int(matchConfirmWindow.Hours())), nil
+	}
+	return "Okay, your matches will count without confirming them.", nil
+}
+
+// historyLength is how many commands `history` shows.
+const historyLength = 10
+
+// History shows the Recurser's most recent commands, if they've opted in to
+// keeping them.
+func (pl *PairingLogic) History(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+	if !rec.KeepHistory {
+		return "I'm not keeping track of your commands. Use `set history on` if you'd like me to!", nil
+	}
+
+	entries, err := store.History(pl.db).GetLastN(ctx, rec.Realm, rec.ID, historyLength)
+	if err != nil {
+		return "", readError(err)
+	}
+	if len(entries) == 0 {
+		return "You haven't sent me any commands since turning on history.", nil
+	}
+
+	loc := rec.Location()
+	history := "Your most recent commands:"
+	for _, entry := range entries {
+		sentAt := time.Unix(entry.Timestamp, 0).In(loc).Format("2006-01-02 15:04")
+		history += fmt.Sprintf("\n* %s: `%s`", sentAt, entry.Command)
+	}
+	return history, nil
+}
+
+// SetShowOnLeaderboard opts the Recurser in to (or out of) the leaderboard.
+func (pl *PairingLogic) SetShowOnLeaderboard(ctx context.Context, rec *store.Recurser, show bool) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.ShowOnLeaderboard = show
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if show {
+		return "You're on the board! Check it out with `leaderboard` (it might take a few minutes to show up).", nil
+	}
+	return "Okay, I'll leave you off the leaderboard.", nil
+}
+
+// SetDiscoverable opts the Recurser in to (or out of) showing their profile
+// to `who`.
+func (pl *PairingLogic) SetDiscoverable(ctx context.Context, rec *store.Recurser, discoverable bool) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.Discoverable = discoverable
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if discoverable {
+		return "Done. Anyone can now see your bio, topics, and whether you're at RC with `who`.", nil
+	}
+	return "Okay, `who` won't show your profile anymore.", nil
+}
+
+// SetEmailFallback opts the Recurser in to (or out of) getting their match
+// message by email when it can't be sent on Zulip.
+func (pl *PairingLogic) SetEmailFallback(ctx context.Context, rec *store.Recurser, on bool) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.EmailFallback = on
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if !on {
+		return "Okay, I won't email you.", nil
+	}
+	if rec.Email == "" {
+		return "Done, but I don't know your email address, so I can't actually email you. Make sure your email is visible to bots in your Zulip settings.", nil
+	}
+	return fmt.Sprintf("Done. If I can't send you your match on Zulip, I'll email it to %s instead.", rec.Email), nil
+}
+
+// SetNudge opts the Recurser in to (or out of) a reminder a few hours after
+// their match message.
+func (pl *PairingLogic) SetNudge(ctx context.Context, rec *store.Recurser, on bool) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.Nudge = on
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if on {
+		return "Done. A few hours after your match message, I'll remind you to get in touch with your partner.", nil
+	}
+	return "Okay, no more reminders.", nil
+}
+
+// SetMatchTime sets the local time of day when the Recurser hears about their
+// daily match. An empty value means as soon as possible.
+func (pl *PairingLogic) SetMatchTime(ctx context.Context, rec *store.Recurser, matchTime string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.MatchTime = matchTime
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if matchTime == "" {
+		return "Okay, I'll tell you about your matches as soon as I make them.", nil
+	}
+	return fmt.Sprintf("Got it! I'll tell you about your matches around **%s** (%s). If your partner picked an earlier time, you'll hear at their time instead.", matchTime, rec.Location()), nil
+}
+
+// SetMaxWeekly limits how many times the Recurser is matched in a week. Zero
+// means no limit.
+func (pl *PairingLogic) SetMaxWeekly(ctx context.Context, rec *store.Recurser, maxWeekly int) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.MaxWeekly = maxWeekly
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if maxWeekly == 0 {
+		return "No limits! I'll match you on every day in your schedule.", nil
+	}
+	return fmt.Sprintf("Got it, I'll match you at most **%d** time(s) in any 7-day stretch.", maxWeekly), nil
+}
+
+// `set duration` takes between minDuration and maxDuration minutes.
+const (
+	minDuration = 15
+	maxDuration = 240
+)
+
+// SetDuration sets how long the Recurser would like to pair for, in minutes.
+// Zero means no preference.
+func (pl *PairingLogic) SetDuration(ctx context.Context, rec *store.Recurser, minutes int) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.Duration = minutes
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if minutes == 0 {
+		return "Okay, your match messages won't say how long you'd like to pair.", nil
+	}
+	return fmt.Sprintf("Got it! Your match messages will say you'd like to pair for about **%d minutes**, so you and your partner can work out how long to go for.", minutes), nil
+}
+
+// SetBatchPref sets whether the Recurser would rather pair within their own
+// batch or across batches.
+func (pl *PairingLogic) SetBatchPref(ctx context.Context, rec *store.Recurser, pref string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.BatchPref = pref
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	switch pref {
+	case store.BatchPrefSame:
+		return "Got it, I'll try to match you with people in your own batch.", nil
+	case store.BatchPrefCross:
+		return "Got it, I'll try to match you with people from other batches.", nil
+	}
+	return "Got it, I'll match you with anyone, whatever their batch.", nil
+}
+
+// SetMode sets how the Recurser would rather pair. In person only makes sense
+// for people at the hub, since that's the only way they'll be matched.
+func (pl *PairingLogic) SetMode(ctx context.Context, rec *store.Recurser, mode string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+	if mode == store.ModeInPerson && !rec.CurrentlyAtRC {
+		return "Sorry, in person pairing is only for people who are at RC right now. Try `set mode voice` or `set mode text` instead!", nil
+	}
+
+	rec.Mode = mode
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if mode == store.ModeAny {
+		return "Got it, I'll match you with anyone, however they'd like to pair.", nil
+	}
+	return fmt.Sprintf("Got it, I'll try to match you with people who'd also like to pair %s, and let your partners know.", modeNames[mode]), nil
+}
+
+// SetVerbosity sets how much the Recurser's match messages include.
+func (pl *PairingLogic) SetVerbosity(ctx context.Context, rec *store.Recurser, verbosity string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.Verbosity = verbosity
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	switch verbosity {
+	case store.VerbosityMinimal:
+		return "Got it, your match messages will just say who you're paired with.", nil
+	case store.VerbosityFull:
+		return "Got it, your match messages will include the theme and everyone's bios and topics.", nil
+	}
+	return "Got it, your match messages will include the theme and everyone's bios.", nil
+}
+
+func (pl *PairingLogic) Subscribe(ctx context.Context, rec *store.Recurser) (string, error) {
+	if rec.IsSubscribed {
+		return "You're already subscribed! Use `schedule` to set your schedule.", nil
+	}
+
+	now := time.Now()
+	if rec.InSubscriptionCooldown(now) {
+		return cooldownMessage, nil
+	}
+
+	if rec.CanRestore(now) {
+		return pl.Restore(ctx, rec)
+	}
+
+	if rec.UnsubscribedAt != 0 {
+		// Too late to restore, so start over as if this were a new Recurser.
+		rec = &store.Recurser{
+			ID:       rec.ID,
+			Name:     rec.Name,
+			Email:    rec.Email,
+			Schedule: store.DefaultSchedule(),
+			Realm:    rec.Realm,
+		}
+	}
+
+	// Pairing Bot is only for Recursers, so make sure this is one. This also
+	// catches accounts that don't share an email with the RC directory.
+	_, err := pl.recurse.ProfileByEmail(ctx, rec.Email)
+	if errors.Is(err, recurse.ErrNotFound) {
+		return notARecurserMessage, nil
+	} else if err != nil {
+		logger(ctx).Warn("Could not look up profile from RC API", slog.Any("error", err))
+		return "", readError(err)
+	}
+
+	atRC, err := pl.recurse.IsCurrentlyAtRC(ctx, rec.ID)
+	if err != nil {
+		logger(ctx).Warn("Could not read currently-at-RC data from RC API", slog.Any("error", err))
+		return "", readError(err)
+	}
+
+	rec.CurrentlyAtRC = atRC
+	rec.SubscriptionChangedAt = now.Unix()
+
+	saved, err := store.Recursers(pl.db).EnsureSubscribed(ctx, rec)
+	if err != nil {
+		logger(ctx).Error("Could not update recurser in database", slog.Any("error", err))
+		return "", writeError(err)
+	}
+	if !saved {
+		// Another command subscribed them while this one was running.
+		return "You're already subscribed! Use `schedule` to set your schedule.", nil
+	}
+	return subscribeMessage, nil
+}
+
+func (pl *PairingLogic) Unsubscribe(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	now := time.Now()
+	if rec.InSubscriptionCooldown(now) {
+		return cooldownMessage, nil
+	}
+
+	// Keep the record around for a while in case this was a mistake. The
+	// end-of-batch job purges it once the grace period is over.
+	rec.UnsubscribedAt = now.Unix()
+	rec.SubscriptionChangedAt = now.Unix()
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+	return unsubscribeMessage, nil
+}
+
+// Restore re-subscribes a Recurser who recently unsubscribed, keeping their
+// old schedule and settings.
+func (pl *PairingLogic) Restore(ctx context.Context, rec *store.Recurser) (string, error) {
+	if rec.IsSubscribed {
+		return "You're already subscribed, so there's nothing to restore!", nil
+	}
+	now := time.Now()
+	if !rec.CanRestore(now) {
+		return "I don't have any recent settings to restore for you. Use `subscribe` to start fresh!", nil
+	}
+	if rec.InSubscriptionCooldown(now) {
+		return cooldownMessage, nil
+	}
+
+	rec.UnsubscribedAt = 0
+	rec.SubscriptionChangedAt = now.Unix()
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+	return "Welcome back! I've restored your old schedule and settings. Use `status` to check them.", nil
+}
+
+func (pl *PairingLogic) SkipTomorrow(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	rec.IsSkippingTomorrow = true
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+	// The flag is cleared by the next match run, which might not be pairing
+	// for tomorrow in their time zone, so say which day it is.
+	return fmt.Sprintf("Tomorrow: cancelled. I feel you. **I will not match you** for pairing in the next match run, which is for **%s** your time <3", rec.NextRunDate(time.Now())), nil
+}
+
+func (pl *PairingLogic) UnskipTomorrow(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+	rec.IsSkippingTomorrow = false
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Tomorrow: uncancelled! Heckin *yes*! **I will match you** for pairing in the next match run, which is for **%s** your time :)", rec.NextRunDate(time.Now())), nil
+}
+
+// maxSkipDays is the most days `skip <n> days` can skip. Longer breaks are
+// what `pause` is for.
+const maxSkipDays = 30
 
-	case "get-reviews":
-		numReviews := 5
-		if len(cmdArgs) > 0 {
-			numReviews, _ = strconv.Atoi(cmdArgs[0])
-		}
-		return pl.GetReviews(ctx, numReviews)
+// SkipDays skips the Recurser's next n days, whether or not they're on their
+// schedule. This replaces any earlier `skip <n> days`.
+func (pl *PairingLogic) SkipDays(ctx context.Context, rec *store.Recurser, n int) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
 
-	case "cookie":
-		return cookieClubMessage, nil
+	rec.SkipDays(time.Now(), n)
 
-	case "help":
-		return helpMessage, nil
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+	last := time.Unix(rec.SkipUntil, 0).In(rec.Location()).AddDate(0, 0, -1)
+	return fmt.Sprintf("Got it, **I will not match you** for pairing through %s. Send `unskip days` to come back early!", last.Format("Monday, January 2")), nil
+}
 
-	case "version":
-		return pl.version, nil
+// UnskipDays undoes `skip <n> days`.
+func (pl *PairingLogic) UnskipDays(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
 
-	case "thanks":
-		return youreWelcomeMessage, nil
+	if rec.SkipDaysLeft(time.Now()) == 0 {
+		return "You weren't skipping any days, so there's nothing to undo!", nil
+	}
+	rec.SkipUntil = 0
 
-	default:
-		// this won't execute because all input has been sanitized
-		// by parseCmd() and all cases are handled explicitly above
-		return "", nil
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
 	}
+	return "Back on! **I will match you** on your usual schedule again.", nil
 }
 
-func (pl *PairingLogic) SetSchedule(ctx context.Context, rec *store.Recurser, days []string) (string, error) {
+// Pause stops matching the Recurser for the given number of weeks, keeping
+// their schedule intact. Zero weeks means they stay paused until they resume.
+func (pl *PairingLogic) Pause(ctx context.Context, rec *store.Recurser, weeks int) (string, error) {
 	if !rec.IsSubscribed {
 		return notSubscribedMessage, nil
 	}
 
-	rec.Schedule = store.NewSchedule(days)
+	var response string
+	if weeks == 0 {
+		rec.PausedUntil = store.PausedIndefinitely
+		response = "Pairing is paused until you tell me to `resume`. Your schedule will be right where you left it."
+	} else {
+		until := time.Now().AddDate(0, 0, 7*weeks)
+		rec.PausedUntil = until.Unix()
+		response = fmt.Sprintf("Pairing is paused until **%s**. Your schedule will be right where you left it. Send `resume` to come back early!", until.In(rec.Location()).Format("Monday, January 2"))
+	}
 
-	if err := store.Recursers(pl.db).Set(ctx, rec.ID, rec); err != nil {
-		return writeErrorMessage, err
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
 	}
-	return "Awesome, your new schedule's been set! You can check it with `status`.", nil
+	return response, nil
 }
 
-func (pl *PairingLogic) Subscribe(ctx context.Context, rec *store.Recurser) (string, error) {
-	if rec.IsSubscribed {
-		return "You're already subscribed! Use `schedule` to set your schedule.", nil
+// Resume ends a pause early.
+func (pl *PairingLogic) Resume(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
 	}
 
-	atRC, err := pl.recurse.IsCurrentlyAtRC(ctx, rec.ID)
-	if err != nil {
-		log.Printf("Could not read currently-at-RC data from RC API: %s", err)
-		return readErrorMessage, err
+	if !rec.IsPaused(time.Now()) {
+		return "You're not paused! I'll keep matching you on your usual schedule.", nil
 	}
 
-	rec.CurrentlyAtRC = atRC
+	rec.PausedUntil = 0
 
-	if err = store.Recursers(pl.db).Set(ctx, rec.ID, rec); err != nil {
-		log.Printf("Could not update recurser in database: %s", err)
-		return writeErrorMessage, err
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
 	}
-	return subscribeMessage, nil
+	return "Welcome back! I'll start matching you on your usual schedule again.", nil
 }
 
-func (pl *PairingLogic) Unsubscribe(ctx context.Context, rec *store.Recurser) (string, error) {
+// SkipDate adds a one-off date (formatted as time.DateOnly) that the Recurser
+// won't be matched on.
+func (pl *PairingLogic) SkipDate(ctx context.Context, rec *store.Recurser, date string) (string, error) {
 	if !rec.IsSubscribed {
 		return notSubscribedMessage, nil
 	}
 
-	if err := store.Recursers(pl.db).Delete(ctx, rec.ID); err != nil {
-		return writeErrorMessage, err
+	if date < rec.MatchDate(time.Now()) {
+		return fmt.Sprintf("%s has already happened! I can only skip days in the future.", date), nil
 	}
-	return unsubscribeMessage, nil
+
+	if rec.SkipDates == nil {
+		rec.SkipDates = make(map[string]bool)
+	}
+	rec.SkipDates[date] = true
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Got it, **I will not match you** for pairing on %s.", date), nil
 }
 
-func (pl *PairingLogic) SkipTomorrow(ctx context.Context, rec *store.Recurser) (string, error) {
+// UnskipDate removes a one-off skip date.
+func (pl *PairingLogic) UnskipDate(ctx context.Context, rec *store.Recurser, date string) (string, error) {
 	if !rec.IsSubscribed {
 		return notSubscribedMessage, nil
 	}
 
-	rec.IsSkippingTomorrow = true
+	if !rec.SkipDates[date] {
+		return fmt.Sprintf("You weren't skipping %s, so there's nothing to undo!", date), nil
+	}
+	delete(rec.SkipDates, date)
 
-	if err := store.Recursers(pl.db).Set(ctx, rec.ID, rec); err != nil {
-		return writeErrorMessage, err
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
 	}
-	return `Tomorrow: cancelled. I feel you. **I will not match you** for pairing tomorrow <3`, nil
+	return fmt.Sprintf("Back on! **I will match you** for pairing on %s (if it's on your schedule).", date), nil
 }
 
-func (pl *PairingLogic) UnskipTomorrow(ctx context.Context, rec *store.Recurser) (string, error) {
+// Next tells the Recurser which day they'll be matched for next.
+func (pl *PairingLogic) Next(ctx context.Context, rec *store.Recurser) (string, error) {
 	if !rec.IsSubscribed {
 		return notSubscribedMessage, nil
 	}
-	rec.IsSkippingTomorrow = false
+	return nextMatchMessage(rec, time.Now()), nil
+}
+
+// nextMatchMessage describes the Recurser's next match as of `now`.
+func nextMatchMessage(rec *store.Recurser, now time.Time) string {
+	if rec.PausedUntil == store.PausedIndefinitely {
+		return "**You're paused**, so I won't match you until you `resume`."
+	}
 
-	if err := store.Recursers(pl.db).Set(ctx, rec.ID, rec); err != nil {
-		return writeErrorMessage, err
+	date, ok := rec.NextMatchDate(now)
+	if !ok {
+		return "I don't have any upcoming days to match you on. Use `schedule` to pick some days, and check `status` for any skips."
 	}
-	return "Tomorrow: uncancelled! Heckin *yes*! **I will match you** for pairing tomorrow :)", nil
+
+	// The date is already in their time zone, so treat it as a plain date.
+	day, _ := time.Parse(time.DateOnly, date)
+	next := fmt.Sprintf("**%s, %s**", day.Weekday(), date)
+
+	if rec.IsPaused(now) {
+		until := time.Unix(rec.PausedUntil, 0).In(rec.Location()).Format(time.DateOnly)
+		return fmt.Sprintf("**You're paused** until %s, so your next match is on %s.", until, next)
+	}
+	return fmt.Sprintf("Your next match is on %s.", next)
 }
 
 func (pl *PairingLogic) Status(ctx context.Context, rec *store.Recurser) (string, error) {
@@ -164,37 +1304,417 @@ func (pl *PairingLogic) Status(ctx context.Context, rec *store.Recurser) (string
 	// make a sorted list of their schedule
 	var schedule []string
 	for _, day := range daysList {
-		// this line is a little wild, sorry. it looks so weird because we
-		// have to do type assertion on both interface types
-		if rec.Schedule[strings.ToLower(day)] {
-			schedule = append(schedule, day)
+		segment, ok := rec.ScheduledSegment(strings.ToLower(day))
+		if !ok {
+			continue
+		}
+
+		var entry string
+		switch segment {
+		case store.SegmentAM:
+			entry = day + " mornings"
+		case store.SegmentPM:
+			entry = day + " afternoons"
+		default:
+			entry = day + "s"
+		}
+		if _, ok := rec.Biweekly[strings.ToLower(day)]; ok {
+			entry += " (every other week)"
 		}
+		schedule = append(schedule, entry)
 	}
 	// make a lil nice-lookin schedule string
 	var scheduleStr string
-	for i := range schedule[:len(schedule)-1] {
-		scheduleStr += schedule[i] + "s, "
+	if len(schedule) > 0 {
+		for i := range schedule[:len(schedule)-1] {
+			scheduleStr += schedule[i] + ", "
+		}
 	}
 	if len(schedule) > 1 {
-		scheduleStr += "and " + schedule[len(schedule)-1] + "s"
+		scheduleStr += "and " + schedule[len(schedule)-1]
 	} else if len(schedule) == 1 {
-		scheduleStr += schedule[0] + "s"
+		scheduleStr += schedule[0]
+	}
+
+	timezone := rec.Location().String()
+
+	status := fmt.Sprintf("* You're %v\n* You're scheduled for pairing on **%v**\n* **You're%vset to skip** pairing tomorrow\n* Your time zone is **%v**", whoami, scheduleStr, skipStr, timezone)
+
+	var muted []string
+	for _, day := range daysList {
+		if rec.MutedDays[strings.ToLower(day)] {
+			muted = append(muted, day+"s")
+		}
+	}
+	if len(muted) > 0 {
+		status += fmt.Sprintf("\n* **You've muted** %s, so you won't be matched on them until you `unmute` them", joinAnd(muted))
+	}
+
+	now := time.Now()
+	if rec.PausedUntil == store.PausedIndefinitely {
+		status += "\n* **You're paused** until you `resume`"
+	} else if rec.IsPaused(now) {
+		remaining := time.Unix(rec.PausedUntil, 0).Sub(now)
+		days := int(math.Ceil(remaining.Hours() / 24))
+		status += fmt.Sprintf("\n* **You're paused** for %d more day(s)", days)
+	}
+
+	if days := rec.SkipDaysLeft(now); days > 0 {
+		status += fmt.Sprintf("\n* **You're skipping** the next %d day(s)", days)
+	}
+
+	if rec.Bio != "" {
+		status += fmt.Sprintf("\n* Your bio is: %v", rec.Bio)
+	}
+
+	if rec.MaxWeekly > 0 {
+		status += fmt.Sprintf("\n* You'll be matched at most **%v** time(s) a week", rec.MaxWeekly)
+	}
+
+	if rec.Duration > 0 {
+		status += fmt.Sprintf("\n* You'd like to pair for about **%d minutes**", rec.Duration)
+	}
+
+	switch rec.BatchPref {
+	case store.BatchPrefSame:
+		status += "\n* You'd rather pair with people in **your own batch**"
+	case store.BatchPrefCross:
+		status += "\n* You'd rather pair with people from **other batches**"
+	}
+
+	if name, ok := modeNames[rec.Mode]; ok {
+		status += fmt.Sprintf("\n* You'd like to pair **%s**", name)
+	}
+
+	switch rec.Verbosity {
+	case store.VerbosityMinimal:
+		status += "\n* Your match messages are **minimal**"
+	case store.VerbosityFull:
+		status += "\n* Your match messages are **full**, with everyone's topics"
+	}
+
+	if rec.KeepHistory {
+		status += "\n* I'm keeping a `history` of your commands"
+	}
+
+	if rec.ConfirmMatches {
+		status += "\n* You `confirm` your matches before they count"
+	}
+
+	if rec.IsMentor {
+		status += "\n* You're in the **mentor** pool, so you're matched with current Recursers"
+	}
+
+	if override := rec.ActiveWeekOverride(now); len(override) > 0 {
+		status += fmt.Sprintf("\n* Just for this week, %s", describeWeekOverride(override))
+	}
+
+	if rec.MatchTime != "" {
+		status += fmt.Sprintf("\n* You'll hear about your matches around **%v**", rec.MatchTime)
+	}
+
+	if len(rec.Topics) > 0 {
+		status += fmt.Sprintf("\n* Your topics are %v", formatTopics(rec.Topics))
+	}
+
+	var upcomingSkips []string
+	for date := range rec.SkipDates {
+		if date >= rec.MatchDate(now) {
+			upcomingSkips = append(upcomingSkips, date)
+		}
+	}
+	slices.Sort(upcomingSkips)
+	if len(upcomingSkips) > 0 {
+		status += fmt.Sprintf("\n* You're also skipping **%s**", strings.Join(upcomingSkips, ", "))
+	}
+
+	return status, nil
+}
+
+// matchNowTTL is how long an on-demand match request waits for a partner.
+const matchNowTTL = 30 * time.Minute
+
+// MatchNow pairs the Recurser with someone else who's waiting for an
+// on-demand match. If nobody is waiting, the Recurser waits for the next
+// person instead.
+func (pl *PairingLogic) MatchNow(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	now := time.Now()
+
+	partner, err := store.MatchRequests(pl.db).ClaimOldest(ctx, rec.Realm, rec.ID, rec.BlockedIDs(), now.Add(-matchNowTTL))
+	if err != nil {
+		return "", readError(err)
+	}
+
+	if partner == nil {
+		err := store.MatchRequests(pl.db).Set(ctx, store.MatchRequest{
+			ID:        rec.ID,
+			Name:      rec.Name,
+			Timestamp: now.Unix(),
+			Blocked:   rec.BlockedIDs(),
+			Realm:     rec.Realm,
+		})
+		if err != nil {
+			return "", writeError(err)
+		}
+		return fmt.Sprintf("Nobody else is looking for a partner right now, so I've added you to the queue. If someone else asks in the next %d minutes, I'll match you up! (Send `cancel match` if you change your mind.)", int(matchNowTTL.Minutes())), nil
+	}
+
+	// In case the requester was also waiting from an earlier request.
+	if _, err := store.MatchRequests(pl.db).Delete(ctx, rec.Realm, rec.ID); err != nil {
+		logger(ctx).Error("Could not remove match request", slog.Any("error", err))
+	}
+
+	ids := []int64{rec.ID, partner.ID}
+	if err := pl.zulipFor(rec.Realm).SendUserMessage(ctx, ids, matchNowMessage); err != nil {
+		logger(ctx).Error("Could not send matchNowMessage", slog.Int64("partnerId", partner.ID), slog.Any("error", err))
+		return "", &commandError{Reply: "I found you a partner, but something went wrong when I tried to introduce you. Sorry! Try again in a bit.", Err: err}
+	}
+	logger(ctx).Info("Matched on demand", slog.Int64("partnerId", partner.ID))
+
+	if err := store.Pairings(pl.db).AddMatch(ctx, store.Match{Recursers: ids, Timestamp: now.Unix(), Realm: rec.Realm}); err != nil {
+		logger(ctx).Error("Could not record the on-demand match", slog.Int64("partnerId", partner.ID), slog.Any("error", err))
+	}
+	if err := store.Pairings(pl.db).SetNumPairings(ctx, store.Pairing{Value: 1, NumRecursers: 2, Timestamp: now.Unix()}); err != nil {
+		logger(ctx).Error("Could not record the on-demand pairing", slog.Any("error", err))
+	}
+
+	return fmt.Sprintf("You've been matched with %s! Check your DMs :)", partner.Name), nil
+}
+
+// CancelMatchNow withdraws the Recurser's on-demand match request.
+func (pl *PairingLogic) CancelMatchNow(ctx context.Context, rec *store.Recurser) (string, error) {
+	deleted, err := store.MatchRequests(pl.db).Delete(ctx, rec.Realm, rec.ID)
+	if err != nil {
+		return "", writeError(err)
 	}
+	if !deleted {
+		return "You aren't in the queue for an on-demand match. (Send `match now` to join it.)", nil
+	}
+	return "Okay, you're out of the queue for an on-demand match.", nil
+}
+
+// noShowReportWindow is how long after a match someone can report that their
+// partner didn't show up.
+const noShowReportWindow = 7 * 24 * time.Hour
+
+// ReportNoShow records that the Recurser's partner didn't show up for their
+// most recent match.
+func (pl *PairingLogic) ReportNoShow(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	match, err := store.Pairings(pl.db).LatestMatchFor(ctx, rec.Realm, rec.ID)
+	if err != nil {
+		return "", readError(err)
+	}
+	if match == nil || time.Since(time.Unix(match.Timestamp, 0)) > noShowReportWindow {
+		return "I couldn't find a match from the last week to report.", nil
+	}
+
+	if slices.Contains(match.NoShowReportedBy, rec.ID) {
+		return "You've already reported your last match. Thanks for letting me know!", nil
+	}
+
+	if err := store.Pairings(pl.db).ReportNoShow(ctx, match.ID, rec.ID); err != nil {
+		return "", writeError(err)
+	}
+	return "Sorry your partner didn't make it! I've made a note of it, which helps the maintainers keep matching working well.", nil
+}
+
+// ratingWindow is how long after a match someone can rate it.
+const ratingWindow = 7 * 24 * time.Hour
+
+// Rate records the Recurser's rating (1 to 5) for their most recent match.
+// Their partners never see it.
+func (pl *PairingLogic) Rate(ctx context.Context, rec *store.Recurser, rating int) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	match, err := store.Pairings(pl.db).LatestMatchFor(ctx, rec.Realm, rec.ID)
+	if err != nil {
+		return "", readError(err)
+	}
+	if match == nil || time.Since(time.Unix(match.Timestamp, 0)) > ratingWindow {
+		return "I couldn't find a match from the last week to rate.", nil
+	}
+
+	_, rated := match.RatingBy(rec.ID)
+	if err := store.Pairings(pl.db).Rate(ctx, match.ID, rec.ID, rating); err != nil {
+		return "", writeError(err)
+	}
+	if rated {
+		return fmt.Sprintf("I've changed your rating for your last match to %d. Your partner won't see it.", rating), nil
+	}
+	return fmt.Sprintf("Thanks! I've rated your last match %d. Your partner won't see it, but it helps the maintainers see how matching is going.", rating), nil
+}
+
+// Stats reports how much the Recurser has paired.
+func (pl *PairingLogic) Stats(ctx context.Context, rec *store.Recurser) (string, error) {
+	matches, err := store.Pairings(pl.db).GetAllMatchesFor(ctx, rec.Realm, rec.ID)
+	if err != nil {
+		return "", readError(err)
+	}
+
+	if len(matches) == 0 {
+		return "You haven't been matched with anyone yet! Your stats will show up here after your first pairing :)", nil
+	}
+
+	stats := computeStats(matches, rec, time.Now())
+
+	return fmt.Sprintf("* You've been matched **%d** time(s) in total\n* Your current streak is **%d** day(s)\n* Your longest streak is **%d** day(s)", stats.Total, stats.CurrentStreak, stats.LongestStreak), nil
+}
+
+// Leaderboard shows the opted-in Recursers who have been matched the most
+// this batch.
+func (pl *PairingLogic) Leaderboard(ctx context.Context) (string, error) {
+	entries, err := pl.leaderboard.get(time.Now(), leaderboardTTL, func() ([]leaderboardEntry, error) {
+		return pl.computeLeaderboard(ctx)
+	})
+	if err != nil {
+		return "", readError(err)
+	}
+
+	if len(entries) == 0 {
+		return "Nobody's on the leaderboard yet! Send `set leaderboard on` to join in.", nil
+	}
+
+	response := "**Most matches this batch:**\n"
+	for i, entry := range entries {
+		response += fmt.Sprintf("%d. %s (%d)\n", i+1, entry.Name, entry.Count)
+	}
+	response += "\n(Only people who opted in with `set leaderboard on` are shown.)"
+	return response, nil
+}
+
+// Milestones reports how many times the whole community has paired, and
+// celebrates round numbers.
+func (pl *PairingLogic) Milestones(ctx context.Context) (string, error) {
+	totals, err := pl.milestones.get(time.Now(), milestonesTTL, func() (pairingTotals, error) {
+		allTime, err := store.Pairings(pl.db).GetTotalPairings(ctx)
+		if err != nil {
+			return pairingTotals{}, fmt.Errorf("get all-time pairings: %w", err)
+		}
+		lastWeek, err := store.Pairings(pl.db).GetTotalPairingsDuringLastWeek(ctx)
+		if err != nil {
+			return pairingTotals{}, fmt.Errorf("get last week's pairings: %w", err)
+		}
+		return pairingTotals{AllTime: allTime, LastWeek: lastWeek}, nil
+	})
+	if err != nil {
+		return "", readError(err)
+	}
+	return milestoneMessage(totals), nil
+}
+
+// computeLeaderboard counts matches since the start of the current batch for
+// everyone who has opted in to the leaderboard.
+func (pl *PairingLogic) computeLeaderboard(ctx context.Context) ([]leaderboardEntry, error) {
+	batches, err := pl.recurse.AllBatches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get list of batches: %w", err)
+	}
+	batch, _ := recurse.CurrentBatch(batches)
+
+	matches, err := store.Pairings(pl.db).GetMatchesSince(ctx, time.Time(batch.StartDate))
+	if err != nil {
+		return nil, fmt.Errorf("get matches this batch: %w", err)
+	}
+
+	recursers, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get list of recursers: %w", err)
+	}
+
+	return rankLeaderboard(recursers, matches), nil
+}
+
+// announcementTTL is how long a drafted announcement waits for confirmation.
+const announcementTTL = time.Hour
+
+// DraftAnnouncement saves an announcement for every subscriber, to be sent once
+// the maintainer confirms it. This makes it harder to mass-DM everyone by
+// accident.
+func (pl *PairingLogic) DraftAnnouncement(ctx context.Context, rec *store.Recurser, content string) (string, error) {
+	err := store.Announcements(pl.db).Set(ctx, store.PendingAnnouncement{
+		AuthorID:  rec.ID,
+		Content:   content,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		return "", writeError(err)
+	}
+
+	recursers, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+
+	return fmt.Sprintf("Here's your announcement:\n\n```quote\n%s\n```\n\nSend `announce confirm` within the hour to DM it to all **%d** subscribers, or `announce cancel` to throw it away.", content, len(recursers)), nil
+}
+
+// ConfirmAnnouncement sends the maintainer's drafted announcement to every
+// subscriber.
+func (pl *PairingLogic) ConfirmAnnouncement(ctx context.Context, rec *store.Recurser) (string, error) {
+	announcements := store.Announcements(pl.db)
+
+	pending, err := announcements.Get(ctx, rec.ID)
+	if err != nil {
+		return "", readError(err)
+	}
+	if pending == nil || time.Since(time.Unix(pending.Timestamp, 0)) > announcementTTL {
+		return "You don't have an announcement waiting to be sent. Start one with `announce <text>`.", nil
+	}
+
+	// Delete the draft first so that it can't go out twice.
+	if err := announcements.Delete(ctx, rec.ID); err != nil {
+		return "", writeError(err)
+	}
+
+	recursers, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+
+	sent, err := pl.broadcast(ctx, recursers, pending.Content)
+	if err != nil {
+		return "", &commandError{Reply: fmt.Sprintf("I sent your announcement to %d of %d subscribers, but couldn't reach the rest. Check the logs for details.", sent, len(recursers)), Err: err}
+	}
+	return fmt.Sprintf("I sent your announcement to all %d subscribers!", sent), nil
+}
 
-	return fmt.Sprintf("* You're %v\n* You're scheduled for pairing on **%v**\n* **You're%vset to skip** pairing tomorrow", whoami, scheduleStr, skipStr), nil
+// CancelAnnouncement throws away the maintainer's drafted announcement.
+func (pl *PairingLogic) CancelAnnouncement(ctx context.Context, rec *store.Recurser) (string, error) {
+	if err := store.Announcements(pl.db).Delete(ctx, rec.ID); err != nil {
+		return "", writeError(err)
+	}
+	return "Okay, I won't send it.", nil
 }
 
-func (pl *PairingLogic) AddReview(ctx context.Context, rec *store.Recurser, content string) (string, error) {
+// AddReview saves a review of Pairing Bot. Anonymous reviews don't record who
+// wrote them.
+func (pl *PairingLogic) AddReview(ctx context.Context, rec *store.Recurser, content, category string, anonymous bool) (string, error) {
 	currentTimestamp := time.Now().Unix()
 
+	email := rec.Email
+	if anonymous {
+		email = ""
+	}
+
 	err := store.Reviews(pl.db).Insert(ctx, store.Review{
 		Content:   content,
 		Timestamp: currentTimestamp,
-		Email:     rec.Email,
+		Email:     email,
+		Category:  category,
 	})
 	if err != nil {
-		log.Println("Encountered an error when trying to save a review: ", err)
-		return writeErrorMessage, err
+		logger(ctx).Error("Could not save a review", slog.Any("error", err))
+		return "", writeError(err)
 	}
 
 	return "Thank you for sharing your review with pairing bot!", nil
@@ -203,13 +1723,103 @@ func (pl *PairingLogic) AddReview(ctx context.Context, rec *store.Recurser, cont
 func (pl *PairingLogic) GetReviews(ctx context.Context, numReviews int) (string, error) {
 	lastN, err := store.Reviews(pl.db).GetLastN(ctx, numReviews)
 	if err != nil {
-		log.Printf("Encountered an error when trying to fetch the last %v reviews: %v", numReviews, err)
-		return readErrorMessage, err
+		logger(ctx).Error("Could not fetch reviews", slog.Int("count", numReviews), slog.Any("error", err))
+		return "", readError(err)
+	}
+
+	return formatReviews(lastN), nil
+}
+
+// reviewPageSize is how many reviews `reviews` shows at a time.
+const reviewPageSize = 10
+
+// ReviewsPage shows maintainers the reviews, newest first, a page at a time.
+// `reviews` starts from the newest, and `reviews next` carries on from where
+// they left off.
+func (pl *PairingLogic) ReviewsPage(ctx context.Context, rec *store.Recurser, next bool) (string, error) {
+	reviews := store.Reviews(pl.db)
+
+	var startAfter store.ReviewPosition
+	if next {
+		var err error
+		startAfter, err = reviews.GetCursor(ctx, rec.ID)
+		if err != nil {
+			return "", readError(err)
+		}
+		if startAfter == (store.ReviewPosition{}) {
+			return "You're at the end of the reviews. Send `reviews` to start again from the newest.", nil
+		}
+	}
+
+	page, cursor, err := reviews.GetPage(ctx, reviewPageSize, startAfter)
+	if err != nil {
+		logger(ctx).Error("Could not fetch a page of reviews", slog.Int64("startAfter", startAfter.Timestamp), slog.Any("error", err))
+		return "", readError(err)
+	}
+	if err := reviews.SetCursor(ctx, rec.ID, cursor); err != nil {
+		return "", writeError(err)
+	}
+
+	return formatReviewsPage(page, cursor != (store.ReviewPosition{}), rec.Location()), nil
+}
+
+// formatReviewsPage lists one page of reviews in order, with who wrote them
+// (if they weren't anonymous).
+func formatReviewsPage(page []store.Review, more bool, loc *time.Location) string {
+	if len(page) == 0 {
+		return "There aren't any reviews yet."
+	}
+
+	var b strings.Builder
+	for _, rev := range page {
+		written := time.Unix(rev.Timestamp, 0).In(loc).Format("2006-01-02")
+
+		author := "anonymous"
+		if rev.Email != "" {
+			author = rev.Email
+		}
+
+		var category string
+		if rev.Category != "" {
+			category = " **#" + rev.Category + "**"
+		}
+
+		fmt.Fprintf(&b, "* %s (%s)%s: %s\n", written, author, category, rev.Content)
+	}
+
+	if more {
+		b.WriteString("\nSend `reviews next` for more.")
+	} else {
+		b.WriteString("\nThat's all of them!")
+	}
+	return b.String()
+}
+
+// formatReviews lists reviews grouped by category, in the order of
+// reviewCategories. Uncategorized reviews come last.
+func formatReviews(reviews []store.Review) string {
+	groups := make(map[string][]store.Review)
+	for _, rev := range reviews {
+		groups[rev.Category] = append(groups[rev.Category], rev)
 	}
 
 	response := "Here are some reviews of pairing bot:\n"
-	for _, rev := range lastN {
-		response += "* \"" + rev.Content + "\"!\n"
+	for _, category := range slices.Concat(reviewCategories, []string{""}) {
+		group := groups[category]
+		if len(group) == 0 {
+			continue
+		}
+
+		// Only label the uncategorized reviews if there are other groups.
+		if category != "" {
+			response += fmt.Sprintf("\n**#%s**\n", category)
+		} else if len(group) < len(reviews) {
+			response += "\n**Other**\n"
+		}
+
+		for _, rev := range group {
+			response += "* \"" + rev.Content + "\"!\n"
+		}
 	}
-	return response, nil
+	return response
 }
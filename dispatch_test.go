@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/recursecenter/pairing-bot/internal/assert"
 	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/recurse"
 	"github.com/recursecenter/pairing-bot/store"
 )
 
@@ -52,3 +57,301 @@ func Test_dispatch(t *testing.T) {
 		}
 	})
 }
+
+func TestPairingLogic_theme(t *testing.T) {
+	ctx := context.Background()
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx)}
+
+	maintainer := &store.Recurser{Name: "Maintainer", IsSubscribed: true}
+	for key := range maintainers {
+		maintainer.Realm, maintainer.ID = key.realm, key.id
+		break
+	}
+	someone := &store.Recurser{ID: 1, Name: "Someone", IsSubscribed: true}
+
+	// Zulip IDs are only unique within a realm, so this is someone else.
+	lookalike := &store.Recurser{ID: maintainer.ID, Name: "Lookalike", Realm: "sister", IsSubscribed: true}
+
+	theme := func(t *testing.T) string {
+		msg, err := pl.dispatch(ctx, "theme", nil, someone)
+		assert.NoError(t, err)
+		return msg
+	}
+
+	t.Run("starts empty", func(t *testing.T) {
+		assert.Equal(t, theme(t), "There's no theme right now. Pair on whatever you like!")
+	})
+
+	t.Run("only maintainers can set it", func(t *testing.T) {
+		msg, err := pl.dispatch(ctx, "set", []string{"theme", "Anything goes"}, someone)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Sorry, only maintainers can set the theme.")
+		assert.Equal(t, theme(t), "There's no theme right now. Pair on whatever you like!")
+	})
+
+	t.Run("a maintainer's ID in another realm can't set it", func(t *testing.T) {
+		msg, err := pl.dispatch(ctx, "set", []string{"theme", "Anything goes"}, lookalike)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Sorry, only maintainers can set the theme.")
+		assert.Equal(t, theme(t), "There's no theme right now. Pair on whatever you like!")
+	})
+
+	t.Run("set", func(t *testing.T) {
+		_, err := pl.dispatch(ctx, "set", []string{"theme", "Debugging war stories"}, maintainer)
+		assert.NoError(t, err)
+		assert.Equal(t, theme(t), "This week's theme is: Debugging war stories")
+	})
+
+	t.Run("only maintainers can clear it", func(t *testing.T) {
+		msg, err := pl.dispatch(ctx, "clear", []string{"theme"}, someone)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Sorry, only maintainers can clear the theme.")
+		assert.Equal(t, theme(t), "This week's theme is: Debugging war stories")
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		_, err := pl.dispatch(ctx, "clear", []string{"theme"}, maintainer)
+		assert.NoError(t, err)
+		assert.Equal(t, theme(t), "There's no theme right now. Pair on whatever you like!")
+	})
+}
+
+func TestPairingLogic_Subscribe(t *testing.T) {
+	ctx := context.Background()
+	rc := &pbtest.FakeRecurse{
+		Active: []recurse.Profile{{Name: "Ada", ZulipID: 1}},
+		Emails: map[string]recurse.Profile{
+			"ada@recurse.example.net":   {Name: "Ada", ZulipID: 1},
+			"grace@recurse.example.net": {Name: "Grace", ZulipID: 2},
+		},
+	}
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx), recurse: rc}
+
+	subscribe := func(t *testing.T, id int64, email string) (string, *store.Recurser) {
+		rec, err := store.Recursers(pl.db).GetByUserID(ctx, store.DefaultRealm, id, email, "")
+		assert.NoError(t, err)
+
+		msg, err := pl.dispatch(ctx, "subscribe", nil, rec)
+		assert.NoError(t, err)
+
+		rec, err = store.Recursers(pl.db).GetByUserID(ctx, store.DefaultRealm, id, email, "")
+		assert.NoError(t, err)
+		return msg, rec
+	}
+
+	t.Run("at RC", func(t *testing.T) {
+		msg, rec := subscribe(t, 1, "ada@recurse.example.net")
+		assert.Equal(t, msg, subscribeMessage)
+		assert.Equal(t, rec.IsSubscribed, true)
+		assert.Equal(t, rec.CurrentlyAtRC, true)
+	})
+
+	t.Run("alum", func(t *testing.T) {
+		msg, rec := subscribe(t, 2, "grace@recurse.example.net")
+		assert.Equal(t, msg, subscribeMessage)
+		assert.Equal(t, rec.IsSubscribed, true)
+		assert.Equal(t, rec.CurrentlyAtRC, false)
+	})
+
+	t.Run("not a Recurser", func(t *testing.T) {
+		msg, rec := subscribe(t, 3, "someone@example.net")
+		assert.Equal(t, msg, notARecurserMessage)
+		assert.Equal(t, rec.IsSubscribed, false)
+	})
+
+	t.Run("API error", func(t *testing.T) {
+		rc.Err = errors.New("unavailable")
+		t.Cleanup(func() { rc.Err = nil })
+
+		rec := &store.Recurser{ID: 4, Email: "alan@recurse.example.net", Schedule: store.DefaultSchedule()}
+		msg, err := pl.dispatch(ctx, "subscribe", nil, rec)
+		if err == nil {
+			t.Fatal("expected the API error")
+		}
+		assert.Equal(t, replyFor(msg, err), readErrorMessage)
+		assert.Equal(t, strings.Contains(err.Error(), "unavailable"), true)
+	})
+}
+
+func TestPairingLogic_subscriptionCooldown(t *testing.T) {
+	ctx := context.Background()
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx)}
+
+	rec := &store.Recurser{ID: 1, Name: "Someone", Schedule: store.DefaultSchedule(), IsSubscribed: true}
+	assert.NoError(t, store.Recursers(pl.db).Set(ctx, rec))
+
+	reload := func(t *testing.T) *store.Recurser {
+		r, err := store.Recursers(pl.db).GetByUserID(ctx, store.DefaultRealm, rec.ID, "", "")
+		assert.NoError(t, err)
+		return r
+	}
+
+	t.Run("first change is allowed", func(t *testing.T) {
+		msg, err := pl.dispatch(ctx, "unsubscribe", nil, reload(t))
+		assert.NoError(t, err)
+		assert.Equal(t, msg, unsubscribeMessage)
+	})
+
+	t.Run("rejected within the window", func(t *testing.T) {
+		msg, err := pl.dispatch(ctx, "restore", nil, reload(t))
+		assert.NoError(t, err)
+		assert.Equal(t, msg, cooldownMessage)
+		assert.Equal(t, reload(t).IsSubscribed, false)
+	})
+
+	t.Run("allowed after the window", func(t *testing.T) {
+		r := reload(t)
+		r.SubscriptionChangedAt = time.Now().Add(-store.SubscriptionCooldown - time.Second).Unix()
+		assert.NoError(t, store.Recursers(pl.db).Set(ctx, r))
+
+		msg, err := pl.dispatch(ctx, "restore", nil, reload(t))
+		assert.NoError(t, err)
+		assert.Equal(t, strings.HasPrefix(msg, "Welcome back!"), true)
+		assert.Equal(t, reload(t).IsSubscribed, true)
+	})
+
+	t.Run("no-ops don't count as changes", func(t *testing.T) {
+		msg, err := pl.dispatch(ctx, "subscribe", nil, reload(t))
+		assert.NoError(t, err)
+		assert.Equal(t, strings.HasPrefix(msg, "You're already subscribed!"), true)
+	})
+}
+
+func TestPairingLogic_SetDuration(t *testing.T) {
+	ctx := context.Background()
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx)}
+
+	rec := &store.Recurser{ID: 1, Name: "Someone", Schedule: store.DefaultSchedule(), IsSubscribed: true}
+	assert.NoError(t, store.Recursers(pl.db).Set(ctx, rec))
+
+	reload := func(t *testing.T) *store.Recurser {
+		r, err := store.Recursers(pl.db).GetByUserID(ctx, store.DefaultRealm, rec.ID, "", "")
+		assert.NoError(t, err)
+		return r
+	}
+
+	t.Run("set", func(t *testing.T) {
+		_, err := pl.dispatch(ctx, "set", []string{"duration", "60"}, reload(t))
+		assert.NoError(t, err)
+		assert.Equal(t, reload(t).Duration, 60)
+
+		msg, err := pl.dispatch(ctx, "status", nil, reload(t))
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Contains(msg, "You'd like to pair for about **60 minutes**"), true)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		_, err := pl.dispatch(ctx, "clear", []string{"duration"}, reload(t))
+		assert.NoError(t, err)
+		assert.Equal(t, reload(t).Duration, 0)
+	})
+}
+
+func Test_formatReviews(t *testing.T) {
+	t.Run("uncategorized", func(t *testing.T) {
+		reviews := []store.Review{{Content: "nice"}, {Content: "great"}}
+		assert.Equal(t, formatReviews(reviews), "Here are some reviews of pairing bot:\n* \"nice\"!\n* \"great\"!\n")
+	})
+
+	t.Run("grouped by category", func(t *testing.T) {
+		reviews := []store.Review{
+			{Content: "it broke", Category: "bug"},
+			{Content: "nice"},
+			{Content: "more cookies", Category: "idea"},
+			{Content: "great", Category: "praise"},
+			{Content: "also broke", Category: "bug"},
+		}
+
+		expected := "Here are some reviews of pairing bot:\n" +
+			"\n**#praise**\n* \"great\"!\n" +
+			"\n**#idea**\n* \"more cookies\"!\n" +
+			"\n**#bug**\n* \"it broke\"!\n* \"also broke\"!\n" +
+			"\n**Other**\n* \"nice\"!\n"
+		assert.Equal(t, formatReviews(reviews), expected)
+	})
+}
+
+func Test_formatReviewsPage(t *testing.T) {
+	march14 := time.Date(2024, time.March, 14, 12, 0, 0, 0, time.UTC).Unix()
+	page := []store.Review{
+		{Content: "more cookies", Email: "ada@recurse.example.net", Category: "idea", Timestamp: march14},
+		{Content: "nice", Timestamp: march14},
+	}
+
+	assert.Equal(t, formatReviewsPage(page, true, time.UTC),
+		"* 2024-03-14 (ada@recurse.example.net) **#idea**: more cookies\n"+
+			"* 2024-03-14 (anonymous): nice\n"+
+			"\nSend `reviews next` for more.")
+	assert.Equal(t, strings.HasSuffix(formatReviewsPage(page, false, time.UTC), "\nThat's all of them!"), true)
+	assert.Equal(t, formatReviewsPage(nil, false, time.UTC), "There aren't any reviews yet.")
+}
+
+func Test_nextMatchMessage(t *testing.T) {
+	now := time.Date(2024, time.March, 12, 12, 0, 0, 0, time.UTC) // Tuesday
+	weekdays := store.NewSchedule([]string{"monday", "wednesday", "friday"})
+
+	for name, tc := range map[string]struct {
+		Rec      store.Recurser
+		Expected string
+	}{
+		"scheduled": {
+			store.Recurser{Schedule: weekdays},
+			"Your next match is on **Wednesday, 2024-03-13**.",
+		},
+		"paused": {
+			store.Recurser{Schedule: weekdays, PausedUntil: time.Date(2024, time.March, 20, 12, 0, 0, 0, time.UTC).Unix()},
+			"**You're paused** until 2024-03-20, so your next match is on **Friday, 2024-03-22**.",
+		},
+		"paused indefinitely": {
+			store.Recurser{Schedule: weekdays, PausedUntil: store.PausedIndefinitely},
+			"**You're paused**, so I won't match you until you `resume`.",
+		},
+		"empty schedule": {
+			store.Recurser{Schedule: store.EmptySchedule()},
+			"I don't have any upcoming days to match you on. Use `schedule` to pick some days, and check `status` for any skips.",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, nextMatchMessage(&tc.Rec, now), tc.Expected)
+		})
+	}
+}
+
+func Test_describeWeekOverride(t *testing.T) {
+	assert.Equal(t, describeWeekOverride(map[string]bool{"thursday": true}), "you're pairing on **Thursday**")
+	assert.Equal(t, describeWeekOverride(map[string]bool{"monday": false}), "you're not pairing on **Monday**")
+	assert.Equal(t,
+		describeWeekOverride(map[string]bool{"sunday": true, "monday": false, "thursday": true, "friday": false}),
+		"you're pairing on **Thursday and Sunday** and not on **Monday and Friday**")
+}
+
+func Test_noOverlapWarning(t *testing.T) {
+	// Everyone else pairs on weekdays.
+	counts := map[string]int{"monday": 3, "tuesday": 2, "wednesday": 3, "thursday": 1, "friday": 2}
+
+	t.Run("lone sunday", func(t *testing.T) {
+		rec := store.Recurser{Schedule: store.NewSchedule([]string{"sunday"})}
+		assert.Equal(t, strings.Contains(noOverlapWarning(&rec, counts), "nobody else is scheduled on Sunday"), true)
+	})
+
+	t.Run("lone weekend", func(t *testing.T) {
+		rec := store.Recurser{Schedule: store.NewSchedule([]string{"saturday", "sunday-am"})}
+		assert.Equal(t, strings.Contains(noOverlapWarning(&rec, counts), "on Saturday and Sunday"), true)
+	})
+
+	t.Run("one shared day is enough", func(t *testing.T) {
+		rec := store.Recurser{Schedule: store.NewSchedule([]string{"thursday", "sunday"})}
+		assert.Equal(t, noOverlapWarning(&rec, counts), "")
+	})
+
+	t.Run("no days at all", func(t *testing.T) {
+		rec := store.Recurser{Schedule: store.EmptySchedule()}
+		assert.Equal(t, noOverlapWarning(&rec, counts), "")
+	})
+}
+
+func Test_joinAnd(t *testing.T) {
+	assert.Equal(t, joinAnd([]string{"A"}), "A")
+	assert.Equal(t, joinAnd([]string{"A", "B"}), "A and B")
+	assert.Equal(t, joinAnd([]string{"A", "B", "C"}), "A, B, and C")
+}
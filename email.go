@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// matchEmailSubject is the subject of emailed match messages.
+const matchEmailSubject = "Your Pairing Bot match"
+
+// emailFallback emails a match message that Zulip couldn't deliver to everyone
+// in the group who asked for that with `set email-fallback on`.
+func (pl *PairingLogic) emailFallback(ctx context.Context, group []store.Recurser, message string) {
+	if pl.email == nil {
+		return
+	}
+	for _, rec := range group {
+		if !rec.EmailFallback || rec.Email == "" {
+			continue
+		}
+
+		log := logger(ctx).With(slog.Int64("recurserId", rec.ID))
+		if err := pl.email.SendEmail(ctx, rec.Email, matchEmailSubject, message); err != nil {
+			log.Error("Could not email the match message either", slog.Any("error", err))
+			continue
+		}
+		log.Info("Emailed the match message instead")
+	}
+}
+
+// smtpEmailer sends email through an SMTP server, logging in as the sender.
+type smtpEmailer struct {
+	// addr is the server's host:port.
+	addr     string
+	from     string
+	password TokenFunc
+}
+
+func (s *smtpEmailer) SendEmail(ctx context.Context, to, subject, body string) error {
+	// Anything that could end a header line could add headers of its own.
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return errors.New("email header contains a line break")
+	}
+
+	password, err := s.password(ctx)
+	if err != nil {
+		return fmt.Errorf("get SMTP password: %w", err)
+	}
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return fmt.Errorf("parse SMTP address: %w", err)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s", s.from, to, subject, body)
+	return smtp.SendMail(s.addr, smtp.PlainAuth("", s.from, password, host), s.from, []string{to}, []byte(msg))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+var _ Emailer = (*pbtest.FakeEmailer)(nil)
+
+func TestPairingLogic_emailFallback(t *testing.T) {
+	ctx := context.Background()
+	emailer := &pbtest.FakeEmailer{}
+	pl := &PairingLogic{email: emailer}
+
+	pl.emailFallback(ctx, []store.Recurser{
+		{ID: 1, Email: "ada@recurse.example.net", EmailFallback: true},
+		{ID: 2, Email: "grace@recurse.example.net"},
+		{ID: 3, EmailFallback: true},
+	}, "You're matched!")
+
+	// Only the opted-in Recurser with an address gets it.
+	assert.Equal(t, emailer.Sent, []pbtest.Email{
+		{To: "ada@recurse.example.net", Subject: matchEmailSubject, Body: "You're matched!"},
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		pl := &PairingLogic{}
+		pl.emailFallback(ctx, []store.Recurser{{ID: 1, Email: "ada@recurse.example.net", EmailFallback: true}}, "You're matched!")
+	})
+}
+
+func TestPairingLogic_match_emailFallback(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+
+	// Zulip is up, but every DM fails.
+	fake := pbtest.NewFakeZulip(t)
+	fake.Status = func(msg pbtest.ZulipMessage) int {
+		if msg.Type != "" {
+			return http.StatusBadRequest
+		}
+		return 0
+	}
+
+	emailer := &pbtest.FakeEmailer{}
+	pl := &PairingLogic{db: db, zulip: fake.Client, email: emailer, repeatWindowDays: 7, seeds: rand.New(rand.NewSource(1))}
+
+	everyDay := store.NewSchedule([]string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"})
+	for _, rec := range []store.Recurser{
+		{ID: 1, Name: "One", Email: "one@recurse.example.net", Schedule: everyDay, EmailFallback: true},
+		{ID: 2, Name: "Two", Email: "two@recurse.example.net", Schedule: everyDay},
+	} {
+		if err := store.Recursers(db).Set(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := pl.match(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, len(emailer.Sent), 1)
+	assert.Equal(t, emailer.Sent[0].To, "one@recurse.example.net")
+	assert.Equal(t, strings.Contains(emailer.Sent[0].Body, "Two"), true)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// A userExport is everything Pairing Bot stores about one Recurser, as sent
+// by `export`. Fields that only matter inside Pairing Bot (like document IDs)
+// are left out, and so is anything other people said about them, like their
+// partners' no-show reports.
+type userExport struct {
+	// Recurser is nil if they have no record, because they never subscribed
+	// or their record was purged.
+	Recurser *exportRecurser `json:"recurser"`
+	Matches  []exportMatch   `json:"matches"`
+	Reviews  []exportReview  `json:"reviews"`
+	History  []exportCommand `json:"history"`
+}
+
+type exportRecurser struct {
+	ID                  int64           `json:"id"`
+	Name                string          `json:"name"`
+	Email               string          `json:"email"`
+	Schedule            []string        `json:"schedule"`
+	ThisWeek            map[string]bool `json:"this_week,omitempty"`
+	MutedDays           []string        `json:"muted_days,omitempty"`
+	Timezone            string          `json:"timezone,omitempty"`
+	SkipDates           []string        `json:"skip_dates,omitempty"`
+	SkipUntil           string          `json:"skip_until,omitempty"`
+	SkippingTomorrow    bool            `json:"skipping_tomorrow"`
+	PausedUntil         string          `json:"paused_until,omitempty"`
+	Bio                 string          `json:"bio,omitempty"`
+	Topics              []string        `json:"topics,omitempty"`
+	MatchTime           string          `json:"match_time,omitempty"`
+	MaxWeekly           int             `json:"max_weekly,omitempty"`
+	BatchPref           string          `json:"batch_pref,omitempty"`
+	Duration            int             `json:"duration,omitempty"`
+	Verbosity           string          `json:"verbosity,omitempty"`
+	Mode                string          `json:"mode,omitempty"`
+	Blocks              []string        `json:"blocks,omitempty"`
+	Aliases             []string        `json:"aliases,omitempty"`
+	SchedulePresets     []string        `json:"schedule_presets,omitempty"`
+	WeeklySummaryOptOut bool            `json:"weekly_summary_opt_out"`
+	ShowOnLeaderboard   bool            `json:"show_on_leaderboard"`
+	Discoverable        bool            `json:"discoverable"`
+	EmailFallback       bool            `json:"email_fallback"`
+	Nudge               bool            `json:"nudge"`
+	KeepHistory         bool            `json:"keep_history"`
+	ConfirmMatches      bool            `json:"confirm_matches"`
+	IsMentor            bool            `json:"is_mentor"`
+	CurrentlyAtRC       bool            `json:"currently_at_rc"`
+	LastActive          string          `json:"last_active,omitempty"`
+	UnsubscribedAt      string          `json:"unsubscribed_at,omitempty"`
+}
+
+type exportMatch struct {
+	Time           string  `json:"time"`
+	PartnerIDs     []int64 `json:"partner_ids"`
+	ReportedNoShow bool    `json:"reported_no_show,omitempty"`
+	Rating         int     `json:"rating,omitempty"`
+}
+
+type exportReview struct {
+	Time     string `json:"time"`
+	Content  string `json:"content"`
+	Category string `json:"category,omitempty"`
+}
+
+type exportCommand struct {
+	Time    string `json:"time"`
+	Command string `json:"command"`
+}
+
+// newUserExport puts together the export for a Recurser, with times in their
+// time zone and oldest first. hasRecord is whether they have a Recurser
+// record at all.
+func newUserExport(rec *store.Recurser, hasRecord bool, matches []store.Match, reviews []store.Review, history []store.HistoryEntry) userExport {
+	loc := rec.Location()
+	formatTime := func(unix int64) string {
+		return time.Unix(unix, 0).In(loc).Format(time.RFC3339)
+	}
+
+	// Empty sections show up as [] rather than null.
+	export := userExport{
+		Matches: []exportMatch{},
+		Reviews: []exportReview{},
+		History: []exportCommand{},
+	}
+
+	if hasRecord {
+		r := &exportRecurser{
+			ID:                  rec.ID,
+			Name:                rec.Name,
+			Email:               rec.Email,
+			Schedule:            []string{},
+			ThisWeek:            rec.ActiveWeekOverride(time.Now()),
+			Timezone:            rec.Timezone,
+			SkippingTomorrow:    rec.IsSkippingTomorrow,
+			Bio:                 rec.Bio,
+			Topics:              rec.Topics,
+			MatchTime:           rec.MatchTime,
+			MaxWeekly:           rec.MaxWeekly,
+			BatchPref:           rec.BatchPref,
+			Duration:            rec.Duration,
+			Verbosity:           rec.Verbosity,
+			Mode:                rec.Mode,
+			WeeklySummaryOptOut: rec.WeeklySummaryOptOut,
+			ShowOnLeaderboard:   rec.ShowOnLeaderboard,
+			Discoverable:        rec.Discoverable,
+			EmailFallback:       rec.EmailFallback,
+			Nudge:               rec.Nudge,
+			KeepHistory:         rec.KeepHistory,
+			ConfirmMatches:      rec.ConfirmMatches,
+			IsMentor:            rec.IsMentor,
+			CurrentlyAtRC:       rec.CurrentlyAtRC,
+		}
+		for _, day := range scheduleShortcuts["everyday"] {
+			segment, ok := rec.ScheduledSegment(day)
+			if !ok {
+				continue
+			}
+			entry := day
+			if segment != "" {
+				entry += "-" + segment
+			}
+			if _, ok := rec.Biweekly[day]; ok {
+				entry += "-biweekly"
+			}
+			r.Schedule = append(r.Schedule, entry)
+		}
+		for _, day := range scheduleShortcuts["everyday"] {
+			if rec.MutedDays[day] {
+				r.MutedDays = append(r.MutedDays, day)
+			}
+		}
+		for date, skip := range rec.SkipDates {
+			if skip {
+				r.SkipDates = append(r.SkipDates, date)
+			}
+		}
+		slices.Sort(r.SkipDates)
+		if rec.SkipUntil != 0 {
+			r.SkipUntil = formatTime(rec.SkipUntil)
+		}
+		switch {
+		case rec.PausedUntil == store.PausedIndefinitely:
+			r.PausedUntil = "indefinitely"
+		case rec.PausedUntil != 0:
+			r.PausedUntil = formatTime(rec.PausedUntil)
+		}
+		for _, block := range rec.Blocks {
+			r.Blocks = append(r.Blocks, block.Name)
+		}
+		for name, expansion := range rec.Aliases {
+			r.Aliases = append(r.Aliases, name+" = "+expansion)
+		}
+		slices.Sort(r.Aliases)
+		for name, preset := range rec.SchedulePresets {
+			r.SchedulePresets = append(r.SchedulePresets, name+" = "+strings.Join(scheduleWords(preset), " "))
+		}
+		slices.Sort(r.SchedulePresets)
+		if rec.LastActive != 0 {
+			r.LastActive = formatTime(rec.LastActive)
+		}
+		if rec.UnsubscribedAt != 0 {
+			r.UnsubscribedAt = formatTime(rec.UnsubscribedAt)
+		}
+		export.Recurser = r
+	}
+
+	slices.SortFunc(matches, func(a, b store.Match) int { return cmp.Compare(a.Timestamp, b.Timestamp) })
+	for _, match := range matches {
+		// Only their own rating, since the others' are private.
+		rating, _ := match.RatingBy(rec.ID)
+		export.Matches = append(export.Matches, exportMatch{
+			Time:           formatTime(match.Timestamp),
+			PartnerIDs:     slices.DeleteFunc(slices.Clone(match.Recursers), func(id int64) bool { return id == rec.ID }),
+			ReportedNoShow: slices.Contains(match.NoShowReportedBy, rec.ID),
+			Rating:         rating,
+		})
+	}
+
+	slices.SortFunc(reviews, func(a, b store.Review) int { return cmp.Compare(a.Timestamp, b.Timestamp) })
+	for _, review := range reviews {
+		export.Reviews = append(export.Reviews, exportReview{
+			Time:     formatTime(review.Timestamp),
+			Content:  review.Content,
+			Category: review.Category,
+		})
+	}
+
+	slices.SortFunc(history, func(a, b store.HistoryEntry) int { return cmp.Compare(a.Timestamp, b.Timestamp) })
+	for _, entry := range history {
+		export.History = append(export.History, exportCommand{
+			Time:    formatTime(entry.Timestamp),
+			Command: entry.Command,
+		})
+	}
+
+	return export
+}
+
+// Export sends the Recurser everything Pairing Bot stores about them, as JSON.
+// It works whether or not they're subscribed, since their reviews (and, for a
+// while, their record) stick around after they unsubscribe.
+func (pl *PairingLogic) Export(ctx context.Context, rec *store.Recurser) (string, error) {
+	matches, err := store.Pairings(pl.db).GetAllMatchesFor(ctx, rec.Realm, rec.ID)
+	if err != nil {
+		return "", readError(err)
+	}
+
+	var reviews []store.Review
+	if rec.Email != "" {
+		reviews, err = store.Reviews(pl.db).GetByEmail(ctx, rec.Email)
+		if err != nil {
+			return "", readError(err)
+		}
+	}
+
+	history, err := store.History(pl.db).GetAllFor(ctx, rec.Realm, rec.ID)
+	if err != nil {
+		return "", readError(err)
+	}
+
+	// Someone who unsubscribed recently still has a record.
+	hasRecord := rec.IsSubscribed || rec.UnsubscribedAt != 0
+
+	data, err := json.MarshalIndent(newUserExport(rec, hasRecord, matches, reviews, history), "", "  ")
+	if err != nil {
+		return "", &commandError{Reply: "Sorry, I couldn't put your data together.", Err: err}
+	}
+	return fmt.Sprintf("Here's everything I have about you. (Anonymous reviews aren't included, since I don't know who wrote them.)\n\n```json\n%s\n```", data), nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_newUserExport(t *testing.T) {
+	rec := store.Recurser{
+		ID:           1,
+		Name:         "Ada",
+		Email:        "ada@recurse.example.net",
+		Schedule:     store.NewSchedule([]string{"monday", "friday-pm"}),
+		Bio:          "Engines",
+		Blocks:       []store.Block{{ID: 3, Name: "Charles"}},
+		Aliases:      map[string]string{"sk": "skip tomorrow", "n": "next"},
+		IsSubscribed: true,
+		Segment:      store.SegmentPM,
+		BatchID:      42,
+	}
+	rec.SchedulePresets = map[string]map[string]bool{
+		"work":  store.NewSchedule([]string{"monday", "tuesday-am"}),
+		"light": store.NewSchedule([]string{"friday"}),
+	}
+	matches := []store.Match{
+		{ID: "b", Recursers: []int64{1, 2}, Timestamp: 200, NoShowReportedBy: []int64{2}, Ratings: map[string]int{"1": 4, "2": 1}},
+		{ID: "a", Recursers: []int64{4, 1, 5}, Timestamp: 100, NoShowReportedBy: []int64{1}},
+	}
+	reviews := []store.Review{{Content: "Love it", Email: rec.Email, Timestamp: 300, Category: "praise"}}
+	history := []store.HistoryEntry{{UserID: 1, Command: "status", Timestamp: 400}}
+
+	data, err := json.Marshal(newUserExport(&rec, true, matches, reviews, history))
+	assert.NoError(t, err)
+
+	var got map[string]any
+	assert.NoError(t, json.Unmarshal(data, &got))
+
+	t.Run("has every section", func(t *testing.T) {
+		for _, section := range []string{"recurser", "matches", "reviews", "history"} {
+			if _, ok := got[section]; !ok {
+				t.Errorf("missing section %q", section)
+			}
+		}
+	})
+
+	t.Run("recurser", func(t *testing.T) {
+		r := got["recurser"].(map[string]any)
+		assert.Equal(t, r["name"], any("Ada"))
+		assert.Equal(t, r["schedule"], any([]any{"monday", "friday-pm"}))
+		assert.Equal(t, r["blocks"], any([]any{"Charles"}))
+		assert.Equal(t, r["aliases"], any([]any{"n = next", "sk = skip tomorrow"}))
+		assert.Equal(t, r["schedule_presets"], any([]any{"light = friday", "work = monday tuesday-am"}))
+
+		// Internal fields are left out.
+		for _, field := range []string{"Segment", "segment", "BatchID", "batch_id", "IsSubscribed"} {
+			if _, ok := r[field]; ok {
+				t.Errorf("internal field %q was exported", field)
+			}
+		}
+	})
+
+	t.Run("matches", func(t *testing.T) {
+		// Oldest first, with only the requester's own no-show reports and
+		// ratings.
+		assert.Equal(t, got["matches"], any([]any{
+			map[string]any{"time": "1970-01-01T00:01:40Z", "partner_ids": []any{4.0, 5.0}, "reported_no_show": true},
+			map[string]any{"time": "1970-01-01T00:03:20Z", "partner_ids": []any{2.0}, "rating": 4.0},
+		}))
+	})
+
+	t.Run("reviews and history", func(t *testing.T) {
+		assert.Equal(t, got["reviews"], any([]any{
+			map[string]any{"time": "1970-01-01T00:05:00Z", "content": "Love it", "category": "praise"},
+		}))
+		assert.Equal(t, got["history"], any([]any{
+			map[string]any{"time": "1970-01-01T00:06:40Z", "command": "status"},
+		}))
+	})
+
+	t.Run("no record", func(t *testing.T) {
+		export := newUserExport(&store.Recurser{ID: 1}, false, nil, nil, nil)
+
+		data, err := json.Marshal(export)
+		assert.NoError(t, err)
+		assert.Equal(t, string(data), `{"recurser":null,"matches":[],"reviews":[],"history":[]}`)
+	})
+}
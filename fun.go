@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// Fun responses are lightweight commands, like `encourage`, that maintainers
+// add with `fun add`. They're kept apart from the built-in commands: parseCmd
+// doesn't know about them, and they're only looked up when a message doesn't
+// start with a command it knows.
+
+// funResponse returns a reply for a message that starts with one of the fun
+// keywords, and whether there was one.
+func (pl *PairingLogic) funResponse(ctx context.Context, cmdStr string) (string, bool) {
+	keyword, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(cmdStr)), " ")
+	if keyword == "" {
+		return "", false
+	}
+
+	responses, err := store.FunResponses(pl.db).Get(ctx, keyword)
+	if err != nil {
+		logger(ctx).Warn("Could not look up fun responses", slog.String("keyword", keyword), slog.Any("error", err))
+		return "", false
+	}
+	if len(responses) == 0 {
+		return "", false
+	}
+	return pickFunResponse(responses, rand.New(rand.NewSource(time.Now().UnixNano()))), true
+}
+
+// pickFunResponse picks one of the responses at random.
+func pickFunResponse(responses []string, r *rand.Rand) string {
+	return responses[r.Intn(len(responses))]
+}
+
+// checkFunKeyword returns why the keyword can't have fun responses, or "" if
+// it can. Built-in commands (admin commands included) always win, so a fun
+// keyword with the same name would never be used.
+func checkFunKeyword(keyword string) string {
+	if _, _, err := parseCmd(keyword); !errors.Is(err, ErrUnknownCommand) {
+		return fmt.Sprintf("`%s` is already a command, so it can't be a fun keyword.", keyword)
+	}
+	return ""
+}
+
+// FunStatus lists the fun keywords and how many responses each has.
+func (pl *PairingLogic) FunStatus(ctx context.Context) (string, error) {
+	all, err := store.FunResponses(pl.db).GetAll(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+	if len(all) == 0 {
+		return "There aren't any fun responses. Send `fun add <keyword> <response>` to add one.", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("Fun responses:")
+	for _, fun := range all {
+		fmt.Fprintf(&b, "\n* `%s` (%d):", fun.Keyword, len(fun.Responses))
+		for _, response := range fun.Responses {
+			fmt.Fprintf(&b, "\n  * %s", response)
+		}
+	}
+	return b.String(), nil
+}
+
+// AddFunResponse adds a response to a fun keyword, creating it if it's new.
+func (pl *PairingLogic) AddFunResponse(ctx context.Context, keyword, response string) (string, error) {
+	if reason := checkFunKeyword(keyword); reason != "" {
+		return reason, nil
+	}
+
+	if err := store.FunResponses(pl.db).Add(ctx, keyword, response); err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Done. Sending `%s` might get that response now.", keyword), nil
+}
+
+// RemoveFunResponse removes one response from a fun keyword, or the whole
+// keyword if response is empty. A keyword without any responses left is
+// removed too.
+func (pl *PairingLogic) RemoveFunResponse(ctx context.Context, keyword, response string) (string, error) {
+	responses, err := store.FunResponses(pl.db).Get(ctx, keyword)
+	if err != nil {
+		return "", readError(err)
+	}
+	if len(responses) == 0 {
+		return fmt.Sprintf("There's no fun keyword called `%s`.", keyword), nil
+	}
+
+	if response == "" || (len(responses) == 1 && responses[0] == response) {
+		if err := store.FunResponses(pl.db).Delete(ctx, keyword); err != nil {
+			return "", writeError(err)
+		}
+		return fmt.Sprintf("Done. `%s` isn't a fun keyword anymore.", keyword), nil
+	}
+
+	if !slices.Contains(responses, response) {
+		return fmt.Sprintf("`%s` doesn't have that response. Send `fun` to see the ones it has.", keyword), nil
+	}
+	if err := store.FunResponses(pl.db).Remove(ctx, keyword, response); err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Done. `%s` has %d response(s) left.", keyword, len(responses)-1), nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+)
+
+func Test_pickFunResponse(t *testing.T) {
+	responses := []string{"You've got this!", "Keep going!", "Nice work!"}
+
+	pick := func(seed int64) []string {
+		r := rand.New(rand.NewSource(seed))
+		var picked []string
+		for range 20 {
+			picked = append(picked, pickFunResponse(responses, r))
+		}
+		return picked
+	}
+
+	// The same seed picks the same responses.
+	assert.Equal(t, pick(1), pick(1))
+
+	t.Run("every response gets picked", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for _, response := range pick(1) {
+			seen[response] = true
+		}
+		assert.Equal(t, len(seen), len(responses))
+	})
+
+	t.Run("only one", func(t *testing.T) {
+		assert.Equal(t, pickFunResponse([]string{"Hooray!"}, rand.New(rand.NewSource(1))), "Hooray!")
+	})
+}
+
+func Test_checkFunKeyword(t *testing.T) {
+	assert.Equal(t, checkFunKeyword("encourage"), "")
+	for _, keyword := range []string{"skip", "cookie", "fun", "roster"} {
+		assert.Equal(t, checkFunKeyword(keyword), "`"+keyword+"` is already a command, so it can't be a fun keyword.")
+	}
+}
+
+func TestPairingLogic_funResponses(t *testing.T) {
+	ctx := context.Background()
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx)}
+
+	_, ok := pl.funResponse(ctx, "encourage")
+	assert.Equal(t, ok, false)
+
+	_, err := pl.AddFunResponse(ctx, "encourage", "You've got this!")
+	assert.NoError(t, err)
+	_, err = pl.AddFunResponse(ctx, "encourage", "Keep going!")
+	assert.NoError(t, err)
+
+	msg, err := pl.FunStatus(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, "Fun responses:\n* `encourage` (2):\n  * You've got this!\n  * Keep going!")
+
+	// Keywords are matched on the first word, in any case.
+	reply, ok := pl.funResponse(ctx, "Encourage me please")
+	assert.Equal(t, ok, true)
+	if reply != "You've got this!" && reply != "Keep going!" {
+		t.Errorf("got %q, wanted one of the responses", reply)
+	}
+
+	t.Run("built-in commands", func(t *testing.T) {
+		msg, err := pl.AddFunResponse(ctx, "skip", "Skipping is fun!")
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "`skip` is already a command, so it can't be a fun keyword.")
+	})
+
+	t.Run("removal", func(t *testing.T) {
+		msg, err := pl.RemoveFunResponse(ctx, "encourage", "You've got this!")
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Done. `encourage` has 1 response(s) left.")
+
+		reply, ok := pl.funResponse(ctx, "encourage")
+		assert.Equal(t, ok, true)
+		assert.Equal(t, reply, "Keep going!")
+
+		// Removing the last response removes the keyword.
+		msg, err = pl.RemoveFunResponse(ctx, "encourage", "Keep going!")
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Done. `encourage` isn't a fun keyword anymore.")
+
+		_, ok = pl.funResponse(ctx, "encourage")
+		assert.Equal(t, ok, false)
+
+		msg, err = pl.RemoveFunResponse(ctx, "encourage", "")
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "There's no fun keyword called `encourage`.")
+	})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout bounds how long a health check waits for the database.
+// Monitoring would rather get a quick "no" than wait for a slow "yes".
+const healthCheckTimeout = 2 * time.Second
+
+// healthFailureTTL is how long a failed health check is remembered. Reporting
+// the same failure for a little while keeps the status from flapping when the
+// database is having trouble, and avoids piling more requests onto it.
+const healthFailureTTL = 10 * time.Second
+
+// healthCheck runs a dependency check for /healthz, remembering recent
+// failures.
+type healthCheck struct {
+	check func(context.Context) error
+
+	mu       sync.Mutex
+	failedAt time.Time
+	err      error
+}
+
+// run returns the result of the check as of `now`. A recent failure is
+// returned without checking again.
+func (h *healthCheck) run(ctx context.Context, now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.failedAt.IsZero() && now.Sub(h.failedAt) < healthFailureTTL {
+		return h.err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	h.err = h.check(ctx)
+	if h.err != nil {
+		h.failedAt = now
+	} else {
+		h.failedAt = time.Time{}
+	}
+	return h.err
+}
+
+// healthStatus is the body of a /healthz response.
+type healthStatus struct {
+	Status  string `json:"status"`
+	Version string `json:"version"`
+}
+
+// healthz reports whether Pairing Bot can reach its database, along with the
+// version that's running. It responds with 200 if everything is fine and 503
+// otherwise.
+func (pl *PairingLogic) healthz(w http.ResponseWriter, r *http.Request) {
+	code := http.StatusOK
+	body := healthStatus{Status: "ok", Version: pl.version}
+
+	if err := pl.health.run(r.Context(), time.Now()); err != nil {
+		logger(r.Context()).Error("Health check failed", slog.Any("error", err))
+		code = http.StatusServiceUnavailable
+		body.Status = "unavailable"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger(r.Context()).Error("Could not write JSON response", slog.Any("error", err))
+	}
+}
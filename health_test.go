@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+)
+
+func TestPairingLogic_healthz(t *testing.T) {
+	serve := func(pl *PairingLogic) (int, healthStatus) {
+		w := httptest.NewRecorder()
+		pl.healthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		var body healthStatus
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, body
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		pl := &PairingLogic{
+			version: "test version",
+			health:  healthCheck{check: func(context.Context) error { return nil }},
+		}
+
+		code, body := serve(pl)
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, body, healthStatus{Status: "ok", Version: "test version"})
+	})
+
+	t.Run("database unreachable", func(t *testing.T) {
+		pl := &PairingLogic{
+			version: "test version",
+			health:  healthCheck{check: func(context.Context) error { return errors.New("connection refused") }},
+		}
+
+		code, body := serve(pl)
+		assert.Equal(t, code, http.StatusServiceUnavailable)
+		assert.Equal(t, body, healthStatus{Status: "unavailable", Version: "test version"})
+	})
+}
+
+func Test_healthCheck_run(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, time.March, 14, 12, 0, 0, 0, time.UTC)
+
+	errDown := errors.New("connection refused")
+
+	var calls int
+	var fail error
+	h := healthCheck{check: func(context.Context) error {
+		calls++
+		return fail
+	}}
+
+	// Successes aren't cached.
+	assert.NoError(t, h.run(ctx, start))
+	assert.NoError(t, h.run(ctx, start))
+	assert.Equal(t, calls, 2)
+
+	// A failure is remembered for a little while...
+	fail = errDown
+	assert.ErrorIs(t, h.run(ctx, start), errDown)
+
+	fail = nil
+	assert.ErrorIs(t, h.run(ctx, start.Add(healthFailureTTL-time.Second)), errDown)
+	assert.Equal(t, calls, 3)
+
+	// ...and then checked again.
+	assert.NoError(t, h.run(ctx, start.Add(healthFailureTTL)))
+	assert.Equal(t, calls, 4)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// commandHelp is the detailed help for each command, shown by `help <command>`.
+// Commands that are undone by another command (like skip and unskip) share a
+// single entry, listed under both names in helpAliases.
+var commandHelp = map[string]string{
+	"subscribe": "**`subscribe`** starts matching you with other Pairing Bot users for pair programming.\n" +
+		"* You'll be matched every weekday until you change your `schedule`\n" +
+		"* Pairing Bot is just for Recursers, so your Zulip email needs to match your RC profile\n" +
+		"* If you unsubscribed in the last 14 days, this brings back your old settings (just like `restore`)",
+
+	"unsubscribe": "**`unsubscribe`** stops matching you entirely.\n" +
+		"* Your settings are kept for 14 days. Send `restore` (or `subscribe`) in that time to get them back\n" +
+		"* After that, I forget about you completely",
+
+	"schedule": "**`schedule <days>`** sets which days of the week you want to be matched.\n" +
+		"* `schedule mon wed friday` matches you on Mondays, Wednesdays, and Fridays\n" +
+		"* Days can be full names (`monday`) or abbreviations (`mon`), in any order\n" +
+		"* `weekdays`, `weekends`, and `everyday` cover several days at once, and `except` leaves some out: `schedule weekdays except wed`\n" +
+		"* Add `-am` or `-pm` for half a day: `schedule mon-am fri-pm` only pairs you with people free at the same time\n" +
+		"* Add `-biweekly` for every other week, starting with the next one: `schedule mon wed-biweekly` (or `wed-pm-biweekly`)\n" +
+		"* This replaces your old schedule, so list every day you want\n" +
+		"* `save schedule as work` saves your schedule to switch back to with `load schedule work`. `schedules` lists the ones you've saved, and `delete schedule work` forgets one\n" +
+		"* If nobody else is scheduled on any of your days, I'll let you know, since you wouldn't get matched",
+
+	"thisweek": "**`thisweek add <days>`** or **`thisweek remove <days>`** changes your schedule for this week only.\n" +
+		"* `thisweek add thu` also matches you this Thursday, and `thisweek remove mon` skips this Monday\n" +
+		"* Days work like in `schedule`, including `weekdays` and `weekends`, but always cover the whole day\n" +
+		"* `thisweek clear` undoes your changes for the week. Next week, you're back to your usual schedule either way",
+
+	"mute": "**`mute <days>`** stops matching you on those days every week, until you `unmute` them.\n" +
+		"* `mute wed` keeps Wednesday in your `schedule`, so `unmute wed` brings it right back\n" +
+		"* Days work like in `thisweek`, including `weekdays` and `weekends`\n" +
+		"* `thisweek add wed` still matches you on a muted Wednesday, for that week only\n" +
+		"* `status` lists the days you've muted",
+
+	"skip": "**`skip tomorrow`** or **`skip <date>`** skips pairing for a single day.\n" +
+		"* `skip tomorrow` is valid until matches go out at 04:00 UTC\n" +
+		"* `skip 2024-03-14` skips a specific date in your time zone. You can skip as many dates as you like\n" +
+		"* `skip 3 days` skips the next 3 days in a row, up to 30\n" +
+		"* `unskip tomorrow`, `unskip 2024-03-14`, and `unskip days` undo them\n" +
+		"* `status` lists your upcoming skips",
+
+	"pause": "**`pause <weeks>`** stops matching you for a while without losing your schedule.\n" +
+		"* `pause 3` pauses for 3 weeks\n" +
+		"* `pause` with no number pauses until you `resume`\n" +
+		"* `resume` ends a pause early",
+
+	"status": "**`status`** shows your current schedule, skips, time zone, and other settings.",
+
+	"next": "**`next`** shows the next date you'll be matched for.\n" +
+		"* This takes your `schedule`, skips, pause, and time zone into account",
+
+	"availability": "**`availability`** shows how many other people are pairing on each of the rest of this week's days.\n" +
+		"* This counts everyone's skips and pauses, so it's a good way to pick a day to `thisweek add`",
+
+	"set": "**`set <setting> <value>`** changes one of your settings. Most can be undone with `clear <setting>`.\n" +
+		"* `set timezone America/New_York` sets your time zone, which decides what \"tomorrow\" means for you. The default is UTC\n" +
+		"* `set bio I'm writing a ray tracer!` shares a short intro (up to 280 characters) with your partners. `clear bio` removes it\n" +
+		"* `set topics go, rust` nudges matching toward people with a topic in common. `clear topics` removes them\n" +
+		"* `set matchtime 09:00` delivers your match message around 9am your time. `clear matchtime` goes back to right away\n" +
+		"* `set maxweekly 3` matches you at most 3 times in any week. `0` removes the limit\n" +
+		"* `set duration 60` tells your partners you'd like to pair for about 60 minutes. `clear duration` removes it\n" +
+		"* `set batchpref same`, `cross`, or `any` prefers partners from your own batch or other batches\n" +
+		"* `set mode voice`, `text`, `inperson`, or `any` prefers partners who'd like to pair the same way. `inperson` is only for people at RC, and only matches others there\n" +
+		"* `set verbosity minimal`, `normal`, or `full` controls how much your match messages include: just who you're paired with, the theme and bios too (the default), or everyone's topics as well\n" +
+		"* `set weekly-summary on` or `off` controls the weekly summary of who you paired with\n" +
+		"* `set leaderboard on` or `off` controls whether you appear on the `leaderboard`\n" +
+		"* `set nudge on` or `off` controls whether I remind you to get in touch with your partner a few hours after your match\n" +
+		"* `set email-fallback on` or `off` controls whether I email you your match when I can't send it on Zulip\n" +
+		"* `set discoverable on` or `off` controls whether `who` shows others your bio, topics, and whether you're at RC\n" +
+		"* `set history on` or `off` controls whether I remember your commands for `history`\n" +
+		"* `set confirm on` or `off` controls whether you `confirm` each match before it counts\n" +
+		"* `set mentor on` or `off` (for alumni) puts you in the mentor pool, to be matched with current Recursers instead of the daily matches",
+
+	"theme": "**`theme`** shows this week's conversation starter, if the maintainers have set one.\n" +
+		"* It's also included in your match message",
+
+	"topics": "**`topics`** shows the topics you've shared with `set topics`.",
+
+	"stats": "**`stats`** shows how many times you've been matched and your pairing streaks.",
+
+	"noshow": "**`noshow`** reports that your partner didn't show up for your most recent match.\n" +
+		"* This works for matches from the last week\n" +
+		"* The maintainers can see these reports, but your partner isn't told",
+
+	"rate": "**`rate <1-5>`** rates your most recent match, from 1 (not great) to 5 (great).\n" +
+		"* This works for matches from the last week. Rating again changes your rating\n" +
+		"* Your partner never sees it. The maintainers only see the average",
+
+	"confirm": "**`confirm`** confirms your latest match, after you `set confirm on`.\n" +
+		"* You have 6 hours from when the match message goes out. After that, the match is off and doesn't count\n" +
+		"* `decline` calls off the match if you can't make it. Your partner is offered a `match now` partner instead",
+
+	"block": "**`block <person>`** makes sure you're never matched with someone.\n" +
+		"* Mention them (like `block @**Ada Lovelace**`) or use their name\n" +
+		"* They aren't told, and it works for `match now` too\n" +
+		"* `unblock <person>` undoes it, and `blocks` lists who you've blocked",
+
+	"who": "**`who <person>`** shows someone's bio, topics, and whether they're at RC right now.\n" +
+		"* Mention them (like `who @**Ada Lovelace**`) or use their name\n" +
+		"* This only works for people who opted in with `set discoverable on`",
+
+	"alias": "**`alias <name> = <command>`** gives a command a shortcut of your own.\n" +
+		"* `alias sk = skip tomorrow` makes `sk` the same as `skip tomorrow`\n" +
+		"* Anything after the alias is added to the command: with `alias s = skip`, `s 2024-03-14` skips that date\n" +
+		"* Aliases can't have the same name as a command, or stand for another alias\n" +
+		"* `aliases` lists yours, and `unalias sk` removes one",
+
+	"met": "**`met <person>`** tells you whether you've been matched with someone before.\n" +
+		"* Mention them (like `met @**Ada Lovelace**`) or use their name\n" +
+		"* I'll tell you how many times you've been matched, and when the last time was",
+
+	"pair": "**`pair <person>`** asks someone in particular to pair with you.\n" +
+		"* Mention them (like `pair @**Ada Lovelace**`) or use their name\n" +
+		"* They have 24 hours to send `pair accept` or `pair decline`, and I'll let you know what they say\n" +
+		"* Accepted requests count as a match, just like daily ones",
+
+	"export": "**`export`** sends you everything I've stored about you, as JSON.\n" +
+		"* That's your settings, your matches, the reviews you've written, and your `history`\n" +
+		"* Anonymous reviews aren't included, since I don't know who wrote them",
+
+	"delete": "**`delete me`** permanently deletes everything I've stored about you.\n" +
+		"* That's your settings, the reviews you've written, and your `history`. Your past matches are kept so your partners' stats stay the same\n" +
+		"* `delete me matches` removes you from your past matches too\n" +
+		"* Send `delete me confirm` within the hour to go ahead, or `delete me cancel` to keep everything\n" +
+		"* This can't be undone. If you just want to stop getting matched, `unsubscribe` is enough",
+
+	"reset": "**`reset`** puts all of your settings back to how they were when you subscribed, without unsubscribing you.\n" +
+		"* Your schedule goes back to every weekday, and your skips, pause, bio, topics, saved schedules, aliases, and `set` preferences are cleared\n" +
+		"* Your time zone, blocks, and past matches are kept\n" +
+		"* Send `reset confirm` to go ahead. This can't be undone",
+
+	"leaderboard": "**`leaderboard`** shows who's been matched the most this batch.\n" +
+		"* Only people who opt in with `set leaderboard on` are shown",
+
+	"milestones": "**`milestones`** shows how many times Recursers have paired with Pairing Bot, all-time.\n" +
+		"* Every 1,000 pairings is a milestone, and I'll celebrate for a week after we pass one",
+
+	"match": "**`match now`** finds you an extra pairing partner right away.\n" +
+		"* If nobody else is waiting, I'll hold your spot for 30 minutes\n" +
+		"* `cancel match` gives up your spot",
+
+	"add-review": "**`add-review <review>`** shares a publicly viewable review of Pairing Bot.\n" +
+		"* Start with `#praise`, `#idea`, or `#bug` to tag it, like `add-review #idea more cookies`\n" +
+		"* `anonymous review <review>` doesn't record who wrote it",
+
+	"bug": "**`bug <description>`** reports a problem with Pairing Bot to the maintainers.\n" +
+		"* It's filed as a public issue on GitHub (without your name), and I'll send you the link",
+
+	"get-reviews": "**`get-reviews [number]`** shows recent reviews of Pairing Bot.\n" +
+		"* `get-reviews` shows the 5 most recent. `get-reviews 10` shows 10",
+
+	"history": "**`history`** shows the last 10 commands you sent me, with when you sent them.\n" +
+		"* This only works after you opt in with `set history on`. `set history off` stops recording",
+
+	"cookie": "**`cookie`** only use this command if you like :cookie::cookie::cookie:",
+
+	"version": "**`version`** shows which version of Pairing Bot is running.",
+}
+
+// helpAliases maps commands that don't have their own entry in commandHelp to
+// the entry that covers them.
+var helpAliases = map[string]string{
+	"unskip":    "skip",
+	"unmute":    "mute",
+	"resume":    "pause",
+	"clear":     "set",
+	"cancel":    "match",
+	"restore":   "unsubscribe",
+	"anonymous": "add-review",
+	"decline":   "confirm",
+	"unblock":   "block",
+	"blocks":    "block",
+	"unalias":   "alias",
+	"aliases":   "alias",
+	"save":      "schedule",
+	"load":      "schedule",
+	"schedules": "schedule",
+}
+
+// helpFor returns the help text for `help <command>`. An empty command gets
+// the general help, as does an unknown one (with a note saying so). People who
+// aren't subscribed get a short version of the general help, since most
+// commands don't do anything for them yet.
+func helpFor(command string, subscribed bool) string {
+	general := helpMessage
+	if !subscribed {
+		general = newcomerHelpMessage
+	}
+
+	if command == "" {
+		return general
+	}
+
+	command = strings.ToLower(command)
+	if alias, ok := helpAliases[command]; ok {
+		command = alias
+	}
+
+	if help, ok := commandHelp[command]; ok {
+		return help
+	}
+	return fmt.Sprintf("I don't have any help for %q, but here's what I can do:\n\n%s", command, general)
+}
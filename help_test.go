@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestPairingLogic_dispatch_help(t *testing.T) {
+	ctx := context.Background()
+	pl := &PairingLogic{}
+
+	subscribed, err := pl.dispatch(ctx, "help", nil, &store.Recurser{ID: 1, IsSubscribed: true})
+	assert.NoError(t, err)
+	assert.Equal(t, subscribed, helpMessage)
+
+	newcomer, err := pl.dispatch(ctx, "help", nil, &store.Recurser{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, newcomer, newcomerHelpMessage)
+}
+
+func Test_helpFor(t *testing.T) {
+	t.Run("general help", func(t *testing.T) {
+		assert.Equal(t, helpFor("", true), helpMessage)
+	})
+
+	t.Run("not subscribed", func(t *testing.T) {
+		help := helpFor("", false)
+		assert.Equal(t, help, newcomerHelpMessage)
+		assert.Equal(t, strings.Contains(help, "`subscribe`"), true)
+		assert.Equal(t, strings.Contains(help, "`help <command>`"), true)
+
+		// Commands that only make sense once subscribed are left out.
+		for _, command := range []string{"`skip", "`pause", "`match now`", "`set "} {
+			assert.Equal(t, strings.Contains(help, command), false)
+			assert.Equal(t, strings.Contains(helpMessage, command), true)
+		}
+		assert.Equal(t, len(help) < len(helpMessage), true)
+	})
+
+	t.Run("command help", func(t *testing.T) {
+		assert.Equal(t, helpFor("schedule", true), commandHelp["schedule"])
+		assert.Equal(t, helpFor("SKIP", true), commandHelp["skip"])
+
+		// Anyone can get help for a specific command.
+		assert.Equal(t, helpFor("schedule", false), commandHelp["schedule"])
+	})
+
+	t.Run("aliases", func(t *testing.T) {
+		assert.Equal(t, helpFor("unskip", true), commandHelp["skip"])
+		assert.Equal(t, helpFor("clear", true), commandHelp["set"])
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		help := helpFor("frobnicate", true)
+		assert.Equal(t, strings.Contains(help, `"frobnicate"`), true)
+		assert.Equal(t, strings.HasSuffix(help, helpMessage), true)
+
+		help = helpFor("frobnicate", false)
+		assert.Equal(t, strings.HasSuffix(help, newcomerHelpMessage), true)
+	})
+
+	t.Run("every entry is a real command", func(t *testing.T) {
+		for command := range commandHelp {
+			_, _, err := parseCmd(command)
+			if errors.Is(err, ErrUnknownCommand) {
+				t.Errorf("help for unknown command %q", command)
+			}
+		}
+		for alias, command := range helpAliases {
+			if _, ok := commandHelp[command]; !ok {
+				t.Errorf("alias %q points at missing entry %q", alias, command)
+			}
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// holidayDate is the date that the match run at `now` pairs for on the holiday
+// calendar, which is in loc (UTC if it's nil). It's the same date as
+// store.Recurser.MatchDate for someone in loc.
+func holidayDate(now time.Time, loc *time.Location) string {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return store.MatchTimeIn(now, loc).Format(time.DateOnly)
+}
+
+// holidayLocation is the calendar's time zone, or UTC if it doesn't have one
+// (or has one that doesn't exist).
+func holidayLocation(config store.HolidayConfig) *time.Location {
+	loc, err := time.LoadLocation(config.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// holidayOn returns the holiday that the match run at `now` falls on, or nil if
+// it's a normal day. If the calendar can't be read, it's a normal day.
+func (pl *PairingLogic) holidayOn(ctx context.Context, now time.Time) *store.Holiday {
+	config, err := store.Holidays(pl.db).GetConfig(ctx)
+	if err != nil {
+		logger(ctx).Warn("Could not get the holiday calendar's time zone, so using UTC", slog.Any("error", err))
+	}
+
+	holiday, err := store.Holidays(pl.db).Get(ctx, holidayDate(now, holidayLocation(config)))
+	if err != nil {
+		logger(ctx).Warn("Could not check the holiday calendar, so matching anyway", slog.Any("error", err))
+		return nil
+	}
+	return holiday
+}
+
+// announceHoliday posts in the welcome stream that there's no matching today,
+// if the holiday asks for that.
+func (pl *PairingLogic) announceHoliday(ctx context.Context, holiday *store.Holiday) {
+	if !holiday.Announce {
+		return
+	}
+
+	msg := "Happy holiday! There's no pairing today. See you next time :)"
+	if holiday.Name != "" {
+		msg = fmt.Sprintf("Happy %s! There's no pairing today. See you next time :)", holiday.Name)
+	}
+	if err := pl.zulip.PostToTopic(ctx, pl.welcomeStream, "🍐🤖", msg); err != nil {
+		logger(ctx).Error("Could not post about the holiday", slog.Any("error", err))
+	}
+}
+
+// HolidaysStatus lists the holidays from today on.
+func (pl *PairingLogic) HolidaysStatus(ctx context.Context) (string, error) {
+	config, err := store.Holidays(pl.db).GetConfig(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+	holidays, err := store.Holidays(pl.db).ListFrom(ctx, holidayDate(time.Now(), holidayLocation(config)))
+	if err != nil {
+		return "", readError(err)
+	}
+
+	if len(holidays) == 0 {
+		return "There are no holidays coming up. Send `holidays add <date> <name>` to add one.", nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "There's no matching on these days (in **%s**):", holidayZone(config))
+	for _, holiday := range holidays {
+		fmt.Fprintf(&sb, "\n* %s", holiday.Date)
+		if holiday.Name != "" {
+			fmt.Fprintf(&sb, ": %s", holiday.Name)
+		}
+		if holiday.Announce {
+			sb.WriteString(" (announced)")
+		}
+	}
+	return sb.String(), nil
+}
+
+// AddHoliday adds a day with no matching to the calendar. If `announce` is
+// set, the match run that day posts about it.
+func (pl *PairingLogic) AddHoliday(ctx context.Context, rec *store.Recurser, date, name string, announce bool) (string, error) {
+	holiday := store.Holiday{
+		Date:     date,
+		Name:     name,
+		Announce: announce,
+		AddedBy:  rec.ID,
+	}
+	config, err := store.Holidays(pl.db).GetConfig(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+	if err := store.Holidays(pl.db).Set(ctx, holiday); err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Done. Nobody will be matched on %s (in **%s**). Send `holidays remove %s` to undo this.", date, holidayZone(config), date), nil
+}
+
+// RemoveHoliday takes a day off the calendar, so it's matched like any other.
+func (pl *PairingLogic) RemoveHoliday(ctx context.Context, date string) (string, error) {
+	holiday, err := store.Holidays(pl.db).Get(ctx, date)
+	if err != nil {
+		return "", readError(err)
+	}
+	if holiday == nil {
+		return fmt.Sprintf("%s isn't a holiday.", date), nil
+	}
+
+	if err := store.Holidays(pl.db).Delete(ctx, date); err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Done. %s is matched like any other day again.", date), nil
+}
+
+// SetHolidayTimezone changes the time zone of the holiday calendar. The dates
+// already on it stay the same.
+func (pl *PairingLogic) SetHolidayTimezone(ctx context.Context, rec *store.Recurser, timezone string) (string, error) {
+	config := store.HolidayConfig{
+		Timezone:  timezone,
+		UpdatedBy: rec.ID,
+		Timestamp: time.Now().Unix(),
+	}
+	if err := store.Holidays(pl.db).SetConfig(ctx, config); err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Done. The holiday calendar is in **%s** now.", holidayZone(config)), nil
+}
+
+// holidayZone is the name of the time zone the holiday calendar is in.
+func holidayZone(config store.HolidayConfig) string {
+	if config.Timezone == "" {
+		return "UTC"
+	}
+	return config.Timezone
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_holidayDate(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	// The Christmas match run is still Christmas Eve in New York (EST), but
+	// it's pairing for Christmas there too.
+	christmas := time.Date(2024, time.December, 25, store.MatchRunHour, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, holidayDate(christmas, nil), "2024-12-25")
+	assert.Equal(t, holidayDate(christmas, time.UTC), "2024-12-25")
+	assert.Equal(t, holidayDate(christmas, newYork), "2024-12-25")
+
+	// In the summer (EDT), the match run is just after midnight in New York.
+	july4 := time.Date(2024, time.July, 4, store.MatchRunHour, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, holidayDate(july4, nil), "2024-07-04")
+	assert.Equal(t, holidayDate(july4, newYork), "2024-07-04")
+
+	// Other times of day are rounded to the closest day.
+	early := time.Date(2024, time.December, 25, 3, 0, 0, 0, time.UTC)
+	late := time.Date(2024, time.December, 24, 14, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, holidayDate(early, newYork), "2024-12-25")
+	assert.Equal(t, holidayDate(late, nil), "2024-12-25")
+}
+
+func TestPairingLogic_match_holiday(t *testing.T) {
+	ctx := context.Background()
+
+	// Each test gets its own database and records who each message was
+	// sent to.
+	setup := func(t *testing.T) (*PairingLogic, func() []string) {
+		fake := pbtest.NewFakeZulip(t)
+
+		db := pbtest.FirestoreClient(t, ctx)
+		everyDay := store.NewSchedule([]string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"})
+		for _, rec := range []store.Recurser{
+			{ID: 1, Name: "One", Schedule: everyDay, IsSubscribed: true},
+			{ID: 2, Name: "Two", Schedule: everyDay, IsSubscribed: true},
+		} {
+			if err := store.Recursers(db).Set(ctx, &rec); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		pl := &PairingLogic{db: db, zulip: fake.Client, repeatWindowDays: 7, seeds: rand.New(rand.NewSource(1)), welcomeStream: "welcome"}
+		return pl, func() []string {
+			var sentTo []string
+			for _, msg := range fake.Messages() {
+				sentTo = append(sentTo, msg.To)
+			}
+			return sentTo
+		}
+	}
+
+	matchesToday := func(t *testing.T, pl *PairingLogic) int {
+		matches, err := store.Pairings(pl.db).GetMatchesSince(ctx, time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+		return len(matches)
+	}
+
+	admin := &store.Recurser{ID: 1}
+	today := holidayDate(time.Now(), nil)
+
+	t.Run("holiday", func(t *testing.T) {
+		pl, sentTo := setup(t)
+
+		_, err := pl.AddHoliday(ctx, admin, today, "Pairing Day Off", true)
+		assert.NoError(t, err)
+
+		assert.NoError(t, pl.Match(ctx))
+		assert.Equal(t, matchesToday(t, pl), 0)
+
+		// Only the announcement went out.
+		assert.Equal(t, sentTo(), []string{"welcome"})
+	})
+
+	t.Run("skippers", func(t *testing.T) {
+		pl, _ := setup(t)
+
+		skipper := store.Recurser{ID: 3, Name: "Three", Schedule: store.DefaultSchedule(), IsSubscribed: true, IsSkippingTomorrow: true}
+		assert.NoError(t, store.Recursers(pl.db).Set(ctx, &skipper))
+		_, err := pl.AddHoliday(ctx, admin, today, "", false)
+		assert.NoError(t, err)
+
+		// Skipping tomorrow was only for this run, even though nobody was
+		// matched.
+		assert.NoError(t, pl.Match(ctx))
+		skippers, err := store.Recursers(pl.db).ListSkippingTomorrow(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, len(skippers), 0)
+	})
+
+	t.Run("time zone", func(t *testing.T) {
+		pl, _ := setup(t)
+
+		msg, err := pl.SetHolidayTimezone(ctx, admin, "Pacific/Kiritimati")
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Done. The holiday calendar is in **Pacific/Kiritimati** now.")
+
+		kiritimati, err := time.LoadLocation("Pacific/Kiritimati")
+		assert.NoError(t, err)
+		now := time.Now()
+		_, err = pl.AddHoliday(ctx, admin, holidayDate(now, kiritimati), "Line Islands Day", false)
+		assert.NoError(t, err)
+
+		holiday := pl.holidayOn(ctx, now)
+		assert.Equal(t, holiday != nil, true)
+		assert.Equal(t, holiday.Name, "Line Islands Day")
+
+		msg, err = pl.HolidaysStatus(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.HasPrefix(msg, "There's no matching on these days (in **Pacific/Kiritimati**):"), true)
+	})
+
+	t.Run("holiday without an announcement", func(t *testing.T) {
+		pl, sentTo := setup(t)
+
+		_, err := pl.AddHoliday(ctx, admin, today, "", false)
+		assert.NoError(t, err)
+
+		assert.NoError(t, pl.Match(ctx))
+		assert.Equal(t, matchesToday(t, pl), 0)
+		assert.Equal(t, len(sentTo()), 0)
+	})
+
+	t.Run("normal day", func(t *testing.T) {
+		pl, sentTo := setup(t)
+
+		// Holidays on other days don't matter.
+		_, err := pl.AddHoliday(ctx, admin, holidayDate(time.Now().AddDate(0, 0, 1), nil), "Tomorrow", true)
+		assert.NoError(t, err)
+
+		assert.NoError(t, pl.Match(ctx))
+		assert.Equal(t, matchesToday(t, pl), 1)
+		assert.Equal(t, len(sentTo()) > 0, true)
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		pl, _ := setup(t)
+
+		_, err := pl.AddHoliday(ctx, admin, today, "Oops", true)
+		assert.NoError(t, err)
+		msg, err := pl.RemoveHoliday(ctx, today)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Done. "+today+" is matched like any other day again.")
+
+		assert.NoError(t, pl.Match(ctx))
+		assert.Equal(t, matchesToday(t, pl), 1)
+	})
+}
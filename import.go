@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// importColumns are the columns /import expects, in order, in the CSV's header
+// row. The schedule is written like the `schedule` command's days, like
+// "mon wed fri" or "weekdays except wed".
+var importColumns = []string{"id", "name", "email", "schedule"}
+
+// maxImportSize is the largest CSV /import accepts.
+const maxImportSize = 1 << 20
+
+// An importReport is what /import did with each row of the CSV.
+type importReport struct {
+	Imported int           `json:"imported"`
+	Failed   int           `json:"failed"`
+	Rows     []importedRow `json:"rows"`
+}
+
+// An importedRow is the result for one row. Rows are numbered from 1, counting
+// the header, so they match the line numbers in a spreadsheet.
+type importedRow struct {
+	Row   int    `json:"row"`
+	ID    int64  `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Import creates or updates Recursers from a CSV in the request body (see
+// importColumns). Each row is handled on its own, so a bad row is reported
+// without stopping the rest. It responds with an importReport.
+//
+// New Recursers are subscribed. Existing ones keep the rest of their settings,
+// including whether they're subscribed.
+func (pl *PairingLogic) Import(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	reader := csv.NewReader(http.MaxBytesReader(w, r.Body, maxImportSize))
+	reader.FieldsPerRecord = len(importColumns)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil || !slices.Equal(header, importColumns) {
+		http.Error(w, fmt.Sprintf("The first row must be the header %q", strings.Join(importColumns, ",")), http.StatusBadRequest)
+		return
+	}
+
+	var report importReport
+	for row := 2; ; row++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		result := importedRow{Row: row}
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			result.Error = parseErr.Err.Error()
+		case err != nil:
+			logger(ctx).Error("Could not read the CSV to import", slog.Any("error", err))
+			http.Error(w, "Could not read the CSV", http.StatusBadRequest)
+			return
+		default:
+			result.ID, err = pl.importRow(ctx, fields)
+			if err != nil {
+				result.Error = err.Error()
+			}
+		}
+
+		if result.Error != "" {
+			report.Failed++
+		} else {
+			report.Imported++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	logger(ctx).Info("Imported Recursers", slog.Int("imported", report.Imported), slog.Int("failed", report.Failed))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logger(ctx).Error("Could not write JSON response", slog.Any("error", err))
+	}
+}
+
+// importRow validates one row of the CSV and saves it. It returns the row's
+// Recurser ID, if the row got that far.
+func (pl *PairingLogic) importRow(ctx context.Context, fields []string) (int64, error) {
+	id, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("id must be a Zulip user ID, got %q", fields[0])
+	}
+	name, email := strings.TrimSpace(fields[1]), strings.TrimSpace(fields[2])
+	if name == "" {
+		return id, errors.New("name is empty")
+	}
+	if !strings.Contains(email, "@") {
+		return id, fmt.Errorf("email must be an email address, got %q", email)
+	}
+
+	days, err := parseSchedule(strings.Fields(fields[3]))
+	if err != nil {
+		return id, fmt.Errorf("schedule %q: %w", fields[3], err)
+	}
+	var biweekly []string
+	if i := slices.Index(days, "biweekly"); i >= 0 {
+		days, biweekly = days[:i], days[i+1:]
+	}
+
+	rec, err := store.Recursers(pl.db).GetByUserID(ctx, store.DefaultRealm, id, email, name)
+	if err != nil {
+		logger(ctx).Error("Could not look up a Recurser to import", slog.Int64("recurserId", id), slog.Any("error", err))
+		return id, errors.New("could not read the existing record")
+	}
+
+	rec.Schedule = store.NewSchedule(days)
+	rec.SetBiweekly(time.Now(), biweekly)
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		logger(ctx).Error("Could not save an imported Recurser", slog.Int64("recurserId", id), slog.Any("error", err))
+		return id, errors.New("could not save the record")
+	}
+	return id, nil
+}
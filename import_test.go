@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestPairingLogic_Import(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+	pl := &PairingLogic{db: db}
+
+	// Grace already has a record, with settings that the import keeps.
+	if err := store.Recursers(db).Set(ctx, &store.Recurser{ID: 2, Name: "Grace", Bio: "Compilers", Schedule: store.DefaultSchedule()}); err != nil {
+		t.Fatal(err)
+	}
+
+	csv := "id,name,email,schedule\n" +
+		"1,Ada,ada@recurse.example.net,mon wed fri\n" +
+		"2,Grace Hopper,grace@recurse.example.net,weekdays except wed\n" +
+		"3,Alan,alan@recurse.example.net,someday\n" +
+		"4,Charles,charles@recurse.example.net\n"
+
+	req := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(csv))
+	w := httptest.NewRecorder()
+	pl.Import(w, req)
+	assert.Equal(t, w.Code, http.StatusOK)
+
+	var report importReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, report.Imported, 2)
+	assert.Equal(t, report.Failed, 2)
+	assert.Equal(t, report.Rows[0], importedRow{Row: 2, ID: 1})
+	assert.Equal(t, report.Rows[1], importedRow{Row: 3, ID: 2})
+	assert.Equal(t, report.Rows[2].ID, int64(3))
+	assert.Equal(t, strings.Contains(report.Rows[2].Error, "someday"), true)
+	assert.Equal(t, report.Rows[3].Row, 5)
+	assert.Equal(t, strings.Contains(report.Rows[3].Error, "wrong number of fields"), true)
+
+	all, err := store.Recursers(db).GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(all), 2)
+
+	ada, err := store.Recursers(db).GetByUserID(ctx, store.DefaultRealm, 1, "ada@recurse.example.net", "Ada")
+	assert.NoError(t, err)
+	assert.Equal(t, ada.IsSubscribed, true)
+	assert.Equal(t, ada.Schedule, store.NewSchedule([]string{"monday", "wednesday", "friday"}))
+
+	grace, err := store.Recursers(db).GetByUserID(ctx, store.DefaultRealm, 2, "grace@recurse.example.net", "Grace Hopper")
+	assert.NoError(t, err)
+	assert.Equal(t, grace.Bio, "Compilers")
+	assert.Equal(t, grace.Schedule, store.NewSchedule([]string{"monday", "tuesday", "thursday", "friday"}))
+}
+
+func TestPairingLogic_Import_badHeader(t *testing.T) {
+	pl := &PairingLogic{}
+
+	req := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader("1,Ada,ada@recurse.example.net,mon\n"))
+	w := httptest.NewRecorder()
+	pl.Import(w, req)
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+}
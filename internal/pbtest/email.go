@@ -0,0 +1,33 @@
+package pbtest
+
+import (
+	"context"
+	"sync"
+)
+
+// An Email is one message sent with FakeEmailer.
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// FakeEmailer is an in-memory stand-in for an email server. It keeps every
+// email it's asked to send.
+type FakeEmailer struct {
+	mu   sync.Mutex
+	Sent []Email
+
+	// Err, if set, is returned instead of sending.
+	Err error
+}
+
+func (f *FakeEmailer) SendEmail(_ context.Context, to, subject, body string) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Sent = append(f.Sent, Email{To: to, Subject: subject, Body: body})
+	return nil
+}
@@ -12,7 +12,7 @@ import (
 )
 
 // FirestoreClient returns a Firestore client scoped to a new random project ID.
-func FirestoreClient(t *testing.T, ctx context.Context) *firestore.Client {
+func FirestoreClient(t testing.TB, ctx context.Context) *firestore.Client {
 	client, err := firestore.NewClient(ctx, projectID(t))
 	if err != nil {
 		t.Fatal(err)
@@ -28,12 +28,12 @@ func FirestoreClient(t *testing.T, ctx context.Context) *firestore.Client {
 }
 
 // projectID generates a fake Google Cloud project ID for use in tests.
-func projectID(t *testing.T) string {
+func projectID(t testing.TB) string {
 	return fmt.Sprintf("fake-project-%d", RandInt64(t))
 }
 
 // RandInt64 generates a random number from the default source.
-func RandInt64(t *testing.T) int64 {
+func RandInt64(t testing.TB) int64 {
 	int64Max := int64(1<<63 - 1)
 
 	n, err := rand.Int(rand.Reader, big.NewInt(int64Max))
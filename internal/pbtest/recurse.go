@@ -0,0 +1,76 @@
+package pbtest
+
+import (
+	"context"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/recurse"
+)
+
+// FakeRecurse is an in-memory stand-in for the Recurse API. Its methods
+// answer from the fields, the same way recurse.Client answers from the real
+// API.
+type FakeRecurse struct {
+	// Active are the profiles of everyone currently at RC.
+	Active []recurse.Profile
+
+	// Emails has the profile for each email address that belongs to a
+	// Recurser, whether or not they're at RC right now.
+	Emails map[string]recurse.Profile
+
+	// Batches are all the batches, most recent first.
+	Batches []recurse.Batch
+
+	// Err, if set, is returned by every method instead.
+	Err error
+}
+
+func (f *FakeRecurse) ActiveRecursers(context.Context) ([]recurse.Profile, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.Active, nil
+}
+
+func (f *FakeRecurse) ProfileByEmail(_ context.Context, email string) (recurse.Profile, error) {
+	if f.Err != nil {
+		return recurse.Profile{}, f.Err
+	}
+	profile, ok := f.Emails[email]
+	if !ok {
+		return recurse.Profile{}, recurse.ErrNotFound
+	}
+	return profile, nil
+}
+
+func (f *FakeRecurse) IsCurrentlyAtRC(_ context.Context, zulipID int64) (bool, error) {
+	if f.Err != nil {
+		return false, f.Err
+	}
+	for _, profile := range f.Active {
+		if profile.ZulipID == zulipID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *FakeRecurse) AllBatches(context.Context) ([]recurse.Batch, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.Batches, nil
+}
+
+func (f *FakeRecurse) RecentlyStarted(_ context.Context, since time.Time) ([]recurse.Profile, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	var started []recurse.Profile
+	for _, profile := range f.Active {
+		if stint, ok := profile.CurrentStint(); ok && time.Time(stint.StartDate).After(since) {
+			started = append(started, profile)
+		}
+	}
+	return started, nil
+}
@@ -0,0 +1,122 @@
+package pbtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/zulip"
+)
+
+// A ZulipMessage is one message sent to FakeZulip.
+type ZulipMessage struct {
+	// Type is "private" for direct messages and "stream" for stream posts.
+	Type string
+
+	// To is the recipient list of a direct message (like "[1,2]"), or the
+	// stream of a post.
+	To      string
+	Topic   string
+	Content string
+}
+
+// FakeZulip is a stand-in for the Zulip API, running on a local test server.
+// It keeps every message that Client sends, even the ones it rejects.
+type FakeZulip struct {
+	// Client sends to the fake server.
+	Client *zulip.Client
+
+	// Status, if set, picks the HTTP status code to answer each request
+	// with, or zero for success. Requests that aren't messages (like
+	// zulip.Client.Ping) get the zero ZulipMessage. Without it, everything
+	// succeeds.
+	Status func(ZulipMessage) int
+
+	mu       sync.Mutex
+	messages []ZulipMessage
+	requests int
+}
+
+// NewFakeZulip starts a FakeZulip that's shut down at the end of the test.
+func NewFakeZulip(t testing.TB) *FakeZulip {
+	f := &FakeZulip{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg ZulipMessage
+		if r.Method == http.MethodPost {
+			msg = ZulipMessage{
+				Type:    r.FormValue("type"),
+				To:      r.FormValue("to"),
+				Topic:   r.FormValue("topic"),
+				Content: r.FormValue("content"),
+			}
+		}
+
+		f.mu.Lock()
+		f.requests++
+		if msg != (ZulipMessage{}) {
+			f.messages = append(f.messages, msg)
+		}
+		status := f.Status
+		f.mu.Unlock()
+
+		if status != nil {
+			if code := status(msg); code != 0 {
+				w.WriteHeader(code)
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := zulip.NewClient(
+		zulip.StaticCredentials("fake-username", "fake-password"),
+		zulip.WithHTTP(srv.Client()),
+		zulip.WithBaseURL(srv.URL),
+		zulip.WithRateLimit(1000, 1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Client = client
+
+	return f
+}
+
+// Messages returns every message sent so far, in order.
+func (f *FakeZulip) Messages() []ZulipMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]ZulipMessage(nil), f.messages...)
+}
+
+// DMs returns the content of each direct message sent to the recipient list
+// (like "[1,2]") so far, in order.
+func (f *FakeZulip) DMs(to string) []string {
+	var contents []string
+	for _, msg := range f.Messages() {
+		if msg.Type == "private" && msg.To == to {
+			contents = append(contents, msg.Content)
+		}
+	}
+	return contents
+}
+
+// Posts returns every stream post sent so far, in order.
+func (f *FakeZulip) Posts() []ZulipMessage {
+	var posts []ZulipMessage
+	for _, msg := range f.Messages() {
+		if msg.Type == "stream" {
+			posts = append(posts, msg)
+		}
+	}
+	return posts
+}
+
+// Requests returns how many requests the server has answered, including ones
+// that weren't messages.
+func (f *FakeZulip) Requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
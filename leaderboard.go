@@ -0,0 +1,55 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// leaderboardSize is the number of Recursers shown on the leaderboard.
+const leaderboardSize = 10
+
+// leaderboardTTL is how long to reuse a computed leaderboard. Counting every
+// match in the batch is a lot of reads, and nobody needs it to be up to the
+// second.
+const leaderboardTTL = 10 * time.Minute
+
+type leaderboardEntry struct {
+	Name  string
+	Count int
+}
+
+// rankLeaderboard counts matches for each Recurser who opted in to the
+// leaderboard and returns the top leaderboardSize of them. Ties are broken
+// alphabetically by name.
+func rankLeaderboard(recursers []store.Recurser, matches []store.Match) []leaderboardEntry {
+	counts := make(map[recurserKey]int)
+	for _, match := range matches {
+		for _, id := range match.Recursers {
+			counts[recurserKey{match.Realm, id}]++
+		}
+	}
+
+	var entries []leaderboardEntry
+	for _, rec := range recursers {
+		count := counts[recurserKey{rec.Realm, rec.ID}]
+		if !rec.ShowOnLeaderboard || count == 0 {
+			continue
+		}
+		entries = append(entries, leaderboardEntry{Name: rec.Name, Count: count})
+	}
+
+	slices.SortFunc(entries, func(a, b leaderboardEntry) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	if len(entries) > leaderboardSize {
+		entries = entries[:leaderboardSize]
+	}
+	return entries
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_rankLeaderboard(t *testing.T) {
+	recursers := []store.Recurser{
+		{ID: 1, Name: "Ada", ShowOnLeaderboard: true},
+		{ID: 2, Name: "Grace", ShowOnLeaderboard: true},
+		{ID: 3, Name: "Private", ShowOnLeaderboard: false},
+		{ID: 4, Name: "Barbara", ShowOnLeaderboard: true},
+		{ID: 5, Name: "Nobody", ShowOnLeaderboard: true},
+	}
+	matches := []store.Match{
+		{Recursers: []int64{1, 3}},
+		{Recursers: []int64{2, 3}},
+		{Recursers: []int64{2, 4}},
+		{Recursers: []int64{1, 4, 3}},
+	}
+
+	assert.Equal(t, rankLeaderboard(recursers, matches), []leaderboardEntry{
+		{Name: "Ada", Count: 2},
+		{Name: "Barbara", Count: 2},
+		{Name: "Grace", Count: 2},
+	})
+
+	t.Run("top 10 only", func(t *testing.T) {
+		var many []store.Recurser
+		var matches []store.Match
+		for i := range 15 {
+			many = append(many, store.Recurser{ID: int64(i), Name: string(rune('A' + i)), ShowOnLeaderboard: true})
+			matches = append(matches, store.Match{Recursers: []int64{int64(i)}})
+		}
+
+		entries := rankLeaderboard(many, matches)
+		assert.Equal(t, len(entries), 10)
+		assert.Equal(t, entries[9].Name, "J")
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type loggerKey struct{}
+
+// withLogger returns a copy of ctx that carries the logger. Use logger(ctx) to
+// get it back out.
+func withLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// logger returns the logger for this context, which includes attributes like
+// the request ID. Contexts without one get the default logger.
+func logger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// withRequestLogger wraps an HTTP handler so that everything it logs includes
+// the handler's name and an ID for the request. That makes it possible to
+// follow one request through the logs.
+func withRequestLogger(handler string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := slog.Default().With(
+			slog.String("handler", handler),
+			slog.String("requestId", requestID(r)),
+		)
+		next(w, r.WithContext(withLogger(r.Context(), l)))
+	}
+}
+
+// requestID returns an ID for the request. On App Engine, this is the trace ID
+// that the load balancer adds, so our logs line up with the request logs.
+// Otherwise, it's a new random ID.
+//
+// https://cloud.google.com/trace/docs/trace-context#legacy-http-header
+func requestID(r *http.Request) string {
+	if trace, _, _ := strings.Cut(r.Header.Get("X-Cloud-Trace-Context"), "/"); trace != "" {
+		return trace
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(id[:])
+}
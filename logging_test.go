@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+)
+
+func Test_requestID(t *testing.T) {
+	t.Run("uses the trace ID", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/match", nil)
+		r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+		assert.Equal(t, requestID(r), "105445aa7843bc8bf206b12000100000")
+	})
+
+	t.Run("makes up an ID without one", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/match", nil)
+		first, second := requestID(r), requestID(r)
+		assert.Equal(t, len(first), 16)
+		if first == second {
+			t.Errorf("got the same ID twice: %q", first)
+		}
+	})
+}
+
+func Test_withRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	handler := withRequestLogger("/match", func(w http.ResponseWriter, r *http.Request) {
+		logger(r.Context()).Info("hello")
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/match", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "abc123/1")
+	handler(httptest.NewRecorder(), r)
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, line["msg"], any("hello"))
+	assert.Equal(t, line["handler"], any("/match"))
+	assert.Equal(t, line["requestId"], any("abc123"))
+}
+
+func Test_logger_default(t *testing.T) {
+	if logger(context.Background()) != slog.Default() {
+		t.Error("expected the default logger")
+	}
+}
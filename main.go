@@ -5,8 +5,17 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"math/rand"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Recursers can pick any IANA time zone
 
 	"cloud.google.com/go/firestore"
 	"github.com/recursecenter/pairing-bot/recurse"
@@ -14,9 +23,16 @@ import (
 	"github.com/recursecenter/pairing-bot/zulip"
 )
 
+// startTime is when the process started, for reporting uptime.
+var startTime time.Time
+
 // It's alive! The application starts here.
 func main() {
-	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+	startTime = time.Now()
+
+	// Logs are JSON for Cloud Logging, unless PB_LOG_FORMAT=text asks for
+	// something easier to read locally.
+	var logHandler slog.Handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
 		AddSource: true,
 		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
 			// Leave all nested attributes as-is.
@@ -38,7 +54,11 @@ func main() {
 			}
 			return attr
 		},
-	})))
+	})
+	if os.Getenv("PB_LOG_FORMAT") == "text" {
+		logHandler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{AddSource: true})
+	}
+	slog.SetDefault(slog.New(logHandler))
 
 	ctx := context.Background()
 
@@ -49,8 +69,9 @@ func main() {
 	projectId := "pairing-bot-284823"
 	botUsername := "pairing-bot@recurse.zulipchat.com"
 	welcomeStream := "🧑‍💻 current batches" // The emoji is literally part of the channel name!
+	adminStream := "pairing-bot"
 
-	log.Printf("Running the app in environment = %s", appEnv)
+	slog.Info("Running the app", slog.String("environment", appEnv))
 
 	// We have two pairing bot projects. One for production and one for testing/dev work.
 	if appEnv != "production" {
@@ -59,13 +80,15 @@ func main() {
 		projectId = "pairing-bot-dev"
 		botUsername = "dev-pairing-bot@recurse.zulipchat.com"
 		welcomeStream = "test-bot"
+		adminStream = "test-bot"
 	}
 
 	// Set up database wrappers. The Firestore client has a connection pool, so
 	// we can share this one DB handle among all the collection helpers.
 	db, err := firestore.NewClient(ctx, projectId)
 	if err != nil {
-		log.Panic(err)
+		slog.Error("Could not connect to Firestore", slog.Any("error", err))
+		os.Exit(1)
 	}
 	defer db.Close()
 
@@ -81,11 +104,44 @@ func main() {
 		}, nil
 	}
 
-	zulipClient, err := zulip.NewClient(zulipCredentials)
+	var zulipOpts []zulip.ClientOpt
+	if r, ok := os.LookupEnv("PB_ZULIP_RATE_LIMIT"); ok {
+		perSecond, err := strconv.ParseFloat(r, 64)
+		if err != nil || perSecond <= 0 {
+			log.Panicf("PB_ZULIP_RATE_LIMIT must be a positive number, got %q", r)
+		}
+		zulipOpts = append(zulipOpts, zulip.WithRateLimit(perSecond, 1))
+	}
+
+	zulipClient, err := zulip.NewClient(zulipCredentials, zulipOpts...)
 	if err != nil {
 		panic(err)
 	}
 
+	// Recursers in other Zulip realms talk to their own realm's bot, and are
+	// only matched with each other.
+	otherRealms, err := parseRealms(os.Getenv("PB_ZULIP_REALMS"))
+	if err != nil {
+		log.Panicf("Could not parse PB_ZULIP_REALMS: %v", err)
+	}
+	realmClients := make(map[string]*zulip.Client)
+	for name, botEmail := range otherRealms {
+		credentials := func(ctx context.Context) (zulip.Credentials, error) {
+			password, err := store.Secrets(db).Get(ctx, "zulip_api_key_"+name)
+			if err != nil {
+				return zulip.Credentials{}, err
+			}
+			return zulip.Credentials{Username: botEmail, Password: password}, nil
+		}
+
+		_, host, _ := strings.Cut(botEmail, "@")
+		opts := append(slices.Clone(zulipOpts), zulip.WithBaseURL("https://"+host+"/api/v1/"))
+		realmClients[name], err = zulip.NewClient(credentials, opts...)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	recurseAccessToken := func(ctx context.Context) (recurse.AccessToken, error) {
 		token, err := store.Secrets(db).Get(ctx, "recurse_access_token")
 		if err != nil {
@@ -103,22 +159,77 @@ func main() {
 		db:      db,
 		recurse: recurseClient,
 		zulip:   zulipClient,
+		realms:  realmClients,
+
+		version:          appVersion,
+		welcomeStream:    welcomeStream,
+		adminStream:      adminStream,
+		repeatWindowDays: 7,
+		seeds:            rand.New(rand.NewSource(time.Now().UnixNano())),
+
+		health: healthCheck{check: func(ctx context.Context) error {
+			return store.Ping(ctx, db)
+		}},
+	}
+
+	// Admin tools (dashboards, debugging) authenticate with this token. It's
+	// still called metrics_token from when /metrics was all it unlocked, so
+	// existing deployments keep working.
+	adminToken := func(ctx context.Context) (string, error) {
+		return store.Secrets(db).Get(ctx, "metrics_token")
+	}
+
+	// Cron jobs come from App Engine, or from anything else that knows this
+	// token.
+	cronToken := func(ctx context.Context) (string, error) {
+		return store.Secrets(db).Get(ctx, "cron_token")
+	}
 
-		version:       appVersion,
-		welcomeStream: welcomeStream,
+	// Maintainers can pause the cron jobs with `maintenance on`.
+	job := func(j JobFunc) http.HandlerFunc {
+		return cron(cronToken, unlessMaintenance(pl.inMaintenance, j))
 	}
 
-	http.HandleFunc("/", http.NotFound)                 // will this handle anything that's not defined?
-	http.HandleFunc("/webhooks", pl.handle)             // from zulip
-	http.HandleFunc("/match", cron(pl.Match))           // from GCP- daily
-	http.HandleFunc("/endofbatch", cron(pl.EndOfBatch)) // from GCP- weekly
-	http.HandleFunc("/welcome", cron(pl.Welcome))       // from GCP- weekly
-	http.HandleFunc("/checkin", cron(pl.Checkin))       // from GCP- weekly
+	// Admins can debug matching with /match?dryrun=true to see who *would* be
+	// matched without sending any messages.
+	matchHandler := withDryRun(
+		job(pl.Match),
+		requireToken(adminToken, serveJSON(pl.DryRunMatch)),
+	)
+
+	// Everything a handler logs is tagged with its path and a request ID.
+	route := func(pattern string, handler http.HandlerFunc) {
+		http.HandleFunc(pattern, withRequestLogger(pattern, handler))
+	}
+
+	http.HandleFunc("/", http.NotFound)                                // will this handle anything that's not defined?
+	route("/webhooks", pl.handle)                                      // from zulip
+	route("/webhooks/{realm}", pl.handle)                              // from other zulip realms
+	route("/match", matchHandler)                                      // from GCP- daily
+	route("/endofbatch", job(pl.EndOfBatch))                           // from GCP- weekly
+	route("/welcome", job(pl.Welcome))                                 // from GCP- weekly
+	route("/checkin", job(pl.Checkin))                                 // from GCP- weekly
+	route("/weeklysummary", job(pl.WeeklySummary))                     // from GCP- weekly
+	route("/syncrc", job(pl.SyncRC))                                   // from GCP- daily
+	route("/sendscheduled", job(pl.SendScheduled))                     // from GCP- every 15 minutes
+	route("/expirematches", job(pl.ExpirePendingMatches))              // from GCP- every 15 minutes
+	route("/revealintros", job(pl.RevealBlindIntros))                  // from GCP- every 15 minutes
+	route("/mentormatch", job(pl.MentorMatch))                         // from GCP- weekly
+	route("/backup", job(pl.Backup))                                   // from GCP- daily
+	route("/nudge", job(pl.Nudge))                                     // from GCP- daily
+	route("/usage", job(pl.UsageRollup))                               // from GCP- daily
+	route("/stale", job(pl.UnsubscribeStale))                          // from GCP- daily
+	route("/metrics", requireToken(adminToken, serveJSON(pl.Metrics))) // for dashboards
+	route("/noshows", requireToken(adminToken, serveJSON(pl.NoShows))) // for admins
+	route("/ratings", requireToken(adminToken, serveJSON(pl.Ratings))) // for admins
+	route("POST /import", requireToken(adminToken, pl.Import))         // for admins
+	route("/healthz", pl.healthz)                                      // for uptime monitoring
+	route("/version", serveJSON(pl.VersionInfo))                       // for checking deploys
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
-		log.Printf("Defaulting to port %s", port)
+		slog.Info("Defaulting to port", slog.String("port", port))
 	}
 
 	if m, ok := os.LookupEnv("PB_MAINT"); ok {
@@ -127,6 +238,136 @@ func main() {
 		}
 	}
 
-	log.Printf("Listening on port %s", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", port), nil))
+	if d, ok := os.LookupEnv("PB_REPEAT_WINDOW_DAYS"); ok {
+		days, err := strconv.Atoi(d)
+		if err != nil || days < 0 {
+			log.Panicf("PB_REPEAT_WINDOW_DAYS must be a non-negative integer, got %q", d)
+		}
+		pl.repeatWindowDays = days
+	}
+
+	if bucket, ok := os.LookupEnv("PB_BACKUP_BUCKET"); ok {
+		pl.backups, err = newGCSBackupWriter(ctx, bucket)
+		if err != nil {
+			log.Panicf("Could not set up backups to %q: %v", bucket, err)
+		}
+	}
+
+	if addr, ok := os.LookupEnv("PB_SMTP_ADDR"); ok {
+		pl.email = &smtpEmailer{
+			addr: addr,
+			from: os.Getenv("PB_EMAIL_FROM"),
+			password: func(ctx context.Context) (string, error) {
+				return store.Secrets(db).Get(ctx, "smtp_password")
+			},
+		}
+	}
+
+	if repo, ok := os.LookupEnv("PB_GITHUB_REPO"); ok {
+		pl.issues = &githubIssueFiler{
+			http:    http.DefaultClient,
+			baseURL: "https://api.github.com",
+			repo:    repo,
+			token: func(ctx context.Context) (string, error) {
+				return store.Secrets(db).Get(ctx, "github_token")
+			},
+		}
+	}
+
+	if dir, ok := os.LookupEnv("PB_TEMPLATES_DIR"); ok {
+		if err := overrideTemplates(dir); err != nil {
+			log.Panicf("Could not load the templates in PB_TEMPLATES_DIR: %v", err)
+		}
+	}
+
+	// App Engine sends SIGTERM before stopping an instance (like during a
+	// deploy). Finish what we're doing first so a match run doesn't stop
+	// halfway through sending messages.
+	stop, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	slog.Info("Listening", slog.String("port", port))
+	if err := serve(stop, fmt.Sprintf(":%s", port), http.DefaultServeMux); err != nil {
+		slog.Error("Server stopped", slog.Any("error", err))
+	}
+
+	// Returning runs the deferred db.Close(), now that no handlers are using it.
+}
+
+// parseRealms parses PB_ZULIP_REALMS, a comma-separated list of realm=bot-email
+// entries like "sister=pairing-bot@sister.zulipchat.com". It returns each
+// realm's bot email, keyed by the realm's name.
+func parseRealms(s string) (map[string]string, error) {
+	realms := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, botEmail, ok := strings.Cut(entry, "=")
+		if !ok || !validRealmName(name) || !strings.Contains(botEmail, "@") {
+			return nil, fmt.Errorf("%q isn't a realm=bot-email entry", entry)
+		}
+		if _, ok := realms[name]; ok {
+			return nil, fmt.Errorf("realm %q is listed twice", name)
+		}
+		realms[name] = botEmail
+	}
+	return realms, nil
+}
+
+// validRealmName returns whether name can be used for a realm. Realm names end
+// up in document IDs, secret names, and URLs, so they're limited to lowercase
+// letters, digits, and underscores.
+func validRealmName(name string) bool {
+	if name == store.DefaultRealm {
+		return false
+	}
+	for _, c := range name {
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// shutdownTimeout is how long to wait for in-flight requests when shutting
+// down. App Engine stops the instance for good about 30 seconds after asking
+// it to shut down.
+const shutdownTimeout = 25 * time.Second
+
+// serve runs an HTTP server on addr until ctx is done, and then shuts down
+// gracefully: it stops accepting new requests and waits up to shutdownTimeout
+// for in-flight requests to finish.
+func serve(ctx context.Context, addr string, handler http.Handler) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return serveListener(ctx, ln, handler)
+}
+
+// serveListener is serve for an existing listener.
+func serveListener(ctx context.Context, ln net.Listener, handler http.Handler) error {
+	srv := &http.Server{Handler: handler}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, waiting for in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shut down: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+)
+
+func Test_serveListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serveListener(ctx, ln, handler)
+	}()
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{string(body), err}
+	}()
+
+	// Shut down while the request is still being handled.
+	<-started
+	cancel()
+
+	// The in-flight request still finishes...
+	got := <-responses
+	assert.NoError(t, got.err)
+	assert.Equal(t, got.body, "done")
+
+	// ...and then the server stops cleanly.
+	assert.NoError(t, <-served)
+
+	// New requests are turned away.
+	if _, err := http.Get("http://" + ln.Addr().String()); err == nil {
+		t.Error("expected the server to refuse new connections")
+	}
+}
+
+func Test_parseRealms(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		realms, err := parseRealms("sister=pairing-bot@sister.zulipchat.com, other_2=bot@zulip.example.net")
+		assert.NoError(t, err)
+		assert.Equal(t, realms, map[string]string{
+			"sister":  "pairing-bot@sister.zulipchat.com",
+			"other_2": "bot@zulip.example.net",
+		})
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		realms, err := parseRealms("")
+		assert.NoError(t, err)
+		assert.Equal(t, len(realms), 0)
+	})
+
+	for name, s := range map[string]string{
+		"no email":     "sister",
+		"no name":      "=bot@sister.zulipchat.com",
+		"not an email": "sister=sister.zulipchat.com",
+		"bad name":     "Sister-Realm=bot@sister.zulipchat.com",
+		"listed twice": "sister=a@sister.zulipchat.com,sister=b@sister.zulipchat.com",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseRealms(s); err == nil {
+				t.Errorf("expected an error for %q", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// inMaintenance returns whether a maintainer has turned on maintenance mode,
+// which pauses the cron jobs (see unlessMaintenance).
+//
+// This is separate from pl.maintenanceMode, which comes from the environment
+// and turns away messages from everyone but the maintainers.
+func (pl *PairingLogic) inMaintenance(ctx context.Context) (bool, error) {
+	maintenance, err := store.MaintenanceMode(pl.db).Get(ctx)
+	if err != nil {
+		return false, err
+	}
+	return maintenance.On, nil
+}
+
+// MaintenanceStatus reports whether maintenance mode is on, and who changed it
+// last.
+func (pl *PairingLogic) MaintenanceStatus(ctx context.Context, rec *store.Recurser) (string, error) {
+	maintenance, err := store.MaintenanceMode(pl.db).Get(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+
+	var changed string
+	if maintenance.UpdatedBy != 0 {
+		changed = fmt.Sprintf(" (since @_**|%d** changed it at %s)", maintenance.UpdatedBy, time.Unix(maintenance.Timestamp, 0).In(rec.Location()).Format("2006-01-02 15:04"))
+	}
+
+	if maintenance.On {
+		return fmt.Sprintf("Maintenance mode is **on**%s, so the cron jobs (like matching) are paused. Send `maintenance off` to start them again.", changed), nil
+	}
+	return fmt.Sprintf("Maintenance mode is **off**%s, so the cron jobs are running as usual. Send `maintenance on` to pause them.", changed), nil
+}
+
+// SetMaintenance turns maintenance mode on or off.
+func (pl *PairingLogic) SetMaintenance(ctx context.Context, rec *store.Recurser, on bool) (string, error) {
+	err := store.MaintenanceMode(pl.db).Set(ctx, store.Maintenance{
+		On:        on,
+		UpdatedBy: rec.ID,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		return "", writeError(err)
+	}
+
+	if on {
+		return "Maintenance mode is on. I'll skip the cron jobs (like matching) until someone sends `maintenance off`.", nil
+	}
+	return "Maintenance mode is off. The cron jobs will run as usual again.", nil
+}
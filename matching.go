@@ -0,0 +1,394 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// A matchPlan is the result of running the matching algorithm, before any
+// notifications are sent or records are written.
+type matchPlan struct {
+	// Groups are the pairs (and possibly one group of three) to match.
+	Groups [][]store.Recurser
+
+	// OddOneOut is set if there was only one Recurser to match today.
+	OddOneOut *store.Recurser
+
+	// Unmatched are the Recursers who couldn't join any group, because of
+	// blocks (see store.Recurser.CanPairWith) or, when matching with mentors,
+	// because there weren't enough mentors.
+	Unmatched []store.Recurser
+}
+
+// matchRecursers is the whole matching algorithm: it shuffles the Recursers
+// using rng, favoring the ones with more weight (see favorIdle), then groups
+// them with pairUp. It doesn't do any I/O, so the same inputs and seed always
+// give the same plan. It returns an error instead of a plan that would match
+// someone with themselves (see matchPlan.check).
+func matchRecursers(recursers []store.Recurser, recent pairSet, weights map[recurserKey]float64, rng *rand.Rand) (matchPlan, error) {
+	shuffled := uniqueRecursers(recursers)
+	rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	if len(weights) > 0 {
+		favorIdle(shuffled, weights, rng)
+	}
+
+	// With only one person, there's nobody to group them with.
+	if len(shuffled) == 1 {
+		return matchPlan{OddOneOut: &shuffled[0]}, nil
+	}
+
+	groups, unmatched := pairUp(shuffled, recent)
+	plan := matchPlan{Groups: groups, Unmatched: unmatched}
+	if err := plan.check(); err != nil {
+		return matchPlan{}, err
+	}
+	return plan, nil
+}
+
+// Recursers who haven't been matched in a while get a head start in the
+// shuffle. Each idleWeightDays since their last match adds one to their
+// weight (which starts at 1), up to maxIdleWeight. People who have never
+// been matched get the most.
+const (
+	idleWeightDays = 7
+	maxIdleWeight  = 4
+)
+
+// idleWeights returns how much favorIdle should favor each Recurser, given
+// when they were last matched (see store.PairingsClient.LastMatchTimes).
+func idleWeights(recursers []store.Recurser, lastMatched map[recurserKey]int64, now time.Time) map[recurserKey]float64 {
+	weights := make(map[recurserKey]float64)
+	for _, rec := range recursers {
+		key := recurserKey{rec.Realm, rec.ID}
+		last, ok := lastMatched[key]
+		if !ok {
+			weights[key] = maxIdleWeight
+			continue
+		}
+
+		idleDays := now.Sub(time.Unix(last, 0)).Hours() / 24
+		weights[key] = min(1+max(idleDays, 0)/idleWeightDays, maxIdleWeight)
+	}
+	return weights
+}
+
+// favorIdle reorders the shuffled Recursers so that the ones with more weight
+// tend to come first. Anyone missing from weights has a weight of 1.
+//
+// It's a weighted shuffle, not a sort: everyone can still end up anywhere, and
+// people with the same weight stay in random order. Coming first matters
+// because pairUp gives earlier Recursers the first pick of partners, and the
+// last one of an odd number joins a pair as a third.
+func favorIdle(shuffled []store.Recurser, weights map[recurserKey]float64, rng *rand.Rand) {
+	// Sorting by u^(1/weight), for a uniform random u, puts each Recurser
+	// first with a probability proportional to their weight.
+	keys := make(map[recurserKey]float64, len(shuffled))
+	for _, rec := range shuffled {
+		key := recurserKey{rec.Realm, rec.ID}
+		weight := weights[key]
+		if weight <= 0 {
+			weight = 1
+		}
+		keys[key] = math.Pow(rng.Float64(), 1/weight)
+	}
+	slices.SortStableFunc(shuffled, func(a, b store.Recurser) int {
+		return cmp.Compare(keys[recurserKey{b.Realm, b.ID}], keys[recurserKey{a.Realm, a.ID}])
+	})
+}
+
+// matchMentors pairs current Recursers with mentors, for the mentor matching
+// job. Like matchRecursers, it shuffles both lists using rng first. Each mentor
+// is matched with at most one Recurser, and mentors are never matched with
+// each other, so the Recursers after the mentors run out are unmatched.
+//
+// Partners are chosen with choosePartner, so blocks, half days, and recent
+// pairs are handled the same way as in the daily matches.
+func matchMentors(recursers, mentors []store.Recurser, recent pairSet, rng *rand.Rand) (matchPlan, error) {
+	shuffled := uniqueRecursers(recursers)
+	rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	available := uniqueRecursers(mentors)
+	rng.Shuffle(len(available), func(i, j int) { available[i], available[j] = available[j], available[i] })
+
+	var plan matchPlan
+	for _, rec := range shuffled {
+		mentor := choosePartner(rec, available, recent)
+		if mentor < 0 {
+			plan.Unmatched = append(plan.Unmatched, rec)
+			continue
+		}
+
+		plan.Groups = append(plan.Groups, []store.Recurser{rec, available[mentor]})
+		available = slices.Delete(available, mentor, mentor+1)
+	}
+	if err := plan.check(); err != nil {
+		return matchPlan{}, err
+	}
+	return plan, nil
+}
+
+// recurserKey identifies a Recurser, since IDs are only unique within a realm.
+type recurserKey struct {
+	realm string
+	id    int64
+}
+
+// uniqueRecursers returns a copy of the Recursers with only the first of any
+// that are listed more than once, so nobody can be matched with themselves.
+func uniqueRecursers(recursers []store.Recurser) []store.Recurser {
+	seen := make(map[recurserKey]bool)
+	var unique []store.Recurser
+	for _, rec := range recursers {
+		key := recurserKey{rec.Realm, rec.ID}
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, rec)
+		}
+	}
+	return unique
+}
+
+// check makes sure every group in the plan has at least two different people,
+// and that nobody is in more than one group. The matching algorithm never
+// does otherwise, but if it ever did, it's better to fail the run than to send
+// someone a match with themselves or with nobody.
+func (p matchPlan) check() error {
+	seen := make(map[recurserKey]bool)
+	for i, group := range p.Groups {
+		if len(group) < 2 {
+			return fmt.Errorf("group %d has %d Recurser(s), so someone would be matched with nobody", i, len(group))
+		}
+		for _, rec := range group {
+			key := recurserKey{rec.Realm, rec.ID}
+			if seen[key] {
+				return fmt.Errorf("Recurser %d is in group %d more than once, or in more than one group", rec.ID, i)
+			}
+			seen[key] = true
+		}
+	}
+	return nil
+}
+
+// pairKey identifies an unordered pair of Recursers by their realm and IDs.
+type pairKey struct {
+	realm string
+	a, b  int64
+}
+
+func newPairKey(realm string, id1, id2 int64) pairKey {
+	if id1 > id2 {
+		id1, id2 = id2, id1
+	}
+	return pairKey{realm, id1, id2}
+}
+
+// pairSet is a set of Recurser pairs, used to remember who was recently
+// matched with whom.
+type pairSet map[pairKey]struct{}
+
+// recentPairs collects every pair of Recursers that shared a match.
+func recentPairs(matches []store.Match) pairSet {
+	pairs := make(pairSet)
+	for _, match := range matches {
+		for i, id1 := range match.Recursers {
+			for _, id2 := range match.Recursers[i+1:] {
+				pairs[newPairKey(match.Realm, id1, id2)] = struct{}{}
+			}
+		}
+	}
+	return pairs
+}
+
+func (s pairSet) contains(realm string, id1, id2 int64) bool {
+	_, ok := s[newPairKey(realm, id1, id2)]
+	return ok
+}
+
+// withinWeeklyCap filters out Recursers who have already reached their
+// MaxWeekly number of matches. The matches should cover the last week.
+func withinWeeklyCap(recursers []store.Recurser, lastWeek []store.Match) []store.Recurser {
+	counts := make(map[recurserKey]int)
+	for _, match := range lastWeek {
+		for _, id := range match.Recursers {
+			counts[recurserKey{match.Realm, id}]++
+		}
+	}
+
+	var allowed []store.Recurser
+	for _, rec := range recursers {
+		if rec.MaxWeekly > 0 && counts[recurserKey{rec.Realm, rec.ID}] >= rec.MaxWeekly {
+			continue
+		}
+		allowed = append(allowed, rec)
+	}
+	return allowed
+}
+
+// topicLookahead is how many non-repeat candidates to consider when looking
+// for a partner with shared topics.
+const topicLookahead = 3
+
+// choosePartner returns the index of the best partner for rec among the
+// candidates, or -1 if blocks rule out all of them. See pairUp for how "best"
+// is decided.
+func choosePartner(rec store.Recurser, candidates []store.Recurser, recent pairSet) int {
+	var allowed, available, fresh, compatible []int
+	for i, candidate := range candidates {
+		if !rec.CanPairWith(&candidates[i]) {
+			continue
+		}
+
+		allowed = append(allowed, i)
+		if !rec.SegmentOverlaps(&candidates[i]) {
+			continue
+		}
+
+		available = append(available, i)
+		if recent.contains(rec.Realm, rec.ID, candidate.ID) {
+			continue
+		}
+
+		fresh = append(fresh, i)
+		if rec.BatchCompatible(&candidates[i]) && rec.ModeCompatible(&candidates[i]) {
+			compatible = append(compatible, i)
+			if len(compatible) == topicLookahead {
+				break
+			}
+		}
+	}
+
+	best := compatible
+	if len(best) == 0 {
+		best = fresh
+	}
+	if len(best) == 0 {
+		best = available
+	}
+	if len(best) == 0 {
+		best = allowed
+	}
+	if len(best) == 0 {
+		return -1
+	}
+
+	for _, i := range best[:min(len(best), topicLookahead)] {
+		if rec.SharesTopicWith(&candidates[i]) {
+			return i
+		}
+	}
+	return best[0]
+}
+
+// pairUp splits an (already shuffled) list of Recursers into pairs. If there's
+// an odd number of Recursers, the last one joins one of the pairs to make a
+// group of three. So nobody gets left out, as long as there are at least two
+// Recursers.
+//
+// Blocks (see store.Recurser.CanPairWith) are the exception: they're a hard
+// constraint. Someone who can't pair with anyone left gets another chance
+// with the others who were left over, then tries joining a pair as a third
+// person, and if that's not possible either, they're returned as unmatched.
+//
+// Half-day schedules (see store.Recurser.SegmentOverlaps) come first: each
+// Recurser is only paired with someone available at the same time of day,
+// unless there's nobody like that left.
+//
+// Avoiding recent pairs is a soft constraint: each Recurser is paired with the
+// first remaining Recurser they haven't recently been matched with. If
+// everyone left is a repeat, they get the first one anyway.
+//
+// Batch preferences (see store.Recurser.BatchCompatible) and how people like
+// to pair (see store.Recurser.ModeCompatible) are also soft: among the
+// non-repeats, people whose preferences are respected come first. Pairing in
+// person is the exception, since it only works at RC (see CanPairWith).
+//
+// Shared topics are a tiebreaker on top of that: among the next few
+// non-repeat candidates, someone with a topic in common wins. Looking only a
+// few candidates ahead keeps the shuffle in charge, so people with niche
+// interests don't end up matched with the same few people all the time.
+func pairUp(recursers []store.Recurser, recent pairSet) ([][]store.Recurser, []store.Recurser) {
+	remaining := append([]store.Recurser(nil), recursers...)
+
+	var extra *store.Recurser
+	if len(remaining)%2 != 0 {
+		last := remaining[len(remaining)-1]
+		extra = &last
+		remaining = remaining[:len(remaining)-1]
+	}
+
+	var pairs [][]store.Recurser
+	var leftover []store.Recurser
+	if extra != nil {
+		leftover = append(leftover, *extra)
+	}
+	for len(remaining) > 0 {
+		first := remaining[0]
+		remaining = remaining[1:]
+
+		partner := choosePartner(first, remaining, recent)
+		if partner < 0 {
+			leftover = append(leftover, first)
+			continue
+		}
+
+		pairs = append(pairs, []store.Recurser{first, remaining[partner]})
+		remaining = append(remaining[:partner], remaining[partner+1:]...)
+	}
+
+	// Without blocks, this is just the extra person. With them, the people
+	// left over might still be able to pair with each other.
+	var alone []store.Recurser
+	for len(leftover) > 0 {
+		first := leftover[0]
+		leftover = leftover[1:]
+
+		partner := choosePartner(first, leftover, recent)
+		if partner < 0 {
+			alone = append(alone, first)
+			continue
+		}
+
+		pairs = append(pairs, []store.Recurser{first, leftover[partner]})
+		leftover = append(leftover[:partner], leftover[partner+1:]...)
+	}
+
+	var unmatched []store.Recurser
+	for _, rec := range alone {
+		group := chooseGroup(rec, pairs, recent)
+		if group < 0 {
+			unmatched = append(unmatched, rec)
+			continue
+		}
+		pairs[group] = append(pairs[group], rec)
+	}
+
+	return pairs, unmatched
+}
+
+// chooseGroup returns the index of the best pair for a leftover Recurser to
+// join, or -1 if blocks rule out all of them. Groups that already have three
+// people are never chosen.
+//
+// Same idea as choosePartner: prefer a pair that's available at the same time
+// and has no recent history with the Recurser, but fall back to the last pair
+// that blocks allow.
+func chooseGroup(rec store.Recurser, groups [][]store.Recurser, recent pairSet) int {
+	fallback := -1
+	for i, group := range groups {
+		if len(group) != 2 || !rec.CanPairWith(&group[0]) || !rec.CanPairWith(&group[1]) {
+			continue
+		}
+
+		fallback = i
+		overlaps := rec.SegmentOverlaps(&group[0]) && rec.SegmentOverlaps(&group[1])
+		if overlaps && !recent.contains(rec.Realm, rec.ID, group[0].ID) && !recent.contains(rec.Realm, rec.ID, group[1].ID) {
+			return i
+		}
+	}
+	return fallback
+}
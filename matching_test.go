@@ -0,0 +1,697 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// fakeRecursers makes Recursers with IDs 0 through n-1.
+func fakeRecursers(n int) []store.Recurser {
+	var all []store.Recurser
+	for i := range n {
+		all = append(all, store.Recurser{ID: int64(i)})
+	}
+	return all
+}
+
+// pairIDs converts pairs of Recursers into pairs of their IDs.
+func pairIDs(pairs [][]store.Recurser) [][]int64 {
+	var ids [][]int64
+	for _, pair := range pairs {
+		var group []int64
+		for _, rec := range pair {
+			group = append(group, rec.ID)
+		}
+		ids = append(ids, group)
+	}
+	return ids
+}
+
+func Test_matchRecursers(t *testing.T) {
+	for name, tc := range map[string]struct {
+		Recursers int
+		Recent    []store.Match
+
+		// Only one of these is checked, depending on the number of Recursers.
+		Groups    [][]int64
+		OddOneOut int64
+	}{
+		"nobody":           {Recursers: 0, Groups: nil},
+		"one person":       {Recursers: 1, OddOneOut: 0},
+		"two people":       {Recursers: 2, Groups: [][]int64{{0, 1}}},
+		"four people":      {Recursers: 4, Groups: [][]int64{{0, 1}, {3, 2}}},
+		"odd number":       {Recursers: 5, Groups: [][]int64{{2, 0, 3}, {1, 4}}},
+		"avoids repeats":   {Recursers: 4, Recent: []store.Match{{Recursers: []int64{0, 1}}}, Groups: [][]int64{{0, 3}, {1, 2}}},
+		"repeats if stuck": {Recursers: 2, Recent: []store.Match{{Recursers: []int64{0, 1}}}, Groups: [][]int64{{0, 1}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			recursers := fakeRecursers(tc.Recursers)
+			plan, err := matchRecursers(recursers, recentPairs(tc.Recent), nil, rand.New(rand.NewSource(1)))
+			assert.NoError(t, err)
+
+			if tc.Recursers == 1 {
+				assert.Equal(t, len(plan.Groups), 0)
+				assert.Equal(t, plan.OddOneOut.ID, tc.OddOneOut)
+				return
+			}
+			if plan.OddOneOut != nil {
+				t.Errorf("expected no odd one out, got %d", plan.OddOneOut.ID)
+			}
+			assert.Equal(t, pairIDs(plan.Groups), tc.Groups)
+
+			// The input isn't changed.
+			assert.Equal(t, recursers, fakeRecursers(tc.Recursers))
+		})
+	}
+
+	t.Run("same seed, same matches", func(t *testing.T) {
+		recursers := fakeRecursers(20)
+		first, err := matchRecursers(recursers, nil, nil, rand.New(rand.NewSource(42)))
+		assert.NoError(t, err)
+		second, err := matchRecursers(recursers, nil, nil, rand.New(rand.NewSource(42)))
+		assert.NoError(t, err)
+		assert.Equal(t, pairIDs(first.Groups), pairIDs(second.Groups))
+	})
+}
+
+func Test_matchRecursers_neverAlone(t *testing.T) {
+	// A single person, even if they're listed more than once (like from
+	// duplicate records), is the odd one out rather than their own partner.
+	for _, recursers := range [][]store.Recurser{
+		{{ID: 7}},
+		{{ID: 7}, {ID: 7}},
+		{{ID: 7}, {ID: 7}, {ID: 7}},
+	} {
+		plan, err := matchRecursers(recursers, nil, nil, rand.New(rand.NewSource(1)))
+		assert.NoError(t, err)
+		assert.Equal(t, len(plan.Groups), 0)
+		assert.Equal(t, len(plan.Unmatched), 0)
+		assert.Equal(t, plan.OddOneOut.ID, int64(7))
+	}
+
+	t.Run("mentors", func(t *testing.T) {
+		plan, err := matchMentors([]store.Recurser{{ID: 7}}, []store.Recurser{{ID: 7, IsMentor: true}}, nil, rand.New(rand.NewSource(1)))
+		assert.NoError(t, err)
+		assert.Equal(t, len(plan.Groups), 0)
+		assert.Equal(t, pairIDs([][]store.Recurser{plan.Unmatched}), [][]int64{{7}})
+	})
+}
+
+func Test_idleWeights(t *testing.T) {
+	now := time.Date(2024, time.March, 14, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(n int) int64 { return now.AddDate(0, 0, -n).Unix() }
+
+	recursers := fakeRecursers(5)
+	lastMatched := map[recurserKey]int64{
+		{id: 0}: daysAgo(0),
+		{id: 1}: daysAgo(7),
+		{id: 2}: daysAgo(14),
+		{id: 3}: daysAgo(60),
+		// 4 has never been matched.
+
+		// Someone else with the same ID in another realm.
+		{"sister", 4}: daysAgo(0),
+	}
+
+	assert.Equal(t, idleWeights(recursers, lastMatched, now), map[recurserKey]float64{
+		{id: 0}: 1,
+		{id: 1}: 2,
+		{id: 2}: 3,
+		{id: 3}: maxIdleWeight,
+		{id: 4}: maxIdleWeight,
+	})
+}
+
+func Test_favorIdle(t *testing.T) {
+	now := time.Date(2024, time.March, 14, 12, 0, 0, 0, time.UTC)
+
+	// Everyone was matched yesterday, except for 0, who hasn't been matched
+	// in a month.
+	recursers := fakeRecursers(10)
+	lastMatched := make(map[recurserKey]int64)
+	for _, rec := range recursers {
+		lastMatched[recurserKey{rec.Realm, rec.ID}] = now.AddDate(0, 0, -1).Unix()
+	}
+	lastMatched[recurserKey{id: 0}] = now.AddDate(0, 0, -30).Unix()
+	weights := idleWeights(recursers, lastMatched, now)
+
+	const runs = 1000
+	var idleFirst, idleExtra, busyFirst int
+	for seed := range int64(runs) {
+		rng := rand.New(rand.NewSource(seed))
+		shuffled := slices.Clone(recursers)
+		rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		favorIdle(shuffled, weights, rng)
+
+		switch shuffled[0].ID {
+		case 0:
+			idleFirst++
+		case 1:
+			busyFirst++
+		}
+
+		// With an odd number, the last one joins a pair as a third.
+		plan, err := matchRecursers(recursers[:9], nil, weights, rand.New(rand.NewSource(seed)))
+		assert.NoError(t, err)
+		for _, group := range plan.Groups {
+			if len(group) == 3 && group[2].ID == 0 {
+				idleExtra++
+			}
+		}
+	}
+
+	// With 9 busy people and 1 idle one with 4x the weight, the idle one
+	// comes first 4/13 of the time, and each of the busy ones 1/13.
+	if idleFirst < runs/5 || idleFirst > runs/2 {
+		t.Errorf("the idle Recurser came first in %d of %d runs, wanted about %d", idleFirst, runs, runs*4/13)
+	}
+	if busyFirst > runs/8 {
+		t.Errorf("a busy Recurser came first in %d of %d runs, wanted about %d", busyFirst, runs, runs/13)
+	}
+
+	// It's a bias, not a rule: the idle one can still be the third, just less
+	// often than the 1 in 9 they would be without it.
+	if idleExtra == 0 || idleExtra >= runs/9 {
+		t.Errorf("the idle Recurser was the third in a group %d of %d times, wanted between 0 and %d", idleExtra, runs, runs/9)
+	}
+}
+
+func Test_matchPlan_check(t *testing.T) {
+	ada, grace, alan := store.Recurser{ID: 1}, store.Recurser{ID: 2}, store.Recurser{ID: 3}
+
+	assert.NoError(t, matchPlan{}.check())
+	assert.NoError(t, matchPlan{Groups: [][]store.Recurser{{ada, grace, alan}}}.check())
+	assert.NoError(t, matchPlan{Groups: [][]store.Recurser{{ada, {ID: 1, Realm: "sister"}}}}.check())
+
+	for name, plan := range map[string]matchPlan{
+		"empty group":    {Groups: [][]store.Recurser{{ada, grace}, {}}},
+		"nobody else":    {Groups: [][]store.Recurser{{ada}}},
+		"themselves":     {Groups: [][]store.Recurser{{ada, ada}}},
+		"two groups":     {Groups: [][]store.Recurser{{ada, grace}, {alan, ada}}},
+		"group of three": {Groups: [][]store.Recurser{{ada, grace, grace}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := plan.check(); err == nil {
+				t.Errorf("expected an error for %v", pairIDs(plan.Groups))
+			}
+		})
+	}
+}
+
+func Test_pairUp(t *testing.T) {
+	t.Run("no history", func(t *testing.T) {
+		pairs, _ := pairUp(fakeRecursers(4), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 1}, {2, 3}})
+	})
+
+	t.Run("prefers fresh partners", func(t *testing.T) {
+		recent := recentPairs([]store.Match{
+			{Recursers: []int64{0, 1}},
+			{Recursers: []int64{2, 3}},
+		})
+
+		pairs, _ := pairUp(fakeRecursers(4), recent)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 2}, {1, 3}})
+	})
+
+	t.Run("history order doesn't matter", func(t *testing.T) {
+		recent := recentPairs([]store.Match{
+			{Recursers: []int64{1, 0}},
+		})
+
+		pairs, _ := pairUp(fakeRecursers(4), recent)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 2}, {1, 3}})
+	})
+
+	t.Run("allows repeats as a last resort", func(t *testing.T) {
+		recent := recentPairs([]store.Match{
+			{Recursers: []int64{0, 1}},
+		})
+
+		pairs, _ := pairUp(fakeRecursers(2), recent)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 1}})
+	})
+
+	t.Run("nobody is left out", func(t *testing.T) {
+		// Everyone has recently paired with everyone else.
+		recent := recentPairs([]store.Match{
+			{Recursers: []int64{0, 1, 2, 3, 4, 5}},
+		})
+
+		pairs, _ := pairUp(fakeRecursers(6), recent)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 1}, {2, 3}, {4, 5}})
+	})
+}
+
+func Test_pairUp_topics(t *testing.T) {
+	withTopics := func(n int, topics map[int64][]string) []store.Recurser {
+		recursers := fakeRecursers(n)
+		for i := range recursers {
+			recursers[i].Topics = topics[recursers[i].ID]
+		}
+		return recursers
+	}
+
+	t.Run("prefers a shared topic", func(t *testing.T) {
+		recursers := withTopics(4, map[int64][]string{
+			0: {"go", "rust"},
+			2: {"rust"},
+		})
+
+		pairs, _ := pairUp(recursers, nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 2}, {1, 3}})
+	})
+
+	t.Run("only looks a few candidates ahead", func(t *testing.T) {
+		recursers := withTopics(6, map[int64][]string{
+			0: {"go"},
+			5: {"go"},
+		})
+
+		pairs, _ := pairUp(recursers, nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 1}, {2, 3}, {4, 5}})
+	})
+
+	t.Run("recent pairs still matter more", func(t *testing.T) {
+		recursers := withTopics(4, map[int64][]string{
+			0: {"go"},
+			1: {"go"},
+		})
+		recent := recentPairs([]store.Match{
+			{Recursers: []int64{0, 1}},
+		})
+
+		pairs, _ := pairUp(recursers, recent)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 2}, {1, 3}})
+	})
+}
+
+func Test_pairUp_segments(t *testing.T) {
+	withSegments := func(segments ...string) []store.Recurser {
+		recursers := fakeRecursers(len(segments))
+		for i := range recursers {
+			recursers[i].Segment = segments[i]
+		}
+		return recursers
+	}
+
+	t.Run("pairs overlapping halves", func(t *testing.T) {
+		pairs, _ := pairUp(withSegments(store.SegmentAM, store.SegmentPM, store.SegmentAM, store.SegmentPM), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 2}, {1, 3}})
+	})
+
+	t.Run("all day overlaps with either half", func(t *testing.T) {
+		pairs, _ := pairUp(withSegments(store.SegmentPM, "", store.SegmentAM, store.SegmentAM), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 1}, {2, 3}})
+	})
+
+	t.Run("matters more than recent pairs", func(t *testing.T) {
+		recent := recentPairs([]store.Match{
+			{Recursers: []int64{0, 2}},
+		})
+
+		pairs, _ := pairUp(withSegments(store.SegmentAM, store.SegmentPM, store.SegmentAM, store.SegmentPM), recent)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 2}, {1, 3}})
+	})
+
+	t.Run("nobody is left out", func(t *testing.T) {
+		pairs, _ := pairUp(withSegments(store.SegmentAM, store.SegmentPM), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 1}})
+	})
+
+	t.Run("extra person joins an overlapping pair", func(t *testing.T) {
+		pairs, _ := pairUp(withSegments(store.SegmentAM, store.SegmentAM, store.SegmentPM, store.SegmentPM, store.SegmentPM), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 1}, {2, 3, 4}})
+	})
+}
+
+func Test_pairUp_blocks(t *testing.T) {
+	withBlocks := func(n int, blocks map[int64][]int64) []store.Recurser {
+		recursers := fakeRecursers(n)
+		for i := range recursers {
+			for _, id := range blocks[recursers[i].ID] {
+				recursers[i].Blocks = append(recursers[i].Blocks, store.Block{ID: id})
+			}
+		}
+		return recursers
+	}
+
+	t.Run("avoids blocked partners", func(t *testing.T) {
+		pairs, unmatched := pairUp(withBlocks(4, map[int64][]int64{0: {1}}), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 2}, {1, 3}})
+		assert.Equal(t, len(unmatched), 0)
+	})
+
+	t.Run("works both ways", func(t *testing.T) {
+		pairs, _ := pairUp(withBlocks(4, map[int64][]int64{1: {0}}), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 2}, {1, 3}})
+	})
+
+	t.Run("matters more than recent pairs", func(t *testing.T) {
+		recent := recentPairs([]store.Match{
+			{Recursers: []int64{0, 2}},
+			{Recursers: []int64{0, 3}},
+		})
+
+		pairs, _ := pairUp(withBlocks(4, map[int64][]int64{0: {1}}), recent)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 2}, {1, 3}})
+	})
+
+	t.Run("joins a pair if their partner is blocked", func(t *testing.T) {
+		// 2 has blocked 3, who would otherwise be their partner.
+		pairs, unmatched := pairUp(withBlocks(4, map[int64][]int64{2: {3}}), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 1, 2}})
+		assert.Equal(t, pairIDs([][]store.Recurser{unmatched}), [][]int64{{3}})
+	})
+
+	t.Run("left out if blocked by everyone", func(t *testing.T) {
+		pairs, unmatched := pairUp(withBlocks(3, map[int64][]int64{0: {1, 2}}), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{2, 1}})
+		assert.Equal(t, pairIDs([][]store.Recurser{unmatched}), [][]int64{{0}})
+	})
+
+	t.Run("blocked pairs are never produced", func(t *testing.T) {
+		for seed := range int64(100) {
+			rng := rand.New(rand.NewSource(seed))
+
+			// Everyone blocks a few random people.
+			n := 5 + rng.Intn(10)
+			blocks := make(map[int64][]int64)
+			for i := range n {
+				for range rng.Intn(3) {
+					blocks[int64(i)] = append(blocks[int64(i)], rng.Int63n(int64(n)))
+				}
+			}
+			recursers := withBlocks(n, blocks)
+
+			plan, err := matchRecursers(recursers, nil, nil, rng)
+			assert.NoError(t, err)
+
+			var seen []int64
+			for _, group := range plan.Groups {
+				for i := range group {
+					seen = append(seen, group[i].ID)
+					for j := range group[:i] {
+						if !group[i].CanPairWith(&group[j]) {
+							t.Fatalf("seed %d: %d and %d were matched despite a block", seed, group[i].ID, group[j].ID)
+						}
+					}
+				}
+			}
+			for _, rec := range plan.Unmatched {
+				seen = append(seen, rec.ID)
+			}
+
+			// Everyone is either matched or unmatched, exactly once.
+			slices.Sort(seen)
+			assert.Equal(t, seen, pairIDs([][]store.Recurser{recursers})[0])
+		}
+	})
+}
+
+func Test_pairUp_realms(t *testing.T) {
+	inRealms := func(realms ...string) []store.Recurser {
+		recursers := fakeRecursers(len(realms))
+		for i, realm := range realms {
+			recursers[i].Realm = realm
+		}
+		return recursers
+	}
+
+	t.Run("pairs within each realm", func(t *testing.T) {
+		pairs, unmatched := pairUp(inRealms("", "sister", "", "sister"), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 2}, {1, 3}})
+		assert.Equal(t, len(unmatched), 0)
+	})
+
+	t.Run("left out if alone in their realm", func(t *testing.T) {
+		pairs, unmatched := pairUp(inRealms("", "", "sister"), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 1}})
+		assert.Equal(t, pairIDs([][]store.Recurser{unmatched}), [][]int64{{2}})
+	})
+
+	t.Run("groups never mix realms", func(t *testing.T) {
+		realms := []string{"", "sister", "other"}
+		for seed := range int64(100) {
+			rng := rand.New(rand.NewSource(seed))
+
+			var names []string
+			for range 5 + rng.Intn(10) {
+				names = append(names, realms[rng.Intn(len(realms))])
+			}
+
+			plan, err := matchRecursers(inRealms(names...), nil, nil, rng)
+			assert.NoError(t, err)
+			for _, group := range plan.Groups {
+				for _, rec := range group[1:] {
+					if rec.Realm != group[0].Realm {
+						t.Fatalf("seed %d: %d (%q) and %d (%q) were matched across realms", seed, group[0].ID, group[0].Realm, rec.ID, rec.Realm)
+					}
+				}
+			}
+		}
+	})
+}
+
+func Test_matchMentors(t *testing.T) {
+	// Recursers are 0 through 9, and mentors start from 100.
+	mentorsFrom := func(n int) []store.Recurser {
+		var mentors []store.Recurser
+		for i := range n {
+			mentors = append(mentors, store.Recurser{ID: int64(100 + i), IsMentor: true})
+		}
+		return mentors
+	}
+	isMentor := func(rec store.Recurser) bool { return rec.ID >= 100 }
+
+	// check makes sure that every group is one Recurser and one mentor, and
+	// that nobody is left out or matched twice.
+	check := func(t *testing.T, plan matchPlan, recursers, mentors int) {
+		t.Helper()
+
+		seen := make(map[int64]bool)
+		for _, group := range plan.Groups {
+			if len(group) != 2 || isMentor(group[0]) || !isMentor(group[1]) {
+				t.Fatalf("wanted a Recurser and a mentor, got %v", pairIDs([][]store.Recurser{group}))
+			}
+			for _, rec := range group {
+				if seen[rec.ID] {
+					t.Fatalf("%d was matched twice", rec.ID)
+				}
+				seen[rec.ID] = true
+			}
+		}
+		for _, rec := range plan.Unmatched {
+			if isMentor(rec) {
+				t.Fatalf("mentor %d was returned as an unmatched Recurser", rec.ID)
+			}
+			seen[rec.ID] = true
+		}
+
+		assert.Equal(t, len(plan.Groups), min(recursers, mentors))
+		assert.Equal(t, len(plan.Unmatched), max(recursers-mentors, 0))
+	}
+
+	for name, tc := range map[string]struct {
+		Recursers, Mentors int
+	}{
+		"one each":         {1, 1},
+		"same number":      {4, 4},
+		"more mentors":     {2, 5},
+		"more recursers":   {6, 2},
+		"only mentors":     {0, 3},
+		"only recursers":   {3, 0},
+		"nobody available": {0, 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			plan, err := matchMentors(fakeRecursers(tc.Recursers), mentorsFrom(tc.Mentors), nil, rand.New(rand.NewSource(1)))
+			assert.NoError(t, err)
+			check(t, plan, tc.Recursers, tc.Mentors)
+		})
+	}
+
+	t.Run("mentors are never matched with each other", func(t *testing.T) {
+		for seed := range int64(20) {
+			plan, err := matchMentors(fakeRecursers(3), mentorsFrom(8), nil, rand.New(rand.NewSource(seed)))
+			assert.NoError(t, err)
+			check(t, plan, 3, 8)
+		}
+	})
+
+	t.Run("avoids recent mentors", func(t *testing.T) {
+		recent := recentPairs([]store.Match{{Recursers: []int64{0, 100}}})
+
+		for seed := range int64(20) {
+			plan, err := matchMentors(fakeRecursers(1), mentorsFrom(2), recent, rand.New(rand.NewSource(seed)))
+			assert.NoError(t, err)
+			assert.Equal(t, pairIDs(plan.Groups), [][]int64{{0, 101}})
+		}
+	})
+
+	t.Run("respects blocks", func(t *testing.T) {
+		recursers := fakeRecursers(2)
+		recursers[0].Blocks = []store.Block{{ID: 100}}
+
+		for seed := range int64(20) {
+			plan, err := matchMentors(recursers, mentorsFrom(1), nil, rand.New(rand.NewSource(seed)))
+			assert.NoError(t, err)
+			assert.Equal(t, pairIDs(plan.Groups), [][]int64{{1, 100}})
+			assert.Equal(t, pairIDs([][]store.Recurser{plan.Unmatched}), [][]int64{{0}})
+		}
+	})
+}
+
+func Test_withinWeeklyCap(t *testing.T) {
+	recursers := []store.Recurser{
+		{ID: 1, MaxWeekly: 3},                  // At the limit
+		{ID: 2, MaxWeekly: 3},                  // Under the limit
+		{ID: 3, MaxWeekly: 0},                  // No limit
+		{ID: 4, MaxWeekly: 1},                  // Not matched yet
+		{ID: 1, MaxWeekly: 1, Realm: "sister"}, // Someone else's matches
+	}
+
+	// A week's worth of matches.
+	lastWeek := []store.Match{
+		{Recursers: []int64{1, 2}},
+		{Recursers: []int64{1, 3}},
+		{Recursers: []int64{1, 3}},
+		{Recursers: []int64{2, 3}},
+		{Recursers: []int64{3, 5}},
+	}
+
+	allowed := withinWeeklyCap(recursers, lastWeek)
+
+	var ids []int64
+	for _, rec := range allowed {
+		ids = append(ids, rec.ID)
+	}
+	assert.Equal(t, ids, []int64{2, 3, 4, 1})
+}
+
+func Test_pairUp_oddNumbers(t *testing.T) {
+	for _, n := range []int{3, 5, 7} {
+		t.Run(fmt.Sprintf("%d recursers", n), func(t *testing.T) {
+			recursers := fakeRecursers(n)
+			groups, _ := pairUp(recursers, nil)
+
+			// Everyone gets matched exactly once.
+			var matched []int64
+			for _, group := range pairIDs(groups) {
+				matched = append(matched, group...)
+			}
+			slices.Sort(matched)
+
+			var everyone []int64
+			for _, rec := range recursers {
+				everyone = append(everyone, rec.ID)
+			}
+			assert.Equal(t, matched, everyone)
+
+			// There's exactly one group of three.
+			var sizes []int
+			for _, group := range groups {
+				sizes = append(sizes, len(group))
+			}
+			slices.Sort(sizes)
+
+			expected := make([]int, n/2)
+			for i := range expected {
+				expected[i] = 2
+			}
+			expected[len(expected)-1] = 3
+			assert.Equal(t, sizes, expected)
+		})
+	}
+
+	t.Run("extra person avoids recent partners", func(t *testing.T) {
+		recent := recentPairs([]store.Match{
+			{Recursers: []int64{0, 4}},
+		})
+
+		groups, _ := pairUp(fakeRecursers(5), recent)
+		assert.Equal(t, pairIDs(groups), [][]int64{{0, 1}, {2, 3, 4}})
+	})
+
+	t.Run("one person can't be matched", func(t *testing.T) {
+		groups, _ := pairUp(fakeRecursers(1), nil)
+		assert.Equal(t, len(groups), 0)
+	})
+}
+
+func Test_pairUp_batchPref(t *testing.T) {
+	// Recursers 0 and 1 are in batch 100; 2 and 3 are in batch 200.
+	withBatches := func(prefs map[int64]string) []store.Recurser {
+		recursers := fakeRecursers(4)
+		for i := range recursers {
+			recursers[i].BatchID = 100 + 100*(recursers[i].ID/2)
+			recursers[i].BatchPref = prefs[recursers[i].ID]
+		}
+		return recursers
+	}
+
+	t.Run("prefers cross-batch", func(t *testing.T) {
+		pairs, _ := pairUp(withBatches(map[int64]string{0: store.BatchPrefCross}), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 2}, {1, 3}})
+	})
+
+	t.Run("prefers same batch", func(t *testing.T) {
+		pairs, _ := pairUp(withBatches(map[int64]string{1: store.BatchPrefSame}), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 1}, {2, 3}})
+	})
+
+	t.Run("conflicting preferences mean any", func(t *testing.T) {
+		pairs, _ := pairUp(withBatches(map[int64]string{
+			0: store.BatchPrefSame,
+			1: store.BatchPrefCross,
+			2: store.BatchPrefCross,
+		}), nil)
+		// 0 and 1 disagree, so they're fine together. Then 2 would rather
+		// not pair with 3, but there's nobody else left.
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 1}, {2, 3}})
+	})
+
+	t.Run("recent pairs still matter more", func(t *testing.T) {
+		recent := recentPairs([]store.Match{
+			{Recursers: []int64{0, 2}},
+			{Recursers: []int64{0, 3}},
+		})
+
+		pairs, _ := pairUp(withBatches(map[int64]string{0: store.BatchPrefCross}), recent)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 1}, {2, 3}})
+	})
+}
+
+func Test_pairUp_mode(t *testing.T) {
+	withModes := func(modes map[int64]string) []store.Recurser {
+		recursers := fakeRecursers(4)
+		for i := range recursers {
+			recursers[i].Mode = modes[recursers[i].ID]
+		}
+		return recursers
+	}
+
+	t.Run("prefers the same mode", func(t *testing.T) {
+		pairs, _ := pairUp(withModes(map[int64]string{0: store.ModeText, 1: store.ModeVoice, 2: store.ModeText}), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 2}, {1, 3}})
+	})
+
+	t.Run("falls back to any mode", func(t *testing.T) {
+		pairs, _ := pairUp(withModes(map[int64]string{0: store.ModeText, 1: store.ModeVoice, 2: store.ModeVoice, 3: store.ModeVoice}), nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 1}, {2, 3}})
+	})
+
+	t.Run("in person only at RC", func(t *testing.T) {
+		recursers := withModes(map[int64]string{0: store.ModeInPerson})
+		recursers[0].CurrentlyAtRC = true
+		recursers[3].CurrentlyAtRC = true
+
+		pairs, _ := pairUp(recursers, nil)
+		assert.Equal(t, pairIDs(pairs), [][]int64{{0, 3}, {1, 2}})
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// MentorMatch pairs the current Recursers who are scheduled for today with
+// mentors (alumni who opted in with `set mentor on`). It's a separate job from
+// the daily matches, and its matches count just like theirs.
+func (pl *PairingLogic) MentorMatch(ctx context.Context) error {
+	now := time.Now()
+
+	available, err := store.Recursers(pl.db).ListPairingTomorrow(ctx, now)
+	if err != nil {
+		return fmt.Errorf("get today's recursers from DB: %w", err)
+	}
+
+	lastWeek, err := store.Pairings(pl.db).GetMatchesSince(ctx, now.AddDate(0, 0, -7))
+	if err != nil {
+		logger(ctx).Warn("Could not get this week's matches, so weekly limits are ignored", slog.Any("error", err))
+	}
+	available = withinWeeklyCap(available, lastWeek)
+
+	var recursers, mentors []store.Recurser
+	for _, rec := range available {
+		switch {
+		case rec.IsMentor:
+			mentors = append(mentors, rec)
+		case rec.CurrentlyAtRC:
+			recursers = append(recursers, rec)
+		}
+	}
+	if len(recursers) == 0 || len(mentors) == 0 {
+		logger(ctx).Info("Nobody to match with mentors today", slog.Int("recursers", len(recursers)), slog.Int("mentors", len(mentors)))
+		return nil
+	}
+
+	recentMatches, err := store.Pairings(pl.db).GetMatchesSince(ctx, now.AddDate(0, 0, -pl.repeatWindowDays))
+	if err != nil {
+		logger(ctx).Warn("Could not get recent matches, so repeats are allowed", slog.Any("error", err))
+	}
+
+	seed := pl.nextSeed()
+	logger(ctx).Info("Shuffling Recursers and mentors",
+		slog.Int("recursers", len(recursers)),
+		slog.Int("mentors", len(mentors)),
+		slog.Int64("seed", seed),
+	)
+	plan, err := matchMentors(recursers, mentors, recentPairs(recentMatches), rand.New(rand.NewSource(seed)))
+	if err != nil {
+		return fmt.Errorf("match with seed %d: %w", seed, err)
+	}
+
+	for _, group := range plan.Groups {
+		ids := []int64{group[0].ID, group[1].ID}
+		groupLog := logger(ctx).With(slog.Any("recurserIds", ids))
+
+		message := mentorMatchedMessage
+		if verbosity := groupVerbosity(group); verbosity != store.VerbosityMinimal {
+			bios, err := renderBios(group, verbosity == store.VerbosityFull)
+			if err != nil {
+				groupLog.Warn("Could not render bios", slog.Any("error", err))
+			}
+			message += bios
+		}
+
+		if err := pl.zulipFor(group[0].Realm).SendUserMessage(ctx, ids, message); err != nil {
+			groupLog.Error("Could not send mentorMatchedMessage", slog.Any("error", err))
+		}
+		if err := store.Pairings(pl.db).AddMatch(ctx, store.Match{Recursers: ids, Timestamp: time.Now().Unix(), Realm: group[0].Realm}); err != nil {
+			groupLog.Error("Could not record the match", slog.Any("error", err))
+		}
+	}
+
+	logger(ctx).Info("Finished matching with mentors",
+		slog.Int("groups", len(plan.Groups)),
+		slog.Int("unmatched", len(plan.Unmatched)),
+	)
+	return nil
+}
+
+// SetMentor adds the Recurser to (or removes them from) the mentor pool. Only
+// alumni can be mentors, since mentors are left out of the daily matches.
+func (pl *PairingLogic) SetMentor(ctx context.Context, rec *store.Recurser, mentor bool) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+	if mentor && rec.CurrentlyAtRC {
+		return "The mentor pool is for alumni, so you can join once your batch is over. Until then, enjoy your daily matches!", nil
+	}
+
+	rec.IsMentor = mentor
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	if mentor {
+		return "Thanks for mentoring! Whenever mentor matching runs on one of your scheduled days, I'll match you with a current Recurser. You're out of the usual daily matches while you're a mentor.", nil
+	}
+	return "Okay, you're out of the mentor pool and back to the usual daily matches.", nil
+}
@@ -1,36 +1,58 @@
 package main
 
-import (
-	_ "embed"
-	"fmt"
+// Messages that don't depend on who they're sent to are rendered once from
+// their templates (see templates.go), and again whenever the templates are
+// overridden.
+var (
+	oddOneOutMessage     string
+	unmatchedMessage     string
+	mentorMatchedMessage string
+	offboardedMessage    string
+	introMessage         string
+	cookieClubMessage    string
+	helpMessage          string
+	newcomerHelpMessage  string
+	subscribeMessage     string
+	unsubscribeMessage   string
+	notSubscribedMessage string
+	youreWelcomeMessage  string
+	matchNowMessage      string
+	cooldownMessage      string
+	notEnoughMessage     string
+	notARecurserMessage  string
+	writeErrorMessage    string
+	readErrorMessage     string
+	groupDMMessage       string
+	blindIntroMessage    string
 )
 
-//go:embed messages/odd_one_out.md
-var oddOneOutMessage string
-
-//go:embed messages/matched.md
-var matchedMessage string
-
-//go:embed messages/offboarded.md
-var offboardedMessage string
-
-//go:embed messages/intro.md
-var introMessage string
-
-//go:embed messages/cookieClub.md
-var cookieClubMessage string
-
-//go:embed messages/help.md
-var helpMessage string
-
-//go:embed messages/subscribed.md
-var subscribeMessage string
-
-//go:embed messages/unsubscribed.md
-var unsubscribeMessage string
-
-const notSubscribedMessage string = "You're not subscribed to Pairing Bot <3"
-const youreWelcomeMessage string = "You're welcome!"
-
-var writeErrorMessage = fmt.Sprintf("Something went sideways while writing to the database. You should probably ping %v", maintainersMention())
-var readErrorMessage = fmt.Sprintf("Something went sideways while reading from the database. You should probably ping %v", maintainersMention())
+// staticMessages maps each of the messages above to the template it's
+// rendered from.
+var staticMessages = map[string]*string{
+	"odd_one_out.md.tmpl":    &oddOneOutMessage,
+	"unmatched.md.tmpl":      &unmatchedMessage,
+	"mentor_matched.md.tmpl": &mentorMatchedMessage,
+	"offboarded.md.tmpl":     &offboardedMessage,
+	"intro.md.tmpl":          &introMessage,
+	"cookie_club.md.tmpl":    &cookieClubMessage,
+	"help.md.tmpl":           &helpMessage,
+	"newcomer_help.md.tmpl":  &newcomerHelpMessage,
+	"subscribed.md.tmpl":     &subscribeMessage,
+	"unsubscribed.md.tmpl":   &unsubscribeMessage,
+	"not_subscribed.md.tmpl": &notSubscribedMessage,
+	"youre_welcome.md.tmpl":  &youreWelcomeMessage,
+	"match_now.md.tmpl":      &matchNowMessage,
+	"cooldown.md.tmpl":       &cooldownMessage,
+	"not_enough.md.tmpl":     &notEnoughMessage,
+	"not_a_recurser.md.tmpl": &notARecurserMessage,
+	"write_error.md.tmpl":    &writeErrorMessage,
+	"read_error.md.tmpl":     &readErrorMessage,
+	"group_dm.md.tmpl":       &groupDMMessage,
+	"blind_intro.md.tmpl":    &blindIntroMessage,
+}
+
+func init() {
+	if err := renderStaticMessages(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// Met tells the Recurser whether they've been matched with someone before,
+// how many times, and when they were last matched.
+func (pl *PairingLogic) Met(ctx context.Context, rec *store.Recurser, args []string) (string, error) {
+	partner, reply, err := pl.resolveRecurser(ctx, rec, "met", args)
+	if reply != "" || err != nil {
+		return reply, err
+	}
+	if partner.ID == rec.ID {
+		return "That's you!", nil
+	}
+
+	matches, err := store.Pairings(pl.db).GetMatchesWith(ctx, rec.Realm, rec.ID, partner.ID)
+	if err != nil {
+		return "", readError(err)
+	}
+	return formatMet(rec, partner.Name, matches), nil
+}
+
+// formatMet describes the Recurser's matches with someone: how many there were
+// and, if any, the date of the last one in the Recurser's time zone.
+func formatMet(rec *store.Recurser, name string, matches []store.Match) string {
+	if len(matches) == 0 {
+		return fmt.Sprintf("Not yet! You haven't been matched with %s.", name)
+	}
+
+	var latest int64
+	for _, match := range matches {
+		latest = max(latest, match.Timestamp)
+	}
+	date := time.Unix(latest, 0).In(rec.Location()).Format(time.DateOnly)
+
+	if len(matches) == 1 {
+		return fmt.Sprintf("You've been matched with %s once, on %s.", name, date)
+	}
+	return fmt.Sprintf("You've been matched with %s %d times, most recently on %s.", name, len(matches), date)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_formatMet(t *testing.T) {
+	rec := &store.Recurser{ID: 1, Timezone: "America/New_York"}
+	// Late on the 13th in New York.
+	latest := time.Date(2024, time.March, 14, 2, 0, 0, 0, time.UTC).Unix()
+
+	assert.Equal(t, formatMet(rec, "Ada", []store.Match{
+		{Recursers: []int64{1, 2}, Timestamp: latest},
+		{Recursers: []int64{2, 1}, Timestamp: latest - 86400},
+		{Recursers: []int64{1, 2, 3}, Timestamp: latest - 7*86400},
+	}), "You've been matched with Ada 3 times, most recently on 2024-03-13.")
+
+	assert.Equal(t, formatMet(rec, "Ada", []store.Match{{Recursers: []int64{1, 2}, Timestamp: latest}}),
+		"You've been matched with Ada once, on 2024-03-13.")
+
+	t.Run("never met", func(t *testing.T) {
+		assert.Equal(t, formatMet(rec, "Ada", nil), "Not yet! You haven't been matched with Ada.")
+	})
+}
+
+func TestPairingLogic_Met(t *testing.T) {
+	ctx := context.Background()
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx)}
+
+	id := pbtest.RandInt64(t)
+	rec := &store.Recurser{ID: id, Name: "Alan"}
+	matchedAt := time.Date(2024, time.March, 14, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, store.Pairings(pl.db).AddMatch(ctx, store.Match{Recursers: []int64{id, id + 1}, Timestamp: matchedAt.Unix()}))
+	assert.NoError(t, store.Pairings(pl.db).AddMatch(ctx, store.Match{Recursers: []int64{id + 1, id}, Timestamp: matchedAt.AddDate(0, 0, -7).Unix()}))
+
+	msg, err := pl.dispatch(ctx, "met", []string{"Ada", strconv.FormatInt(id+1, 10)}, rec)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, "You've been matched with Ada 2 times, most recently on 2024-03-14.")
+
+	t.Run("never met", func(t *testing.T) {
+		msg, err := pl.dispatch(ctx, "met", []string{"Grace", strconv.FormatInt(id+2, 10)}, rec)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Not yet! You haven't been matched with Grace.")
+	})
+}
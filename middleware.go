@@ -2,29 +2,134 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strings"
 )
 
 // JobFunc is the type of function that can run as a cron job.
 type JobFunc func(context.Context) error
 
-// cron wraps a job function to make it an HTTP handler. The handler enforces
-// that requests originate from App Engine's Cron scheduler.
-func cron(job JobFunc) http.HandlerFunc {
+// cron wraps a job function to make it an HTTP handler. The handler only runs
+// the job for requests from App Engine's Cron scheduler or that include the
+// cron token (see allowCron).
+func cron(token TokenFunc, job JobFunc) http.HandlerFunc {
+	return allowCron(token, func(w http.ResponseWriter, r *http.Request) {
+		err := job(r.Context())
+		if err != nil {
+			logger(r.Context()).Error("Job failed", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	})
+}
+
+// unlessMaintenance wraps a job so that it's skipped while maintenance mode is
+// on. If the mode can't be checked, the job runs anyway: a database that's
+// down will stop most jobs on its own.
+func unlessMaintenance(inMaintenance func(context.Context) (bool, error), job JobFunc) JobFunc {
+	return func(ctx context.Context) error {
+		on, err := inMaintenance(ctx)
+		if err != nil {
+			logger(ctx).Warn("Could not check maintenance mode, so running the job anyway", slog.Any("error", err))
+		} else if on {
+			logger(ctx).Info("Skipping the job during maintenance")
+			return nil
+		}
+		return job(ctx)
+	}
+}
+
+// allowCron wraps an HTTP handler to only allow requests that come from App
+// Engine's Cron scheduler, or that include the shared secret as a bearer token
+// (for other schedulers, or running a job by hand). Anyone else gets 403
+// Forbidden.
+//
+// App Engine removes the X-Appengine-Cron header from outside requests, so
+// only its own scheduler can set it.
+// https://cloud.google.com/appengine/docs/standard/go/scheduling-jobs-with-cron-yaml#validating_cron_requests
+func allowCron(token TokenFunc, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Check that the request is originating from within app engine
-		// https://cloud.google.com/appengine/docs/standard/go/scheduling-jobs-with-cron-yaml#validating_cron_requests
-		if r.Header.Get("X-Appengine-Cron") != "true" {
+		if r.Header.Get("X-Appengine-Cron") == "true" {
+			next(w, r)
+			return
+		}
+
+		want, err := token(r.Context())
+		if err != nil {
+			logger(r.Context()).Error("Could not load token", slog.Any("error", err))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if !hasBearerToken(r, want) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// withDryRun routes requests with the "dryrun=true" query parameter to the
+// dryRun handler instead of the normal one.
+func withDryRun(normal, dryRun http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("dryrun") == "true" {
+			dryRun(w, r)
+			return
+		}
+		normal(w, r)
+	}
+}
+
+// TokenFunc returns the secret token that requests must present.
+type TokenFunc func(context.Context) (string, error)
+
+// requireToken wraps an HTTP handler to only allow requests that include the
+// shared secret as a bearer token ("Authorization: Bearer <token>").
+func requireToken(token TokenFunc, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		want, err := token(r.Context())
+		if err != nil {
+			logger(r.Context()).Error("Could not load token", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if !hasBearerToken(r, want) {
 			http.NotFound(w, r)
 			return
 		}
 
-		err := job(r.Context())
+		next(w, r)
+	}
+}
+
+// hasBearerToken returns whether the request includes the token as
+// "Authorization: Bearer <token>".
+func hasBearerToken(r *http.Request, want string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	// An empty token would let everyone in, so treat it as a mistake.
+	return ok && want != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// serveJSON makes an HTTP handler that responds with the JSON encoding of the
+// value returned by get.
+func serveJSON[T any](get func(context.Context) (T, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		v, err := get(r.Context())
 		if err != nil {
-			slog.Error("Job failed", slog.Any("error", err))
+			logger(r.Context()).Error("Could not build JSON response", slog.Any("error", err))
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			logger(r.Context()).Error("Could not write JSON response", slog.Any("error", err))
+		}
 	}
 }
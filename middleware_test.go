@@ -2,19 +2,26 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/recursecenter/pairing-bot/internal/assert"
 )
 
 func Test_cron(t *testing.T) {
+	token := func(context.Context) (string, error) {
+		return "s3cret", nil
+	}
+
 	t.Run("run job for AppEngine", func(t *testing.T) {
 		// Arrange a cron job that tells us whether it ran.
 		ran := false
-		handler := cron(func(context.Context) error {
+		handler := cron(token, func(context.Context) error {
 			ran = true
 			return nil
 		})
@@ -36,27 +43,80 @@ func Test_cron(t *testing.T) {
 
 	t.Run("deny request outside of cron", func(t *testing.T) {
 		// Arrange a cron job that fails the test if it runs.
-		handler := cron(func(context.Context) error {
+		handler := cron(token, func(context.Context) error {
 			t.Error("handler should not have run")
 			return nil
 		})
 
-		// Prepare a request from outside of AppEngine (no custom header).
+		for name, authorization := range map[string]string{
+			"no token":    "",
+			"wrong token": "Bearer guess",
+			"not bearer":  "Basic s3cret",
+		} {
+			t.Run(name, func(t *testing.T) {
+				// Prepare a request from outside of AppEngine (no custom header).
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				if authorization != "" {
+					req.Header.Set("Authorization", authorization)
+				}
+
+				// Run it!
+				w := httptest.NewRecorder()
+				handler(w, req)
+
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, resp.StatusCode, 403)
+			})
+		}
+	})
+
+	t.Run("run job with the cron token", func(t *testing.T) {
+		ran := false
+		handler := cron(token, func(context.Context) error {
+			ran = true
+			return nil
+		})
+
+		// Prepare a request from outside of AppEngine, with the token.
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
 
-		// Run it!
 		w := httptest.NewRecorder()
 		handler(w, req)
 
 		resp := w.Result()
 		defer resp.Body.Close()
 
-		assert.Equal(t, resp.StatusCode, 404)
+		assert.Equal(t, ran, true)
+		assert.Equal(t, resp.StatusCode, 200)
+	})
+
+	t.Run("deny everyone without a token", func(t *testing.T) {
+		missing := func(context.Context) (string, error) {
+			return "", errors.New("no such secret")
+		}
+		handler := cron(missing, func(context.Context) error {
+			t.Error("handler should not have run")
+			return nil
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer ")
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, resp.StatusCode, 403)
 	})
 
 	t.Run("report job failure", func(t *testing.T) {
 		// Arrange a cron job that errors.
-		handler := cron(func(context.Context) error {
+		handler := cron(token, func(context.Context) error {
 			return errors.New("test error")
 		})
 
@@ -74,3 +134,190 @@ func Test_cron(t *testing.T) {
 		assert.Equal(t, resp.StatusCode, 500)
 	})
 }
+
+func Test_unlessMaintenance(t *testing.T) {
+	for name, tc := range map[string]struct {
+		On      bool
+		Err     error
+		WantRun bool
+	}{
+		"maintenance on":  {On: true, WantRun: false},
+		"maintenance off": {On: false, WantRun: true},
+		"unknown":         {Err: errors.New("database is down"), WantRun: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			inMaintenance := func(context.Context) (bool, error) {
+				return tc.On, tc.Err
+			}
+
+			ran := false
+			job := unlessMaintenance(inMaintenance, func(context.Context) error {
+				ran = true
+				return nil
+			})
+
+			assert.NoError(t, job(context.Background()))
+			assert.Equal(t, ran, tc.WantRun)
+		})
+	}
+
+	t.Run("skipped cron job succeeds", func(t *testing.T) {
+		on := func(context.Context) (bool, error) { return true, nil }
+		token := func(context.Context) (string, error) { return "s3cret", nil }
+		handler := cron(token, unlessMaintenance(on, func(context.Context) error {
+			t.Error("job should not have run")
+			return nil
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Appengine-Cron", "true")
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, resp.StatusCode, 200)
+	})
+}
+
+func Test_requireToken(t *testing.T) {
+	token := func(context.Context) (string, error) {
+		return "s3cret", nil
+	}
+
+	handler := requireToken(token, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	for name, tc := range map[string]struct {
+		Authorization string
+		Status        int
+	}{
+		"correct token": {"Bearer s3cret", http.StatusTeapot},
+		"wrong token":   {"Bearer guess", http.StatusNotFound},
+		"no token":      {"", http.StatusNotFound},
+		"not bearer":    {"Basic s3cret", http.StatusNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.Authorization != "" {
+				req.Header.Set("Authorization", tc.Authorization)
+			}
+
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, resp.StatusCode, tc.Status)
+		})
+	}
+
+	t.Run("empty token denies everyone", func(t *testing.T) {
+		empty := func(context.Context) (string, error) {
+			return "", nil
+		}
+		handler := requireToken(empty, func(w http.ResponseWriter, r *http.Request) {
+			t.Error("handler should not have run")
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer ")
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, resp.StatusCode, 404)
+	})
+}
+
+func Test_serveJSON_metrics(t *testing.T) {
+	handler := serveJSON(func(context.Context) (Metrics, error) {
+		return Metrics{
+			PairingsThisWeek:    12,
+			SubscribedRecursers: 30,
+			SkippingTomorrow:    4,
+		}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	assert.Equal(t, resp.StatusCode, 200)
+	assert.Equal(t, resp.Header.Get("Content-Type"), "application/json")
+
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, body, map[string]any{
+		"pairings_this_week":   12.0,
+		"subscribed_recursers": 30.0,
+		"skipping_tomorrow":    4.0,
+	})
+}
+
+func Test_serveJSON_version(t *testing.T) {
+	defer func(old time.Time) { startTime = old }(startTime)
+	startTime = time.Now().Add(-time.Hour)
+
+	pl := &PairingLogic{version: "test version"}
+	handler := serveJSON(pl.VersionInfo)
+
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	assert.Equal(t, resp.StatusCode, 200)
+
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, body["version"], any("test version"))
+	assert.Equal(t, body["go_version"], any(runtime.Version()))
+	if uptime, ok := body["uptime_seconds"].(float64); !ok || uptime < 3600 {
+		t.Errorf("got uptime_seconds %v, wanted at least an hour", body["uptime_seconds"])
+	}
+}
+
+func Test_withDryRun(t *testing.T) {
+	handler := withDryRun(
+		func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) },
+		func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusAccepted) },
+	)
+
+	for target, status := range map[string]int{
+		"/match":                 http.StatusOK,
+		"/match?dryrun=false":    http.StatusOK,
+		"/match?dryrun=true":     http.StatusAccepted,
+		"/match?dryrun=yes":      http.StatusOK,
+		"/match?x=1&dryrun=true": http.StatusAccepted,
+	} {
+		t.Run(target, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, resp.StatusCode, status)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// milestoneEvery is how many pairings apart the milestones are.
+const milestoneEvery = 1000
+
+// milestonesTTL is how long to reuse the all-time totals. They only change once
+// a day, at the match run.
+const milestonesTTL = 10 * time.Minute
+
+// pairingTotals are the community's pairings: all-time, and during the last
+// week.
+type pairingTotals struct {
+	AllTime  int
+	LastWeek int
+}
+
+// milestoneMessage reports the all-time total, and celebrates if it passed a
+// milestone in the last week. Otherwise it counts down to the next one.
+func milestoneMessage(totals pairingTotals) string {
+	if totals.AllTime == 0 {
+		return "Nobody has been matched yet! The count starts at the next match run."
+	}
+
+	msg := fmt.Sprintf("Recursers have paired **%s** times with Pairing Bot!", formatCount(totals.AllTime))
+
+	milestone := totals.AllTime / milestoneEvery * milestoneEvery
+	if milestone > 0 && totals.AllTime-totals.LastWeek < milestone {
+		return msg + fmt.Sprintf(" We just passed %s pairings :tada:", formatCount(milestone))
+	}
+
+	next := milestone + milestoneEvery
+	return msg + fmt.Sprintf(" Only %s more to go until %s.", formatCount(next-totals.AllTime), formatCount(next))
+}
+
+// formatCount writes n with commas between the thousands, like "10,000".
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+)
+
+func Test_milestoneMessage(t *testing.T) {
+	for name, tc := range map[string]struct {
+		totals pairingTotals
+		want   string
+	}{
+		"none yet": {
+			pairingTotals{},
+			"Nobody has been matched yet! The count starts at the next match run.",
+		},
+		"before the first": {
+			pairingTotals{AllTime: 950, LastWeek: 40},
+			"Recursers have paired **950** times with Pairing Bot! Only 50 more to go until 1,000.",
+		},
+		"just passed": {
+			pairingTotals{AllTime: 10_012, LastWeek: 60},
+			"Recursers have paired **10,012** times with Pairing Bot! We just passed 10,000 pairings :tada:",
+		},
+		"exactly on it": {
+			pairingTotals{AllTime: 2000, LastWeek: 1},
+			"Recursers have paired **2,000** times with Pairing Bot! We just passed 2,000 pairings :tada:",
+		},
+		"passed over a week ago": {
+			pairingTotals{AllTime: 10_012, LastWeek: 12},
+			"Recursers have paired **10,012** times with Pairing Bot! Only 988 more to go until 11,000.",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, milestoneMessage(tc.totals), tc.want)
+		})
+	}
+}
+
+func Test_formatCount(t *testing.T) {
+	for n, want := range map[int]string{
+		0:         "0",
+		999:       "999",
+		1000:      "1,000",
+		123456:    "123,456",
+		1_234_567: "1,234,567",
+	} {
+		assert.Equal(t, formatCount(n), want)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// nudgeDelay is how long after someone's match message goes out before
+// they're reminded to get in touch with their partner.
+const nudgeDelay = 3 * time.Hour
+
+// A nudge is a reminder for one Recurser about one of their matches.
+type nudge struct {
+	Recurser store.Recurser
+	Partners []int64
+}
+
+// nudgesDue returns the reminders to send at `now`, for matches made in the
+// last day. Only Recursers who asked with `set nudge on` get them, and only
+// once their match message has been out for nudgeDelay.
+//
+// There's no way to tell whether the partners have already talked, so
+// everyone who asked gets reminded.
+func nudgesDue(recursers []store.Recurser, matches []store.Match, now time.Time) []nudge {
+	byKey := make(map[recurserKey]store.Recurser)
+	for _, rec := range recursers {
+		if rec.Nudge {
+			byKey[recurserKey{rec.Realm, rec.ID}] = rec
+		}
+	}
+
+	var nudges []nudge
+	for _, match := range matches {
+		made := time.Unix(match.Timestamp, 0)
+		if made.Before(now.Add(-24 * time.Hour)) {
+			continue
+		}
+
+		for _, id := range match.Recursers {
+			rec, ok := byKey[recurserKey{match.Realm, id}]
+			if !ok || rec.NotifyAt(made).After(now.Add(-nudgeDelay)) {
+				continue
+			}
+
+			var partners []int64
+			for _, partner := range match.Recursers {
+				if partner != id {
+					partners = append(partners, partner)
+				}
+			}
+			nudges = append(nudges, nudge{Recurser: rec, Partners: partners})
+		}
+	}
+	return nudges
+}
+
+// Nudge reminds the Recursers who asked for it to get in touch with today's
+// partners. It's meant to run once a day, in the middle of the day.
+func (pl *PairingLogic) Nudge(ctx context.Context) error {
+	now := time.Now()
+
+	matches, err := store.Pairings(pl.db).GetMatchesSince(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		return fmt.Errorf("get today's matches: %w", err)
+	}
+	recursers, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
+	if err != nil {
+		return fmt.Errorf("get list of recursers: %w", err)
+	}
+
+	for _, n := range nudgesDue(recursers, matches, now) {
+		recLog := logger(ctx).With(slog.Int64("recurserId", n.Recurser.ID))
+
+		message, err := renderNudge(n.Partners)
+		if err != nil {
+			recLog.Error("Could not render the nudge", slog.Any("error", err))
+			continue
+		}
+		if err := pl.zulipFor(n.Recurser.Realm).SendUserMessage(ctx, []int64{n.Recurser.ID}, message); err != nil {
+			recLog.Error("Could not send the nudge", slog.Any("error", err))
+			continue
+		}
+		recLog.Info("Sent a nudge")
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_nudgesDue(t *testing.T) {
+	matchedAt := time.Date(2024, time.March, 11, 4, 0, 0, 0, time.UTC)
+	now := time.Date(2024, time.March, 11, 16, 0, 0, 0, time.UTC)
+
+	recursers := []store.Recurser{
+		{ID: 1, Nudge: true},
+		{ID: 2},
+		{ID: 3, Nudge: true, Timezone: "America/New_York", MatchTime: "09:00"},
+		{ID: 4, Nudge: true, Timezone: "America/New_York", MatchTime: "14:00"},
+		{ID: 5, Nudge: true},
+		{ID: 6, Nudge: true},
+		{ID: 7, Nudge: true},
+	}
+	matches := []store.Match{
+		{Recursers: []int64{1, 2}, Timestamp: matchedAt.Unix()},
+		{Recursers: []int64{3, 4}, Timestamp: matchedAt.Unix()},
+		// Too recent, from `match now`.
+		{Recursers: []int64{5, 6}, Timestamp: now.Add(-time.Hour).Unix()},
+		// Yesterday's.
+		{Recursers: []int64{7, 1}, Timestamp: matchedAt.AddDate(0, 0, -1).Unix()},
+	}
+
+	var got []int64
+	partners := make(map[int64][]int64)
+	for _, n := range nudgesDue(recursers, matches, now) {
+		got = append(got, n.Recurser.ID)
+		partners[n.Recurser.ID] = n.Partners
+	}
+
+	// Recurser 2 didn't opt in, and Recurser 4 only just got their match
+	// message, at 14:00 in New York.
+	assert.Equal(t, got, []int64{1, 3})
+	assert.Equal(t, partners, map[int64][]int64{1: {2}, 3: {4}})
+
+	t.Run("nobody opted in", func(t *testing.T) {
+		assert.Equal(t, len(nudgesDue([]store.Recurser{{ID: 1}, {ID: 2}}, matches, now)), 0)
+	})
+}
+
+func Test_renderNudge(t *testing.T) {
+	msg, err := renderNudge([]int64{2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, msg, "Have you scheduled your pairing with @_**|2** and @_**|3** yet? If not, now's a great time to send them a message! :pear:\n\n(Send `set nudge off` if you'd rather not get these reminders.)\n")
+}
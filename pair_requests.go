@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// pairRequestTTL is how long someone has to answer a `pair` request.
+const pairRequestTTL = 24 * time.Hour
+
+// noPairRequestMessage is the reply to `pair accept` or `pair decline` when
+// nobody has asked.
+const noPairRequestMessage = "You don't have a pair request waiting. (Requests expire after a day.)"
+
+// RequestPair asks someone in particular to pair with the Recurser. They answer
+// with `pair accept` or `pair decline`.
+func (pl *PairingLogic) RequestPair(ctx context.Context, rec *store.Recurser, args []string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	person, reply, err := pl.resolveRecurser(ctx, rec, "pair", args)
+	if reply != "" || err != nil {
+		return reply, err
+	}
+
+	if person.ID == rec.ID {
+		return "You can't pair with yourself! Try `match now` to find someone.", nil
+	}
+	if rec.HasBlocked(person.ID) {
+		return fmt.Sprintf("You've blocked %s. Send `unblock %s` first if you'd like to pair with them.", person.Name, person.Name), nil
+	}
+
+	now := time.Now()
+	waiting, err := store.PairRequests(pl.db).Get(ctx, rec.Realm, person.ID, now)
+	if err != nil {
+		return "", readError(err)
+	}
+	if waiting != nil && waiting.From != rec.ID {
+		return fmt.Sprintf("%s already has a pair request waiting. Try again later!", person.Name), nil
+	}
+
+	sent := fmt.Sprintf("I've asked %s! I'll let you know if they accept in the next %d hours.", person.Name, int(pairRequestTTL.Hours()))
+
+	// Like blocks themselves, someone who blocked the Recurser isn't told
+	// about it, and neither is the Recurser. The request just never arrives.
+	other, err := store.Recursers(pl.db).GetByUserID(ctx, rec.Realm, person.ID, "", person.Name)
+	if err != nil {
+		return "", readError(err)
+	}
+	if other.HasBlocked(rec.ID) {
+		return sent, nil
+	}
+
+	err = store.PairRequests(pl.db).Set(ctx, store.PairRequest{
+		From:      rec.ID,
+		FromName:  rec.Name,
+		To:        person.ID,
+		ToName:    person.Name,
+		ExpiresAt: now.Add(pairRequestTTL).Unix(),
+		Realm:     rec.Realm,
+	})
+	if err != nil {
+		return "", writeError(err)
+	}
+
+	ask := fmt.Sprintf("Hi! **%s** would like to pair with you. Send me `pair accept` to say yes, or `pair decline` if you can't make it. The request expires in %d hours.", rec.Name, int(pairRequestTTL.Hours()))
+	if err := pl.zulipFor(rec.Realm).SendUserMessage(ctx, []int64{person.ID}, ask); err != nil {
+		logger(ctx).Error("Could not send the pair request", slog.Int64("partnerId", person.ID), slog.Any("error", err))
+		return "", &commandError{Reply: fmt.Sprintf("Something went wrong when I tried to ask %s. Sorry! Try again in a bit.", person.Name), Err: err}
+	}
+	return sent, nil
+}
+
+// AcceptPair accepts the pair request waiting for the Recurser. Both of them
+// get a DM to start pairing, and it's recorded like any other match.
+func (pl *PairingLogic) AcceptPair(ctx context.Context, rec *store.Recurser) (string, error) {
+	now := time.Now()
+	req, err := store.PairRequests(pl.db).Take(ctx, rec.Realm, rec.ID, now)
+	if err != nil {
+		return "", writeError(err)
+	}
+	if req == nil {
+		return noPairRequestMessage, nil
+	}
+
+	ids := []int64{req.From, req.To}
+	intro := fmt.Sprintf("Hi you two! **%s** asked to pair with **%s**, and they said yes :)\n\nHave fun!", req.FromName, rec.Name)
+	if err := pl.zulipFor(rec.Realm).SendUserMessage(ctx, ids, intro); err != nil {
+		logger(ctx).Error("Could not introduce the pair", slog.Int64("partnerId", req.From), slog.Any("error", err))
+		return "", &commandError{Reply: fmt.Sprintf("Something went wrong when I tried to tell %s. Sorry! Send them a DM to get started.", req.FromName), Err: err}
+	}
+	logger(ctx).Info("Pair request accepted", slog.Int64("partnerId", req.From))
+
+	if err := store.Pairings(pl.db).AddMatch(ctx, store.Match{Recursers: ids, Timestamp: now.Unix(), Realm: rec.Realm}); err != nil {
+		logger(ctx).Error("Could not record the requested match", slog.Int64("partnerId", req.From), slog.Any("error", err))
+	}
+	if err := store.Pairings(pl.db).SetNumPairings(ctx, store.Pairing{Value: 1, NumRecursers: 2, Timestamp: now.Unix()}); err != nil {
+		logger(ctx).Error("Could not record the requested pairing", slog.Any("error", err))
+	}
+
+	return fmt.Sprintf("You're on! I've started a DM for you and %s.", req.FromName), nil
+}
+
+// DeclinePair turns down the pair request waiting for the Recurser, and lets
+// the person who asked know.
+func (pl *PairingLogic) DeclinePair(ctx context.Context, rec *store.Recurser) (string, error) {
+	req, err := store.PairRequests(pl.db).Take(ctx, rec.Realm, rec.ID, time.Now())
+	if err != nil {
+		return "", writeError(err)
+	}
+	if req == nil {
+		return noPairRequestMessage, nil
+	}
+
+	msg := fmt.Sprintf("**%s** can't pair this time. Maybe another day! (Or find someone else with `match now`.)", rec.Name)
+	if err := pl.zulipFor(rec.Realm).SendUserMessage(ctx, []int64{req.From}, msg); err != nil {
+		logger(ctx).Error("Could not tell the requester that the pair request was declined", slog.Int64("partnerId", req.From), slog.Any("error", err))
+	}
+	return fmt.Sprintf("Okay, I've let %s know.", req.FromName), nil
+}
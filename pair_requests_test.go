@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestPairingLogic_pairRequests(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*PairingLogic, *pbtest.FakeZulip) {
+		fake := pbtest.NewFakeZulip(t)
+		return &PairingLogic{db: pbtest.FirestoreClient(t, ctx), zulip: fake.Client}, fake
+	}
+
+	ada := store.Recurser{ID: 1, Name: "Ada", IsSubscribed: true, Realm: store.DefaultRealm}
+	grace := store.Recurser{ID: 2, Name: "Grace", IsSubscribed: true, Realm: store.DefaultRealm}
+	askGrace := []string{"ask", "Grace", strconv.FormatInt(grace.ID, 10)}
+
+	matchesFor := func(t *testing.T, pl *PairingLogic, id int64) int {
+		matches, err := store.Pairings(pl.db).GetAllMatchesFor(ctx, store.DefaultRealm, id)
+		assert.NoError(t, err)
+		return len(matches)
+	}
+
+	t.Run("accept", func(t *testing.T) {
+		pl, fake := setup(t)
+
+		msg, err := pl.dispatch(ctx, "pair", askGrace, &ada)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "I've asked Grace! I'll let you know if they accept in the next 24 hours.")
+		assert.Equal(t, len(fake.DMs("[2]")), 1)
+		assert.Equal(t, strings.HasPrefix(fake.DMs("[2]")[0], "Hi! **Ada** would like to pair with you."), true)
+
+		msg, err = pl.dispatch(ctx, "pair", []string{"accept"}, &grace)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "You're on! I've started a DM for you and Ada.")
+		assert.Equal(t, len(fake.DMs("[1,2]")), 1)
+		assert.Equal(t, strings.HasPrefix(fake.DMs("[1,2]")[0], "Hi you two! **Ada** asked to pair with **Grace**"), true)
+		assert.Equal(t, matchesFor(t, pl, ada.ID), 1)
+
+		// It can only be accepted once.
+		msg, err = pl.dispatch(ctx, "pair", []string{"accept"}, &grace)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, noPairRequestMessage)
+		assert.Equal(t, matchesFor(t, pl, ada.ID), 1)
+	})
+
+	t.Run("decline", func(t *testing.T) {
+		pl, fake := setup(t)
+
+		_, err := pl.dispatch(ctx, "pair", askGrace, &ada)
+		assert.NoError(t, err)
+
+		msg, err := pl.dispatch(ctx, "pair", []string{"decline"}, &grace)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Okay, I've let Ada know.")
+		assert.Equal(t, len(fake.DMs("[1]")), 1)
+		assert.Equal(t, strings.HasPrefix(fake.DMs("[1]")[0], "**Grace** can't pair this time."), true)
+		assert.Equal(t, matchesFor(t, pl, ada.ID), 0)
+	})
+
+	t.Run("expire", func(t *testing.T) {
+		pl, _ := setup(t)
+
+		// A request from long enough ago that it's too late to answer.
+		err := store.PairRequests(pl.db).Set(ctx, store.PairRequest{
+			From:      ada.ID,
+			FromName:  ada.Name,
+			To:        grace.ID,
+			ToName:    grace.Name,
+			ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+			Realm:     store.DefaultRealm,
+		})
+		assert.NoError(t, err)
+
+		msg, err := pl.dispatch(ctx, "pair", []string{"accept"}, &grace)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, noPairRequestMessage)
+		assert.Equal(t, matchesFor(t, pl, ada.ID), 0)
+
+		// Someone else can ask now.
+		alan := store.Recurser{ID: 3, Name: "Alan", IsSubscribed: true, Realm: store.DefaultRealm}
+		msg, err = pl.dispatch(ctx, "pair", askGrace, &alan)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "I've asked Grace! I'll let you know if they accept in the next 24 hours.")
+	})
+
+	t.Run("one request at a time", func(t *testing.T) {
+		pl, _ := setup(t)
+
+		_, err := pl.dispatch(ctx, "pair", askGrace, &ada)
+		assert.NoError(t, err)
+
+		alan := store.Recurser{ID: 3, Name: "Alan", IsSubscribed: true, Realm: store.DefaultRealm}
+		msg, err := pl.dispatch(ctx, "pair", askGrace, &alan)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Grace already has a pair request waiting. Try again later!")
+	})
+
+	t.Run("blocked", func(t *testing.T) {
+		pl, fake := setup(t)
+
+		blocking := grace
+		blocking.Blocks = []store.Block{{ID: ada.ID, Name: ada.Name}}
+		assert.NoError(t, store.Recursers(pl.db).Set(ctx, &blocking))
+
+		// Ada isn't told, but Grace never hears about it.
+		msg, err := pl.dispatch(ctx, "pair", askGrace, &ada)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "I've asked Grace! I'll let you know if they accept in the next 24 hours.")
+		assert.Equal(t, len(fake.DMs("[2]")), 0)
+
+		msg, err = pl.dispatch(ctx, "pair", []string{"accept"}, &blocking)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, noPairRequestMessage)
+	})
+
+	t.Run("yourself", func(t *testing.T) {
+		pl, _ := setup(t)
+
+		msg, err := pl.dispatch(ctx, "pair", []string{"ask", "Ada", "1"}, &ada)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "You can't pair with yourself! Try `match now` to find someone.")
+	})
+}
@@ -3,55 +3,116 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
 	"net/http"
+	"runtime"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/recursecenter/pairing-bot/recurse"
 	"github.com/recursecenter/pairing-bot/store"
 	"github.com/recursecenter/pairing-bot/zulip"
+	"golang.org/x/sync/errgroup"
 )
 
-// maintainers contains the Zulip IDs of the current maintainers.
+// maintainers contains the realms and Zulip IDs of the current maintainers.
+// Zulip IDs are only unique within a realm, so someone in another realm with
+// the same ID as a maintainer isn't one.
 //
 // This is a map instead of a slice to allow for easy membership checks.
-var maintainers = map[int64]struct{}{
-	699369: {}, // Charles Eckman (SP2'24)
-	720507: {}, // Jeremy Kaplan (S1'24)
+var maintainers = map[recurserKey]struct{}{
+	{store.DefaultRealm, 699369}: {}, // Charles Eckman (SP2'24)
+	{store.DefaultRealm, 720507}: {}, // Jeremy Kaplan (S1'24)
 }
 
-// isMaintainer returns whether this Zulip ID is in the maintainer set.
-func isMaintainer(id int64) bool {
-	_, isPresent := maintainers[id]
+// isMaintainer returns whether this Zulip ID in the realm is in the maintainer
+// set.
+func isMaintainer(realm string, id int64) bool {
+	_, isPresent := maintainers[recurserKey{realm, id}]
 	return isPresent
 }
 
 // maintainersMention returns a Zulip-markdown string that mentions all the
-// maintainers.
+// maintainers in the realm.
 //
 // https://zulip.com/help/format-your-message-using-markdown#mention-a-user-or-group
-func maintainersMention() string {
+func maintainersMention(realm string) string {
 	var tags []string
-	for id := range maintainers {
-		tags = append(tags, fmt.Sprintf("@_**|%d**", id))
+	for key := range maintainers {
+		if key.realm == realm {
+			tags = append(tags, fmt.Sprintf("@_**|%d**", key.id))
+		}
 	}
 	return strings.Join(tags, ", ")
 }
 
+// RecurseAPIClient is the part of the Recurse API that Pairing Bot uses. It's
+// a *recurse.Client in production, and usually a pbtest.FakeRecurse in tests.
+type RecurseAPIClient interface {
+	ActiveRecursers(ctx context.Context) ([]recurse.Profile, error)
+	ProfileByEmail(ctx context.Context, email string) (recurse.Profile, error)
+	IsCurrentlyAtRC(ctx context.Context, zulipID int64) (bool, error)
+	AllBatches(ctx context.Context) ([]recurse.Batch, error)
+	RecentlyStarted(ctx context.Context, since time.Time) ([]recurse.Profile, error)
+}
+
+var _ RecurseAPIClient = (*recurse.Client)(nil)
+
 type PairingLogic struct {
 	db      *firestore.Client
 	zulip   *zulip.Client
-	recurse *recurse.Client
+	recurse RecurseAPIClient
+
+	// realms has a Zulip client for each Zulip realm other than RC's own
+	// (store.DefaultRealm), keyed by the realm's name. Recursers in other
+	// realms are only ever matched with each other.
+	realms map[string]*zulip.Client
 
 	version         string
 	maintenanceMode bool
 
+	leaderboard ttlCache[[]leaderboardEntry]
+	milestones  ttlCache[pairingTotals]
+	health      healthCheck
+
+	// repeatWindowDays is how far back to look for previous matches when
+	// trying to avoid repeat pairings.
+	repeatWindowDays int
+
+	// seeds picks the seed for each match run's shuffle. Production seeds it
+	// from the clock; tests can use a fixed seed to get the same matches
+	// every time. Use nextSeed rather than calling it directly.
+	seeds   *rand.Rand
+	seedsMu sync.Mutex
+
 	welcomeStream string
+
+	// adminStream is where maintainers hear about problems, like messages
+	// that couldn't be sent during a match run.
+	adminStream string
+
+	// backups is where Backup saves its files. It's nil if backups aren't
+	// configured.
+	backups backupWriter
+
+	// email sends match messages that Zulip couldn't deliver to the Recursers
+	// who asked for that. It's nil if email isn't configured.
+	email Emailer
+
+	// issues files `bug` reports. It's nil if that isn't configured, in which
+	// case they're saved as reviews.
+	issues issueFiler
+}
+
+// An Emailer sends plain-text email.
+type Emailer interface {
+	SendEmail(ctx context.Context, to, subject, body string) error
 }
 
 func (pl *PairingLogic) handle(w http.ResponseWriter, r *http.Request) {
@@ -64,17 +125,32 @@ func (pl *PairingLogic) handle(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	log.Println("Handling a new Zulip request")
+	logger(ctx).Info("Handling a new Zulip request")
+
+	// Each realm's bot has its own outgoing webhook, at /webhooks/{realm}.
+	realm := r.PathValue("realm")
+	tokenName := "zulip_webhook_token"
+	if realm != store.DefaultRealm {
+		if _, ok := pl.realms[realm]; !ok {
+			logger(ctx).Warn("Rejected webhook for an unknown realm", slog.String("realm", realm))
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		tokenName += "_" + realm
+	}
 
-	botAuth, err := store.Secrets(pl.db).Get(ctx, "zulip_webhook_token")
+	botAuth, err := store.Secrets(pl.db).Get(ctx, tokenName)
 	if err != nil {
-		log.Println("Something weird happened trying to read the auth token from the database")
+		// Without the token, there's no way to tell real requests from fakes.
+		logger(ctx).Error("Could not read the webhook token from the database", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
 	hook, err := zulip.ParseWebhook(r.Body, botAuth)
 	if err != nil {
-		log.Println(err)
-		http.NotFound(w, r) // TODO(@jdkaplan): 401 Unauthorized if token mismatch?
+		logger(ctx).Warn("Rejected webhook", slog.Any("error", err))
+		w.WriteHeader(webhookErrorStatus(err))
 		return
 	}
 
@@ -82,151 +158,718 @@ func (pl *PairingLogic) handle(w http.ResponseWriter, r *http.Request) {
 	// commands in open streams/channels.
 	if hook.Trigger != "direct_message" {
 		if err := responder.Encode(zulip.Reply(introMessage)); err != nil {
-			log.Println(err)
+			logger(ctx).Error("Could not write response", slog.Any("error", err))
 		}
 		return
 	}
 
-	// Don't respond to commands sent in pair-making group DMs. We can
-	// distinguish these by checking whether there are exactly two participants
+	// Match messages start group DMs with Pairing Bot in them, so we hear about
+	// everything partners say to each other there. Stay out of it, unless
+	// someone sends what looks like a command.
+	if hook.Message.DisplayRecipient.IsGroupDM() {
+		if err := responder.Encode(groupDMResponse(hook.Data)); err != nil {
+			logger(ctx).Error("Could not write response", slog.Any("error", err))
+		}
+		return
+	}
+
+	// Commands only come from 1:1 DMs, which have exactly two participants
 	// (Pairing Bot + 1).
 	if len(hook.Message.DisplayRecipient.Users) != 2 {
 		if err := responder.Encode(zulip.NoResponse()); err != nil {
-			log.Println(err)
+			logger(ctx).Error("Could not write response", slog.Any("error", err))
 		}
 		return
 	}
 
 	// for testing only
 	// this responds with a maintenance message and quits if the request is coming from anyone other than a maintainer
-	if !isMaintainer(hook.Message.SenderID) && pl.maintenanceMode {
+	if !isMaintainer(realm, hook.Message.SenderID) && pl.maintenanceMode {
 		if err = responder.Encode(zulip.Reply(`pairing bot is down for maintenance`)); err != nil {
-			log.Println(err)
+			logger(ctx).Error("Could not write response", slog.Any("error", err))
 		}
 		return
 	}
 
-	log.Printf("The user: %s (%d) issued the following request to Pairing Bot: %s", hook.Message.SenderFullName, hook.Message.SenderID, hook.Data)
+	// Zulip can deliver the same message more than once (like when it retries
+	// a webhook that timed out), so skip any we've already handled. If we
+	// can't tell, handling it twice is better than not at all.
+	if id := hook.Message.ID; id != 0 {
+		first, err := store.ProcessedMessages(pl.db).Claim(ctx, realm, id, time.Now())
+		if err != nil {
+			logger(ctx).Warn("Could not check for a repeated message, so handling it anyway", slog.Int64("messageId", id), slog.Any("error", err))
+		} else if !first {
+			logger(ctx).Info("Ignoring a repeated message", slog.Int64("messageId", id))
+			if err := responder.Encode(zulip.NoResponse()); err != nil {
+				logger(ctx).Error("Could not write response", slog.Any("error", err))
+			}
+			return
+		}
+	}
+
+	// Everything logged from here on is about this user's request.
+	ctx = withLogger(ctx, logger(ctx).With(slog.Int64("recurserId", hook.Message.SenderID)))
+	logger(ctx).Info("Received a command",
+		slog.String("name", hook.Message.SenderFullName),
+		slog.String("command", hook.Data),
+	)
 
-	user, err := store.Recursers(pl.db).GetByUserID(ctx, hook.Message.SenderID, hook.Message.SenderEmail, hook.Message.SenderFullName)
+	if err := store.Usage(pl.db).CountCommand(ctx, time.Now()); err != nil {
+		logger(ctx).Warn("Could not count the command for the usage trends", slog.Any("error", err))
+	}
+
+	user, err := store.Recursers(pl.db).GetByUserID(ctx, realm, hook.Message.SenderID, hook.Message.SenderEmail, hook.Message.SenderFullName)
 	if err != nil {
-		log.Println(err)
+		logger(ctx).Error("Could not look up the user", slog.Any("error", err))
 
 		if err = responder.Encode(zulip.Reply(readErrorMessage)); err != nil {
-			log.Println(err)
+			logger(ctx).Error("Could not write response", slog.Any("error", err))
 		}
 		return
 	}
 
+	// Any command counts as activity, so they aren't unsubscribed for being
+	// inactive (see UnsubscribeStale). It's set on the record too, in case
+	// the command saves it.
+	user.LastActive = time.Now().Unix()
+	if err := store.Recursers(pl.db).MarkActive(ctx, realm, user.ID, time.Now()); err != nil {
+		logger(ctx).Warn("Could not record that the user is active", slog.Any("error", err))
+	}
+
 	// you *should* be able to throw any string at this thing and get back a valid command for dispatch()
 	// if there are no command arguments, cmdArgs will be nil
-	cmd, cmdArgs, err := parseCmd(hook.Data)
-	if err != nil {
-		log.Println(err)
+	// The user's own aliases are expanded first, so they parse like the
+	// commands they stand for.
+	cmd, cmdArgs, parseErr := parseCmd(expandAlias(hook.Data, user.Aliases))
+	if parseErr != nil {
+		logger(ctx).Info("Could not parse the command", slog.Any("error", parseErr))
 		// Error cases always correspond to cmd == "help", so it's safe to
 		// continue on to dispatch.
 	}
 
-	// the tofu and potatoes right here y'all
-	response, err := pl.dispatch(ctx, cmd, cmdArgs, user)
-	if err != nil {
-		log.Println(err)
-		// Errors come with non-empty messages sometimes, so continue on.
+	// Messages that aren't commands might be a hello for a blind intro, or
+	// one of the fun keywords.
+	var fun string
+	var isFun bool
+	if errors.Is(parseErr, ErrUnknownCommand) {
+		fun, isFun = pl.blindIntroHello(ctx, user, hook.Data)
+		if !isFun {
+			fun, isFun = pl.funResponse(ctx, hook.Data)
+		}
+	}
+
+	var response string
+	var wordErr *ScheduleWordError
+	if isFun {
+		response = fun
+	} else if errors.As(parseErr, &wordErr) {
+		// Point out the word that didn't make sense, rather than sending the
+		// general help.
+		response = wordErr.Reply()
+	} else {
+		// the tofu and potatoes right here y'all
+		// The error's detail is only logged. The Recurser gets the reply
+		// that goes with it.
+		response, err = pl.dispatch(ctx, cmd, cmdArgs, user)
+		if err != nil {
+			logger(ctx).Error("Command failed", slog.String("command", cmd), slog.Any("error", err))
+			response = replyFor(response, err)
+		}
+	}
+
+	if user.IsSubscribed && user.KeepHistory {
+		entry := store.HistoryEntry{
+			UserID:    user.ID,
+			Command:   strings.TrimSpace(hook.Data),
+			Timestamp: time.Now().Unix(),
+			Realm:     realm,
+		}
+		if err := store.History(pl.db).Insert(ctx, entry); err != nil {
+			logger(ctx).Warn("Could not record command history", slog.Any("error", err))
+		}
+	}
+
+	// If it looks like a typo, point out the command they probably meant
+	// before the general help.
+	if errors.Is(parseErr, ErrUnknownCommand) && !isFun {
+		name, _, _ := strings.Cut(strings.TrimSpace(hook.Data), " ")
+		response = didYouMean(strings.ToLower(name)) + response
 	}
 
 	if err = responder.Encode(zulip.Reply(response)); err != nil {
-		log.Println(err)
+		logger(ctx).Error("Could not write response", slog.Any("error", err))
 		return
 	}
 }
 
+// groupDMResponse answers a message in a group DM with Pairing Bot. Commands
+// are only taken in 1:1 DMs, so anything that parses as one gets pointed there
+// (without running it), and the rest of the conversation is left alone.
+// "thanks" is usually meant for a partner, so it's left alone too.
+func groupDMResponse(data string) zulip.Response {
+	cmd, _, err := parseCmd(data)
+	if err != nil || cmd == "thanks" {
+		return zulip.NoResponse()
+	}
+	return zulip.Reply(groupDMMessage)
+}
+
+// webhookErrorStatus returns the HTTP status code for a webhook that
+// zulip.ParseWebhook rejected.
+func webhookErrorStatus(err error) int {
+	if errors.Is(err, zulip.ErrWebhookUnauthorized) {
+		return http.StatusUnauthorized
+	}
+	return http.StatusBadRequest
+}
+
+// planMatches decides who to match for a match run at `now`. This only reads
+// from the database, so it's safe to use for dry runs.
+func (pl *PairingLogic) planMatches(ctx context.Context, now time.Time) (matchPlan, error) {
+	recursersList, err := store.Recursers(pl.db).ListPairingTomorrow(ctx, now)
+	if err != nil {
+		return matchPlan{}, fmt.Errorf("get today's recursers from DB: %w", err)
+	}
+
+	// Leave out anyone who has had as many matches this week as they wanted.
+	// This uses the same rolling week as GetTotalPairingsDuringLastWeek.
+	lastWeek, err := store.Pairings(pl.db).GetMatchesSince(ctx, now.AddDate(0, 0, -7))
+	if err != nil {
+		logger(ctx).Warn("Could not get this week's matches, so weekly limits are ignored today", slog.Any("error", err))
+	}
+	recursersList = withinWeeklyCap(recursersList, lastWeek)
+
+	// Mentors are only matched by MentorMatch.
+	recursersList = slices.DeleteFunc(recursersList, func(r store.Recurser) bool { return r.IsMentor })
+
+	// Batch preferences need everyone's current batch, which only the Recurse
+	// API knows. Skip the lookup if nobody has a preference.
+	if slices.ContainsFunc(recursersList, func(r store.Recurser) bool { return r.BatchPref != "" && r.BatchPref != store.BatchPrefAny }) {
+		active, err := pl.recurse.ActiveRecursers(ctx)
+		if err != nil {
+			logger(ctx).Warn("Could not get batches from the Recurse API, so batch preferences are ignored today", slog.Any("error", err))
+		}
+		setBatchIDs(recursersList, active)
+	}
+
+	// Try not to pair people who were matched with each other recently.
+	repeatWindowStart := now.AddDate(0, 0, -pl.repeatWindowDays)
+	recentMatches, err := store.Pairings(pl.db).GetMatchesSince(ctx, repeatWindowStart)
+	if err != nil {
+		logger(ctx).Warn("Could not get recent matches, so repeats are allowed today", slog.Any("error", err))
+	}
+
+	// Give people who haven't been matched in a while a better shot at a
+	// good match. IDs are only unique within a realm, so each realm is
+	// looked up on its own.
+	idsByRealm := make(map[string][]int64)
+	for _, rec := range recursersList {
+		idsByRealm[rec.Realm] = append(idsByRealm[rec.Realm], rec.ID)
+	}
+	lastMatched := make(map[recurserKey]int64)
+	for realm, ids := range idsByRealm {
+		last, err := store.Pairings(pl.db).LastMatchTimes(ctx, realm, ids)
+		if err != nil {
+			logger(ctx).Warn("Could not get when people were last matched, so nobody is favored today", slog.Any("error", err))
+			lastMatched = nil
+			break
+		}
+		for id, timestamp := range last {
+			lastMatched[recurserKey{realm, id}] = timestamp
+		}
+	}
+	var weights map[recurserKey]float64
+	if lastMatched != nil {
+		weights = idleWeights(recursersList, lastMatched, now)
+	}
+
+	// Reproducible randomness:
+	// - Get and log a random seed
+	// - Run the shuffle using a source derived from that seed
+	// so we can re-run the shuffle later, if needed.
+	// In dev, you should be able to set the seed below (or give PairingLogic a
+	// fixed seeds source) to get the same shuffle.
+	seed := pl.nextSeed()
+	logger(ctx).Info("Shuffling Recursers",
+		slog.Int("count", len(recursersList)),
+		slog.Int64("seed", seed),
+	)
+
+	plan, err := matchRecursers(recursersList, recentPairs(recentMatches), weights, rand.New(rand.NewSource(seed)))
+	if err != nil {
+		return matchPlan{}, fmt.Errorf("match with seed %d: %w", seed, err)
+	}
+	return plan, nil
+}
+
+// nextSeed returns a seed for a match run's shuffle from pl.seeds, which isn't
+// safe for concurrent use on its own. Without a source, it falls back to the
+// global one.
+func (pl *PairingLogic) nextSeed() int64 {
+	pl.seedsMu.Lock()
+	defer pl.seedsMu.Unlock()
+
+	if pl.seeds == nil {
+		return rand.Int63()
+	}
+	return pl.seeds.Int63()
+}
+
+// zulipFor returns the Zulip client for a realm. Messages to a realm without
+// a client fail, rather than going to whoever has the same ID in RC's realm.
+func (pl *PairingLogic) zulipFor(realm string) *zulip.Client {
+	if realm == store.DefaultRealm {
+		return pl.zulip
+	}
+	if client, ok := pl.realms[realm]; ok {
+		return client
+	}
+	client, _ := zulip.NewClient(func(context.Context) (zulip.Credentials, error) {
+		return zulip.Credentials{}, fmt.Errorf("no Zulip client for realm %q", realm)
+	})
+	return client
+}
+
+// checkZulip makes sure Zulip is up in every realm the plan would send
+// messages to, so that a match run can stop before it changes anything.
+// Realms without a client are left out, since their messages fail either way.
+func (pl *PairingLogic) checkZulip(ctx context.Context, plan matchPlan) error {
+	everyone := slices.Concat(plan.Unmatched, slices.Concat(plan.Groups...))
+	if plan.OddOneOut != nil {
+		everyone = append(everyone, *plan.OddOneOut)
+	}
+
+	checked := make(map[string]bool)
+	for _, rec := range everyone {
+		if checked[rec.Realm] {
+			continue
+		}
+		checked[rec.Realm] = true
+
+		if _, ok := pl.realms[rec.Realm]; !ok && rec.Realm != store.DefaultRealm {
+			continue
+		}
+		if err := pl.zulipFor(rec.Realm).Ping(ctx); err != nil {
+			return fmt.Errorf("check Zulip realm %q: %w", rec.Realm, err)
+		}
+	}
+	return nil
+}
+
 // Match generates new pairs for today and sends notifications for them.
+//
+// Cron can occasionally deliver the same request twice, so each day's run is
+// recorded when it finishes. Later runs on the same (UTC) day do nothing.
 func (pl *PairingLogic) Match(ctx context.Context) error {
-	recursersList, err := store.Recursers(pl.db).ListPairingTomorrow(ctx)
-	log.Println(recursersList)
+	return pl.matchOn(ctx, time.Now())
+}
+
+// matchOn is Match for a run at `now`.
+func (pl *PairingLogic) matchOn(ctx context.Context, now time.Time) error {
+	run := store.MatchRun{
+		Date:      now.UTC().Format(time.DateOnly),
+		Timestamp: now.Unix(),
+	}
+
+	done, err := store.MatchRuns(pl.db).HasRun(ctx, run.Date)
 	if err != nil {
-		return fmt.Errorf("get today's recursers from DB: %w", err)
+		// Better to risk a repeat than to skip everyone's matches.
+		logger(ctx).Warn("Could not check for an earlier match run today, so matching anyway", slog.Any("error", err))
+	} else if done {
+		logger(ctx).Info("Matches were already made today, so skipping this run", slog.String("date", run.Date))
+		return nil
+	}
+
+	if pl.skippingWeekend(ctx, now) {
+		logger(ctx).Info("There's no matching on weekends, so skipping this run", slog.String("date", run.Date))
+		if err := pl.unsetSkippers(ctx); err != nil {
+			logger(ctx).Error("Could not unset skipping", slog.Any("error", err))
+		}
+
+		// Recorded like a normal run, so a repeated request doesn't unset
+		// skips asked for since.
+		if err := store.MatchRuns(pl.db).Record(ctx, run); err != nil {
+			logger(ctx).Warn("Could not record today's match run", slog.Any("error", err))
+		}
+		return nil
+	}
+
+	if holiday := pl.holidayOn(ctx, now); holiday != nil {
+		logger(ctx).Info("It's a holiday, so skipping this run", slog.String("date", holiday.Date), slog.String("holiday", holiday.Name))
+		pl.announceHoliday(ctx, holiday)
+		if err := pl.unsetSkippers(ctx); err != nil {
+			logger(ctx).Error("Could not unset skipping", slog.Any("error", err))
+		}
+
+		// Recorded like a normal run, so a repeated request doesn't post
+		// the announcement (or unset skips) again.
+		if err := store.MatchRuns(pl.db).Record(ctx, run); err != nil {
+			logger(ctx).Warn("Could not record today's match run", slog.Any("error", err))
+		}
+		return nil
 	}
 
+	if err := pl.match(ctx, now); err != nil {
+		return err
+	}
+
+	if err := store.MatchRuns(pl.db).Record(ctx, run); err != nil {
+		logger(ctx).Warn("Could not record today's match run", slog.Any("error", err))
+	}
+	return nil
+}
+
+// unsetSkippers sets everyone who skipped today's run back to
+// isSkippingTomorrow = false. Every run does this, even one that doesn't match
+// anyone, since "skip tomorrow" is only ever for one run.
+func (pl *PairingLogic) unsetSkippers(ctx context.Context) error {
 	skippersList, err := store.Recursers(pl.db).ListSkippingTomorrow(ctx)
 	if err != nil {
 		return fmt.Errorf("get today's skippers from DB: %w", err)
 	}
 
-	// get everyone who was set to skip today and set them back to isSkippingTomorrow = false
 	for _, skipper := range skippersList {
 		err := store.Recursers(pl.db).UnsetSkippingTomorrow(ctx, &skipper)
 		if err != nil {
-			log.Printf("Could not unset skipping for recurser %v: %s\n", skipper.ID, err)
+			logger(ctx).Error("Could not unset skipping", slog.Int64("recurserId", skipper.ID), slog.Any("error", err))
 		}
 	}
+	return nil
+}
 
-	// Reproducible randomness:
-	// - Get and log a random seed
-	// - Run the shuffle using a source derived from that seed
-	// so we can re-run the shuffle later, if needed.
-	// In dev, you should be able to set the seed below to get the same shuffle.
-	seed := rand.Int63()
-	log.Printf("Shuffling %d Recursers using random seed: %d", len(recursersList), seed)
-	randSrc := rand.NewSource(seed)
-	// shuffle our recursers. This will not error if the list is empty
-	rand.New(randSrc).Shuffle(len(recursersList), func(i, j int) { recursersList[i], recursersList[j] = recursersList[j], recursersList[i] })
+// match makes and announces the matches for a match run at `now`.
+func (pl *PairingLogic) match(ctx context.Context, now time.Time) error {
+	plan, err := pl.planMatches(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	// If Zulip is down, give up before anything is written, so the whole run
+	// can be tried again instead of only some people hearing about it.
+	if err := pl.checkZulip(ctx, plan); err != nil {
+		return fmt.Errorf("give up on the match run: %w", err)
+	}
+
+	if err := pl.unsetSkippers(ctx); err != nil {
+		return err
+	}
+
+	// On quiet days, a maintainer can ask to skip the run entirely.
+	config, err := store.MatchConfigs(pl.db).Get(ctx)
+	if err != nil {
+		logger(ctx).Warn("Could not get the match run settings, so matching anyway", slog.Any("error", err))
+	} else if tooFewToMatch(config, plan) {
+		pl.skipQuietRun(ctx, config, plan)
+		return nil
+	}
 
 	// if for some reason there's no matches today, we're done
-	if len(recursersList) == 0 {
-		log.Println("No one was signed up to pair today -- so there were no matches")
+	if len(plan.Groups) == 0 && plan.OddOneOut == nil && len(plan.Unmatched) == 0 {
+		logger(ctx).Info("No one was signed up to pair today -- so there were no matches")
 		return nil
 	}
 
-	// message the peeps!
+	// message the peeps! Maintainers hear about any messages that don't go
+	// out at the end.
+	var failures matchFailures
 
-	// if there's an odd number today, message the last person in the list
-	// and tell them they don't get a match today, then knock them off the list
-	if len(recursersList)%2 != 0 {
-		recurser := recursersList[len(recursersList)-1]
-		recursersList = recursersList[:len(recursersList)-1]
-		log.Printf("%s was the odd-one-out today", recurser.Name)
+	// Let the odd one out know they don't get a match today.
+	if recurser := plan.OddOneOut; recurser != nil {
+		logger(ctx).Info("Odd one out today", slog.Int64("recurserId", recurser.ID))
 
-		err := pl.zulip.SendUserMessage(ctx, []int64{recurser.ID}, oddOneOutMessage)
+		err := pl.zulipFor(recurser.Realm).SendUserMessage(ctx, []int64{recurser.ID}, oddOneOutMessage)
 		if err != nil {
-			log.Printf("Error when trying to send oddOneOut message to %s: %s\n", recurser.Name, err)
+			logger(ctx).Error("Could not send oddOneOutMessage", slog.Int64("recurserId", recurser.ID), slog.Any("error", err))
+			failures.add([]int64{recurser.ID}, "odd one out", err)
 		}
 	}
 
-	for i := 0; i < len(recursersList); i += 2 {
-		rc1 := recursersList[i]
-		rc2 := recursersList[i+1]
-		ids := []int64{rc1.ID, rc2.ID}
+	// Blocks can leave someone without a group. Don't say why, since that
+	// would give away that someone blocked them.
+	for _, recurser := range plan.Unmatched {
+		logger(ctx).Info("Unmatched today", slog.Int64("recurserId", recurser.ID))
 
-		err := pl.zulip.SendUserMessage(ctx, ids, matchedMessage)
+		err := pl.zulipFor(recurser.Realm).SendUserMessage(ctx, []int64{recurser.ID}, unmatchedMessage)
 		if err != nil {
-			log.Printf("Error when trying to send matchedMessage to %s and %s: %s\n", rc1.Name, rc2.Name, err)
+			logger(ctx).Error("Could not send unmatchedMessage", slog.Int64("recurserId", recurser.ID), slog.Any("error", err))
+			failures.add([]int64{recurser.ID}, "unmatched", err)
 		}
-		log.Println(rc1.Name, "was", "matched", "with", rc2.Name)
 	}
 
-	numRecursersPairedUp := len(recursersList)
+	// Everyone gets the same theme, if there is one.
+	var theme string
+	if t, err := store.Themes(pl.db).Get(ctx); err != nil {
+		logger(ctx).Warn("Could not get the theme, so leaving it out", slog.Any("error", err))
+	} else if t != nil {
+		theme = themeNote(t.Text)
+	}
+
+	blind := pl.blindIntrosOn(ctx)
 
-	log.Printf("Pairing Bot paired up %d recursers today", numRecursersPairedUp)
+	numRecursersPairedUp := 0
+
+	// Groups don't depend on each other, so a few are handled at a time to
+	// keep big runs quick. (The Zulip client's rate limit still applies.)
+	var groups errgroup.Group
+	groups.SetLimit(matchConcurrency)
+	for _, group := range plan.Groups {
+		numRecursersPairedUp += len(group)
+		groups.Go(func() error {
+			pl.matchGroup(ctx, now, group, theme, blind, &failures)
+			return nil
+		})
+	}
+	_ = groups.Wait()
+
+	logger(ctx).Info("Finished matching", slog.Int("recursers", numRecursersPairedUp))
+	pl.reportMatchFailures(ctx, now, failures.list)
 
 	pairing := store.Pairing{
-		Value:     numRecursersPairedUp / 2,
-		Timestamp: time.Now().Unix(),
+		Value:        len(plan.Groups),
+		NumRecursers: numRecursersPairedUp,
+		Timestamp:    time.Now().Unix(),
 	}
 
 	if err := store.Pairings(pl.db).SetNumPairings(ctx, pairing); err != nil {
-		log.Printf("Failed to record today's pairings: %s", err)
+		logger(ctx).Error("Could not record today's pairings", slog.Any("error", err))
+	}
+
+	if post, err := store.DailyPosts(pl.db).Get(ctx); err != nil {
+		logger(ctx).Warn("Could not get the daily post settings, so not posting", slog.Any("error", err))
+	} else {
+		pl.postDailyCount(ctx, post, pairing)
 	}
 
 	return nil
 }
 
-// EndOfBatch unsubscribes everyone who just never-graduated with this batch.
+// matchConcurrency is how many groups a match run sends messages to (and
+// records the matches of) at the same time.
+const matchConcurrency = 8
+
+// matchGroup sends one group from a match run their match message, and
+// records the match. Anything that goes wrong is logged, and messages that
+// can't be sent are added to the failures, so the rest of the run carries on.
+// It's safe to call for different groups at the same time.
+func (pl *PairingLogic) matchGroup(ctx context.Context, now time.Time, group []store.Recurser, theme string, blind bool, failures *matchFailures) {
+	var ids []int64
+	for _, rec := range group {
+		ids = append(ids, rec.ID)
+	}
+	groupLog := logger(ctx).With(slog.Any("recurserIds", ids))
+
+	// Pairs get the usual message. The group of three (if there's an odd
+	// number of people today) gets told why there are three of them.
+	message, err := renderMatchMessage(group, theme)
+	if err != nil {
+		groupLog.Error("Could not render the match message", slog.Any("error", err))
+	}
+
+	// Everyone in the group gets the same message, so send it at the
+	// earliest time that anyone asked for.
+	sendAt := group[0].NotifyAt(now)
+	for _, rec := range group[1:] {
+		if t := rec.NotifyAt(now); t.Before(sendAt) {
+			sendAt = t
+		}
+	}
+
+	match := store.Match{
+		Recursers: ids,
+		Timestamp: time.Now().Unix(),
+		Realm:     group[0].Realm,
+	}
+
+	// If anyone wants to confirm their matches, the match isn't recorded
+	// until they do.
+	note, pending := pl.holdForConfirmation(ctx, group, sendAt, match)
+	message += note
+
+	// Blind pairs each get told on their own, without their partner's
+	// name, and are introduced later.
+	deliveries := [][]store.Recurser{group}
+	isBlind := blind && !pending && pl.holdForBlindIntro(ctx, group, sendAt, message)
+	if isBlind {
+		message = blindIntroMessage
+		deliveries = [][]store.Recurser{group[:1], group[1:]}
+	}
+
+	for _, recipients := range deliveries {
+		pl.sendMatchMessage(ctx, now, sendAt, recipients, message, failures)
+	}
+	groupLog.Info("Matched a group", slog.Bool("pending", pending), slog.Bool("blind", isBlind))
+
+	// Being matched counts as activity (see UnsubscribeStale).
+	for _, rec := range group {
+		if err := store.Recursers(pl.db).MarkActive(ctx, rec.Realm, rec.ID, now); err != nil {
+			groupLog.Warn("Could not record that the Recurser is active", slog.Int64("recurserId", rec.ID), slog.Any("error", err))
+		}
+	}
+
+	if pending {
+		return
+	}
+	if err := store.Pairings(pl.db).AddMatch(ctx, match); err != nil {
+		groupLog.Error("Could not record the match", slog.Any("error", err))
+	}
+}
+
+// sendMatchMessage sends the match message to the recipients at sendAt,
+// scheduling it if that's after `now`. Messages that can't be sent are added
+// to the failures.
+func (pl *PairingLogic) sendMatchMessage(ctx context.Context, now, sendAt time.Time, recipients []store.Recurser, message string, failures *matchFailures) {
+	var ids []int64
+	for _, rec := range recipients {
+		ids = append(ids, rec.ID)
+	}
+	groupLog := logger(ctx).With(slog.Any("recurserIds", ids))
+
+	if sendAt.After(now) {
+		err := store.ScheduledMessages(pl.db).Add(ctx, store.ScheduledMessage{
+			Realm:      recipients[0].Realm,
+			Recipients: ids,
+			Content:    message,
+			SendAt:     sendAt.Unix(),
+		})
+		if err != nil {
+			groupLog.Warn("Could not schedule matchedMessage, so sending it now", slog.Any("error", err))
+			sendAt = now
+		}
+	}
+
+	if !sendAt.After(now) {
+		// Everyone in a group is in the same realm (see CanPairWith).
+		err := pl.zulipFor(recipients[0].Realm).SendUserMessage(ctx, ids, message)
+		if err != nil {
+			groupLog.Error("Could not send matchedMessage", slog.Any("error", err))
+			failures.add(ids, "match", err)
+			pl.emailFallback(ctx, recipients, message)
+		}
+	}
+}
+
+// A matchFailure is a match run message that couldn't be sent.
+type matchFailure struct {
+	Recursers []int64
+	Message   string
+	Error     string
+}
+
+// matchFailures collects the messages that couldn't be sent during a match
+// run, so they can be reported all at once. It's safe for concurrent use.
+type matchFailures struct {
+	mu   sync.Mutex
+	list []matchFailure
+}
+
+func (f *matchFailures) add(ids []int64, message string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.list = append(f.list, matchFailure{Recursers: ids, Message: message, Error: err.Error()})
+}
+
+// reportMatchFailures posts a summary of the failures to the admin stream, if
+// there were any.
+func (pl *PairingLogic) reportMatchFailures(ctx context.Context, now time.Time, failures []matchFailure) {
+	if len(failures) == 0 {
+		return
+	}
+
+	summary, err := renderMatchFailures(now, failures)
+	if err != nil {
+		logger(ctx).Error("Could not render the match failures", slog.Any("error", err))
+		return
+	}
+	if err := pl.zulip.PostToTopic(ctx, pl.adminStream, "match failures", summary); err != nil {
+		logger(ctx).Error("Could not report the match failures", slog.Any("error", err))
+	}
+}
+
+// themeNote is the part of a match message that suggests the theme.
+func themeNote(text string) string {
+	return "\n\n:speech_balloon: **This week's theme:** " + text
+}
+
+// dryRunRecurser identifies a Recurser in a DryRunMatch result.
+type dryRunRecurser struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// A DryRunResult lists the matches that a match run would have made.
+type DryRunResult struct {
+	Groups    [][]dryRunRecurser `json:"groups"`
+	OddOneOut *dryRunRecurser    `json:"odd_one_out"`
+	Unmatched []dryRunRecurser   `json:"unmatched,omitempty"`
+}
+
+// DryRunMatch runs the matching algorithm without sending any messages or
+// writing any records, and returns the matches it would have made.
+func (pl *PairingLogic) DryRunMatch(ctx context.Context) (DryRunResult, error) {
+	logger(ctx).Info("DRY RUN: Matching without sending notifications or recording pairings")
+
+	plan, err := pl.planMatches(ctx, time.Now())
+	if err != nil {
+		return DryRunResult{}, err
+	}
+
+	result := DryRunResult{
+		Groups: [][]dryRunRecurser{},
+	}
+	for _, group := range plan.Groups {
+		var members []dryRunRecurser
+		for _, rec := range group {
+			members = append(members, dryRunRecurser{ID: rec.ID, Name: rec.Name})
+		}
+		result.Groups = append(result.Groups, members)
+	}
+	if rec := plan.OddOneOut; rec != nil {
+		result.OddOneOut = &dryRunRecurser{ID: rec.ID, Name: rec.Name}
+	}
+	for _, rec := range plan.Unmatched {
+		result.Unmatched = append(result.Unmatched, dryRunRecurser{ID: rec.ID, Name: rec.Name})
+	}
+
+	logger(ctx).Info("DRY RUN: Finished matching", slog.Int("groups", len(result.Groups)))
+	return result, nil
+}
+
+// SendScheduled sends any scheduled messages that are due.
+func (pl *PairingLogic) SendScheduled(ctx context.Context) error {
+	due, err := store.ScheduledMessages(pl.db).ListDue(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("get scheduled messages from DB: %w", err)
+	}
+
+	for _, msg := range due {
+		if err := pl.zulipFor(msg.Realm).SendUserMessage(ctx, msg.Recipients, msg.Content); err != nil {
+			// Leave it in place to try again next time.
+			logger(ctx).Error("Could not send scheduled message", slog.String("messageId", msg.ID), slog.Any("error", err))
+			continue
+		}
+
+		if err := store.ScheduledMessages(pl.db).Delete(ctx, msg.ID); err != nil {
+			logger(ctx).Error("Could not delete scheduled message after sending it", slog.String("messageId", msg.ID), slog.Any("error", err))
+		}
+	}
+
+	return nil
+}
+
+// EndOfBatch unsubscribes everyone who just never-graduated with this batch,
+// after thanking them with a recap of their pairing.
 func (pl *PairingLogic) EndOfBatch(ctx context.Context) error {
+	// Forget about anyone who unsubscribed and didn't come back in time.
+	purged, err := store.Recursers(pl.db).PurgeUnsubscribed(ctx, time.Now())
+	if err != nil {
+		logger(ctx).Error("Could not purge unsubscribed recursers from DB", slog.Any("error", err))
+	}
+	logger(ctx).Info("Purged unsubscribed recursers", slog.Int("count", len(purged)))
+
 	// getting all the recursers
-	recursersList, err := store.Recursers(pl.db).GetAllUsers(ctx)
+	recursersList, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
 	if err != nil {
-		log.Println("Could not get list of recursers from DB: ", err)
+		logger(ctx).Error("Could not get list of recursers from DB", slog.Any("error", err))
 	}
 
 	profiles, err := pl.recurse.ActiveRecursers(ctx)
@@ -243,58 +886,312 @@ func (pl *PairingLogic) EndOfBatch(ctx context.Context) error {
 
 		recurser := &recursersList[i]
 
+		// The Recurse API only knows about RC's own Zulip realm, so nobody
+		// in another realm is offboarded.
+		if recurser.Realm != store.DefaultRealm {
+			continue
+		}
+
 		isAtRCThisWeek := slices.Contains(idsOfPeopleAtRc, recurser.ID)
 		wasAtRCLastWeek := recursersList[i].CurrentlyAtRC
 
-		log.Printf("User: %s was at RC last week: %t and is at RC this week: %t", recurser.Name, wasAtRCLastWeek, isAtRCThisWeek)
+		recLog := logger(ctx).With(slog.Int64("recurserId", recurser.ID))
+		recLog.Info("Checked whether still at RC",
+			slog.Bool("lastWeek", wasAtRCLastWeek),
+			slog.Bool("thisWeek", isAtRCThisWeek),
+		)
 
 		recurser.CurrentlyAtRC = isAtRCThisWeek
 
-		if err = store.Recursers(pl.db).Set(ctx, recurser.ID, recurser); err != nil {
-			log.Printf("Error encountered while update currentlyAtRC status for user: %s (ID %d)", recurser.Name, recurser.ID)
+		// Clean up while we're here so the skip list doesn't grow forever, and
+		// last week's schedule changes don't linger.
+		recurser.RemovePastSkipDates(time.Now())
+		recurser.RemovePastWeekOverride(time.Now())
+
+		if err = store.Recursers(pl.db).Set(ctx, recurser); err != nil {
+			recLog.Error("Could not update currentlyAtRC", slog.Any("error", err))
 		}
 
 		// If they were at RC last week but not this week then we assume they have graduated or otherwise left RC
 		// In that case we remove them from pairing bot so that inactive people do not get matched
 		// If people who have left RC still want to use pairing bot, we give them the option to resubscribe
 		if wasAtRCLastWeek && !isAtRCThisWeek {
-			var message string
+			pl.offboard(ctx, recurser)
+		}
+	}
 
-			err = store.Recursers(pl.db).Delete(ctx, recurser.ID)
-			if err != nil {
-				log.Println(err)
-				message = fmt.Sprintf("Uh oh, I was trying to offboard you since it's the end of batch, but something went wrong. Consider messaging the maintainers to let them know this happened: %s", maintainersMention())
-			} else {
-				log.Printf("This user has been unsubscribed from pairing bot: %s (ID: %d)", recurser.Name, recurser.ID)
+	return nil
+}
 
-				message = offboardedMessage
-			}
+// offboard unsubscribes a Recurser who's just left RC, after thanking them
+// with a recap of their pairing.
+func (pl *PairingLogic) offboard(ctx context.Context, recurser *store.Recurser) {
+	recLog := logger(ctx).With(slog.Int64("recurserId", recurser.ID))
+	pl.sendBatchRecap(ctx, recurser, time.Now())
 
-			err := pl.zulip.SendUserMessage(ctx, []int64{recurser.ID}, message)
-			if err != nil {
-				log.Printf("Error when trying to send offboarding message to %s (ID %d): %s", recurser.Name, recurser.ID, err)
-			}
+	var message string
+
+	err := store.Recursers(pl.db).Delete(ctx, recurser.Realm, recurser.ID)
+	if err != nil {
+		recLog.Error("Could not offboard", slog.Any("error", err))
+		message = fmt.Sprintf("Uh oh, I was trying to offboard you since it's the end of batch, but something went wrong. Consider messaging the maintainers to let them know this happened: %s", maintainersMention(recurser.Realm))
+	} else {
+		recLog.Info("Offboarded at the end of batch")
+
+		message = offboardedMessage
+	}
+
+	err = pl.zulipFor(recurser.Realm).SendUserMessage(ctx, []int64{recurser.ID}, message)
+	if err != nil {
+		recLog.Error("Could not send offboarding message", slog.Any("error", err))
+	}
+}
+
+// WeeklySummary sends each Recurser a direct message listing who they paired
+// with over the last week.
+func (pl *PairingLogic) WeeklySummary(ctx context.Context) error {
+	recursersList, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
+	if err != nil {
+		return fmt.Errorf("get list of recursers from DB: %w", err)
+	}
+
+	now := time.Now()
+	weekAgo := now.AddDate(0, 0, -7)
+
+	names := make(map[recurserKey]string)
+	for _, recurser := range recursersList {
+		names[recurserKey{recurser.Realm, recurser.ID}] = recurser.Name
+	}
+
+	for _, recurser := range recursersList {
+		// People on a break won't have paired with anyone, and they don't need
+		// to be reminded about it.
+		if recurser.WeeklySummaryOptOut || recurser.IsPaused(now) {
+			continue
+		}
+
+		matches, err := store.Pairings(pl.db).GetMatchesFor(ctx, recurser.Realm, recurser.ID, weekAgo)
+		if err != nil {
+			logger(ctx).Error("Could not get last week's matches", slog.Int64("recurserId", recurser.ID), slog.Any("error", err))
+			continue
+		}
+
+		message, err := renderWeeklySummary(&recurser, matches, names)
+		if err != nil {
+			logger(ctx).Error("Could not render weekly summary", slog.Int64("recurserId", recurser.ID), slog.Any("error", err))
+			continue
+		}
+
+		if err := pl.zulipFor(recurser.Realm).SendUserMessage(ctx, []int64{recurser.ID}, message); err != nil {
+			logger(ctx).Error("Could not send weekly summary", slog.Int64("recurserId", recurser.ID), slog.Any("error", err))
+		}
+	}
+
+	return nil
+}
+
+// Metrics are aggregate statistics about Pairing Bot usage.
+type Metrics struct {
+	PairingsThisWeek    int `json:"pairings_this_week"`
+	SubscribedRecursers int `json:"subscribed_recursers"`
+	SkippingTomorrow    int `json:"skipping_tomorrow"`
+}
+
+// Metrics collects the current Metrics.
+func (pl *PairingLogic) Metrics(ctx context.Context) (Metrics, error) {
+	numPairings, err := store.Pairings(pl.db).GetTotalPairingsDuringLastWeek(ctx)
+	if err != nil {
+		return Metrics{}, fmt.Errorf("get pairings during the last week: %w", err)
+	}
+
+	recursersList, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
+	if err != nil {
+		return Metrics{}, fmt.Errorf("get list of recursers: %w", err)
+	}
+
+	skippersList, err := store.Recursers(pl.db).ListSkippingTomorrow(ctx)
+	if err != nil {
+		return Metrics{}, fmt.Errorf("get list of skippers: %w", err)
+	}
+
+	return Metrics{
+		PairingsThisWeek:    numPairings,
+		SubscribedRecursers: len(recursersList),
+		SkippingTomorrow:    len(skippersList),
+	}, nil
+}
+
+// noShowAdminWindow is how far back the /noshows admin endpoint looks.
+const noShowAdminWindow = 30 * 24 * time.Hour
+
+// A NoShowReport is a match that someone said their partner missed.
+type NoShowReport struct {
+	Timestamp  int64   `json:"timestamp"`
+	Recursers  []int64 `json:"recursers"`
+	ReportedBy []int64 `json:"reported_by"`
+}
+
+// NoShows lists the no-show reports for recent matches.
+func (pl *PairingLogic) NoShows(ctx context.Context) ([]NoShowReport, error) {
+	matches, err := store.Pairings(pl.db).ListNoShows(ctx, time.Now().Add(-noShowAdminWindow))
+	if err != nil {
+		return nil, fmt.Errorf("get no-shows: %w", err)
+	}
+
+	reports := []NoShowReport{}
+	for _, match := range matches {
+		reports = append(reports, NoShowReport{
+			Timestamp:  match.Timestamp,
+			Recursers:  match.Recursers,
+			ReportedBy: match.NoShowReportedBy,
+		})
+	}
+	return reports, nil
+}
+
+// ratingsAdminWindow is how far back the /ratings admin endpoint looks.
+const ratingsAdminWindow = 30 * 24 * time.Hour
+
+// A RatingsSummary is the average rating for recent matches.
+type RatingsSummary struct {
+	Since   int64   `json:"since"`
+	Ratings int     `json:"ratings"`
+	Average float64 `json:"average"`
+}
+
+// Ratings summarizes the ratings for recent matches. Individual ratings are
+// left out so nobody can tell who rated whom.
+func (pl *PairingLogic) Ratings(ctx context.Context) (RatingsSummary, error) {
+	since := time.Now().Add(-ratingsAdminWindow)
+	matches, err := store.Pairings(pl.db).GetMatchesSince(ctx, since)
+	if err != nil {
+		return RatingsSummary{}, fmt.Errorf("get recent matches: %w", err)
+	}
+
+	average, count := store.AverageRating(matches)
+	return RatingsSummary{Since: since.Unix(), Ratings: count, Average: average}, nil
+}
+
+// VersionInfo describes the running instance of Pairing Bot.
+type VersionInfo struct {
+	Version       string `json:"version"`
+	GoVersion     string `json:"go_version"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+}
+
+// VersionInfo reports the deployed version and how long it's been running.
+func (pl *PairingLogic) VersionInfo(ctx context.Context) (VersionInfo, error) {
+	return VersionInfo{
+		Version:       pl.version,
+		GoVersion:     runtime.Version(),
+		UptimeSeconds: int64(time.Since(startTime).Seconds()),
+	}, nil
+}
+
+// SyncRC updates each Recurser's CurrentlyAtRC flag to match the Recurse API.
+//
+// Anyone who's left RC since the last sync is offboarded, like EndOfBatch
+// does. EndOfBatch only offboards people whose flag it sees flip itself, so
+// without this, someone whose stint ended mid-week would never be.
+func (pl *PairingLogic) SyncRC(ctx context.Context) error {
+	recursersList, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
+	if err != nil {
+		return fmt.Errorf("get list of recursers from DB: %w", err)
+	}
+
+	profiles, err := pl.recurse.ActiveRecursers(ctx)
+	if err != nil {
+		return fmt.Errorf("get active Recursers: %w", err)
+	}
+
+	for _, recurser := range updateCurrentlyAtRC(recursersList, profiles) {
+		recLog := logger(ctx).With(slog.Int64("recurserId", recurser.ID))
+		recLog.Info("Updating currentlyAtRC", slog.Bool("currentlyAtRC", recurser.CurrentlyAtRC))
+
+		if !recurser.CurrentlyAtRC {
+			pl.offboard(ctx, &recurser)
+			continue
+		}
+
+		if err := store.Recursers(pl.db).Set(ctx, &recurser); err != nil {
+			recLog.Error("Could not update currentlyAtRC", slog.Any("error", err))
 		}
 	}
 
 	return nil
 }
 
+// updateCurrentlyAtRC sets CurrentlyAtRC for each Recurser based on whether
+// they have an active profile. It returns only the Recursers whose flag
+// changed. Recursers in other realms are left alone, since the Recurse API
+// only has Zulip IDs from RC's own.
+func updateCurrentlyAtRC(recursers []store.Recurser, active []recurse.Profile) []store.Recurser {
+	atRC := make(map[int64]bool)
+	for _, p := range active {
+		atRC[p.ZulipID] = true
+	}
+
+	var changed []store.Recurser
+	for _, recurser := range recursers {
+		if recurser.Realm != store.DefaultRealm || recurser.CurrentlyAtRC == atRC[recurser.ID] {
+			continue
+		}
+
+		recurser.CurrentlyAtRC = atRC[recurser.ID]
+		changed = append(changed, recurser)
+	}
+	return changed
+}
+
+// broadcast sends the message to each Recurser individually. A failed send
+// doesn't stop the rest, so this returns how many were sent along with every
+// error that happened.
+func (pl *PairingLogic) broadcast(ctx context.Context, recursers []store.Recurser, message string) (int, error) {
+	var sent int
+	var errs []error
+	for _, rec := range recursers {
+		if err := pl.zulipFor(rec.Realm).SendUserMessage(ctx, []int64{rec.ID}, message); err != nil {
+			errs = append(errs, fmt.Errorf("send to %d: %w", rec.ID, err))
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+// setBatchIDs fills in each Recurser's BatchID from their active profile.
+// Recursers without one (such as alumni, or anyone in another realm) are left
+// with a zero BatchID.
+func setBatchIDs(recursers []store.Recurser, active []recurse.Profile) {
+	batches := make(map[int64]int64)
+	for _, p := range active {
+		batches[p.ZulipID] = p.CurrentBatchID()
+	}
+
+	for i := range recursers {
+		if recursers[i].Realm != store.DefaultRealm {
+			recursers[i].BatchID = 0
+			continue
+		}
+		recursers[i].BatchID = batches[recursers[i].ID]
+	}
+}
+
 // Checkin posts a message to Pairing Bot's checkin topic.
 func (pl *PairingLogic) Checkin(ctx context.Context) error {
 	numPairings, err := store.Pairings(pl.db).GetTotalPairingsDuringLastWeek(ctx)
 	if err != nil {
-		log.Println("Unable to get the total number of pairings during the last week: : ", err)
+		logger(ctx).Error("Could not get the total number of pairings during the last week", slog.Any("error", err))
 	}
 
-	recursersList, err := store.Recursers(pl.db).GetAllUsers(ctx)
+	recursersList, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
 	if err != nil {
-		log.Printf("Could not get list of recursers from DB: %s\n", err)
+		logger(ctx).Error("Could not get list of recursers from DB", slog.Any("error", err))
 	}
 
 	review, err := store.Reviews(pl.db).GetRandom(ctx)
 	if err != nil {
-		log.Println("Could not get a random review from DB: ", err)
+		logger(ctx).Error("Could not get a random review from DB", slog.Any("error", err))
 	}
 
 	checkinMessage, err := renderCheckin(time.Now(), numPairings, len(recursersList), review.Content)
@@ -321,18 +1218,9 @@ func (pl *PairingLogic) Welcome(ctx context.Context) error {
 		return fmt.Errorf("get list of batches: %w", err)
 	}
 
-	// Loop through the batches until we find the first non-mini batch. Mini
-	// batches are only 1 week long, so it doesn't make sense to send a message
-	// 1 week after a mini batch has started :joy:
-	var currentBatch recurse.Batch
-	for _, batch := range batches {
-		if batch.IsMini() {
-			continue
-		}
-
-		currentBatch = batch
-		break
-	}
+	// Mini batches are only 1 week long, so it doesn't make sense to send a
+	// message 1 week after a mini batch has started :joy:
+	currentBatch, _ := recurse.CurrentBatch(batches)
 
 	now := time.Now()
 	if currentBatch.IsSecondWeek(now) {
@@ -346,5 +1234,69 @@ func (pl *PairingLogic) Welcome(ctx context.Context) error {
 		}
 	}
 
+	return pl.welcomeNewArrivals(ctx, now)
+}
+
+// welcomeNewArrivals DMs everyone who started at RC since the last run to tell
+// them how to subscribe. People who've already subscribed are left alone.
+//
+// Everyone in a batch has the same start date, so remembering the latest one
+// is enough to welcome each of them once.
+func (pl *PairingLogic) welcomeNewArrivals(ctx context.Context, now time.Time) error {
+	since, err := store.Welcomes(pl.db).LastStart(ctx)
+	if err != nil {
+		return fmt.Errorf("get last welcome: %w", err)
+	}
+	// The first time around, don't welcome everyone who's already here.
+	if since.IsZero() {
+		since = now.AddDate(0, 0, -7)
+	}
+
+	arrivals, err := pl.recurse.RecentlyStarted(ctx, since)
+	if err != nil {
+		return fmt.Errorf("get new arrivals: %w", err)
+	}
+	if len(arrivals) == 0 {
+		return nil
+	}
+
+	subscribed, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
+	if err != nil {
+		return fmt.Errorf("get subscribed recursers: %w", err)
+	}
+	isSubscribed := make(map[int64]bool)
+	for _, rec := range subscribed {
+		isSubscribed[rec.ID] = true
+	}
+
+	lastStart := since
+	for _, profile := range arrivals {
+		stint, _ := profile.CurrentStint()
+		if start := time.Time(stint.StartDate); start.After(lastStart) {
+			lastStart = start
+		}
+
+		if isSubscribed[profile.ZulipID] {
+			continue
+		}
+
+		var batch string
+		if stint.Batch != nil {
+			batch = stint.Batch.Name
+		}
+		msg, err := renderOnboarding(profile.Name, batch)
+		if err != nil {
+			return fmt.Errorf("render onboarding message: %w", err)
+		}
+
+		if err := pl.zulip.SendUserMessage(ctx, []int64{profile.ZulipID}, msg); err != nil {
+			logger(ctx).Error("Could not send the onboarding message", slog.Int64("recurserId", profile.ZulipID), slog.Any("error", err))
+		}
+	}
+
+	if err := store.Welcomes(pl.db).SetLastStart(ctx, lastStart); err != nil {
+		return fmt.Errorf("record last welcome: %w", err)
+	}
+	logger(ctx).Info("Welcomed new arrivals", slog.Int("count", len(arrivals)))
 	return nil
 }
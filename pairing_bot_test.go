@@ -0,0 +1,593 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/recurse"
+	"github.com/recursecenter/pairing-bot/store"
+	"github.com/recursecenter/pairing-bot/zulip"
+)
+
+func Test_webhookErrorStatus(t *testing.T) {
+	for name, tc := range map[string]struct {
+		Body     string
+		Expected int
+	}{
+		"wrong token":   {`{"token": "wrong-token"}`, http.StatusUnauthorized},
+		"missing token": {`{"data": "status"}`, http.StatusUnauthorized},
+		"not JSON":      {`token=fake-zulip-token`, http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := zulip.ParseWebhook(strings.NewReader(tc.Body), "fake-zulip-token")
+			assert.Equal(t, webhookErrorStatus(err), tc.Expected)
+		})
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		_, err := zulip.ParseWebhook(strings.NewReader(`{"token": "fake-zulip-token"}`), "fake-zulip-token")
+		assert.NoError(t, err)
+	})
+}
+
+func Test_isMaintainer(t *testing.T) {
+	for key := range maintainers {
+		assert.Equal(t, isMaintainer(key.realm, key.id), true)
+		assert.Equal(t, isMaintainer("sister", key.id), false)
+	}
+	assert.Equal(t, isMaintainer(store.DefaultRealm, 1), false)
+}
+
+func TestPairingLogic_zulipFor(t *testing.T) {
+	rc := &zulip.Client{}
+	sister := &zulip.Client{}
+	pl := &PairingLogic{zulip: rc, realms: map[string]*zulip.Client{"sister": sister}}
+
+	if pl.zulipFor(store.DefaultRealm) != rc {
+		t.Error("expected RC's client for the default realm")
+	}
+	if pl.zulipFor("sister") != sister {
+		t.Error("expected the realm's own client")
+	}
+
+	// Nothing is sent to a realm without a client.
+	err := pl.zulipFor("unknown").SendUserMessage(context.Background(), []int64{1}, "hello")
+	if err == nil || !strings.Contains(err.Error(), `no Zulip client for realm "unknown"`) {
+		t.Errorf("expected a missing-client error, got %v", err)
+	}
+}
+
+func Test_updateCurrentlyAtRC(t *testing.T) {
+	recursers := []store.Recurser{
+		{ID: 1, Name: "Arriving", CurrentlyAtRC: false},
+		{ID: 2, Name: "Leaving", CurrentlyAtRC: true},
+		{ID: 3, Name: "Staying", CurrentlyAtRC: true},
+		{ID: 4, Name: "Alum", CurrentlyAtRC: false},
+		// Same Zulip ID as someone at RC, but in another realm.
+		{ID: 5, Name: "Elsewhere", CurrentlyAtRC: false, Realm: "sister"},
+	}
+
+	// The Recurse API only knows about people who are currently at RC.
+	active := []recurse.Profile{
+		{Name: "Arriving", ZulipID: 1},
+		{Name: "Staying", ZulipID: 3},
+		{Name: "Not Subscribed", ZulipID: 5},
+	}
+
+	changed := updateCurrentlyAtRC(recursers, active)
+
+	assert.Equal(t, changed, []store.Recurser{
+		{ID: 1, Name: "Arriving", CurrentlyAtRC: true},
+		{ID: 2, Name: "Leaving", CurrentlyAtRC: false},
+	})
+
+	// The originals are left alone.
+	assert.Equal(t, recursers[0].CurrentlyAtRC, false)
+}
+
+func TestPairingLogic_SyncRC(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+	fake := pbtest.NewFakeZulip(t)
+
+	// Ada just arrived, and Grace's stint ended in the middle of the week.
+	rc := &pbtest.FakeRecurse{
+		Active: []recurse.Profile{{Name: "Ada", ZulipID: 1}},
+	}
+	for _, rec := range []store.Recurser{
+		{ID: 1, Name: "Ada", IsSubscribed: true},
+		{ID: 2, Name: "Grace", IsSubscribed: true, CurrentlyAtRC: true},
+	} {
+		assert.NoError(t, store.Recursers(db).Set(ctx, &rec))
+	}
+
+	pl := &PairingLogic{db: db, zulip: fake.Client, recurse: rc}
+	assert.NoError(t, pl.SyncRC(ctx))
+
+	ada, err := store.Recursers(db).GetByUserID(ctx, store.DefaultRealm, 1, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, ada.IsSubscribed, true)
+	assert.Equal(t, ada.CurrentlyAtRC, true)
+	assert.Equal(t, len(fake.DMs("[1]")), 0)
+
+	// Grace is offboarded right away, instead of waiting for an end of batch
+	// that won't notice.
+	grace, err := store.Recursers(db).GetByUserID(ctx, store.DefaultRealm, 2, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, grace.IsSubscribed, false)
+	dms := fake.DMs("[2]")
+	assert.Equal(t, len(dms) > 0, true)
+	assert.Equal(t, dms[len(dms)-1], offboardedMessage)
+
+	// Nothing changes the second time.
+	assert.NoError(t, pl.SyncRC(ctx))
+	assert.Equal(t, len(fake.DMs("[2]")), len(dms))
+}
+
+func Test_setBatchIDs(t *testing.T) {
+	inBatch := func(id int64) []recurse.Stint {
+		return []recurse.Stint{{InProgress: true, Batch: &recurse.Batch{ID: id}}}
+	}
+
+	recursers := []store.Recurser{{ID: 1}, {ID: 2}, {ID: 3}}
+	active := []recurse.Profile{
+		{ZulipID: 1, Stints: inBatch(100)},
+		{ZulipID: 2, Stints: inBatch(200)},
+	}
+
+	setBatchIDs(recursers, active)
+
+	assert.Equal(t, recursers, []store.Recurser{
+		{ID: 1, BatchID: 100},
+		{ID: 2, BatchID: 200},
+		{ID: 3, BatchID: 0},
+	})
+}
+
+func TestPairingLogic_broadcast(t *testing.T) {
+	// Pretend that one of the sends fails.
+	fake := pbtest.NewFakeZulip(t)
+	fake.Status = func(msg pbtest.ZulipMessage) int {
+		if msg.To == "[2]" {
+			return http.StatusBadRequest
+		}
+		return 0
+	}
+
+	pl := &PairingLogic{zulip: fake.Client}
+	recursers := []store.Recurser{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	sent, err := pl.broadcast(context.Background(), recursers, "Down for maintenance")
+
+	// Everyone gets their own message, even after a failure.
+	var received []string
+	for _, msg := range fake.Messages() {
+		received = append(received, msg.To)
+	}
+	assert.Equal(t, received, []string{"[1]", "[2]", "[3]"})
+	assert.Equal(t, sent, 2)
+	if err == nil {
+		t.Error("expected an error for the failed send")
+	}
+}
+
+func TestPairingLogic_nextSeed(t *testing.T) {
+	// seeds returns the next few seeds from pl.
+	seeds := func(pl *PairingLogic) []int64 {
+		var all []int64
+		for range 5 {
+			all = append(all, pl.nextSeed())
+		}
+		return all
+	}
+
+	t.Run("fixed seeds give the same matches", func(t *testing.T) {
+		first := &PairingLogic{seeds: rand.New(rand.NewSource(1))}
+		second := &PairingLogic{seeds: rand.New(rand.NewSource(1))}
+
+		firstSeeds, secondSeeds := seeds(first), seeds(second)
+		assert.Equal(t, secondSeeds, firstSeeds)
+
+		recursers := fakeRecursers(10)
+		for i := range firstSeeds {
+			plan, err := matchRecursers(recursers, nil, nil, rand.New(rand.NewSource(firstSeeds[i])))
+			assert.NoError(t, err)
+			again, err := matchRecursers(recursers, nil, nil, rand.New(rand.NewSource(secondSeeds[i])))
+			assert.NoError(t, err)
+			assert.Equal(t, pairIDs(again.Groups), pairIDs(plan.Groups))
+		}
+	})
+
+	t.Run("different sources give different seeds", func(t *testing.T) {
+		first := &PairingLogic{seeds: rand.New(rand.NewSource(1))}
+		second := &PairingLogic{seeds: rand.New(rand.NewSource(2))}
+		assert.Equal(t, slices.Equal(seeds(first), seeds(second)), false)
+	})
+
+	t.Run("works without a source", func(t *testing.T) {
+		// Without a source, the seeds still change from run to run.
+		distinct := make(map[int64]bool)
+		for _, seed := range seeds(&PairingLogic{}) {
+			distinct[seed] = true
+		}
+		assert.Equal(t, len(distinct) > 1, true)
+	})
+}
+
+func TestPairingLogic_Match_onceADay(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+
+	fake := pbtest.NewFakeZulip(t)
+	pl := &PairingLogic{db: db, zulip: fake.Client, repeatWindowDays: 7, seeds: rand.New(rand.NewSource(1))}
+
+	everyDay := store.NewSchedule([]string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"})
+	for _, rec := range []store.Recurser{
+		{ID: 1, Name: "One", Schedule: everyDay},
+		{ID: 2, Name: "Two", Schedule: everyDay},
+	} {
+		if err := store.Recursers(db).Set(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The second run (like a repeated cron request) shouldn't do anything.
+	for range 2 {
+		if err := pl.Match(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matches, err := store.Pairings(db).GetMatchesSince(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(matches), 1)
+	assert.Equal(t, fake.Requests(), 1)
+}
+
+func TestPairingLogic_handle_repeatedMessage(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+
+	if _, err := db.Collection("secrets").Doc("zulip_webhook_token").Set(ctx, map[string]any{"value": "token"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pl := &PairingLogic{db: db}
+
+	// The same message, delivered twice.
+	body := `{
+		"data": "add-review so good",
+		"token": "token",
+		"trigger": "direct_message",
+		"message": {
+			"id": 12345,
+			"display_recipient": [{"id": 1}, {"id": 2}],
+			"sender_id": 1,
+			"sender_email": "ada@recurse.example.net",
+			"sender_full_name": "Ada"
+		}
+	}`
+	var responses []string
+	for range 2 {
+		w := httptest.NewRecorder()
+		pl.handle(w, httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body)))
+		responses = append(responses, w.Body.String())
+	}
+
+	reviews, err := store.Reviews(db).GetAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(reviews), 1)
+
+	// Only the first delivery gets a reply.
+	assert.Equal(t, strings.Contains(responses[0], `"content":""`), false)
+	assert.Equal(t, strings.Contains(responses[1], `"response_not_required":true`), true)
+}
+
+func TestPairingLogic_handle_missingSecret(t *testing.T) {
+	ctx := context.Background()
+
+	// Nobody has saved the webhook token, so no request can be checked.
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx)}
+
+	body := `{"data": "status", "token": "token", "trigger": "direct_message", "message": {"id": 1, "sender_id": 1}}`
+	w := httptest.NewRecorder()
+	pl.handle(w, httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body)))
+
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+	assert.Equal(t, w.Body.String(), "")
+}
+
+func TestPairingLogic_handle_lastActive(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+
+	if _, err := db.Collection("secrets").Doc("zulip_webhook_token").Set(ctx, map[string]any{"value": "token"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pl := &PairingLogic{db: db}
+
+	rec := &store.Recurser{ID: 1, Name: "Ada", Schedule: store.DefaultSchedule(), LastActive: 100}
+	assert.NoError(t, store.Recursers(db).Set(ctx, rec))
+
+	for _, command := range []string{"status", "set bio Engines"} {
+		before := time.Now().Unix()
+
+		body := `{
+			"data": "` + command + `",
+			"token": "token",
+			"trigger": "direct_message",
+			"message": {
+				"display_recipient": [{"id": 1}, {"id": 2}],
+				"sender_id": 1,
+				"sender_email": "ada@recurse.example.net",
+				"sender_full_name": "Ada"
+			}
+		}`
+		pl.handle(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body)))
+
+		// Commands that save the record don't undo it.
+		got, err := store.Recursers(db).GetByUserID(ctx, store.DefaultRealm, rec.ID, "", "")
+		assert.NoError(t, err)
+		if got.LastActive < before {
+			t.Errorf("%q didn't update LastActive: got %d, want at least %d", command, got.LastActive, before)
+		}
+	}
+}
+
+func Test_groupDMResponse(t *testing.T) {
+	for _, data := range []string{"skip tomorrow", "status", "Help"} {
+		assert.Equal(t, groupDMResponse(data), zulip.Reply(groupDMMessage))
+	}
+
+	// Partners talking to each other are left alone.
+	for _, data := range []string{"Hi! Want to pair at 2?", "next week works for me", "thanks"} {
+		assert.Equal(t, groupDMResponse(data), zulip.NoResponse())
+	}
+}
+
+func TestPairingLogic_handle_groupDM(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+
+	if _, err := db.Collection("secrets").Doc("zulip_webhook_token").Set(ctx, map[string]any{"value": "token"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pl := &PairingLogic{db: db}
+
+	body := `{
+		"data": "skip tomorrow",
+		"token": "token",
+		"trigger": "direct_message",
+		"message": {
+			"id": 12345,
+			"display_recipient": [{"id": 1}, {"id": 2}, {"id": 3}],
+			"sender_id": 1,
+			"sender_email": "ada@recurse.example.net",
+			"sender_full_name": "Ada"
+		}
+	}`
+	w := httptest.NewRecorder()
+	pl.handle(w, httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body)))
+
+	var response zulip.Response
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, response, zulip.Reply(groupDMMessage))
+}
+
+func TestPairingLogic_Welcome_newArrivals(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+
+	now := time.Now()
+	startedOn := func(t time.Time, batch *recurse.Batch) []recurse.Stint {
+		return []recurse.Stint{{InProgress: true, StartDate: recurse.Datestamp(t), Batch: batch}}
+	}
+
+	// Grace started long ago, and Alan already subscribed on their first day.
+	rc := &pbtest.FakeRecurse{
+		Active: []recurse.Profile{
+			{Name: "Ada", ZulipID: 1, Stints: startedOn(now.AddDate(0, 0, -2), &recurse.Batch{ID: 7, Name: "Summer 1, 2024"})},
+			{Name: "Grace", ZulipID: 2, Stints: startedOn(now.AddDate(0, 0, -60), nil)},
+			{Name: "Alan", ZulipID: 3, Stints: startedOn(now.AddDate(0, 0, -2), nil)},
+		},
+	}
+
+	fake := pbtest.NewFakeZulip(t)
+
+	if err := store.Recursers(db).Set(ctx, &store.Recurser{ID: 3, Name: "Alan", IsSubscribed: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	pl := &PairingLogic{db: db, zulip: fake.Client, recurse: rc}
+
+	// Running again (like next week's run) doesn't welcome anyone twice.
+	for range 2 {
+		assert.NoError(t, pl.Welcome(ctx))
+	}
+
+	assert.Equal(t, len(fake.Messages()), 1)
+	assert.Equal(t, len(fake.DMs("[1]")), 1)
+	assert.Equal(t, strings.HasPrefix(fake.DMs("[1]")[0], "Hi Ada, welcome to RC and the Summer 1, 2024 batch!"), true)
+}
+
+func TestPairingLogic_match_zulipDown(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+
+	fake := pbtest.NewFakeZulip(t)
+	fake.Status = func(pbtest.ZulipMessage) int { return http.StatusServiceUnavailable }
+
+	pl := &PairingLogic{db: db, zulip: fake.Client, repeatWindowDays: 7, seeds: rand.New(rand.NewSource(1))}
+
+	everyDay := store.NewSchedule([]string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"})
+	for _, rec := range []store.Recurser{
+		{ID: 1, Name: "One", Schedule: everyDay, IsSubscribed: true},
+		{ID: 2, Name: "Two", Schedule: everyDay, IsSubscribed: true},
+		{ID: 3, Name: "Three", Schedule: everyDay, IsSubscribed: true, IsSkippingTomorrow: true},
+	} {
+		if err := store.Recursers(db).Set(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	if err := pl.match(ctx, now); err == nil {
+		t.Fatal("expected the match run to fail")
+	}
+
+	// Only the check was sent, and nothing was recorded, so the run can be
+	// tried again from scratch.
+	assert.Equal(t, fake.Requests(), 1)
+
+	matches, err := store.Pairings(db).GetMatchesSince(ctx, now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, len(matches), 0)
+
+	pairings, err := store.Pairings(db).GetAllPairings(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(pairings), 0)
+
+	skippers, err := store.Recursers(db).ListSkippingTomorrow(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(skippers), 1)
+}
+
+func Test_matchFailures(t *testing.T) {
+	var failures matchFailures
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			failures.add([]int64{int64(i)}, "match", errors.New("oops"))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, len(failures.list), 100)
+}
+
+func TestPairingLogic_match_manyGroups(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+
+	// Stream messages are only sent in production.
+	t.Setenv("APP_ENV", "production")
+
+	// DMs to Recurser 7's group fail.
+	fake := pbtest.NewFakeZulip(t)
+	fake.Status = func(msg pbtest.ZulipMessage) int {
+		if msg.Type != "private" {
+			return 0
+		}
+		var ids []int64
+		if err := json.Unmarshal([]byte(msg.To), &ids); err != nil {
+			t.Error(err)
+		}
+		if slices.Contains(ids, 7) {
+			return http.StatusBadRequest
+		}
+		return 0
+	}
+
+	pl := &PairingLogic{db: db, zulip: fake.Client, adminStream: "admins", repeatWindowDays: 7, seeds: rand.New(rand.NewSource(1))}
+
+	const numRecursers = 40
+	everyDay := store.NewSchedule([]string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"})
+	for id := range int64(numRecursers) {
+		rec := store.Recurser{ID: id + 1, Name: fmt.Sprintf("Recurser %d", id+1), Schedule: everyDay}
+		if err := store.Recursers(db).Set(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	if err := pl.match(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every pair got exactly one message, including the one that failed.
+	received := make(map[string]int)
+	for _, msg := range fake.Messages() {
+		if msg.Type == "private" {
+			received[msg.To]++
+		}
+	}
+	assert.Equal(t, len(received), numRecursers/2)
+	for to, count := range received {
+		if count != 1 {
+			t.Errorf("%s got %d messages", to, count)
+		}
+	}
+
+	// Every match was recorded, and the failure was reported once.
+	matches, err := store.Pairings(db).GetMatchesSince(ctx, now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, len(matches), numRecursers/2)
+
+	posts := fake.Posts()
+	assert.Equal(t, len(posts), 1)
+	assert.Equal(t, strings.Count(posts[0].Content, "\n* "), 1)
+	assert.Equal(t, strings.Contains(posts[0].Content, "@_**|7**"), true)
+}
+
+func TestPairingLogic_match_reportsFailures(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+
+	// Stream messages are only sent in production.
+	t.Setenv("APP_ENV", "production")
+
+	// DMs to anyone in a group with Recurser 2 fail.
+	fake := pbtest.NewFakeZulip(t)
+	fake.Status = func(msg pbtest.ZulipMessage) int {
+		if msg.Type == "private" && strings.Contains(msg.To, "2") {
+			return http.StatusBadRequest
+		}
+		return 0
+	}
+
+	pl := &PairingLogic{db: db, zulip: fake.Client, adminStream: "admins", repeatWindowDays: 7, seeds: rand.New(rand.NewSource(1))}
+
+	everyDay := store.NewSchedule([]string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"})
+	for _, rec := range []store.Recurser{
+		{ID: 1, Name: "One", Schedule: everyDay},
+		{ID: 2, Name: "Two", Schedule: everyDay},
+		{ID: 3, Name: "Three", Schedule: everyDay},
+		{ID: 4, Name: "Four", Schedule: everyDay},
+	} {
+		if err := store.Recursers(db).Set(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := pl.match(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// One summary, listing only the group that couldn't be reached.
+	posts := fake.Posts()
+	assert.Equal(t, len(posts), 1)
+	assert.Equal(t, posts[0].To, "admins")
+	assert.Equal(t, strings.Count(posts[0].Content, "\n* "), 1)
+	assert.Equal(t, strings.Contains(posts[0].Content, "@_**|2**"), true)
+}
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var ErrUnknownCommand = errors.New("unknown command")
@@ -21,22 +23,164 @@ func parseCmd(cmdStr string) (string, []string, error) {
 	rest = strings.TrimSpace(rest)
 
 	switch name {
-	case "subscribe", "unsubscribe", "help", "status", "cookie":
+	case "help":
+		// Only the first word matters: "help skip tomorrow" is help for skip.
+		topic, _, _ := strings.Cut(rest, " ")
+		if topic == "" {
+			return name, nil, nil
+		}
+		return name, []string{strings.ToLower(topic)}, nil
+
+	case "subscribe", "unsubscribe", "restore", "status", "cookie", "resume", "topics", "stats", "leaderboard", "milestones", "history", "next", "dedupe", "noshow", "confirm", "decline", "theme", "blocks", "export", "availability", "aliases", "schedules":
 		if len(rest) > 0 {
 			return "help", nil, fmt.Errorf("%w: wanted no arguments", ErrInvalidArguments)
 		}
 		return name, nil, nil
 
+	case "maintenance", "blindintros":
+		switch mode := strings.ToLower(rest); mode {
+		case "":
+			return name, nil, nil
+		case "on", "off":
+			return name, []string{mode}, nil
+		}
+		return "help", nil, fmt.Errorf(`%w: wanted "on" or "off"`, ErrInvalidArguments)
+
+	case "minparticipants":
+		args := strings.Fields(strings.ToLower(rest))
+		if len(args) == 0 {
+			return name, nil, nil
+		}
+		if args[0] == "off" {
+			args[0] = "0"
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 || len(args) > 2 || (len(args) == 2 && args[1] != "announce") {
+			return "help", nil, fmt.Errorf(`%w: wanted a number of people, optionally followed by "announce"`, ErrInvalidArguments)
+		}
+		return name, args, nil
+
+	case "weekends":
+		args := strings.Fields(rest)
+		if len(args) == 0 {
+			return name, nil, nil
+		}
+		mode := strings.ToLower(args[0])
+		if (mode != "on" && mode != "off") || len(args) > 2 || (mode == "on" && len(args) > 1) {
+			return "help", nil, fmt.Errorf(`%w: wanted "on", or "off" and optionally a time zone`, ErrInvalidArguments)
+		}
+		if len(args) == 1 {
+			return name, []string{mode}, nil
+		}
+		if _, err := time.LoadLocation(args[1]); err != nil || args[1] == "Local" {
+			return "help", nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidArguments, args[1])
+		}
+		return name, []string{mode, args[1]}, nil
+
+	case "holidays":
+		// "holidays add <date> [name...] [announce]", "holidays remove
+		// <date>", or "holidays timezone <zone>". The name keeps its case.
+		args := strings.Fields(rest)
+		if len(args) == 0 {
+			return name, nil, nil
+		}
+		action := strings.ToLower(args[0])
+		if action == "timezone" {
+			if len(args) != 2 {
+				return "help", nil, fmt.Errorf(`%w: wanted "timezone" and a time zone`, ErrInvalidArguments)
+			}
+			if _, err := time.LoadLocation(args[1]); err != nil || args[1] == "Local" {
+				return "help", nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidArguments, args[1])
+			}
+			return name, []string{action, args[1]}, nil
+		}
+		if len(args) < 2 || (action != "add" && action != "remove") || (action == "remove" && len(args) > 2) {
+			return "help", nil, fmt.Errorf(`%w: wanted "add" or "remove" and a date, or "timezone" and a time zone`, ErrInvalidArguments)
+		}
+		if _, err := time.Parse(time.DateOnly, args[1]); err != nil {
+			return "help", nil, fmt.Errorf("%w: wanted a date like 2024-12-25, got %q", ErrInvalidArguments, args[1])
+		}
+		if action == "remove" {
+			return name, []string{action, args[1]}, nil
+		}
+		holiday, announce := args[2:], ""
+		if len(holiday) > 0 && strings.EqualFold(holiday[len(holiday)-1], "announce") {
+			holiday, announce = holiday[:len(holiday)-1], "announce"
+		}
+		return name, []string{action, args[1], strings.Join(holiday, " "), announce}, nil
+
+	case "dailypost":
+		switch strings.ToLower(rest) {
+		case "":
+			return name, nil, nil
+		case "off":
+			return name, []string{"off"}, nil
+		}
+		stream, topic, err := parseStreamTopic(rest)
+		if err != nil {
+			return "help", nil, err
+		}
+		return name, []string{stream, topic}, nil
+
+	case "trends":
+		if rest == "" {
+			return name, []string{strconv.Itoa(defaultTrendsDays)}, nil
+		}
+		days, err := strconv.Atoi(rest)
+		if err != nil || days < 1 || days > maxTrendsDays {
+			return "help", nil, fmt.Errorf("%w: wanted a number of days from 1 to %d", ErrInvalidArguments, maxTrendsDays)
+		}
+		return name, []string{rest}, nil
+
+	case "fun":
+		action, rest, _ := strings.Cut(rest, " ")
+		keyword, response, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		action, keyword, response = strings.ToLower(action), strings.ToLower(keyword), strings.TrimSpace(response)
+		switch {
+		case action == "":
+			return name, nil, nil
+		case action == "add" && keyword != "" && response != "":
+			return name, []string{action, keyword, response}, nil
+		case action == "remove" && keyword != "":
+			return name, []string{action, keyword, response}, nil
+		}
+		return "help", nil, fmt.Errorf(`%w: wanted "add" and a keyword and response, or "remove" and a keyword`, ErrInvalidArguments)
+
+	case "roster":
+		day, err := parseDay(rest)
+		if err != nil {
+			return "help", nil, fmt.Errorf("%w: %w", ErrInvalidArguments, err)
+		}
+		return name, []string{day}, nil
+
 	case "version":
 		// Ignore any extra arguments.
 		return name, nil, nil
 
 	case "add-review":
+		args, err := parseReview(rest)
+		if err != nil {
+			return "help", nil, err
+		}
+		return name, args, nil
+
+	case "bug":
 		if rest == "" {
-			return "help", nil, fmt.Errorf(`%w: wanted review content`, ErrInvalidArguments)
+			return "help", nil, fmt.Errorf(`%w: wanted a description of the bug`, ErrInvalidArguments)
 		}
 		return name, []string{rest}, nil
 
+	case "anonymous":
+		kind, review, _ := strings.Cut(rest, " ")
+		if strings.ToLower(kind) != "review" {
+			return "help", nil, fmt.Errorf(`%w: wanted "review"`, ErrInvalidArguments)
+		}
+		args, err := parseReview(strings.TrimSpace(review))
+		if err != nil {
+			return "help", nil, err
+		}
+		return "anonymous-review", args, nil
+
 	case "get-reviews":
 		args := strings.Fields(rest)
 		switch len(args) {
@@ -52,31 +196,282 @@ func parseCmd(cmdStr string) (string, []string, error) {
 			return "help", nil, fmt.Errorf(`%w: wanted a positive integer`, ErrInvalidArguments)
 		}
 
-	case "schedule":
+	case "reviews":
+		switch page := strings.ToLower(rest); page {
+		case "":
+			return name, nil, nil
+		case "next":
+			return name, []string{page}, nil
+		}
+		return "help", nil, fmt.Errorf(`%w: wanted "next" or nothing`, ErrInvalidArguments)
+
+	case "pause":
 		args := strings.Fields(rest)
+		switch len(args) {
+		case 0:
+			return name, nil, nil
+		case 1:
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				return "help", nil, fmt.Errorf(`%w: wanted a positive number of weeks`, ErrInvalidArguments)
+			}
+			return name, args, nil
+		default:
+			return "help", nil, fmt.Errorf(`%w: wanted a positive number of weeks`, ErrInvalidArguments)
+		}
+
+	case "rate":
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < 1 || n > 5 {
+			return "help", nil, fmt.Errorf("%w: wanted a rating from 1 to 5", ErrInvalidArguments)
+		}
+		return name, []string{rest}, nil
+
+	case "schedule":
+		userSchedule, err := parseSchedule(strings.Fields(rest))
+		if err != nil {
+			return "help", nil, err
+		}
+		return "schedule", userSchedule, nil
+
+	case "thisweek":
+		args := strings.Fields(strings.ToLower(rest))
+		if len(args) == 1 && args[0] == "clear" {
+			return name, args, nil
+		}
+		if len(args) < 2 || (args[0] != "add" && args[0] != "remove") {
+			return "help", nil, fmt.Errorf(`%w: wanted "add" or "remove" and a list of days, or "clear"`, ErrInvalidArguments)
+		}
+
+		days, err := parseDays(args[1:])
+		if err != nil {
+			return "help", nil, err
+		}
+		return name, append([]string{args[0]}, days...), nil
+
+	case "mute", "unmute":
+		args := strings.Fields(strings.ToLower(rest))
 		if len(args) == 0 {
-			return "help", nil, fmt.Errorf("%w: wanted list of days", ErrInvalidArguments)
+			return "help", nil, fmt.Errorf("%w: wanted a list of days", ErrInvalidArguments)
+		}
+		days, err := parseDays(args)
+		if err != nil {
+			return "help", nil, err
 		}
+		return name, days, nil
 
-		var userSchedule []string
+	case "skip", "unskip":
+		// TODO(#49): Allow (un)skipping weekdays by name
+		if rest == "tomorrow" {
+			return name, []string{"tomorrow"}, nil
+		}
+		if _, err := time.Parse(time.DateOnly, rest); err == nil {
+			return name, []string{rest}, nil
+		}
+		if name == "unskip" && rest == "days" {
+			return name, []string{"days"}, nil
+		}
+		if name == "skip" {
+			if args := strings.Fields(rest); len(args) == 2 && (args[1] == "days" || args[1] == "day") {
+				n, err := strconv.Atoi(args[0])
+				if err != nil || n <= 0 || n > maxSkipDays {
+					return "help", nil, fmt.Errorf(`%w: wanted between 1 and %d days`, ErrInvalidArguments, maxSkipDays)
+				}
+				return name, []string{"days", args[0]}, nil
+			}
+		}
+		return "help", nil, fmt.Errorf(`%w: wanted "tomorrow", a date like 2024-03-14, or a number of days`, ErrInvalidArguments)
+	case "set":
+		setting, value, _ := strings.Cut(rest, " ")
+		setting = strings.ToLower(setting)
+		value = strings.TrimSpace(value)
 
-		for _, day := range args {
-			fullDayName, err := parseDay(day)
-			if err != nil {
-				return "help", nil, fmt.Errorf("%w: %w", ErrInvalidArguments, err)
+		switch setting {
+		case "timezone":
+			if value == "" || strings.ContainsAny(value, " \t\n") {
+				return "help", nil, fmt.Errorf("%w: wanted a time zone name", ErrInvalidArguments)
+			}
+			return name, []string{setting, value}, nil
+		case "bio":
+			if value == "" {
+				return "help", nil, fmt.Errorf("%w: wanted bio text", ErrInvalidArguments)
 			}
+			return name, []string{setting, value}, nil
+		case "theme":
+			if value == "" {
+				return "help", nil, fmt.Errorf("%w: wanted theme text", ErrInvalidArguments)
+			}
+			return name, []string{setting, value}, nil
+		case "matchtime":
+			if _, err := time.Parse("15:04", value); err != nil {
+				return "help", nil, fmt.Errorf("%w: wanted a 24-hour time like 09:00", ErrInvalidArguments)
+			}
+			return name, []string{setting, value}, nil
+		case "maxweekly":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return "help", nil, fmt.Errorf("%w: wanted a non-negative number of matches", ErrInvalidArguments)
+			}
+			return name, []string{setting, value}, nil
+		case "duration":
+			n, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(value), "m"))
+			if err != nil || n < minDuration || n > maxDuration {
+				return "help", nil, fmt.Errorf("%w: wanted a number of minutes between %d and %d", ErrInvalidArguments, minDuration, maxDuration)
+			}
+			return name, []string{setting, strconv.Itoa(n)}, nil
+		case "batchpref":
+			value = strings.ToLower(value)
+			if value != "same" && value != "cross" && value != "any" {
+				return "help", nil, fmt.Errorf(`%w: wanted "same", "cross", or "any"`, ErrInvalidArguments)
+			}
+			return name, []string{setting, value}, nil
+		case "mode":
+			value = strings.ReplaceAll(strings.ToLower(value), "-", "")
+			if value != "voice" && value != "text" && value != "inperson" && value != "any" {
+				return "help", nil, fmt.Errorf(`%w: wanted "voice", "text", "inperson", or "any"`, ErrInvalidArguments)
+			}
+			return name, []string{setting, value}, nil
+		case "verbosity":
+			value = strings.ToLower(value)
+			if value != "minimal" && value != "normal" && value != "full" {
+				return "help", nil, fmt.Errorf(`%w: wanted "minimal", "normal", or "full"`, ErrInvalidArguments)
+			}
+			return name, []string{setting, value}, nil
+		case "weekly-summary", "leaderboard", "discoverable", "email-fallback", "nudge", "history", "confirm", "mentor":
+			value = strings.ToLower(value)
+			if value != "on" && value != "off" {
+				return "help", nil, fmt.Errorf(`%w: wanted "on" or "off"`, ErrInvalidArguments)
+			}
+			return name, []string{setting, value}, nil
+		case "topics":
+			var topics []string
+			for _, topic := range strings.Split(value, ",") {
+				if topic = strings.TrimSpace(topic); topic != "" {
+					topics = append(topics, topic)
+				}
+			}
+			if len(topics) == 0 {
+				return "help", nil, fmt.Errorf("%w: wanted a comma-separated list of topics", ErrInvalidArguments)
+			}
+			return name, append([]string{setting}, topics...), nil
+		default:
+			return "help", nil, fmt.Errorf("%w: unknown setting %q", ErrInvalidArguments, setting)
+		}
 
-			userSchedule = append(userSchedule, fullDayName)
+	case "match":
+		if strings.ToLower(rest) != "now" {
+			return "help", nil, fmt.Errorf(`%w: wanted "now"`, ErrInvalidArguments)
 		}
+		return name, []string{"now"}, nil
 
-		return "schedule", userSchedule, nil
+	case "cancel":
+		if strings.ToLower(rest) != "match" {
+			return "help", nil, fmt.Errorf(`%w: wanted "match"`, ErrInvalidArguments)
+		}
+		return name, []string{"match"}, nil
 
-	case "skip", "unskip":
-		// TODO(#49): Allow (un)skipping days other than tomorrow
-		if rest != "tomorrow" {
-			return "help", nil, fmt.Errorf(`%w: wanted "tomorrow"`, ErrInvalidArguments)
+	case "clear":
+		setting := strings.ToLower(rest)
+		switch setting {
+		case "bio", "topics", "matchtime", "theme", "duration":
+			return name, []string{setting}, nil
+		default:
+			return "help", nil, fmt.Errorf("%w: unknown setting %q", ErrInvalidArguments, setting)
+		}
+
+	case "announce":
+		switch strings.ToLower(rest) {
+		case "":
+			return "help", nil, fmt.Errorf("%w: wanted announcement text", ErrInvalidArguments)
+		case "confirm", "cancel":
+			return name, []string{strings.ToLower(rest)}, nil
+		}
+		return name, []string{"draft", rest}, nil
+
+	case "reset":
+		switch strings.ToLower(rest) {
+		case "":
+			return name, nil, nil
+		case "confirm":
+			return name, []string{"confirm"}, nil
+		}
+		return "help", nil, fmt.Errorf(`%w: wanted nothing or "confirm"`, ErrInvalidArguments)
+
+	case "delete":
+		args := strings.Fields(strings.ToLower(rest))
+		if len(args) == 2 && args[0] == "schedule" {
+			return name, args, nil
+		}
+		if len(args) == 0 || args[0] != "me" {
+			return "help", nil, fmt.Errorf(`%w: wanted "me" or "schedule" and a name`, ErrInvalidArguments)
+		}
+		switch {
+		case len(args) == 1:
+			return name, args, nil
+		case len(args) == 2 && (args[1] == "matches" || args[1] == "confirm" || args[1] == "cancel"):
+			return name, args, nil
+		}
+		return "help", nil, fmt.Errorf(`%w: wanted "matches", "confirm", or "cancel" after "delete me"`, ErrInvalidArguments)
+
+	case "block", "unblock", "who", "met":
+		person, id, err := parseMention(rest)
+		if err != nil {
+			return "help", nil, err
+		}
+		if id == "" {
+			return name, []string{person}, nil
+		}
+		return name, []string{person, id}, nil
+
+	case "pair":
+		// "accept" and "decline" answer a request. Anything else is who to ask.
+		switch answer := strings.ToLower(strings.TrimSpace(rest)); answer {
+		case "accept", "decline":
+			return name, []string{answer}, nil
+		}
+		person, id, err := parseMention(rest)
+		if err != nil {
+			return "help", nil, err
 		}
-		return name, []string{"tomorrow"}, nil
+		if id == "" {
+			return name, []string{"ask", person}, nil
+		}
+		return name, []string{"ask", person, id}, nil
+
+	case "alias":
+		alias, expansion, ok := strings.Cut(rest, "=")
+		alias = strings.ToLower(strings.TrimSpace(alias))
+		expansion = strings.TrimSpace(expansion)
+		if !ok || alias == "" || expansion == "" || strings.ContainsAny(alias, " \t\n") {
+			return "help", nil, fmt.Errorf(`%w: wanted a one-word alias and a command, like "sk = skip tomorrow"`, ErrInvalidArguments)
+		}
+		return name, []string{alias, expansion}, nil
+
+	case "unalias":
+		alias := strings.ToLower(rest)
+		if alias == "" || strings.ContainsAny(alias, " \t\n") {
+			return "help", nil, fmt.Errorf("%w: wanted an alias", ErrInvalidArguments)
+		}
+		return name, []string{alias}, nil
+
+	case "save":
+		// "as" is optional: "save schedule as work" or "save schedule work".
+		args := strings.Fields(strings.ToLower(rest))
+		if len(args) == 3 && args[1] == "as" {
+			args = []string{args[0], args[2]}
+		}
+		if len(args) != 2 || args[0] != "schedule" || args[1] == "as" {
+			return "help", nil, fmt.Errorf(`%w: wanted "schedule as" and a one-word name`, ErrInvalidArguments)
+		}
+		return name, args[1:], nil
+
+	case "load":
+		args := strings.Fields(strings.ToLower(rest))
+		if len(args) != 2 || args[0] != "schedule" {
+			return "help", nil, fmt.Errorf(`%w: wanted "schedule" and the name of a saved schedule`, ErrInvalidArguments)
+		}
+		return name, args[1:], nil
+
 	case "thank", "thanks":
 		return "thanks", nil, nil
 	default:
@@ -84,6 +479,230 @@ func parseCmd(cmdStr string) (string, []string, error) {
 	}
 }
 
+// parseMention reads a person from a command, either as a Zulip mention (like
+// "@**Ada Lovelace**", or "@**Ada Lovelace|123**" when Zulip adds the user ID
+// to tell apart people with the same name) or as a plain name. It returns the
+// name and, if the mention had one, the ID.
+func parseMention(text string) (string, string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", "", fmt.Errorf("%w: wanted a person", ErrInvalidArguments)
+	}
+
+	// Silent mentions (with "_") work too, since Zulip suggests either.
+	mention := strings.TrimPrefix(strings.TrimPrefix(text, "@"), "_")
+	if mention != text && strings.HasPrefix(mention, "**") && strings.HasSuffix(mention, "**") && len(mention) > 4 {
+		name, id, hasID := strings.Cut(mention[2:len(mention)-2], "|")
+		if hasID {
+			if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+				return "", "", fmt.Errorf("%w: wanted a user ID in %q", ErrInvalidArguments, text)
+			}
+		}
+		return strings.TrimSpace(name), id, nil
+	}
+	return text, "", nil
+}
+
+// parseStreamTopic reads a stream and topic, written either as "stream >
+// topic" or as a Zulip topic link like "#**stream>topic**".
+func parseStreamTopic(text string) (string, string, error) {
+	if link, ok := strings.CutPrefix(text, "#**"); ok {
+		if link, ok := strings.CutSuffix(link, "**"); ok {
+			text = link
+		}
+	}
+
+	stream, topic, _ := strings.Cut(text, ">")
+	stream, topic = strings.TrimSpace(stream), strings.TrimSpace(topic)
+	if stream == "" || topic == "" {
+		return "", "", fmt.Errorf(`%w: wanted a stream and topic, like "pairing > daily matches"`, ErrInvalidArguments)
+	}
+	return stream, topic, nil
+}
+
+// reviewCategories are the tags that can start a review, like "#bug".
+var reviewCategories = []string{"praise", "idea", "bug"}
+
+// parseReview splits review text into its content and, if it starts with a
+// category tag (like "#bug"), the category. Anything else that starts with "#"
+// is just part of the review.
+func parseReview(review string) ([]string, error) {
+	tag, content, _ := strings.Cut(review, " ")
+	category := strings.ToLower(strings.TrimPrefix(tag, "#"))
+
+	if !strings.HasPrefix(tag, "#") || !slices.Contains(reviewCategories, category) {
+		content, category = review, ""
+	}
+
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, fmt.Errorf(`%w: wanted review content`, ErrInvalidArguments)
+	}
+
+	if category == "" {
+		return []string{content}, nil
+	}
+	return []string{content, category}, nil
+}
+
+// scheduleShortcuts are words that stand for several days in a schedule.
+var scheduleShortcuts = map[string][]string{
+	"weekdays": {"monday", "tuesday", "wednesday", "thursday", "friday"},
+	"weekends": {"saturday", "sunday"},
+	"everyday": {"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"},
+}
+
+// parseSchedule turns the words of a `schedule` command into the list of days
+// for store.NewSchedule, in the order they were first mentioned and without
+// duplicates. Days covering only half a day get an "-am" or "-pm" suffix.
+//
+// Days with a "-biweekly" suffix (like "wed-biweekly" or "fri-pm-biweekly")
+// are only every other week. They're listed again after a "biweekly" marker,
+// for store.Recurser.SetBiweekly.
+//
+// Words after "except" are removed from the days before it, so "weekdays
+// except wed" is every weekday but Wednesday, and "mon except mon-pm" is
+// Monday morning.
+func parseSchedule(args []string) ([]string, error) {
+	include, exclude := args, []string(nil)
+	if i := slices.IndexFunc(args, func(arg string) bool { return strings.EqualFold(arg, "except") }); i >= 0 {
+		include, exclude = args[:i], args[i+1:]
+		if len(exclude) == 0 {
+			return nil, fmt.Errorf(`%w: wanted days after "except"`, ErrInvalidArguments)
+		}
+	}
+	if len(include) == 0 {
+		return nil, fmt.Errorf("%w: wanted list of days", ErrInvalidArguments)
+	}
+
+	// Track which halves of each day are included.
+	var order []string
+	halves := make(map[string][]string)
+	biweekly := make(map[string]bool)
+	for _, word := range include {
+		days, segments, isBiweekly, err := parseScheduleWord(word)
+		if err != nil {
+			return nil, &ScheduleWordError{Word: word, Err: err}
+		}
+		for _, day := range days {
+			biweekly[day] = biweekly[day] || isBiweekly
+			if _, ok := halves[day]; !ok {
+				order = append(order, day)
+			}
+			for _, segment := range segments {
+				if !slices.Contains(halves[day], segment) {
+					halves[day] = append(halves[day], segment)
+				}
+			}
+		}
+	}
+
+	for _, word := range exclude {
+		days, segments, _, err := parseScheduleWord(word)
+		if err != nil {
+			return nil, &ScheduleWordError{Word: word, Err: err}
+		}
+		for _, day := range days {
+			halves[day] = slices.DeleteFunc(halves[day], func(s string) bool { return slices.Contains(segments, s) })
+		}
+	}
+
+	var schedule, biweeklyDays []string
+	for _, day := range order {
+		switch len(halves[day]) {
+		case 0:
+			// Removed by the except clause.
+			continue
+		case 1:
+			schedule = append(schedule, day+"-"+halves[day][0])
+		default:
+			schedule = append(schedule, day)
+		}
+		if biweekly[day] {
+			biweeklyDays = append(biweeklyDays, day)
+		}
+	}
+	if len(schedule) == 0 {
+		return nil, fmt.Errorf(`%w: "except" removed every day`, ErrInvalidArguments)
+	}
+	if len(biweeklyDays) > 0 {
+		schedule = append(append(schedule, "biweekly"), biweeklyDays...)
+	}
+	return schedule, nil
+}
+
+// A ScheduleWordError is a word in a schedule that isn't a day, a shortcut
+// like "weekdays", or one of those with a suffix like "-am". Any one of them
+// makes the whole schedule invalid.
+type ScheduleWordError struct {
+	Word string
+	Err  error
+}
+
+func (e *ScheduleWordError) Error() string { return e.Err.Error() }
+func (e *ScheduleWordError) Unwrap() error { return e.Err }
+
+// Reply tells the Recurser which word didn't make sense, and what a schedule
+// can have instead.
+func (e *ScheduleWordError) Reply() string {
+	return fmt.Sprintf("I didn't understand `%s`, so I left your schedule as it was. Schedules can have:\n", e.Word) +
+		"* Days, like `mon` or `monday`\n" +
+		"* `weekdays`, `weekends`, or `everyday`\n" +
+		"* Any of those with `-am`, `-pm`, or `-biweekly` on the end, like `fri-pm`\n" +
+		"* `except` and the days to leave out, like `weekdays except wed`\n\n" +
+		"Send `help schedule` for more examples."
+}
+
+// parseScheduleWord parses a single word of a schedule, like "mon", "fri-pm",
+// or "weekdays", into the days and half-day segments ("am" and "pm") it covers,
+// and whether it ended in "-biweekly".
+func parseScheduleWord(word string) ([]string, []string, bool, error) {
+	word, biweekly := strings.CutSuffix(strings.ToLower(word), "-biweekly")
+	name, segment, _ := strings.Cut(word, "-")
+
+	segments := []string{"am", "pm"}
+	switch segment = strings.ToLower(segment); segment {
+	case "":
+	case "am", "pm":
+		segments = []string{segment}
+	default:
+		return nil, nil, false, fmt.Errorf(`%w: wanted "am", "pm", or "biweekly" after %q, got %q`, ErrInvalidArguments, name, segment)
+	}
+
+	if days, ok := scheduleShortcuts[name]; ok {
+		return days, segments, biweekly, nil
+	}
+
+	day, err := parseDay(name)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("%w: %w", ErrInvalidArguments, err)
+	}
+	return []string{day}, segments, biweekly, nil
+}
+
+// parseDays turns lowercase words into whole days, in the order they were first
+// mentioned and without duplicates. Words can be days or scheduleShortcuts,
+// but not the half days or "except" of a `schedule`.
+func parseDays(words []string) ([]string, error) {
+	var days []string
+	for _, word := range words {
+		expanded, ok := scheduleShortcuts[word]
+		if !ok {
+			day, err := parseDay(word)
+			if err != nil {
+				return nil, &ScheduleWordError{Word: word, Err: fmt.Errorf("%w: %w", ErrInvalidArguments, err)}
+			}
+			expanded = []string{day}
+		}
+		for _, day := range expanded {
+			if !slices.Contains(days, day) {
+				days = append(days, day)
+			}
+		}
+	}
+	return days, nil
+}
+
 var ErrUnknownDay = errors.New("unknown day abbreviation")
 
 // parseDay expands day name abbreviations into their canonical form.
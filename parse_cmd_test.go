@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/recursecenter/pairing-bot/internal/assert"
@@ -14,11 +16,23 @@ type parseResult struct {
 var acceptedCommands = map[string]parseResult{
 	"subscribe":   {"subscribe", nil},
 	"unsubscribe": {"unsubscribe", nil},
+	"restore":     {"restore", nil},
 	"help":        {"help", nil},
 	"status":      {"status", nil},
 	"get-reviews": {"get-reviews", nil},
 	"cookie":      {"cookie", nil},
 	"version":     {"version", nil},
+	"pause":       {"pause", nil},
+	"stats":       {"stats", nil},
+	"leaderboard": {"leaderboard", nil},
+	"milestones":  {"milestones", nil},
+	"history":     {"history", nil},
+	"next":        {"next", nil},
+	"dedupe":      {"dedupe", nil},
+	"noshow":      {"noshow", nil},
+	"confirm":     {"confirm", nil},
+	"decline":     {"decline", nil},
+	"resume":      {"resume", nil},
 
 	// This command ignores its arguments.
 	"version info": {"version", nil},
@@ -27,8 +41,26 @@ var acceptedCommands = map[string]parseResult{
 	"skip tomorrow":   {"skip", []string{"tomorrow"}},
 	"unskip tomorrow": {"unskip", []string{"tomorrow"}},
 
+	// Or a specific date.
+	"skip 2024-03-14":   {"skip", []string{"2024-03-14"}},
+	"unskip 2024-03-14": {"unskip", []string{"2024-03-14"}},
+	"skip 3 days":       {"skip", []string{"days", "3"}},
+	"rate 5":            {"rate", []string{"5"}},
+	"rate  1":           {"rate", []string{"1"}},
+	"skip 1 day":        {"skip", []string{"days", "1"}},
+	"unskip days":       {"unskip", []string{"days"}},
+
 	// Schedules!
-	"schedule monday":         {"schedule", []string{"monday"}},
+	"schedule monday": {"schedule", []string{"monday"}},
+
+	"thisweek add thu":           {"thisweek", []string{"add", "thursday"}},
+	"thisweek REMOVE mon Monday": {"thisweek", []string{"remove", "monday"}},
+	"thisweek add weekends fri":  {"thisweek", []string{"add", "saturday", "sunday", "friday"}},
+	"thisweek clear":             {"thisweek", []string{"clear"}},
+	"mute wed":                   {"mute", []string{"wednesday"}},
+	"mute weekends Sat":          {"mute", []string{"saturday", "sunday"}},
+	"unmute Wednesday":           {"unmute", []string{"wednesday"}},
+
 	"schedule sunday":         {"schedule", []string{"sunday"}},
 	"schedule friday tuesday": {"schedule", []string{"friday", "tuesday"}},
 	"schedule mon tue wed thu fri sat sun": {
@@ -52,9 +84,168 @@ var acceptedCommands = map[string]parseResult{
 	// Day names (as keywords) are also case-insensitive
 	"schedule MoN WED fRi": {"schedule", []string{"monday", "wednesday", "friday"}},
 
+	// Shortcuts for groups of days, which can leave some out.
+	"schedule weekdays":                  {"schedule", []string{"monday", "tuesday", "wednesday", "thursday", "friday"}},
+	"schedule Weekends":                  {"schedule", []string{"saturday", "sunday"}},
+	"schedule everyday":                  {"schedule", []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}},
+	"schedule weekdays except wednesday": {"schedule", []string{"monday", "tuesday", "thursday", "friday"}},
+	"schedule everyday EXCEPT weekends":  {"schedule", []string{"monday", "tuesday", "wednesday", "thursday", "friday"}},
+	"schedule sun weekends mon":          {"schedule", []string{"sunday", "saturday", "monday"}},
+	"schedule weekdays-am except fri":    {"schedule", []string{"monday-am", "tuesday-am", "wednesday-am", "thursday-am"}},
+	"schedule mon wed except mon-pm":     {"schedule", []string{"monday-am", "wednesday"}},
+	"schedule mon-am mon-pm":             {"schedule", []string{"monday"}},
+	"schedule mon wed-biweekly":          {"schedule", []string{"monday", "wednesday", "biweekly", "wednesday"}},
+	"schedule fri-PM-Biweekly":           {"schedule", []string{"friday-pm", "biweekly", "friday"}},
+	"schedule weekends-biweekly":         {"schedule", []string{"saturday", "sunday", "biweekly", "saturday", "sunday"}},
+
+	// Days can be limited to the morning or afternoon.
+	"schedule mon-am wed FRI-PM": {"schedule", []string{"monday-am", "wednesday", "friday-pm"}},
+
 	// Review content *is* case-sensitive.
 	"add-review   I :heart: Pairing Bot!\n": {"add-review", []string{"I :heart: Pairing Bot!"}},
 
+	// Reviews can start with a category tag, and can be anonymous.
+	"add-review #bug It matched me with myself": {"add-review", []string{"It matched me with myself", "bug"}},
+	"add-review #Praise so good":                {"add-review", []string{"so good", "praise"}},
+	"add-review #1 bot ever":                    {"add-review", []string{"#1 bot ever"}},
+	"anonymous review I :heart: Pairing Bot!":   {"anonymous-review", []string{"I :heart: Pairing Bot!"}},
+	"Anonymous Review #idea more cookies":       {"anonymous-review", []string{"more cookies", "idea"}},
+	"Bug It matched me with myself":             {"bug", []string{"It matched me with myself"}},
+
+	// Announcements are drafted and then confirmed (or canceled).
+	"announce Down for maintenance at 10:00": {"announce", []string{"draft", "Down for maintenance at 10:00"}},
+	"announce confirm":                       {"announce", []string{"confirm"}},
+	"maintenance":                            {"maintenance", nil},
+	"maintenance ON":                         {"maintenance", []string{"on"}},
+	"maintenance off":                        {"maintenance", []string{"off"}},
+	"blindintros":                            {"blindintros", nil},
+	"blindintros On":                         {"blindintros", []string{"on"}},
+	"roster Monday":                          {"roster", []string{"monday"}},
+	"roster thu":                             {"roster", []string{"thursday"}},
+	"availability":                           {"availability", nil},
+	"minparticipants":                        {"minparticipants", nil},
+	"minparticipants 4":                      {"minparticipants", []string{"4"}},
+	"minparticipants 4 Announce":             {"minparticipants", []string{"4", "announce"}},
+	"minparticipants off":                    {"minparticipants", []string{"0"}},
+	"announce Cancel":                        {"announce", []string{"cancel"}},
+	"weekends":                               {"weekends", nil},
+	"weekends On":                            {"weekends", []string{"on"}},
+	"weekends off":                           {"weekends", []string{"off"}},
+	"weekends off America/New_York":          {"weekends", []string{"off", "America/New_York"}},
+	"holidays":                               {"holidays", nil},
+	"holidays add 2024-12-25":                {"holidays", []string{"add", "2024-12-25", "", ""}},
+	"holidays add 2024-12-25 Boxing Day":     {"holidays", []string{"add", "2024-12-25", "Boxing Day", ""}},
+	"holidays Add 2024-12-25 Yule ANNOUNCE":  {"holidays", []string{"add", "2024-12-25", "Yule", "announce"}},
+	"holidays remove 2024-12-25":             {"holidays", []string{"remove", "2024-12-25"}},
+	"holidays Timezone America/New_York":     {"holidays", []string{"timezone", "America/New_York"}},
+	"dailypost":                              {"dailypost", nil},
+	"dailypost Off":                          {"dailypost", []string{"off"}},
+	"dailypost pairing > daily matches":      {"dailypost", []string{"pairing", "daily matches"}},
+	"dailypost #**pairing>daily matches**":   {"dailypost", []string{"pairing", "daily matches"}},
+	"trends":                                 {"trends", []string{"7"}},
+	"trends 30":                              {"trends", []string{"30"}},
+	"reviews":                                {"reviews", nil},
+	"reviews Next":                           {"reviews", []string{"next"}},
+	"fun":                                    {"fun", nil},
+	"fun add Encourage You've got this!":     {"fun", []string{"add", "encourage", "You've got this!"}},
+	"fun remove encourage You've got this!":  {"fun", []string{"remove", "encourage", "You've got this!"}},
+	"fun remove encourage":                   {"fun", []string{"remove", "encourage", ""}},
+
+	// Help takes an optional command name.
+	"help skip":          {"help", []string{"skip"}},
+	"help Set":           {"help", []string{"set"}},
+	"help skip tomorrow": {"help", []string{"skip"}},
+	"help me":            {"help", []string{"me"}},
+
+	"pause 1": {"pause", []string{"1"}},
+	"pause 3": {"pause", []string{"3"}},
+
+	// Settings take a name and a value.
+	"set timezone Europe/Berlin":  {"set", []string{"timezone", "Europe/Berlin"}},
+	"SET TimeZone America/Denver": {"set", []string{"timezone", "America/Denver"}},
+
+	// Bios keep their spacing and case.
+	"set bio I'm writing a  Ray Tracer!": {"set", []string{"bio", "I'm writing a  Ray Tracer!"}},
+	"clear bio":                          {"clear", []string{"bio"}},
+
+	// On-demand matching
+	"match now":    {"match", []string{"now"}},
+	"Match NOW":    {"match", []string{"now"}},
+	"cancel match": {"cancel", []string{"match"}},
+
+	// Topics are split on commas, but normalized later.
+	"set topics go, Rust,,distributed systems ": {"set", []string{"topics", "go", "Rust", "distributed systems"}},
+	"topics": {"topics", nil},
+
+	"theme":                           {"theme", nil},
+	"set theme Debugging war stories": {"set", []string{"theme", "Debugging war stories"}},
+	"clear theme":                     {"clear", []string{"theme"}},
+
+	// People can be mentioned or named.
+	"block @**Ada Lovelace**":     {"block", []string{"Ada Lovelace"}},
+	"block @_**Ada Lovelace**":    {"block", []string{"Ada Lovelace"}},
+	"block @**Ada Lovelace|123**": {"block", []string{"Ada Lovelace", "123"}},
+	"unblock Ada Lovelace":        {"unblock", []string{"Ada Lovelace"}},
+	"blocks":                      {"blocks", nil},
+	"who @**Ada Lovelace|123**":   {"who", []string{"Ada Lovelace", "123"}},
+	"who Ada Lovelace":            {"who", []string{"Ada Lovelace"}},
+	"met @**Ada Lovelace|123**":   {"met", []string{"Ada Lovelace", "123"}},
+	"met Ada Lovelace":            {"met", []string{"Ada Lovelace"}},
+	"pair @**Ada Lovelace|123**":  {"pair", []string{"ask", "Ada Lovelace", "123"}},
+	"pair Ada Lovelace":           {"pair", []string{"ask", "Ada Lovelace"}},
+	"pair accept":                 {"pair", []string{"accept"}},
+	"pair Decline":                {"pair", []string{"decline"}},
+
+	// Aliases keep the command's case, since parseCmd sees it again later.
+	"alias sk = skip tomorrow": {"alias", []string{"sk", "skip tomorrow"}},
+	"alias SK=skip tomorrow":   {"alias", []string{"sk", "skip tomorrow"}},
+	"alias b = set bio Hi":     {"alias", []string{"b", "set bio Hi"}},
+	"unalias sk":               {"unalias", []string{"sk"}},
+	"aliases":                  {"aliases", nil},
+
+	"save schedule as work": {"save", []string{"work"}},
+	"Save Schedule Work":    {"save", []string{"work"}},
+	"load schedule work":    {"load", []string{"work"}},
+	"schedules":             {"schedules", nil},
+	"delete schedule work":  {"delete", []string{"schedule", "work"}},
+
+	"export":            {"export", nil},
+	"delete me":         {"delete", []string{"me"}},
+	"Delete Me Matches": {"delete", []string{"me", "matches"}},
+	"delete me confirm": {"delete", []string{"me", "confirm"}},
+	"delete me cancel":  {"delete", []string{"me", "cancel"}},
+	"reset":             {"reset", nil},
+	"Reset Confirm":     {"reset", []string{"confirm"}},
+
+	"set weekly-summary off": {"set", []string{"weekly-summary", "off"}},
+	"set weekly-summary ON":  {"set", []string{"weekly-summary", "on"}},
+	"set history on":         {"set", []string{"history", "on"}},
+	"set history off":        {"set", []string{"history", "off"}},
+	"set confirm on":         {"set", []string{"confirm", "on"}},
+	"set mentor off":         {"set", []string{"mentor", "off"}},
+	"set leaderboard on":     {"set", []string{"leaderboard", "on"}},
+	"set discoverable off":   {"set", []string{"discoverable", "off"}},
+	"set email-fallback on":  {"set", []string{"email-fallback", "on"}},
+	"set nudge ON":           {"set", []string{"nudge", "on"}},
+	"set matchtime 09:00":    {"set", []string{"matchtime", "09:00"}},
+	"set matchtime 17:30":    {"set", []string{"matchtime", "17:30"}},
+	"clear matchtime":        {"clear", []string{"matchtime"}},
+	"set maxweekly 3":        {"set", []string{"maxweekly", "3"}},
+	"set maxweekly 0":        {"set", []string{"maxweekly", "0"}},
+	"set batchpref cross":    {"set", []string{"batchpref", "cross"}},
+	"set batchpref Same":     {"set", []string{"batchpref", "same"}},
+	"set batchpref any":      {"set", []string{"batchpref", "any"}},
+	"set mode voice":         {"set", []string{"mode", "voice"}},
+	"set mode In-Person":     {"set", []string{"mode", "inperson"}},
+	"set mode any":           {"set", []string{"mode", "any"}},
+	"set verbosity Minimal":  {"set", []string{"verbosity", "minimal"}},
+	"set verbosity full":     {"set", []string{"verbosity", "full"}},
+	"set duration 60":        {"set", []string{"duration", "60"}},
+	"set duration 45M":       {"set", []string{"duration", "45"}},
+	"clear duration":         {"clear", []string{"duration"}},
+	"clear topics":           {"clear", []string{"topics"}},
+	"CLEAR BIO":              {"clear", []string{"bio"}},
+
 	// We appreciate being appreciated
 	"thanks":    {"thanks", nil},
 	"thank you": {"thanks", nil},
@@ -78,34 +269,179 @@ var rejectedCommands = map[string]error{
 	"": ErrUnknownCommand,
 
 	// Funnily enough: nil, these *do* give you what you want!
-	"help me":       ErrInvalidArguments,
-	"halp":          ErrUnknownCommand,
-	"schedule":      ErrInvalidArguments,
-	"schedule help": ErrUnknownDay,
+	"halp":                              ErrUnknownCommand,
+	"schedule":                          ErrInvalidArguments,
+	"schedule help":                     ErrUnknownDay,
+	"schedule mon-noon":                 ErrInvalidArguments,
+	"schedule mon-biweekly-am":          ErrInvalidArguments,
+	"schedule weekdays except":          ErrInvalidArguments,
+	"schedule except mon":               ErrInvalidArguments,
+	"schedule weekends except sat sun":  ErrInvalidArguments,
+	"schedule weekdays except holidays": ErrUnknownDay,
+	"schedule lunch-am":                 ErrUnknownDay,
+	"thisweek add someday":              ErrUnknownDay,
+
+	"thisweek":           ErrInvalidArguments,
+	"thisweek add":       ErrInvalidArguments,
+	"thisweek swap mon":  ErrInvalidArguments,
+	"thisweek clear mon": ErrInvalidArguments,
+	"mute":               ErrInvalidArguments,
+	"unmute mon-am":      ErrInvalidArguments,
 
 	// Unexpected arguments
-	"status me": ErrInvalidArguments,
-	"cookie me": ErrInvalidArguments,
+	"status me":     ErrInvalidArguments,
+	"next week":     ErrInvalidArguments,
+	"noshow @alice": ErrInvalidArguments,
+	"confirm match": ErrInvalidArguments,
+	"decline it":    ErrInvalidArguments,
+	"cookie me":     ErrInvalidArguments,
+	"stats me":      ErrInvalidArguments,
 
 	// Did they really want `schedule`?
 	"subscribe tue":   ErrInvalidArguments,
 	"unsubscribe thu": ErrInvalidArguments,
+	"restore all":     ErrInvalidArguments,
 
 	// (Un)skipping requires an argument.
 	"skip":   ErrInvalidArguments,
 	"unskip": ErrInvalidArguments,
 
-	// TODO(#49): Allow (un)skipping days other than tomorrow
+	// TODO(#49): Allow (un)skipping weekdays by name
 	"skip friday": ErrInvalidArguments,
 	"unskip next": ErrInvalidArguments,
 
+	// Dates must be real and written in full.
+	"skip 2024-02-30":            ErrInvalidArguments,
+	"skip 3/14":                  ErrInvalidArguments,
+	"skip 0 days":                ErrInvalidArguments,
+	"rate":                       ErrInvalidArguments,
+	"rate 0":                     ErrInvalidArguments,
+	"rate 6":                     ErrInvalidArguments,
+	"rate great":                 ErrInvalidArguments,
+	"skip -2 days":               ErrInvalidArguments,
+	"skip 99 days":               ErrInvalidArguments,
+	"skip three days":            ErrInvalidArguments,
+	"skip 3 weeks":               ErrInvalidArguments,
+	"unskip 3 days":              ErrInvalidArguments,
+	"skip 2024-03-14 2024-03-15": ErrInvalidArguments,
+
+	// Pauses are measured in whole weeks.
+	"pause 0":         ErrInvalidArguments,
+	"pause -2":        ErrInvalidArguments,
+	"pause forever":   ErrInvalidArguments,
+	"pause 1 2":       ErrInvalidArguments,
+	"resume tomorrow": ErrInvalidArguments,
+
+	// Settings need a known name and a value.
+	"set":                           ErrInvalidArguments,
+	"set timezone":                  ErrInvalidArguments,
+	"set timezone America/New York": ErrInvalidArguments,
+	"set favorite-color periwinkle": ErrInvalidArguments,
+	"set bio":                       ErrInvalidArguments,
+	"set topics":                    ErrInvalidArguments,
+	"set topics , ,":                ErrInvalidArguments,
+	"topics go":                     ErrInvalidArguments,
+	"set theme":                     ErrInvalidArguments,
+	"theme please":                  ErrInvalidArguments,
+	"set weekly-summary":            ErrInvalidArguments,
+	"set weekly-summary nah":        ErrInvalidArguments,
+	"set history":                   ErrInvalidArguments,
+	"history 20":                    ErrInvalidArguments,
+	"set confirm maybe":             ErrInvalidArguments,
+	"match":                         ErrInvalidArguments,
+	"match later":                   ErrInvalidArguments,
+	"cancel":                        ErrInvalidArguments,
+	"set leaderboard":               ErrInvalidArguments,
+	"set matchtime":                 ErrInvalidArguments,
+	"set matchtime 9am":             ErrInvalidArguments,
+	"set matchtime 25:00":           ErrInvalidArguments,
+	"set maxweekly":                 ErrInvalidArguments,
+	"set maxweekly -1":              ErrInvalidArguments,
+	"set maxweekly lots":            ErrInvalidArguments,
+	"set batchpref":                 ErrInvalidArguments,
+	"set batchpref mine":            ErrInvalidArguments,
+	"set mode":                      ErrInvalidArguments,
+	"set mode video":                ErrInvalidArguments,
+	"set verbosity":                 ErrInvalidArguments,
+	"set verbosity chatty":          ErrInvalidArguments,
+	"set duration":                  ErrInvalidArguments,
+	"set duration 5":                ErrInvalidArguments,
+	"set duration 600":              ErrInvalidArguments,
+	"set duration an hour":          ErrInvalidArguments,
+	"leaderboard please":            ErrInvalidArguments,
+	"milestones today":              ErrInvalidArguments,
+	"clear":                         ErrInvalidArguments,
+	"block":                         ErrInvalidArguments,
+	"unblock":                       ErrInvalidArguments,
+	"block @**Ada Lovelace|ada**":   ErrInvalidArguments,
+	"blocks Ada":                    ErrInvalidArguments,
+	"who":                           ErrInvalidArguments,
+	"met":                           ErrInvalidArguments,
+	"pair":                          ErrInvalidArguments,
+	"alias sk":                      ErrInvalidArguments,
+	"alias sk =":                    ErrInvalidArguments,
+	"alias s k = skip tomorrow":     ErrInvalidArguments,
+	"unalias":                       ErrInvalidArguments,
+	"unalias s k":                   ErrInvalidArguments,
+	"aliases sk":                    ErrInvalidArguments,
+	"export csv":                    ErrInvalidArguments,
+	"delete":                        ErrInvalidArguments,
+	"delete you":                    ErrInvalidArguments,
+	"delete me now":                 ErrInvalidArguments,
+	"delete me matches confirm":     ErrInvalidArguments,
+	"delete schedule":               ErrInvalidArguments,
+	"reset everything":              ErrInvalidArguments,
+	"blindintros sometimes":         ErrInvalidArguments,
+	"weekends maybe":                ErrInvalidArguments,
+	"holidays add":                  ErrInvalidArguments,
+	"holidays add christmas":        ErrInvalidArguments,
+	"holidays add 2024-13-01":       ErrInvalidArguments,
+	"holidays remove 2024-12-25 x":  ErrInvalidArguments,
+	"holidays next":                 ErrInvalidArguments,
+	"holidays timezone":             ErrInvalidArguments,
+	"holidays timezone Mars/Base":   ErrInvalidArguments,
+	"weekends on America/New_York":  ErrInvalidArguments,
+	"weekends off Mars/Olympus":     ErrInvalidArguments,
+	"trends 0":                      ErrInvalidArguments,
+	"trends 365":                    ErrInvalidArguments,
+	"trends week":                   ErrInvalidArguments,
+	"reviews 2":                     ErrInvalidArguments,
+	"fun add encourage":             ErrInvalidArguments,
+	"fun remove":                    ErrInvalidArguments,
+	"fun edit encourage Hi":         ErrInvalidArguments,
+	"save":                          ErrInvalidArguments,
+	"save schedule":                 ErrInvalidArguments,
+	"save schedule as":              ErrInvalidArguments,
+	"save schedule as my work":      ErrInvalidArguments,
+	"save bio as work":              ErrInvalidArguments,
+	"load schedule":                 ErrInvalidArguments,
+	"load schedule my work":         ErrInvalidArguments,
+	"schedules work":                ErrInvalidArguments,
+	"clear timezone":                ErrInvalidArguments,
+
 	// This is not the way to delete reviews you don't like 😛
 	"get-reviews -1":  ErrInvalidArguments,
 	"get-reviews -10": ErrInvalidArguments,
 
 	"get-reviews 1 2": ErrInvalidArguments,
 
-	"add-review": ErrInvalidArguments,
+	"add-review":             ErrInvalidArguments,
+	"add-review #bug":        ErrInvalidArguments,
+	"bug":                    ErrInvalidArguments,
+	"anonymous":              ErrInvalidArguments,
+	"announce":               ErrInvalidArguments,
+	"maintenance later":      ErrInvalidArguments,
+	"roster":                 ErrInvalidArguments,
+	"roster someday":         ErrInvalidArguments,
+	"availability monday":    ErrInvalidArguments,
+	"minparticipants -1":     ErrInvalidArguments,
+	"minparticipants few":    ErrInvalidArguments,
+	"minparticipants 4 post": ErrInvalidArguments,
+	"dailypost pairing":      ErrInvalidArguments,
+	"dailypost > matches":    ErrInvalidArguments,
+	"dailypost #**pairing**": ErrInvalidArguments,
+	"anonymous review":       ErrInvalidArguments,
+	"anonymous reviews rock": ErrInvalidArguments,
 
 	// Unknown commands
 	"scheduleing monday": ErrUnknownCommand,
@@ -125,3 +461,29 @@ func TestParseCmdReject(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCmdScheduleWord(t *testing.T) {
+	for input, word := range map[string]string{
+		"schedule mon frday wed":             "frday",
+		"schedule weekdays except tues-noon": "tues-noon",
+		"schedule lunch-am fri":              "lunch-am",
+		"thisweek add thu someday":           "someday",
+		"mute wed someday":                   "someday",
+	} {
+		t.Run(input, func(t *testing.T) {
+			_, _, err := parseCmd(input)
+
+			var wordErr *ScheduleWordError
+			if !errors.As(err, &wordErr) {
+				t.Fatalf("got error %v, wanted a ScheduleWordError", err)
+			}
+			assert.Equal(t, wordErr.Word, word)
+			assert.ErrorIs(t, err, ErrInvalidArguments)
+
+			reply := wordErr.Reply()
+			if !strings.Contains(reply, "`"+word+"`") || !strings.Contains(reply, "left your schedule as it was") {
+				t.Errorf("reply %q doesn't point out %q", reply, word)
+			}
+		})
+	}
+}
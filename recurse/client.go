@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"maps"
 	"net/http"
@@ -51,8 +52,42 @@ func NewClient(accessToken AccessTokenFunc, opts ...ClientOpt) (*Client, error)
 //
 // https://github.com/recursecenter/wiki/wiki/Recurse-Center-API#Profiles
 type Profile struct {
-	Name    string `json:"name"`
-	ZulipID int64  `json:"zulip_id"`
+	Name    string  `json:"name"`
+	ZulipID int64   `json:"zulip_id"`
+	Stints  []Stint `json:"stints,omitempty"`
+}
+
+// A Stint is a period of time someone spent at RC, like attending a batch or
+// working as faculty.
+//
+// https://github.com/recursecenter/wiki/wiki/Recurse-Center-API#Profiles
+type Stint struct {
+	InProgress bool      `json:"in_progress"`
+	StartDate  Datestamp `json:"start_date"`
+
+	// Batch is only set for stints that are part of a batch.
+	Batch *Batch `json:"batch"`
+}
+
+// CurrentBatchID returns the ID of the batch the Recurser is currently
+// attending, or zero if they aren't in a batch right now.
+func (p Profile) CurrentBatchID() int64 {
+	for i := len(p.Stints) - 1; i >= 0; i-- {
+		if stint := p.Stints[i]; stint.InProgress && stint.Batch != nil {
+			return stint.Batch.ID
+		}
+	}
+	return 0
+}
+
+// CurrentStint returns the stint the Recurser is in right now, if any.
+func (p Profile) CurrentStint() (Stint, bool) {
+	for i := len(p.Stints) - 1; i >= 0; i-- {
+		if stint := p.Stints[i]; stint.InProgress {
+			return stint, true
+		}
+	}
+	return Stint{}, false
 }
 
 // ActiveRecursers fetches the profiles for all recursers currently at RC.
@@ -102,6 +137,30 @@ func (c *Client) activeRecursers(ctx context.Context, offset int, limit int) ([]
 	return profiles, json.NewDecoder(resp.Body).Decode(&profiles)
 }
 
+// ErrNotFound is returned when the requested Recurse resource doesn't exist.
+var ErrNotFound = errors.New("not found")
+
+// ProfileByEmail fetches the profile for the Recurser with this email address.
+// If there's no such Recurser, this returns ErrNotFound.
+//
+// https://github.com/recursecenter/wiki/wiki/Recurse-Center-API#Profiles
+func (c *Client) ProfileByEmail(ctx context.Context, email string) (Profile, error) {
+	resp, err := c.get(ctx, "profiles/"+url.PathEscape(email), nil)
+
+	var respErr *ResponseError
+	if errors.As(err, &respErr) && respErr.Response.StatusCode == http.StatusNotFound {
+		respErr.Response.Body.Close()
+		return Profile{}, ErrNotFound
+	}
+	if err != nil {
+		return Profile{}, fmt.Errorf("get profile by email: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var profile Profile
+	return profile, json.NewDecoder(resp.Body).Decode(&profile)
+}
+
 // Datestamp is a time.Time wrapper for parsing dates. It implements
 // json.Unmarshaler by parsing the value with time.DateOnly in UTC.
 type Datestamp time.Time
@@ -144,6 +203,7 @@ func (d Datestamp) Equal(other Datestamp) bool {
 //
 // https://github.com/recursecenter/wiki/wiki/Recurse-Center-API#Batches
 type Batch struct {
+	ID        int64     `json:"id"`
 	Name      string    `json:"name"`
 	StartDate Datestamp `json:"start_date"`
 }
@@ -160,6 +220,20 @@ func (b Batch) IsSecondWeek(now time.Time) bool {
 	return 1*week < activeTime && activeTime < 2*week
 }
 
+// CurrentBatch returns the most recent non-mini batch from a list of batches
+// sorted with the most recent first (like the one returned by AllBatches).
+//
+// Mini batches are only 1 week long, and they overlap with regular batches, so
+// they're not usually what we mean by "the current batch".
+func CurrentBatch(batches []Batch) (Batch, bool) {
+	for _, batch := range batches {
+		if !batch.IsMini() {
+			return batch, true
+		}
+	}
+	return Batch{}, false
+}
+
 // AllBatches returns all RC batches up to the current batch with the most
 // recent batch first.
 //
@@ -175,6 +249,25 @@ func (c *Client) AllBatches(ctx context.Context) ([]Batch, error) {
 	return batches, json.NewDecoder(resp.Body).Decode(&batches)
 }
 
+// RecentlyStarted fetches the profiles for recursers currently at RC whose
+// current stint started after the given time.
+func (c *Client) RecentlyStarted(ctx context.Context, since time.Time) ([]Profile, error) {
+	// Like IsCurrentlyAtRC, there aren't enough people at RC to make it worth
+	// filtering on the server.
+	active, err := c.ActiveRecursers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var started []Profile
+	for _, profile := range active {
+		if stint, ok := profile.CurrentStint(); ok && time.Time(stint.StartDate).After(since) {
+			started = append(started, profile)
+		}
+	}
+	return started, nil
+}
+
 // IsCurrentlyAtRC returns whether the user's Zulip ID appears in the list of
 // profiles for recursers currently at RC.
 func (c *Client) IsCurrentlyAtRC(ctx context.Context, zulipID int64) (bool, error) {
@@ -267,6 +267,55 @@ func TestClient_IsCurrentlyAtRC(t *testing.T) {
 	}
 }
 
+func TestClient_RecentlyStarted(t *testing.T) {
+	srv := mockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, "/profiles")
+
+		_, err := w.Write([]byte(`[
+			{"name": "Old Timer", "zulip_id": 1, "stints": [{"in_progress": true, "start_date": "2024-02-19"}]},
+			{"name": "New Arrival", "zulip_id": 2, "stints": [
+				{"in_progress": false, "start_date": "2022-01-03"},
+				{"in_progress": true, "start_date": "2024-05-20"}
+			]},
+			{"name": "No Stint", "zulip_id": 3}
+		]`))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	defer srv.Close()
+
+	client, err := recurse.NewClient(
+		recurse.StaticAccessToken("fake-access-token"),
+		recurse.WithHTTP(srv.Client()),
+		recurse.WithBaseURL(srv.URL()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	for since, expected := range map[string][]int64{
+		"2024-01-01": {1, 2},
+		"2024-02-19": {2},
+		"2024-05-20": nil,
+	} {
+		t.Run(since, func(t *testing.T) {
+			profiles, err := client.RecentlyStarted(ctx, must(time.Parse(time.DateOnly, since)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []int64
+			for _, profile := range profiles {
+				ids = append(ids, profile.ZulipID)
+			}
+			assert.Equal(t, ids, expected)
+		})
+	}
+}
+
 func TestClient_recurse_errors(t *testing.T) {
 	srv := mockServer(t, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusTeapot)
@@ -352,3 +401,86 @@ func TestBatch_IsSecondWeek(t *testing.T) {
 	assert.Equal(t, batch.IsSecondWeek(week2cron), true)
 	assert.Equal(t, batch.IsSecondWeek(week3cron), false)
 }
+
+func TestCurrentBatch(t *testing.T) {
+	batches := loadJSON[[]recurse.Batch](t, "testdata/batches.json")
+
+	t.Run("skips mini batches", func(t *testing.T) {
+		// This starts with three mini batches.
+		batch, ok := recurse.CurrentBatch(batches[2:])
+		assert.Equal(t, ok, true)
+		assert.Equal(t, batch.Name, "Summer 2, 2023")
+	})
+
+	t.Run("no batches", func(t *testing.T) {
+		_, ok := recurse.CurrentBatch(nil)
+		assert.Equal(t, ok, false)
+	})
+}
+
+func TestProfile_CurrentBatchID(t *testing.T) {
+	var profile recurse.Profile
+	err := json.Unmarshal([]byte(`{
+		"name": "Your Name",
+		"zulip_id": 1000,
+		"stints": [
+			{"in_progress": false, "batch": {"id": 100, "name": "Fall 1, 2022"}},
+			{"in_progress": true, "batch": {"id": 154, "name": "Summer 2, 2023"}},
+			{"in_progress": true, "batch": null}
+		]
+	}`), &profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, profile.CurrentBatchID(), int64(154))
+
+	t.Run("no current batch", func(t *testing.T) {
+		profile.Stints = profile.Stints[:1]
+		assert.Equal(t, profile.CurrentBatchID(), int64(0))
+	})
+}
+
+func TestClient_ProfileByEmail(t *testing.T) {
+	srv := mockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodGet)
+
+		switch r.URL.Path {
+		case "/profiles/found@recurse.example.net":
+			err := json.NewEncoder(w).Encode(recurse.Profile{
+				Name:    "Your Name",
+				ZulipID: 1000,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer srv.AssertRequestCount(2)
+
+	client, err := recurse.NewClient(
+		recurse.StaticAccessToken("fake-access-token"),
+		recurse.WithHTTP(srv.Client()),
+		recurse.WithBaseURL(srv.URL()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	t.Run("found", func(t *testing.T) {
+		profile, err := client.ProfileByEmail(ctx, "found@recurse.example.net")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, profile, recurse.Profile{Name: "Your Name", ZulipID: 1000})
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := client.ProfileByEmail(ctx, "typo@recurse.example.net")
+		assert.ErrorIs(t, err, recurse.ErrNotFound)
+	})
+}
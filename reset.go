@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// resetSettings puts every one of the Recurser's settings back to what a new
+// subscriber gets. Who they are, their subscription, their time zone, and their
+// blocks are kept: forgetting a block could match them with someone they never
+// want to see again.
+//
+// Each field is listed on purpose. When adding a field to store.Recurser,
+// decide whether `reset` should clear it, and add it here (or to keptFields in
+// the test).
+func resetSettings(rec *store.Recurser) {
+	// Schedule
+	rec.Schedule = store.DefaultSchedule()
+	rec.Biweekly = nil
+	rec.ThisWeek = store.WeekOverride{}
+	rec.SchedulePresets = nil
+	rec.MutedDays = nil
+
+	// Skips and pauses
+	rec.IsSkippingTomorrow = false
+	rec.SkipDates = nil
+	rec.SkipUntil = 0
+	rec.PausedUntil = 0
+
+	// About them
+	rec.Bio = ""
+	rec.Topics = nil
+
+	// Preferences
+	rec.WeeklySummaryOptOut = false
+	rec.ShowOnLeaderboard = false
+	rec.Nudge = false
+	rec.EmailFallback = false
+	rec.Discoverable = false
+	rec.MatchTime = ""
+	rec.MaxWeekly = 0
+	rec.BatchPref = ""
+	rec.Duration = 0
+	rec.Mode = ""
+	rec.Verbosity = ""
+	rec.KeepHistory = false
+	rec.Aliases = nil
+	rec.IsMentor = false
+	rec.ConfirmMatches = false
+}
+
+// Reset explains what `reset confirm` does, since it can't be undone.
+func (pl *PairingLogic) Reset(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+	return "This puts all of your settings back to how they were when you subscribed: your schedule goes back to every weekday, and your muted days, skips, pause, bio, topics, saved schedules, aliases, and preferences (like `set matchtime` or `set nudge`) are cleared. You stay subscribed, and your time zone, blocks, and past matches are kept.\n\nThis can't be undone! Send `reset confirm` to go ahead.", nil
+}
+
+// ConfirmReset puts all of the Recurser's settings back to the defaults,
+// without unsubscribing them. Their matches and history are left alone.
+func (pl *PairingLogic) ConfirmReset(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+
+	resetSettings(rec)
+
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+	return "Done, you're starting fresh! You'll be matched every weekday. Send `status` to see your settings.", nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_resetSettings(t *testing.T) {
+	// keptFields are the store.Recurser fields that `reset` leaves alone.
+	// Every other field goes back to its default.
+	keptFields := map[string]bool{
+		"ID":                    true,
+		"Name":                  true,
+		"Email":                 true,
+		"CurrentlyAtRC":         true,
+		"Timezone":              true,
+		"Segment":               true,
+		"BatchID":               true,
+		"Blocks":                true,
+		"Realm":                 true,
+		"UnsubscribedAt":        true,
+		"SubscriptionChangedAt": true,
+		"LastActive":            true,
+		"StaleWarnedAt":         true,
+		"IsSubscribed":          true,
+	}
+
+	// Every field is set, so the test can tell whether it was reset.
+	before := store.Recurser{
+		ID:                    1,
+		Name:                  "Ada",
+		Email:                 "ada@recurse.example.net",
+		IsSkippingTomorrow:    true,
+		Schedule:              store.NewSchedule([]string{"saturday"}),
+		CurrentlyAtRC:         true,
+		Timezone:              "Europe/London",
+		PausedUntil:           store.PausedIndefinitely,
+		SkipDates:             map[string]bool{"2024-03-14": true},
+		SkipUntil:             time.Now().Unix(),
+		Bio:                   "Engines",
+		Topics:                []string{"math"},
+		WeeklySummaryOptOut:   true,
+		ShowOnLeaderboard:     true,
+		Nudge:                 true,
+		EmailFallback:         true,
+		Discoverable:          true,
+		MatchTime:             "09:00",
+		MaxWeekly:             3,
+		BatchPref:             store.BatchPrefSame,
+		Duration:              60,
+		Mode:                  store.ModeVoice,
+		Verbosity:             store.VerbosityFull,
+		Segment:               store.SegmentAM,
+		BatchID:               42,
+		KeepHistory:           true,
+		Biweekly:              map[string]int{"saturday": 1},
+		MutedDays:             map[string]bool{"saturday": true},
+		ThisWeek:              store.WeekOverride{WeekOf: "2024-03-11"},
+		Blocks:                []store.Block{{ID: 2, Name: "Charles"}},
+		Aliases:               map[string]string{"sk": "skip tomorrow"},
+		SchedulePresets:       map[string]map[string]bool{"work": store.NewSchedule([]string{"monday"})},
+		IsMentor:              true,
+		Realm:                 "other",
+		ConfirmMatches:        true,
+		UnsubscribedAt:        1,
+		LastActive:            1,
+		StaleWarnedAt:         1,
+		SubscriptionChangedAt: 1,
+		IsSubscribed:          true,
+	}
+
+	after := before
+	resetSettings(&after)
+
+	b, a := reflect.ValueOf(before), reflect.ValueOf(after)
+	for i := range b.NumField() {
+		name := b.Type().Field(i).Name
+		t.Run(name, func(t *testing.T) {
+			if b.Field(i).IsZero() {
+				t.Fatalf("%s isn't set in the test, so it can't tell whether reset clears it", name)
+			}
+
+			switch {
+			case keptFields[name]:
+				assert.Equal(t, a.Field(i).Interface(), b.Field(i).Interface())
+			case name == "Schedule":
+				assert.Equal(t, after.Schedule, store.DefaultSchedule())
+			default:
+				if !a.Field(i).IsZero() {
+					t.Errorf("%s wasn't reset: %v", name, a.Field(i).Interface())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// Roster lists everyone who will be matched on the next day with that name,
+// taking skips and pauses into account. Mentors are left out, since they're
+// only matched by MentorMatch.
+func (pl *PairingLogic) Roster(ctx context.Context, day string) (string, error) {
+	scheduled, err := store.Recursers(pl.db).ListScheduledOn(ctx, time.Now(), day)
+	if err != nil {
+		return "", readError(err)
+	}
+	scheduled = slices.DeleteFunc(scheduled, func(r store.Recurser) bool { return r.IsMentor })
+
+	var names []string
+	for _, rec := range scheduled {
+		names = append(names, rec.Name)
+	}
+	return renderRoster(day, names)
+}
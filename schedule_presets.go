@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// maxSchedulePresets is how many saved schedules one Recurser can have.
+const maxSchedulePresets = 10
+
+// scheduleWords lists the days of a schedule the way `schedule` takes them,
+// like "monday" or "friday-pm", from Monday to Sunday.
+func scheduleWords(schedule map[string]bool) []string {
+	var words []string
+	for _, day := range scheduleShortcuts["everyday"] {
+		for _, word := range []string{day, day + "-" + store.SegmentAM, day + "-" + store.SegmentPM} {
+			if schedule[word] {
+				words = append(words, word)
+			}
+		}
+	}
+	return words
+}
+
+// describePreset is a saved schedule for a reply, like "`monday friday-pm`".
+func describePreset(schedule map[string]bool) string {
+	words := scheduleWords(schedule)
+	if len(words) == 0 {
+		return "no days"
+	}
+	return "`" + strings.Join(words, " ") + "`"
+}
+
+// SaveSchedule saves the Recurser's current schedule under a name, so they can
+// switch back to it later with LoadSchedule. Saving over an existing name
+// replaces it.
+func (pl *PairingLogic) SaveSchedule(ctx context.Context, rec *store.Recurser, name string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+	if _, ok := rec.SchedulePresets[name]; !ok && len(rec.SchedulePresets) >= maxSchedulePresets {
+		return fmt.Sprintf("You can only save %d schedules. Send `delete schedule <name>` to make room.", maxSchedulePresets), nil
+	}
+
+	if rec.SchedulePresets == nil {
+		rec.SchedulePresets = make(map[string]map[string]bool)
+	}
+	rec.SchedulePresets[name] = maps.Clone(rec.Schedule)
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	msg := fmt.Sprintf("Saved your schedule (%s) as `%s`. Send `load schedule %s` to switch back to it.", describePreset(rec.Schedule), name, name)
+	if len(rec.Biweekly) > 0 {
+		msg += " Saved schedules don't remember which days are every other week, so those will be every week when you load it."
+	}
+	return msg, nil
+}
+
+// LoadSchedule replaces the Recurser's schedule with one they saved. Like
+// `schedule`, every day of the new schedule is every week.
+func (pl *PairingLogic) LoadSchedule(ctx context.Context, rec *store.Recurser, name string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+	preset, ok := rec.SchedulePresets[name]
+	if !ok {
+		return fmt.Sprintf("You don't have a saved schedule called `%s`. Send `schedules` to see the ones you have.", name), nil
+	}
+
+	rec.Schedule = maps.Clone(preset)
+	rec.SetBiweekly(time.Now(), nil)
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+
+	var warning string
+	if counts, err := store.Recursers(pl.db).CountScheduledDays(ctx, rec.ID); err != nil {
+		logger(ctx).Warn("Could not check whether anyone shares the schedule", slog.Any("error", err))
+	} else {
+		warning = noOverlapWarning(rec, counts)
+	}
+	return fmt.Sprintf("Done, you're on your `%s` schedule (%s) now.", name, describePreset(preset)) + warning, nil
+}
+
+// DeleteSchedule removes one of the Recurser's saved schedules. Their current
+// schedule stays the same.
+func (pl *PairingLogic) DeleteSchedule(ctx context.Context, rec *store.Recurser, name string) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+	if _, ok := rec.SchedulePresets[name]; !ok {
+		return fmt.Sprintf("You don't have a saved schedule called `%s`.", name), nil
+	}
+
+	delete(rec.SchedulePresets, name)
+	if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+		return "", writeError(err)
+	}
+	return fmt.Sprintf("Done, I've forgotten your `%s` schedule. Your current schedule hasn't changed.", name), nil
+}
+
+// ListSchedules shows the Recurser their saved schedules, in alphabetical
+// order.
+func (pl *PairingLogic) ListSchedules(ctx context.Context, rec *store.Recurser) (string, error) {
+	if !rec.IsSubscribed {
+		return notSubscribedMessage, nil
+	}
+	if len(rec.SchedulePresets) == 0 {
+		return "You don't have any saved schedules. Send `save schedule as <name>` to save the one you have now.", nil
+	}
+
+	names := make([]string, 0, len(rec.SchedulePresets))
+	for name := range rec.SchedulePresets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	b.WriteString("Your saved schedules:")
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n* `%s`: %s", name, describePreset(rec.SchedulePresets[name]))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_describePreset(t *testing.T) {
+	assert.Equal(t, describePreset(store.NewSchedule([]string{"friday-pm", "monday", "wednesday-am"})), "`monday wednesday-am friday-pm`")
+	assert.Equal(t, describePreset(store.EmptySchedule()), "no days")
+}
+
+func TestPairingLogic_schedulePresets(t *testing.T) {
+	ctx := context.Background()
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx)}
+
+	get := func(t *testing.T) *store.Recurser {
+		t.Helper()
+		rec, err := store.Recursers(pl.db).GetByUserID(ctx, store.DefaultRealm, 1, "", "Ada")
+		assert.NoError(t, err)
+		return rec
+	}
+
+	rec := &store.Recurser{ID: 1, Name: "Ada", IsSubscribed: true, Schedule: store.NewSchedule([]string{"monday", "tuesday", "wednesday"})}
+	assert.NoError(t, store.Recursers(pl.db).Set(ctx, rec))
+
+	msg, err := pl.dispatch(ctx, "save", []string{"busy"}, rec)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, "Saved your schedule (`monday tuesday wednesday`) as `busy`. Send `load schedule busy` to switch back to it.")
+
+	rec = get(t)
+	_, err = pl.dispatch(ctx, "schedule", []string{"friday-pm"}, rec)
+	assert.NoError(t, err)
+	_, err = pl.dispatch(ctx, "save", []string{"light"}, rec)
+	assert.NoError(t, err)
+
+	t.Run("list", func(t *testing.T) {
+		msg, err := pl.dispatch(ctx, "schedules", nil, get(t))
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Your saved schedules:\n* `busy`: `monday tuesday wednesday`\n* `light`: `friday-pm`")
+	})
+
+	t.Run("load", func(t *testing.T) {
+		rec := get(t)
+		_, err := pl.dispatch(ctx, "load", []string{"busy"}, rec)
+		assert.NoError(t, err)
+		assert.Equal(t, get(t).Schedule, store.NewSchedule([]string{"monday", "tuesday", "wednesday"}))
+
+		msg, err := pl.dispatch(ctx, "load", []string{"chill"}, rec)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "You don't have a saved schedule called `chill`. Send `schedules` to see the ones you have.")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		rec := get(t)
+		_, err := pl.dispatch(ctx, "delete", []string{"schedule", "light"}, rec)
+		assert.NoError(t, err)
+
+		rec = get(t)
+		assert.Equal(t, len(rec.SchedulePresets), 1)
+		// Deleting a saved schedule leaves the current one alone.
+		assert.Equal(t, rec.Schedule, store.NewSchedule([]string{"monday", "tuesday", "wednesday"}))
+
+		msg, err := pl.dispatch(ctx, "delete", []string{"schedule", "light"}, rec)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "You don't have a saved schedule called `light`.")
+	})
+}
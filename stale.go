@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// Recursers who haven't sent a command or been matched in staleAfter are
+// unsubscribed, so the pool is only people who still want to pair. They're
+// warned staleNotice ahead of time, and anything they send in the meantime
+// keeps them subscribed.
+const (
+	staleAfter  = 90 * 24 * time.Hour
+	staleNotice = 7 * 24 * time.Hour
+)
+
+// A staleAction is what the stale account job does with one Recurser.
+type staleAction int
+
+const (
+	staleNone staleAction = iota
+
+	// staleStartClock is for records from before LastActive was tracked.
+	// They count as active from the job's first run.
+	staleStartClock
+
+	staleWarn
+	staleUnsubscribe
+)
+
+// staleActionFor decides what to do with a subscribed Recurser at `now`. They
+// have to be warned (after they were last active) and then given the whole
+// notice period before they're unsubscribed, even if the job didn't run for
+// a while.
+func staleActionFor(rec store.Recurser, now time.Time) staleAction {
+	if rec.LastActive == 0 {
+		return staleStartClock
+	}
+
+	inactive := now.Sub(time.Unix(rec.LastActive, 0))
+	warned := rec.StaleWarnedAt > rec.LastActive
+
+	switch {
+	case !warned && inactive >= staleAfter-staleNotice:
+		return staleWarn
+	case warned && inactive >= staleAfter && !now.Before(time.Unix(rec.StaleWarnedAt, 0).Add(staleNotice)):
+		return staleUnsubscribe
+	}
+	return staleNone
+}
+
+// UnsubscribeStale warns the Recursers who are close to staleAfter without any
+// activity, and unsubscribes the ones who were warned and still haven't done
+// anything. Like `unsubscribe`, their records are kept for the grace period,
+// so they can `restore` them.
+func (pl *PairingLogic) UnsubscribeStale(ctx context.Context) error {
+	now := time.Now()
+
+	recursers, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
+	if err != nil {
+		return fmt.Errorf("get list of recursers: %w", err)
+	}
+
+	var warned, unsubscribed int
+	for i := range recursers {
+		rec := &recursers[i]
+		recLog := logger(ctx).With(slog.Int64("recurserId", rec.ID))
+
+		var message string
+		switch staleActionFor(*rec, now) {
+		case staleNone:
+			continue
+		case staleStartClock:
+			rec.LastActive = now.Unix()
+		case staleWarn:
+			rec.StaleWarnedAt = now.Unix()
+			message = fmt.Sprintf("Hi! You haven't used Pairing Bot or been matched in a while, so I'll unsubscribe you in %d days to keep the pairing pool fresh. If you'd like to stay, just send me any command (like `status`) before then.", int(staleNotice.Hours()/24))
+		case staleUnsubscribe:
+			// SubscriptionChangedAt is left alone, since they didn't change
+			// it themselves and shouldn't have to wait to `restore`.
+			rec.UnsubscribedAt = now.Unix()
+			message = fmt.Sprintf("I've unsubscribed you from Pairing Bot, since you haven't used it in %d days. Your settings are kept for %d days: send `restore` before then to pick up where you left off.", int(staleAfter.Hours()/24), int(store.UnsubscribeGracePeriod.Hours()/24))
+		}
+
+		if err := store.Recursers(pl.db).Set(ctx, rec); err != nil {
+			recLog.Error("Could not update the inactive Recurser", slog.Any("error", err))
+			continue
+		}
+		if message == "" {
+			continue
+		}
+
+		if rec.UnsubscribedAt != 0 {
+			unsubscribed++
+		} else {
+			warned++
+		}
+		if err := pl.zulipFor(rec.Realm).SendUserMessage(ctx, []int64{rec.ID}, message); err != nil {
+			recLog.Error("Could not tell the Recurser about being inactive", slog.Any("error", err))
+		}
+	}
+
+	logger(ctx).Info("Checked for inactive Recursers", slog.Int("warned", warned), slog.Int("unsubscribed", unsubscribed))
+	return nil
+}
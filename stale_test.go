@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_staleActionFor(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) int64 { return now.Add(-d).Unix() }
+	const day = 24 * time.Hour
+
+	for name, tc := range map[string]struct {
+		rec  store.Recurser
+		want staleAction
+	}{
+		"not tracked yet":            {store.Recurser{}, staleStartClock},
+		"active recently":            {store.Recurser{LastActive: ago(day)}, staleNone},
+		"almost time to warn":        {store.Recurser{LastActive: ago(staleAfter - staleNotice - day)}, staleNone},
+		"time to warn":               {store.Recurser{LastActive: ago(staleAfter - staleNotice)}, staleWarn},
+		"long inactive, not warned":  {store.Recurser{LastActive: ago(200 * day)}, staleWarn},
+		"warned, still in notice":    {store.Recurser{LastActive: ago(staleAfter), StaleWarnedAt: ago(staleNotice - day)}, staleNone},
+		"warned, notice over":        {store.Recurser{LastActive: ago(staleAfter), StaleWarnedAt: ago(staleNotice)}, staleUnsubscribe},
+		"active again after warning": {store.Recurser{LastActive: ago(day), StaleWarnedAt: ago(2 * day)}, staleNone},
+		"warned before an old break": {store.Recurser{LastActive: ago(staleAfter), StaleWarnedAt: ago(staleAfter + day)}, staleWarn},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, staleActionFor(tc.rec, now), tc.want)
+		})
+	}
+}
+
+func TestPairingLogic_UnsubscribeStale(t *testing.T) {
+	ctx := context.Background()
+
+	fake := pbtest.NewFakeZulip(t)
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx), zulip: fake.Client}
+
+	now := time.Now()
+	for _, rec := range []store.Recurser{
+		{ID: 1, Name: "Ada", LastActive: now.Add(-time.Hour).Unix()},
+		{ID: 2, Name: "Grace", LastActive: now.Add(-staleAfter).Unix()},
+		{ID: 3, Name: "Alan", LastActive: now.Add(-staleAfter).Unix(), StaleWarnedAt: now.Add(-staleNotice).Unix()},
+		{ID: 4, Name: "Barbara"},
+	} {
+		rec.Schedule = store.DefaultSchedule()
+		assert.NoError(t, store.Recursers(pl.db).Set(ctx, &rec))
+	}
+
+	assert.NoError(t, pl.UnsubscribeStale(ctx))
+
+	get := func(id int64) *store.Recurser {
+		rec, err := store.Recursers(pl.db).GetByUserID(ctx, store.DefaultRealm, id, "", "")
+		assert.NoError(t, err)
+		return rec
+	}
+
+	// Ada is active, so nothing happens.
+	assert.Equal(t, get(1).IsSubscribed, true)
+	assert.Equal(t, len(fake.DMs("[1]")), 0)
+
+	// Grace is warned, but still subscribed.
+	assert.Equal(t, get(2).IsSubscribed, true)
+	assert.Equal(t, get(2).StaleWarnedAt != 0, true)
+	assert.Equal(t, len(fake.DMs("[2]")), 1)
+
+	// Alan was warned a week ago, so they're unsubscribed.
+	assert.Equal(t, get(3).IsSubscribed, false)
+	assert.Equal(t, len(fake.DMs("[3]")), 1)
+
+	// Barbara's clock starts now, without a message.
+	assert.Equal(t, get(4).IsSubscribed, true)
+	assert.Equal(t, get(4).LastActive != 0, true)
+	assert.Equal(t, len(fake.DMs("[4]")), 0)
+
+	// Running again doesn't warn Grace twice.
+	assert.NoError(t, pl.UnsubscribeStale(ctx))
+	assert.Equal(t, len(fake.DMs("[2]")), 1)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// pairingStats summarizes a Recurser's match history.
+type pairingStats struct {
+	// Total is the number of matches the Recurser has been in.
+	Total int
+
+	// CurrentStreak is the number of consecutive days (ending today or
+	// yesterday) that the Recurser has been matched.
+	CurrentStreak int
+
+	// LongestStreak is the largest number of consecutive days the Recurser
+	// has ever been matched.
+	LongestStreak int
+}
+
+// computeStats builds pairingStats from a Recurser's matches. Days are counted
+// the same way as the match run does (see store.Recurser.MatchDate).
+func computeStats(matches []store.Match, rec *store.Recurser, now time.Time) pairingStats {
+	stats := pairingStats{Total: len(matches)}
+
+	// Convert to distinct match days, in order.
+	var days []time.Time
+	for _, match := range matches {
+		days = append(days, matchDay(rec, time.Unix(match.Timestamp, 0)))
+	}
+	slices.SortFunc(days, func(a, b time.Time) int { return a.Compare(b) })
+	days = slices.CompactFunc(days, func(a, b time.Time) bool { return a.Equal(b) })
+
+	streak := 0
+	for i, day := range days {
+		if i > 0 && days[i-1].AddDate(0, 0, 1).Equal(day) {
+			streak++
+		} else {
+			streak = 1
+		}
+		stats.LongestStreak = max(stats.LongestStreak, streak)
+	}
+
+	// The last streak only counts as current if it hasn't been broken yet.
+	if len(days) > 0 {
+		today := matchDay(rec, now)
+		last := days[len(days)-1]
+		if last.Equal(today) || last.AddDate(0, 0, 1).Equal(today) {
+			stats.CurrentStreak = streak
+		}
+	}
+
+	return stats
+}
+
+// matchDay returns the Recurser's MatchDate for t as midnight UTC of that date,
+// which makes it easy to do date arithmetic.
+func matchDay(rec *store.Recurser, t time.Time) time.Time {
+	day, _ := time.Parse(time.DateOnly, rec.MatchDate(t))
+	return day
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_computeStats(t *testing.T) {
+	rec := &store.Recurser{ID: 1, Timezone: "America/New_York"}
+	day := func(d int) store.Match {
+		return store.Match{
+			Recursers: []int64{1, 2},
+			Timestamp: time.Date(2024, time.March, d, 4, 0, 0, 0, time.UTC).Unix(),
+		}
+	}
+	now := time.Date(2024, time.March, 20, 12, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		Matches  []store.Match
+		Expected pairingStats
+	}{
+		"no history": {
+			nil,
+			pairingStats{},
+		},
+		"one match today": {
+			[]store.Match{day(20)},
+			pairingStats{Total: 1, CurrentStreak: 1, LongestStreak: 1},
+		},
+		"streak ending yesterday": {
+			[]store.Match{day(19), day(17), day(18)},
+			pairingStats{Total: 3, CurrentStreak: 3, LongestStreak: 3},
+		},
+		"broken streak": {
+			[]store.Match{day(1), day(2), day(3), day(4), day(10), day(11)},
+			pairingStats{Total: 6, CurrentStreak: 0, LongestStreak: 4},
+		},
+		"longest streak in the past": {
+			[]store.Match{day(1), day(2), day(3), day(19), day(20)},
+			pairingStats{Total: 5, CurrentStreak: 2, LongestStreak: 3},
+		},
+		"two matches in one day": {
+			[]store.Match{day(19), day(19), day(20)},
+			pairingStats{Total: 3, CurrentStreak: 2, LongestStreak: 2},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, computeStats(tc.Matches, rec, now), tc.Expected)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A PendingAnnouncement is a message that a maintainer wants to send to every
+// subscriber, waiting for them to confirm it.
+type PendingAnnouncement struct {
+	AuthorID  int64  `firestore:"authorId"`
+	Content   string `firestore:"content"`
+	Timestamp int64  `firestore:"timestamp"`
+}
+
+// AnnouncementsClient manages announcements that haven't been confirmed yet.
+type AnnouncementsClient struct {
+	client *firestore.Client
+}
+
+func Announcements(client *firestore.Client) *AnnouncementsClient {
+	return &AnnouncementsClient{client}
+}
+
+// Set replaces the author's pending announcement.
+func (a *AnnouncementsClient) Set(ctx context.Context, announcement PendingAnnouncement) error {
+	docID := strconv.FormatInt(announcement.AuthorID, 10)
+	_, err := a.client.Collection("announcements").Doc(docID).Set(ctx, announcement)
+	return err
+}
+
+// Get returns the author's pending announcement, or nil if they don't have
+// one.
+func (a *AnnouncementsClient) Get(ctx context.Context, authorID int64) (*PendingAnnouncement, error) {
+	docID := strconv.FormatInt(authorID, 10)
+	doc, err := a.client.Collection("announcements").Doc(docID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var announcement PendingAnnouncement
+	if err := doc.DataTo(&announcement); err != nil {
+		return nil, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return &announcement, nil
+}
+
+// Delete discards the author's pending announcement, if there is one.
+func (a *AnnouncementsClient) Delete(ctx context.Context, authorID int64) error {
+	docID := strconv.FormatInt(authorID, 10)
+	_, err := a.client.Collection("announcements").Doc(docID).Delete(ctx)
+	return err
+}
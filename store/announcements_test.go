@@ -0,0 +1,45 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreAnnouncementsClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	announcements := store.Announcements(client)
+
+	announcement := store.PendingAnnouncement{
+		AuthorID:  pbtest.RandInt64(t),
+		Content:   "Pairing Bot will be down for maintenance tomorrow",
+		Timestamp: pbtest.RandInt64(t),
+	}
+
+	if err := announcements.Set(ctx, announcement); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := announcements.Get(ctx, announcement.AuthorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, pending, &announcement)
+
+	if err := announcements.Delete(ctx, announcement.AuthorID); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err = announcements.Get(ctx, announcement.AuthorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var none *store.PendingAnnouncement
+	assert.Equal(t, pending, none)
+}
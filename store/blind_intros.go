@@ -0,0 +1,270 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BlindIntroConfig is whether pairs are matched blind: their match messages
+// leave out who the partner is until both of them have said hi. Maintainers
+// can change it without a deploy.
+type BlindIntroConfig struct {
+	On bool `firestore:"on"`
+
+	// UpdatedBy is the Zulip ID of the maintainer who last changed it.
+	UpdatedBy int64 `firestore:"updatedBy"`
+	Timestamp int64 `firestore:"timestamp"`
+}
+
+// BlindIntroConfigClient manages the blind intro setting.
+type BlindIntroConfigClient struct {
+	client *firestore.Client
+}
+
+func BlindIntroConfigs(client *firestore.Client) *BlindIntroConfigClient {
+	return &BlindIntroConfigClient{client}
+}
+
+// Get returns the blind intro setting, which is off if it's never been set.
+func (b *BlindIntroConfigClient) Get(ctx context.Context) (BlindIntroConfig, error) {
+	doc, err := b.client.Collection("config").Doc("blindIntros").Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return BlindIntroConfig{}, nil
+	} else if err != nil {
+		return BlindIntroConfig{}, err
+	}
+
+	var config BlindIntroConfig
+	if err := doc.DataTo(&config); err != nil {
+		return BlindIntroConfig{}, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return config, nil
+}
+
+// Set replaces the blind intro setting.
+func (b *BlindIntroConfigClient) Set(ctx context.Context, config BlindIntroConfig) error {
+	return withRetry(ctx, func() error {
+		_, err := b.client.Collection("config").Doc("blindIntros").Set(ctx, config)
+		return err
+	})
+}
+
+// A BlindIntro is a pair who were matched blind, and haven't been introduced
+// to each other yet.
+type BlindIntro struct {
+	// ID is the Firestore document ID. It is not written to or read from the
+	// document itself.
+	ID string `firestore:"-"`
+
+	Recursers []int64 `firestore:"recursers"`
+
+	// Content is the usual match message, which introduces them.
+	Content string `firestore:"content"`
+
+	// Hellos are the first messages each Recurser sent after they were
+	// matched, by Zulip ID.
+	Hellos map[string]string `firestore:"hellos"`
+
+	// SendAt is the Unix timestamp when they're told they've been matched.
+	// Messages before then aren't hellos.
+	SendAt int64 `firestore:"sendAt"`
+
+	// RevealAt is the Unix timestamp after which they're introduced, even if
+	// they haven't both said hi.
+	RevealAt int64 `firestore:"revealAt"`
+
+	// Realm is the Zulip realm of the Recursers (see Recurser.Realm).
+	Realm string `firestore:"realm,omitempty"`
+}
+
+// SayHello records the Recurser's first message, and returns whether everyone
+// has said hi now. Only the first message counts.
+func (b *BlindIntro) SayHello(userID int64, message string) bool {
+	key := strconv.FormatInt(userID, 10)
+	if _, ok := b.Hellos[key]; !ok {
+		if b.Hellos == nil {
+			b.Hellos = make(map[string]string)
+		}
+		b.Hellos[key] = message
+	}
+	return b.AllSaidHello()
+}
+
+// AllSaidHello returns whether everyone has said hi.
+func (b *BlindIntro) AllSaidHello() bool {
+	for _, id := range b.Recursers {
+		if _, ok := b.Hellos[strconv.FormatInt(id, 10)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Hello returns the Recurser's first message, or "" if they haven't said hi.
+func (b *BlindIntro) Hello(userID int64) string {
+	return b.Hellos[strconv.FormatInt(userID, 10)]
+}
+
+// IsDue returns whether it's time to introduce them as of `now`, whether or
+// not they've said hi.
+func (b *BlindIntro) IsDue(now time.Time) bool {
+	return now.Unix() >= b.RevealAt
+}
+
+// BlindIntrosClient manages the pairs waiting to be introduced.
+type BlindIntrosClient struct {
+	client *firestore.Client
+}
+
+func BlindIntros(client *firestore.Client) *BlindIntrosClient {
+	return &BlindIntrosClient{client}
+}
+
+// Add saves a new blind intro.
+func (b *BlindIntrosClient) Add(ctx context.Context, intro BlindIntro) error {
+	// Pick the document ID up front, so a retry can't save the intro twice.
+	doc := b.client.Collection("blindIntros").NewDoc()
+	return withRetry(ctx, func() error {
+		_, err := doc.Set(ctx, intro)
+		return err
+	})
+}
+
+// GetFor returns the most recent blind intro of the Recurser in the realm that's
+// been sent as of `now`, or nil if there isn't one.
+func (b *BlindIntrosClient) GetFor(ctx context.Context, realm string, userID int64, now time.Time) (*BlindIntro, error) {
+	iter := b.client.
+		Collection("blindIntros").
+		Where("recursers", "array-contains", userID).
+		Documents(ctx)
+	intros, err := fetchBlindIntros(iter)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *BlindIntro
+	for i := range intros {
+		intro := &intros[i]
+		if intro.Realm != realm || intro.SendAt > now.Unix() {
+			continue
+		}
+		if latest == nil || intro.SendAt > latest.SendAt {
+			latest = intro
+		}
+	}
+	return latest, nil
+}
+
+// DeleteAllFor deletes every blind intro the Recurser is in (along with their
+// partners' hellos), and returns how many there were.
+func (b *BlindIntrosClient) DeleteAllFor(ctx context.Context, realm string, userID int64) (int, error) {
+	iter := b.client.
+		Collection("blindIntros").
+		Where("recursers", "array-contains", userID).
+		Documents(ctx)
+	return deleteAllIn(ctx, iter, realm)
+}
+
+// SayHello records the Recurser's first message in the blind intro, and
+// returns the intro as it is afterwards. Two people saying hi at once can't
+// overwrite each other.
+func (b *BlindIntrosClient) SayHello(ctx context.Context, id string, userID int64, message string) (*BlindIntro, error) {
+	doc := b.client.Collection("blindIntros").Doc(id)
+
+	var intro BlindIntro
+	err := b.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snapshot, err := tx.Get(doc)
+		if err != nil {
+			return err
+		}
+		intro = BlindIntro{}
+		if err := snapshot.DataTo(&intro); err != nil {
+			return fmt.Errorf("parse document %q: %w", doc.Path, err)
+		}
+		intro.ID = id
+
+		intro.SayHello(userID, message)
+		return tx.Set(doc, intro)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &intro, nil
+}
+
+// Claim deletes the blind intro so that it can be revealed, and returns
+// whether it was still there. If it returns false, someone else is already
+// revealing it.
+func (b *BlindIntrosClient) Claim(ctx context.Context, id string) (bool, error) {
+	doc := b.client.Collection("blindIntros").Doc(id)
+
+	// A transaction, so that the reveal job and a hello at once can't both
+	// claim it.
+	var claimed bool
+	err := b.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		_, err := tx.Get(doc)
+		if status.Code(err) == codes.NotFound {
+			claimed = false
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		claimed = true
+		return tx.Delete(doc)
+	})
+	if err != nil {
+		return false, err
+	}
+	return claimed, nil
+}
+
+// Unclaim puts back a blind intro that was claimed but couldn't be revealed,
+// so that the reveal job tries again.
+func (b *BlindIntrosClient) Unclaim(ctx context.Context, intro BlindIntro) error {
+	return withRetry(ctx, func() error {
+		_, err := b.client.Collection("blindIntros").Doc(intro.ID).Set(ctx, intro)
+		return err
+	})
+}
+
+// ListDue returns the blind intros that should be revealed as of `now`.
+func (b *BlindIntrosClient) ListDue(ctx context.Context, now time.Time) ([]BlindIntro, error) {
+	iter := b.client.
+		Collection("blindIntros").
+		Where("revealAt", "<=", now.Unix()).
+		Documents(ctx)
+	return fetchBlindIntros(iter)
+}
+
+// fetchBlindIntros is like fetchAll, but also fills in each ID.
+func fetchBlindIntros(iter *firestore.DocumentIterator) ([]BlindIntro, error) {
+	defer iter.Stop()
+
+	var all []BlindIntro
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return all, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		var intro BlindIntro
+		if err := doc.DataTo(&intro); err != nil {
+			log.Printf("Skipping %q: %s", doc.Ref.Path, err)
+			continue
+		}
+		intro.ID = doc.Ref.ID
+
+		all = append(all, intro)
+	}
+}
@@ -0,0 +1,146 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestBlindIntro_SayHello(t *testing.T) {
+	intro := store.BlindIntro{Recursers: []int64{1, 2}}
+
+	assert.Equal(t, intro.SayHello(1, "hi!"), false)
+	assert.Equal(t, intro.SayHello(1, "anyone there?"), false)
+	assert.Equal(t, intro.Hello(1), "hi!")
+	assert.Equal(t, intro.Hello(2), "")
+	assert.Equal(t, intro.SayHello(2, "hello"), true)
+}
+
+func TestBlindIntro_IsDue(t *testing.T) {
+	now := time.Now()
+	intro := store.BlindIntro{RevealAt: now.Unix()}
+
+	assert.Equal(t, intro.IsDue(now.Add(-time.Second)), false)
+	assert.Equal(t, intro.IsDue(now), true)
+}
+
+func TestFirestoreBlindIntroConfigClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	configs := store.BlindIntroConfigs(client)
+
+	t.Run("off by default", func(t *testing.T) {
+		got, err := configs.Get(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, got, store.BlindIntroConfig{})
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		want := store.BlindIntroConfig{On: true, UpdatedBy: 1, Timestamp: 100}
+		assert.NoError(t, configs.Set(ctx, want))
+
+		got, err := configs.Get(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, got, want)
+	})
+}
+
+func TestFirestoreBlindIntrosClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	intros := store.BlindIntros(client)
+
+	now := time.Now()
+
+	sent := store.BlindIntro{
+		Recursers: []int64{1, 2},
+		Content:   "Hi you two!",
+		SendAt:    now.Add(-time.Hour).Unix(),
+		RevealAt:  now.Add(time.Hour).Unix(),
+	}
+	later := store.BlindIntro{
+		Recursers: []int64{3, 4},
+		Content:   "Hi you two!",
+		SendAt:    now.Add(time.Hour).Unix(),
+		RevealAt:  now.Add(2 * time.Hour).Unix(),
+	}
+	due := store.BlindIntro{
+		Recursers: []int64{5, 6},
+		Content:   "Hi you two!",
+		SendAt:    now.Add(-2 * time.Hour).Unix(),
+		RevealAt:  now.Add(-time.Second).Unix(),
+	}
+
+	for _, intro := range []store.BlindIntro{sent, later, due} {
+		assert.NoError(t, intros.Add(ctx, intro))
+	}
+
+	t.Run("gets an intro once it's sent", func(t *testing.T) {
+		got, err := intros.GetFor(ctx, store.DefaultRealm, 2, now)
+		assert.NoError(t, err)
+		if got == nil {
+			t.Fatal("got no intro")
+		}
+		assert.Equal(t, got.Recursers, sent.Recursers)
+
+		got, err = intros.GetFor(ctx, store.DefaultRealm, 3, now)
+		assert.NoError(t, err)
+		if got != nil {
+			t.Errorf("got an intro that hasn't been sent yet: %+v", got)
+		}
+
+		got, err = intros.GetFor(ctx, "sister", 2, now)
+		assert.NoError(t, err)
+		if got != nil {
+			t.Errorf("got someone else's intro from another realm: %+v", got)
+		}
+	})
+
+	t.Run("records hellos", func(t *testing.T) {
+		intro, err := intros.GetFor(ctx, store.DefaultRealm, 1, now)
+		assert.NoError(t, err)
+
+		got, err := intros.SayHello(ctx, intro.ID, 1, "hi!")
+		assert.NoError(t, err)
+		assert.Equal(t, got.AllSaidHello(), false)
+
+		got, err = intros.SayHello(ctx, intro.ID, 2, "hello")
+		assert.NoError(t, err)
+		assert.Equal(t, got.AllSaidHello(), true)
+		assert.Equal(t, got.Hello(1), "hi!")
+	})
+
+	t.Run("lists the due intros", func(t *testing.T) {
+		got, err := intros.ListDue(ctx, now)
+		assert.NoError(t, err)
+		if len(got) != 1 {
+			t.Fatalf("got %d due intros, want 1", len(got))
+		}
+		assert.Equal(t, got[0].Recursers, due.Recursers)
+	})
+
+	t.Run("claims an intro only once", func(t *testing.T) {
+		intro, err := intros.GetFor(ctx, store.DefaultRealm, 5, now)
+		assert.NoError(t, err)
+
+		claimed, err := intros.Claim(ctx, intro.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, claimed, true)
+
+		claimed, err = intros.Claim(ctx, intro.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, claimed, false)
+
+		// Once it's put back, it can be claimed again.
+		assert.NoError(t, intros.Unclaim(ctx, *intro))
+		claimed, err = intros.Claim(ctx, intro.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, claimed, true)
+	})
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DailyPost is where each match run publicly posts how many pairs it made,
+// so that maintainers can change it without a deploy.
+type DailyPost struct {
+	// Stream and Topic are where the post goes. There's no post if Stream is
+	// empty.
+	Stream string `firestore:"stream"`
+	Topic  string `firestore:"topic"`
+
+	// UpdatedBy is the Zulip ID of the maintainer who last changed it.
+	UpdatedBy int64 `firestore:"updatedBy"`
+	Timestamp int64 `firestore:"timestamp"`
+}
+
+// DailyPostClient manages where the daily post goes.
+type DailyPostClient struct {
+	client *firestore.Client
+}
+
+func DailyPosts(client *firestore.Client) *DailyPostClient {
+	return &DailyPostClient{client}
+}
+
+// Get returns where the daily post goes. It's all zero (so there's no post)
+// if it's never been set.
+func (d *DailyPostClient) Get(ctx context.Context) (DailyPost, error) {
+	doc, err := d.client.Collection("config").Doc("dailyPost").Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return DailyPost{}, nil
+	} else if err != nil {
+		return DailyPost{}, err
+	}
+
+	var post DailyPost
+	if err := doc.DataTo(&post); err != nil {
+		return DailyPost{}, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return post, nil
+}
+
+// Set replaces where the daily post goes.
+func (d *DailyPostClient) Set(ctx context.Context, post DailyPost) error {
+	return withRetry(ctx, func() error {
+		_, err := d.client.Collection("config").Doc("dailyPost").Set(ctx, post)
+		return err
+	})
+}
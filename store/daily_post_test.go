@@ -0,0 +1,32 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreDailyPostClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	posts := store.DailyPosts(client)
+
+	t.Run("off by default", func(t *testing.T) {
+		got, err := posts.Get(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, got, store.DailyPost{})
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		want := store.DailyPost{Stream: "pairing", Topic: "daily matches", UpdatedBy: 1, Timestamp: 100}
+		assert.NoError(t, posts.Set(ctx, want))
+
+		got, err := posts.Get(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, got, want)
+	})
+}
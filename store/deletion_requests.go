@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A DeletionRequest is a Recurser's request to delete all of their data,
+// waiting for them to confirm it.
+type DeletionRequest struct {
+	UserID    int64 `firestore:"userId"`
+	Timestamp int64 `firestore:"timestamp"`
+
+	// AnonymizeMatches is set if the Recurser also wants to be removed from
+	// their past matches (see PairingsClient.AnonymizeMatchesFor).
+	AnonymizeMatches bool `firestore:"anonymizeMatches"`
+
+	// Realm is the Zulip realm of the Recurser (see Recurser.Realm).
+	Realm string `firestore:"realm,omitempty"`
+}
+
+// DeletionRequestsClient manages deletion requests that haven't been
+// confirmed yet.
+type DeletionRequestsClient struct {
+	client *firestore.Client
+}
+
+func DeletionRequests(client *firestore.Client) *DeletionRequestsClient {
+	return &DeletionRequestsClient{client}
+}
+
+// Set replaces the Recurser's deletion request.
+func (d *DeletionRequestsClient) Set(ctx context.Context, req DeletionRequest) error {
+	docID := recurserDocID(req.Realm, req.UserID)
+	return withRetry(ctx, func() error {
+		_, err := d.client.Collection("deletionRequests").Doc(docID).Set(ctx, req)
+		return err
+	})
+}
+
+// Get returns the Recurser's deletion request, or nil if they don't have one.
+func (d *DeletionRequestsClient) Get(ctx context.Context, realm string, userID int64) (*DeletionRequest, error) {
+	docID := recurserDocID(realm, userID)
+	doc, err := d.client.Collection("deletionRequests").Doc(docID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var req DeletionRequest
+	if err := doc.DataTo(&req); err != nil {
+		return nil, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return &req, nil
+}
+
+// Delete discards the Recurser's deletion request, if there is one.
+func (d *DeletionRequestsClient) Delete(ctx context.Context, realm string, userID int64) error {
+	docID := recurserDocID(realm, userID)
+	return withRetry(ctx, func() error {
+		_, err := d.client.Collection("deletionRequests").Doc(docID).Delete(ctx)
+		return err
+	})
+}
@@ -0,0 +1,64 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreDeletionRequestsClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	requests := store.DeletionRequests(client)
+
+	req := store.DeletionRequest{
+		UserID:           pbtest.RandInt64(t),
+		Timestamp:        pbtest.RandInt64(t),
+		AnonymizeMatches: true,
+	}
+
+	if err := requests.Set(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := requests.Get(ctx, req.Realm, req.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, pending, &req)
+
+	if err := requests.Delete(ctx, req.Realm, req.UserID); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err = requests.Get(ctx, req.Realm, req.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var none *store.DeletionRequest
+	assert.Equal(t, pending, none)
+
+	t.Run("same ID in another realm", func(t *testing.T) {
+		theirs := store.DeletionRequest{UserID: req.UserID, Timestamp: req.Timestamp, Realm: "sister"}
+		if err := requests.Set(ctx, theirs); err != nil {
+			t.Fatal(err)
+		}
+
+		pending, err := requests.Get(ctx, store.DefaultRealm, req.UserID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, pending, none)
+
+		pending, err = requests.Get(ctx, "sister", req.UserID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, pending, &theirs)
+	})
+}
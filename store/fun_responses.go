@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A FunResponse is a lightweight command, like `encourage`, that replies with
+// one of a list of responses. Maintainers can change them without a deploy.
+type FunResponse struct {
+	Keyword   string   `firestore:"keyword"`
+	Responses []string `firestore:"responses"`
+}
+
+// FunResponsesClient manages the fun responses. Each keyword is a document in
+// the funResponses collection.
+type FunResponsesClient struct {
+	client *firestore.Client
+}
+
+func FunResponses(client *firestore.Client) *FunResponsesClient {
+	return &FunResponsesClient{client}
+}
+
+// Get returns the responses for the keyword, or nil if there aren't any.
+func (f *FunResponsesClient) Get(ctx context.Context, keyword string) ([]string, error) {
+	doc, err := f.client.Collection("funResponses").Doc(keyword).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var fun FunResponse
+	if err := doc.DataTo(&fun); err != nil {
+		return nil, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return fun.Responses, nil
+}
+
+// GetAll returns every keyword and its responses, in alphabetical order.
+func (f *FunResponsesClient) GetAll(ctx context.Context) ([]FunResponse, error) {
+	iter := f.client.Collection("funResponses").OrderBy("keyword", firestore.Asc).Documents(ctx)
+	return fetchAll[FunResponse](iter)
+}
+
+// Add adds a response to the keyword, creating the keyword if it's new.
+// Adding a response the keyword already has has no effect.
+func (f *FunResponsesClient) Add(ctx context.Context, keyword, response string) error {
+	doc := f.client.Collection("funResponses").Doc(keyword)
+	return withRetry(ctx, func() error {
+		_, err := doc.Set(ctx, map[string]any{
+			"keyword":   keyword,
+			"responses": firestore.ArrayUnion(response),
+		}, firestore.MergeAll)
+		return err
+	})
+}
+
+// Remove removes one response from the keyword, leaving the rest.
+func (f *FunResponsesClient) Remove(ctx context.Context, keyword, response string) error {
+	doc := f.client.Collection("funResponses").Doc(keyword)
+	return withRetry(ctx, func() error {
+		_, err := doc.Update(ctx, []firestore.Update{
+			{Path: "responses", Value: firestore.ArrayRemove(response)},
+		})
+		return err
+	})
+}
+
+// Delete removes the keyword and all of its responses.
+func (f *FunResponsesClient) Delete(ctx context.Context, keyword string) error {
+	return withRetry(ctx, func() error {
+		_, err := f.client.Collection("funResponses").Doc(keyword).Delete(ctx)
+		return err
+	})
+}
@@ -0,0 +1,53 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreFunResponsesClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	fun := store.FunResponses(client)
+
+	t.Run("missing keyword", func(t *testing.T) {
+		got, err := fun.Get(ctx, "encourage")
+		assert.NoError(t, err)
+		assert.Equal(t, len(got), 0)
+	})
+
+	t.Run("add and remove", func(t *testing.T) {
+		assert.NoError(t, fun.Add(ctx, "encourage", "You've got this!"))
+		assert.NoError(t, fun.Add(ctx, "encourage", "Keep going!"))
+		assert.NoError(t, fun.Add(ctx, "encourage", "Keep going!"))
+		assert.NoError(t, fun.Add(ctx, "cheer", "Hooray!"))
+
+		got, err := fun.Get(ctx, "encourage")
+		assert.NoError(t, err)
+		assert.Equal(t, got, []string{"You've got this!", "Keep going!"})
+
+		assert.NoError(t, fun.Remove(ctx, "encourage", "You've got this!"))
+		got, err = fun.Get(ctx, "encourage")
+		assert.NoError(t, err)
+		assert.Equal(t, got, []string{"Keep going!"})
+	})
+
+	t.Run("get all and delete", func(t *testing.T) {
+		all, err := fun.GetAll(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, all, []store.FunResponse{
+			{Keyword: "cheer", Responses: []string{"Hooray!"}},
+			{Keyword: "encourage", Responses: []string{"Keep going!"}},
+		})
+
+		assert.NoError(t, fun.Delete(ctx, "cheer"))
+		got, err := fun.Get(ctx, "cheer")
+		assert.NoError(t, err)
+		assert.Equal(t, len(got), 0)
+	})
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"log"
+	"slices"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// A HistoryEntry is one command that a Recurser sent to Pairing Bot. These are
+// only recorded for Recursers who opt in with KeepHistory.
+type HistoryEntry struct {
+	UserID    int64  `firestore:"userId"`
+	Command   string `firestore:"command"`
+	Timestamp int64  `firestore:"timestamp"`
+
+	// Realm is the Zulip realm of the Recurser (see Recurser.Realm).
+	Realm string `firestore:"realm,omitempty"`
+}
+
+// HistoryClient manages Recursers' command histories.
+type HistoryClient struct {
+	client *firestore.Client
+}
+
+func History(client *firestore.Client) *HistoryClient {
+	return &HistoryClient{client}
+}
+
+func (h *HistoryClient) Insert(ctx context.Context, entry HistoryEntry) error {
+	_, _, err := h.client.Collection("history").Add(ctx, entry)
+	return err
+}
+
+// GetLastN returns (up to) the n most recent commands sent by the Recurser,
+// newest first.
+//
+// This query needs a composite index on (userId, timestamp desc).
+func (h *HistoryClient) GetLastN(ctx context.Context, realm string, userID int64, n int) ([]HistoryEntry, error) {
+	// Entries in the default realm don't have the field at all, so the realm
+	// can't be part of the query. Anyone else with the same ID is skipped
+	// instead.
+	iter := h.client.
+		Collection("history").
+		Where("userId", "==", userID).
+		OrderBy("timestamp", firestore.Desc).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var entries []HistoryEntry
+	for len(entries) < n {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		var entry HistoryEntry
+		if err := doc.DataTo(&entry); err != nil {
+			log.Printf("Skipping %q: %s", doc.Ref.Path, err)
+			continue
+		}
+		if entry.Realm == realm {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// GetAllFor returns every command recorded for the Recurser, in no particular
+// order.
+func (h *HistoryClient) GetAllFor(ctx context.Context, realm string, userID int64) ([]HistoryEntry, error) {
+	iter := h.client.
+		Collection("history").
+		Where("userId", "==", userID).
+		Documents(ctx)
+	all, err := fetchAll[HistoryEntry](iter)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(all, func(e HistoryEntry) bool { return e.Realm != realm }), nil
+}
+
+// DeleteAllFor deletes every command recorded for the Recurser, and returns
+// how many there were.
+func (h *HistoryClient) DeleteAllFor(ctx context.Context, realm string, userID int64) (int, error) {
+	iter := h.client.
+		Collection("history").
+		Where("userId", "==", userID).
+		Documents(ctx)
+	return deleteAllIn(ctx, iter, realm)
+}
@@ -0,0 +1,108 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreHistoryClient(t *testing.T) {
+	t.Run("newest first", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		history := store.History(client)
+
+		userID := pbtest.RandInt64(t)
+		entries := []store.HistoryEntry{
+			{UserID: userID, Command: "subscribe", Timestamp: 100},
+			{UserID: userID, Command: "status", Timestamp: 300},
+			{UserID: userID, Command: "schedule mon", Timestamp: 200},
+
+			// Someone else's command shouldn't show up, even if they have
+			// the same ID in another realm.
+			{UserID: userID + 1, Command: "cookie", Timestamp: 400},
+			{UserID: userID, Command: "cookie", Timestamp: 500, Realm: "sister"},
+		}
+		for _, entry := range entries {
+			if err := history.Insert(ctx, entry); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		actual, err := history.GetLastN(ctx, store.DefaultRealm, userID, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, actual, []store.HistoryEntry{entries[1], entries[2]})
+	})
+	t.Run("everything for one user", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		history := store.History(client)
+
+		userID := pbtest.RandInt64(t)
+		mine := store.HistoryEntry{UserID: userID, Command: "status", Timestamp: 100}
+		for _, entry := range []store.HistoryEntry{mine, {UserID: userID + 1, Command: "cookie", Timestamp: 200}} {
+			if err := history.Insert(ctx, entry); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		actual, err := history.GetAllFor(ctx, store.DefaultRealm, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, actual, []store.HistoryEntry{mine})
+	})
+	t.Run("delete everything for one user", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		history := store.History(client)
+
+		userID := pbtest.RandInt64(t)
+		theirs := store.HistoryEntry{UserID: userID + 1, Command: "cookie", Timestamp: 300}
+		lookalike := store.HistoryEntry{UserID: userID, Command: "help", Timestamp: 400, Realm: "sister"}
+		for _, entry := range []store.HistoryEntry{
+			{UserID: userID, Command: "status", Timestamp: 100},
+			{UserID: userID, Command: "next", Timestamp: 200},
+			theirs,
+			lookalike,
+		} {
+			if err := history.Insert(ctx, entry); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		deleted, err := history.DeleteAllFor(ctx, store.DefaultRealm, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, deleted, 2)
+
+		mine, err := history.GetAllFor(ctx, store.DefaultRealm, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, len(mine), 0)
+
+		others, err := history.GetAllFor(ctx, store.DefaultRealm, theirs.UserID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, others, []store.HistoryEntry{theirs})
+
+		others, err = history.GetAllFor(ctx, "sister", userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, others, []store.HistoryEntry{lookalike})
+	})
+}
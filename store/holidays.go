@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A Holiday is a day when nobody is matched, whatever their schedules say.
+type Holiday struct {
+	// Date is the day, formatted as time.DateOnly. This is also the document
+	// ID.
+	Date string `firestore:"date"`
+	Name string `firestore:"name,omitempty"`
+
+	// Announce is set if the match run on the holiday posts about it in the
+	// welcome stream.
+	Announce bool `firestore:"announce,omitempty"`
+
+	// AddedBy is the Zulip ID of the maintainer who added it.
+	AddedBy int64 `firestore:"addedBy"`
+}
+
+// HolidayConfig is the holiday calendar's settings. Maintainers can change it
+// without a deploy.
+type HolidayConfig struct {
+	// Timezone is the calendar's time zone, like "America/New_York", which
+	// decides which match run falls on a holiday. Empty means UTC.
+	Timezone string `firestore:"timezone"`
+
+	// UpdatedBy is the Zulip ID of the maintainer who last changed it.
+	UpdatedBy int64 `firestore:"updatedBy"`
+	Timestamp int64 `firestore:"timestamp"`
+}
+
+// HolidaysClient manages the community's holiday calendar.
+type HolidaysClient struct {
+	client *firestore.Client
+}
+
+func Holidays(client *firestore.Client) *HolidaysClient {
+	return &HolidaysClient{client}
+}
+
+// Get returns the holiday on the date, or nil if it isn't one.
+func (h *HolidaysClient) Get(ctx context.Context, date string) (*Holiday, error) {
+	doc, err := h.client.Collection("holidays").Doc(date).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var holiday Holiday
+	if err := doc.DataTo(&holiday); err != nil {
+		return nil, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return &holiday, nil
+}
+
+// ListFrom returns the holidays on or after the date, soonest first.
+func (h *HolidaysClient) ListFrom(ctx context.Context, date string) ([]Holiday, error) {
+	iter := h.client.
+		Collection("holidays").
+		Where("date", ">=", date).
+		OrderBy("date", firestore.Asc).
+		Documents(ctx)
+	return fetchAll[Holiday](iter)
+}
+
+// Set adds the holiday, replacing any other one on the same date.
+func (h *HolidaysClient) Set(ctx context.Context, holiday Holiday) error {
+	return withRetry(ctx, func() error {
+		_, err := h.client.Collection("holidays").Doc(holiday.Date).Set(ctx, holiday)
+		return err
+	})
+}
+
+// Delete removes the holiday on the date, if there is one.
+func (h *HolidaysClient) Delete(ctx context.Context, date string) error {
+	return withRetry(ctx, func() error {
+		_, err := h.client.Collection("holidays").Doc(date).Delete(ctx)
+		return err
+	})
+}
+
+// GetConfig returns the calendar's settings. It's all zero (so the calendar is
+// in UTC) if they've never been set.
+func (h *HolidaysClient) GetConfig(ctx context.Context) (HolidayConfig, error) {
+	doc, err := h.client.Collection("config").Doc("holidays").Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return HolidayConfig{}, nil
+	} else if err != nil {
+		return HolidayConfig{}, err
+	}
+
+	var config HolidayConfig
+	if err := doc.DataTo(&config); err != nil {
+		return HolidayConfig{}, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return config, nil
+}
+
+// SetConfig replaces the calendar's settings.
+func (h *HolidaysClient) SetConfig(ctx context.Context, config HolidayConfig) error {
+	return withRetry(ctx, func() error {
+		_, err := h.client.Collection("config").Doc("holidays").Set(ctx, config)
+		return err
+	})
+}
@@ -0,0 +1,41 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreHolidaysClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	holidays := store.Holidays(client)
+
+	got, err := holidays.Get(ctx, "2024-12-25")
+	assert.NoError(t, err)
+	assert.Equal(t, got, (*store.Holiday)(nil))
+
+	newYear := store.Holiday{Date: "2025-01-01", Name: "New Year's Day", AddedBy: 1}
+	christmas := store.Holiday{Date: "2024-12-25", Name: "Christmas", Announce: true, AddedBy: 1}
+	past := store.Holiday{Date: "2024-07-04", AddedBy: 1}
+	for _, holiday := range []store.Holiday{newYear, christmas, past} {
+		assert.NoError(t, holidays.Set(ctx, holiday))
+	}
+
+	got, err = holidays.Get(ctx, "2024-12-25")
+	assert.NoError(t, err)
+	assert.Equal(t, got, &christmas)
+
+	upcoming, err := holidays.ListFrom(ctx, "2024-12-01")
+	assert.NoError(t, err)
+	assert.Equal(t, upcoming, []store.Holiday{christmas, newYear})
+
+	assert.NoError(t, holidays.Delete(ctx, "2024-12-25"))
+	got, err = holidays.Get(ctx, "2024-12-25")
+	assert.NoError(t, err)
+	assert.Equal(t, got, (*store.Holiday)(nil))
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Maintenance is the persisted maintenance mode flag. While it's on, the cron
+// jobs don't run.
+type Maintenance struct {
+	On bool `firestore:"on"`
+
+	// UpdatedBy is the Zulip ID of the maintainer who last changed it.
+	UpdatedBy int64 `firestore:"updatedBy"`
+	Timestamp int64 `firestore:"timestamp"`
+}
+
+// MaintenanceClient manages the maintenance mode flag.
+type MaintenanceClient struct {
+	client *firestore.Client
+}
+
+func MaintenanceMode(client *firestore.Client) *MaintenanceClient {
+	return &MaintenanceClient{client}
+}
+
+// Get returns the current maintenance mode, which is off if it's never been
+// set.
+func (m *MaintenanceClient) Get(ctx context.Context) (Maintenance, error) {
+	doc, err := m.client.Collection("config").Doc("maintenance").Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return Maintenance{}, nil
+	} else if err != nil {
+		return Maintenance{}, err
+	}
+
+	var maintenance Maintenance
+	if err := doc.DataTo(&maintenance); err != nil {
+		return Maintenance{}, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return maintenance, nil
+}
+
+// Set replaces the maintenance mode.
+func (m *MaintenanceClient) Set(ctx context.Context, maintenance Maintenance) error {
+	return withRetry(ctx, func() error {
+		_, err := m.client.Collection("config").Doc("maintenance").Set(ctx, maintenance)
+		return err
+	})
+}
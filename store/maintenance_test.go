@@ -0,0 +1,32 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreMaintenanceClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	maintenance := store.MaintenanceMode(client)
+
+	t.Run("off by default", func(t *testing.T) {
+		got, err := maintenance.Get(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, got, store.Maintenance{})
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		want := store.Maintenance{On: true, UpdatedBy: 1, Timestamp: 100}
+		assert.NoError(t, maintenance.Set(ctx, want))
+
+		got, err := maintenance.Get(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, got, want)
+	})
+}
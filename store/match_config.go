@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MatchConfig is the persisted settings for the daily match run, which
+// maintainers can change without a deploy.
+type MatchConfig struct {
+	// MinParticipants is the fewest Recursers a match run needs. Runs with
+	// fewer are skipped. Zero means any number will do.
+	MinParticipants int `firestore:"minParticipants"`
+
+	// AnnounceSkips is set if a skipped run should tell the welcome stream
+	// that there weren't enough people today.
+	AnnounceSkips bool `firestore:"announceSkips"`
+
+	// UpdatedBy is the Zulip ID of the maintainer who last changed it.
+	UpdatedBy int64 `firestore:"updatedBy"`
+	Timestamp int64 `firestore:"timestamp"`
+}
+
+// MatchConfigClient manages the match run settings.
+type MatchConfigClient struct {
+	client *firestore.Client
+}
+
+func MatchConfigs(client *firestore.Client) *MatchConfigClient {
+	return &MatchConfigClient{client}
+}
+
+// Get returns the current match run settings, which are all zero if they've
+// never been set.
+func (m *MatchConfigClient) Get(ctx context.Context) (MatchConfig, error) {
+	doc, err := m.client.Collection("config").Doc("match").Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return MatchConfig{}, nil
+	} else if err != nil {
+		return MatchConfig{}, err
+	}
+
+	var config MatchConfig
+	if err := doc.DataTo(&config); err != nil {
+		return MatchConfig{}, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return config, nil
+}
+
+// Set replaces the match run settings.
+func (m *MatchConfigClient) Set(ctx context.Context, config MatchConfig) error {
+	return withRetry(ctx, func() error {
+		_, err := m.client.Collection("config").Doc("match").Set(ctx, config)
+		return err
+	})
+}
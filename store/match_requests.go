@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A MatchRequest is a Recurser waiting for an on-demand ("match now") partner.
+type MatchRequest struct {
+	ID        int64  `firestore:"id"`
+	Name      string `firestore:"name"`
+	Timestamp int64  `firestore:"timestamp"`
+
+	// Blocked are the user IDs the Recurser has blocked (see Recurser.Blocks),
+	// copied here so that claiming a request can respect them.
+	Blocked []int64 `firestore:"blocked"`
+
+	// Realm is the Recurser's realm (see Recurser.Realm). Requests are only
+	// claimed by people in the same one.
+	Realm string `firestore:"realm,omitempty"`
+}
+
+// MatchRequestsClient manages pending on-demand match requests.
+type MatchRequestsClient struct {
+	client *firestore.Client
+}
+
+func MatchRequests(client *firestore.Client) *MatchRequestsClient {
+	return &MatchRequestsClient{client}
+}
+
+// Set adds (or refreshes) the Recurser's pending request.
+func (m *MatchRequestsClient) Set(ctx context.Context, req MatchRequest) error {
+	docID := recurserDocID(req.Realm, req.ID)
+	_, err := m.client.Collection("matchRequests").Doc(docID).Set(ctx, req)
+	return err
+}
+
+// Delete withdraws the Recurser's pending request, if there is one, and
+// returns whether there was.
+func (m *MatchRequestsClient) Delete(ctx context.Context, realm string, userID int64) (bool, error) {
+	docID := recurserDocID(realm, userID)
+	_, err := m.client.Collection("matchRequests").Doc(docID).Delete(ctx, firestore.Exists)
+	if status.Code(err) == codes.NotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ClaimOldest removes and returns the oldest request made after `since` by
+// anyone other than userID in their realm. Requests from people that userID has
+// blocked (or who have blocked userID) are left alone. If there are no such
+// requests, this returns nil.
+//
+// Requests made at or before `since` have expired, so they're deleted along the
+// way. This runs in a transaction so that two people can't claim the same
+// request.
+func (m *MatchRequestsClient) ClaimOldest(ctx context.Context, realm string, userID int64, blocked []int64, since time.Time) (*MatchRequest, error) {
+	var claimed *MatchRequest
+
+	err := m.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		claimed = nil
+
+		// All of the reads have to come before any of the writes.
+		expiredQuery := m.client.
+			Collection("matchRequests").
+			Where("timestamp", "<=", since.Unix())
+
+		expired, err := tx.Documents(expiredQuery).GetAll()
+		if err != nil {
+			return err
+		}
+
+		query := m.client.
+			Collection("matchRequests").
+			Where("timestamp", ">", since.Unix()).
+			OrderBy("timestamp", firestore.Asc)
+
+		docs, err := tx.Documents(query).GetAll()
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range expired {
+			if err := tx.Delete(doc.Ref); err != nil {
+				return err
+			}
+		}
+
+		for _, doc := range docs {
+			var req MatchRequest
+			if err := doc.DataTo(&req); err != nil {
+				continue
+			}
+			if req.Realm != realm || req.ID == userID || slices.Contains(blocked, req.ID) || slices.Contains(req.Blocked, userID) {
+				continue
+			}
+
+			claimed = &req
+			return tx.Delete(doc.Ref)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
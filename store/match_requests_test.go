@@ -0,0 +1,127 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreMatchRequestsClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	requests := store.MatchRequests(client)
+
+	now := time.Now()
+	window := now.Add(-30 * time.Minute)
+
+	expired := store.MatchRequest{ID: 1, Name: "Expired", Timestamp: now.Add(-time.Hour).Unix()}
+	older := store.MatchRequest{ID: 2, Name: "Older", Timestamp: now.Add(-10 * time.Minute).Unix()}
+	newer := store.MatchRequest{ID: 3, Name: "Newer", Timestamp: now.Add(-5 * time.Minute).Unix()}
+
+	for _, req := range []store.MatchRequest{expired, older, newer} {
+		if err := requests.Set(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("claims the oldest unexpired request", func(t *testing.T) {
+		claimed, err := requests.ClaimOldest(ctx, store.DefaultRealm, 4, nil, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, claimed, &older)
+	})
+
+	t.Run("deletes expired requests", func(t *testing.T) {
+		// Even with a window that would include it, it's gone now.
+		claimed, err := requests.ClaimOldest(ctx, store.DefaultRealm, 4, []int64{3}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var none *store.MatchRequest
+		assert.Equal(t, claimed, none)
+	})
+
+	t.Run("never claims your own request", func(t *testing.T) {
+		claimed, err := requests.ClaimOldest(ctx, store.DefaultRealm, 3, nil, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var none *store.MatchRequest
+		assert.Equal(t, claimed, none)
+	})
+
+	t.Run("deleted requests can't be claimed", func(t *testing.T) {
+		deleted, err := requests.Delete(ctx, store.DefaultRealm, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, deleted, true)
+
+		// There's nothing left to delete the second time.
+		deleted, err = requests.Delete(ctx, store.DefaultRealm, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, deleted, false)
+
+		claimed, err := requests.ClaimOldest(ctx, store.DefaultRealm, 4, nil, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var none *store.MatchRequest
+		assert.Equal(t, claimed, none)
+	})
+
+	t.Run("blocks work both ways", func(t *testing.T) {
+		blocker := store.MatchRequest{ID: 5, Name: "Blocker", Timestamp: now.Add(-2 * time.Minute).Unix(), Blocked: []int64{6}}
+		other := store.MatchRequest{ID: 7, Name: "Other", Timestamp: now.Add(-time.Minute).Unix()}
+
+		for _, req := range []store.MatchRequest{blocker, other} {
+			if err := requests.Set(ctx, req); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// The blocker's request is older, but they blocked 6.
+		claimed, err := requests.ClaimOldest(ctx, store.DefaultRealm, 6, nil, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, claimed, &other)
+
+		// And 8 has blocked the blocker.
+		if err := requests.Set(ctx, other); err != nil {
+			t.Fatal(err)
+		}
+		claimed, err = requests.ClaimOldest(ctx, store.DefaultRealm, 8, []int64{5}, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, claimed, &other)
+	})
+
+	t.Run("only claims requests from the same realm", func(t *testing.T) {
+		elsewhere := store.MatchRequest{ID: 9, Name: "Elsewhere", Timestamp: now.Add(-time.Minute).Unix(), Realm: "sister"}
+		if err := requests.Set(ctx, elsewhere); err != nil {
+			t.Fatal(err)
+		}
+
+		claimed, err := requests.ClaimOldest(ctx, store.DefaultRealm, 10, nil, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var none *store.MatchRequest
+		assert.Equal(t, claimed, none)
+
+		claimed, err = requests.ClaimOldest(ctx, "sister", 10, nil, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, claimed, &elsewhere)
+	})
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A MatchRun records that the daily matches were made, so that a repeated
+// request for the same day doesn't match everyone again.
+type MatchRun struct {
+	// Date is the UTC day of the run, formatted as time.DateOnly. This is
+	// also the document ID.
+	Date      string `firestore:"date"`
+	Timestamp int64  `firestore:"timestamp"`
+}
+
+// MatchRunsClient manages the record of completed match runs.
+type MatchRunsClient struct {
+	client *firestore.Client
+}
+
+func MatchRuns(client *firestore.Client) *MatchRunsClient {
+	return &MatchRunsClient{client}
+}
+
+// HasRun returns whether a match run was already recorded for the date.
+func (m *MatchRunsClient) HasRun(ctx context.Context, date string) (bool, error) {
+	_, err := m.client.Collection("matchRuns").Doc(date).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Record marks the run's date as done.
+func (m *MatchRunsClient) Record(ctx context.Context, run MatchRun) error {
+	return withRetry(ctx, func() error {
+		_, err := m.client.Collection("matchRuns").Doc(run.Date).Set(ctx, run)
+		return err
+	})
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A PairRequest is one Recurser asking to pair with another particular one
+// (with `pair`), waiting for them to accept or decline.
+type PairRequest struct {
+	From     int64  `firestore:"from"`
+	FromName string `firestore:"fromName"`
+	To       int64  `firestore:"to"`
+	ToName   string `firestore:"toName"`
+
+	// ExpiresAt is the Unix timestamp after which the request can no longer
+	// be accepted.
+	ExpiresAt int64 `firestore:"expiresAt"`
+
+	// Realm is the Zulip realm of both Recursers (see Recurser.Realm).
+	Realm string `firestore:"realm,omitempty"`
+}
+
+// IsExpired returns whether it's too late to accept the request as of `now`.
+func (p *PairRequest) IsExpired(now time.Time) bool {
+	return now.Unix() >= p.ExpiresAt
+}
+
+// PairRequestsClient manages pending requests to pair with a particular
+// person. Each Recurser has at most one waiting for them.
+type PairRequestsClient struct {
+	client *firestore.Client
+}
+
+func PairRequests(client *firestore.Client) *PairRequestsClient {
+	return &PairRequestsClient{client}
+}
+
+// Get returns the request waiting for the Recurser, if there's one that hasn't
+// expired as of `now`. Otherwise it returns nil.
+func (p *PairRequestsClient) Get(ctx context.Context, realm string, to int64, now time.Time) (*PairRequest, error) {
+	doc, err := p.client.Collection("pairRequests").Doc(recurserDocID(realm, to)).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var req PairRequest
+	if err := doc.DataTo(&req); err != nil {
+		return nil, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	if req.IsExpired(now) {
+		return nil, nil
+	}
+	return &req, nil
+}
+
+// Set saves a request, replacing any other request to the same person.
+func (p *PairRequestsClient) Set(ctx context.Context, req PairRequest) error {
+	return withRetry(ctx, func() error {
+		_, err := p.client.Collection("pairRequests").Doc(recurserDocID(req.Realm, req.To)).Set(ctx, req)
+		return err
+	})
+}
+
+// Take removes and returns the request waiting for the Recurser, like Get.
+// Expired requests are removed too, but not returned.
+//
+// This runs in a transaction so that a request can only be answered once.
+func (p *PairRequestsClient) Take(ctx context.Context, realm string, to int64, now time.Time) (*PairRequest, error) {
+	doc := p.client.Collection("pairRequests").Doc(recurserDocID(realm, to))
+
+	var taken *PairRequest
+	err := p.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		taken = nil
+
+		snapshot, err := tx.Get(doc)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var req PairRequest
+		if err := snapshot.DataTo(&req); err != nil {
+			return fmt.Errorf("parse document %q: %w", doc.Path, err)
+		}
+		if !req.IsExpired(now) {
+			taken = &req
+		}
+		return tx.Delete(doc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return taken, nil
+}
+
+// DeleteAllFor deletes every request to or from the Recurser, and returns how
+// many there were.
+func (p *PairRequestsClient) DeleteAllFor(ctx context.Context, realm string, userID int64) (int, error) {
+	var count int
+	for _, field := range []string{"to", "from"} {
+		iter := p.client.
+			Collection("pairRequests").
+			Where(field, "==", userID).
+			Documents(ctx)
+		n, err := deleteAllIn(ctx, iter, realm)
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+	return count, nil
+}
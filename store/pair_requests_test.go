@@ -0,0 +1,77 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestorePairRequestsClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	requests := store.PairRequests(client)
+
+	now := time.Now()
+	req := store.PairRequest{
+		From:      pbtest.RandInt64(t),
+		FromName:  "Ada",
+		To:        pbtest.RandInt64(t),
+		ToName:    "Grace",
+		ExpiresAt: now.Add(time.Hour).Unix(),
+		Realm:     store.DefaultRealm,
+	}
+
+	t.Run("nothing waiting", func(t *testing.T) {
+		got, err := requests.Get(ctx, req.Realm, req.To, now)
+		assert.NoError(t, err)
+		assert.Equal(t, got, (*store.PairRequest)(nil))
+
+		taken, err := requests.Take(ctx, req.Realm, req.To, now)
+		assert.NoError(t, err)
+		assert.Equal(t, taken, (*store.PairRequest)(nil))
+	})
+
+	t.Run("taken once", func(t *testing.T) {
+		assert.NoError(t, requests.Set(ctx, req))
+
+		got, err := requests.Get(ctx, req.Realm, req.To, now)
+		assert.NoError(t, err)
+		assert.Equal(t, got, &req)
+
+		// It only waits for the person it's for.
+		got, err = requests.Get(ctx, req.Realm, req.From, now)
+		assert.NoError(t, err)
+		assert.Equal(t, got, (*store.PairRequest)(nil))
+
+		taken, err := requests.Take(ctx, req.Realm, req.To, now)
+		assert.NoError(t, err)
+		assert.Equal(t, taken, &req)
+
+		taken, err = requests.Take(ctx, req.Realm, req.To, now)
+		assert.NoError(t, err)
+		assert.Equal(t, taken, (*store.PairRequest)(nil))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		assert.NoError(t, requests.Set(ctx, req))
+		later := time.Unix(req.ExpiresAt, 0)
+
+		got, err := requests.Get(ctx, req.Realm, req.To, later)
+		assert.NoError(t, err)
+		assert.Equal(t, got, (*store.PairRequest)(nil))
+
+		// Taking an expired request clears it out without returning it.
+		taken, err := requests.Take(ctx, req.Realm, req.To, later)
+		assert.NoError(t, err)
+		assert.Equal(t, taken, (*store.PairRequest)(nil))
+
+		got, err = requests.Get(ctx, req.Realm, req.To, now)
+		assert.NoError(t, err)
+		assert.Equal(t, got, (*store.PairRequest)(nil))
+	})
+}
@@ -1,18 +1,74 @@
 package store
 
 import (
+	"cmp"
 	"context"
+	"fmt"
 	"log"
+	"slices"
 	"strconv"
 	"time"
 
 	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
 	"google.golang.org/api/iterator"
 )
 
+// A Pairing records the results of one day's match run.
 type Pairing struct {
+	// Value is the number of matches (pairs, plus the occasional group of
+	// three) made that day.
 	Value     int   `firestore:"value"`
 	Timestamp int64 `firestore:"timestamp"`
+
+	// NumRecursers is the total number of Recursers in those matches. This
+	// isn't always 2*Value, because of groups of three.
+	NumRecursers int `firestore:"numRecursers"`
+}
+
+// A Match records a group of Recursers who were paired with each other.
+type Match struct {
+	// ID is the Firestore document ID. Only LatestMatchFor fills it in, and
+	// it is not written to or read from the document itself.
+	ID string `firestore:"-"`
+
+	Recursers []int64 `firestore:"recursers"`
+	Timestamp int64   `firestore:"timestamp"`
+
+	// Realm is the Zulip realm of the Recursers (see Recurser.Realm). Their
+	// IDs only mean anything within it, so lookups by ID check it too.
+	Realm string `firestore:"realm,omitempty"`
+
+	// NoShowReportedBy lists the Recursers in the match who reported that
+	// their partner didn't show up.
+	NoShowReportedBy []int64 `firestore:"noShowReportedBy,omitempty"`
+
+	// Ratings has each rating (1 to 5) that someone in the match gave it,
+	// keyed by their ID (as a string, since Firestore map keys have to be).
+	// They're private: only the maintainers see them, and only as averages.
+	Ratings map[string]int `firestore:"ratings,omitempty"`
+}
+
+// RatingBy returns the rating that the Recurser gave the match, if any.
+func (m *Match) RatingBy(userID int64) (int, bool) {
+	rating, ok := m.Ratings[strconv.FormatInt(userID, 10)]
+	return rating, ok
+}
+
+// AverageRating returns the average of all the ratings in the matches, and how
+// many ratings there were. The average is zero if there weren't any.
+func AverageRating(matches []Match) (float64, int) {
+	var total, count int
+	for _, match := range matches {
+		for _, rating := range match.Ratings {
+			total += rating
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	return float64(total) / float64(count), count
 }
 
 // PairingsClient manages pairing (matching) result records.
@@ -27,8 +83,10 @@ func Pairings(client *firestore.Client) *PairingsClient {
 func (p *PairingsClient) SetNumPairings(ctx context.Context, pairing Pairing) error {
 	timestampAsString := strconv.FormatInt(pairing.Timestamp, 10)
 
-	_, err := p.client.Collection("pairings").Doc(timestampAsString).Set(ctx, pairing)
-	return err
+	return withRetry(ctx, func() error {
+		_, err := p.client.Collection("pairings").Doc(timestampAsString).Set(ctx, pairing)
+		return err
+	})
 }
 
 func (p *PairingsClient) GetTotalPairingsDuringLastWeek(ctx context.Context) (int, error) {
@@ -59,3 +117,289 @@ func (p *PairingsClient) GetTotalPairingsDuringLastWeek(ctx context.Context) (in
 
 	return totalPairings, nil
 }
+
+// GetTotalPairings returns the number of matches made in every match run, ever.
+// It's a single aggregation query, rather than reading every day's results.
+func (p *PairingsClient) GetTotalPairings(ctx context.Context) (int, error) {
+	result, err := p.client.Collection("pairings").NewAggregationQuery().WithSum("value", "total").Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Sums of integers stay integers, unless they'd overflow.
+	total, ok := result["total"].(*firestorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("unexpected sum of pairings: %v", result["total"])
+	}
+	switch v := total.GetValueType().(type) {
+	case *firestorepb.Value_IntegerValue:
+		return int(v.IntegerValue), nil
+	case *firestorepb.Value_DoubleValue:
+		return int(v.DoubleValue), nil
+	}
+	return 0, fmt.Errorf("unexpected sum of pairings: %v", total)
+}
+
+// GetAllPairings returns every day's match run results.
+func (p *PairingsClient) GetAllPairings(ctx context.Context) ([]Pairing, error) {
+	iter := p.client.Collection("pairings").Documents(ctx)
+	return fetchAll[Pairing](iter)
+}
+
+// AddMatch records the members of a single match.
+func (p *PairingsClient) AddMatch(ctx context.Context, match Match) error {
+	// Pick the document ID up front, so a retry can't record the match twice.
+	doc := p.client.Collection("matches").NewDoc()
+	return withRetry(ctx, func() error {
+		_, err := doc.Set(ctx, match)
+		return err
+	})
+}
+
+// PartnersOf returns everyone the given Recurser was matched with after the
+// given time, most recent first. Each partner is only listed once.
+func (p *PairingsClient) PartnersOf(ctx context.Context, realm string, userID int64, since time.Time) ([]int64, error) {
+	matches, err := p.GetMatchesFor(ctx, realm, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int { return cmp.Compare(b.Timestamp, a.Timestamp) })
+
+	var partners []int64
+	for _, match := range matches {
+		for _, id := range match.Recursers {
+			if id != userID && !slices.Contains(partners, id) {
+				partners = append(partners, id)
+			}
+		}
+	}
+	return partners, nil
+}
+
+// GetMatchesFor returns the matches that included the given Recurser made after
+// the given time.
+func (p *PairingsClient) GetMatchesFor(ctx context.Context, realm string, userID int64, since time.Time) ([]Match, error) {
+	// Filtering on the timestamp as well would need a composite index, and
+	// one Recurser's history is small enough to filter here instead.
+	all, err := p.GetAllMatchesFor(ctx, realm, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Match
+	for _, match := range all {
+		if match.Timestamp > since.Unix() {
+			matches = append(matches, match)
+		}
+	}
+	return matches, nil
+}
+
+// GetMatchesForBetween returns the matches that included the given Recurser
+// made from start up to (but not including) end.
+func (p *PairingsClient) GetMatchesForBetween(ctx context.Context, realm string, userID int64, start, end time.Time) ([]Match, error) {
+	// Like GetMatchesFor, this filters here to avoid a composite index.
+	all, err := p.GetAllMatchesFor(ctx, realm, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Match
+	for _, match := range all {
+		if start.Unix() <= match.Timestamp && match.Timestamp < end.Unix() {
+			matches = append(matches, match)
+		}
+	}
+	return matches, nil
+}
+
+// GetAllMatchesFor returns every match that included the given Recurser.
+func (p *PairingsClient) GetAllMatchesFor(ctx context.Context, realm string, userID int64) ([]Match, error) {
+	iter := p.client.
+		Collection("matches").
+		Where("recursers", "array-contains", userID).
+		Documents(ctx)
+	all, err := fetchAll[Match](iter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Matches in the default realm don't have the field at all, so the realm
+	// can't be part of the query.
+	return slices.DeleteFunc(all, func(m Match) bool { return m.Realm != realm }), nil
+}
+
+// GetMatchesWith returns every match that included both of the given
+// Recursers.
+func (p *PairingsClient) GetMatchesWith(ctx context.Context, realm string, userID, partnerID int64) ([]Match, error) {
+	all, err := p.GetAllMatchesFor(ctx, realm, userID)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(all, func(m Match) bool { return !slices.Contains(m.Recursers, partnerID) }), nil
+}
+
+// LatestMatchFor returns the most recent match that included the given
+// Recurser, with its ID set, or nil if they've never been matched.
+func (p *PairingsClient) LatestMatchFor(ctx context.Context, realm string, userID int64) (*Match, error) {
+	iter := p.client.
+		Collection("matches").
+		Where("recursers", "array-contains", userID).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var latest *Match
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return latest, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		var match Match
+		if err := doc.DataTo(&match); err != nil {
+			log.Printf("Skipping %q: %s", doc.Ref.Path, err)
+			continue
+		}
+		match.ID = doc.Ref.ID
+
+		if match.Realm != realm {
+			continue
+		}
+		if latest == nil || match.Timestamp > latest.Timestamp {
+			latest = &match
+		}
+	}
+}
+
+// maxContainsAny is the most values Firestore allows in one "array-contains-any"
+// filter.
+const maxContainsAny = 30
+
+// LastMatchTimes returns when each of the given Recursers in the realm was last
+// matched, as a Unix timestamp. Anyone who has never been matched is left out.
+func (p *PairingsClient) LastMatchTimes(ctx context.Context, realm string, userIDs []int64) (map[int64]int64, error) {
+	last := make(map[int64]int64)
+	for start := 0; start < len(userIDs); start += maxContainsAny {
+		chunk := userIDs[start:min(start+maxContainsAny, len(userIDs))]
+		iter := p.client.
+			Collection("matches").
+			Where("recursers", "array-contains-any", chunk).
+			Documents(ctx)
+		matches, err := fetchAll[Match](iter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			if match.Realm != realm {
+				continue
+			}
+			for _, id := range match.Recursers {
+				if slices.Contains(chunk, id) && match.Timestamp > last[id] {
+					last[id] = match.Timestamp
+				}
+			}
+		}
+	}
+	return last, nil
+}
+
+// ReportNoShow records that the reporter's partner didn't show up for the
+// match. Reporting the same match more than once has no extra effect.
+func (p *PairingsClient) ReportNoShow(ctx context.Context, matchID string, reporterID int64) error {
+	doc := p.client.Collection("matches").Doc(matchID)
+	return withRetry(ctx, func() error {
+		_, err := doc.Update(ctx, []firestore.Update{
+			{Path: "noShowReportedBy", Value: firestore.ArrayUnion(reporterID)},
+		})
+		return err
+	})
+}
+
+// Rate records the rater's rating for the match, replacing any rating they
+// gave it before.
+func (p *PairingsClient) Rate(ctx context.Context, matchID string, raterID int64, rating int) error {
+	doc := p.client.Collection("matches").Doc(matchID)
+	return withRetry(ctx, func() error {
+		_, err := doc.Update(ctx, []firestore.Update{
+			{FieldPath: firestore.FieldPath{"ratings", strconv.FormatInt(raterID, 10)}, Value: rating},
+		})
+		return err
+	})
+}
+
+// AnonymizeMatchesFor replaces the Recurser's ID with 0 in every match they
+// were in, and drops their no-show reports and ratings, and returns how many
+// matches there were. The matches still count toward everyone's stats, but can't be tied back
+// to the Recurser. Matches in other realms are left alone, even if someone
+// there has the same ID.
+func (p *PairingsClient) AnonymizeMatchesFor(ctx context.Context, realm string, userID int64) (int, error) {
+	docs, err := p.client.
+		Collection("matches").
+		Where("recursers", "array-contains", userID).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, doc := range docs {
+		var match Match
+		if err := doc.DataTo(&match); err != nil {
+			return 0, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+		}
+		if match.Realm != realm {
+			continue
+		}
+		count++
+
+		recursers := make([]int64, len(match.Recursers))
+		for i, id := range match.Recursers {
+			if id != userID {
+				recursers[i] = id
+			}
+		}
+
+		err := withRetry(ctx, func() error {
+			_, err := doc.Ref.Update(ctx, []firestore.Update{
+				{Path: "recursers", Value: recursers},
+				{Path: "noShowReportedBy", Value: firestore.ArrayRemove(userID)},
+				{FieldPath: firestore.FieldPath{"ratings", strconv.FormatInt(userID, 10)}, Value: firestore.Delete},
+			})
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// ListNoShows returns the matches made after the given time that someone
+// reported as a no-show.
+func (p *PairingsClient) ListNoShows(ctx context.Context, since time.Time) ([]Match, error) {
+	matches, err := p.GetMatchesSince(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	var noShows []Match
+	for _, match := range matches {
+		if len(match.NoShowReportedBy) > 0 {
+			noShows = append(noShows, match)
+		}
+	}
+	return noShows, nil
+}
+
+// GetMatchesSince returns all matches made after the given time.
+func (p *PairingsClient) GetMatchesSince(ctx context.Context, since time.Time) ([]Match, error) {
+	iter := p.client.
+		Collection("matches").
+		Where("timestamp", ">", since.Unix()).
+		Documents(ctx)
+	return fetchAll[Match](iter)
+}
@@ -1,7 +1,10 @@
 package store_test
 
 import (
+	"cmp"
 	"context"
+	"slices"
+	"strconv"
 	"testing"
 	"time"
 
@@ -36,5 +39,365 @@ func TestFirestorePairingsClient(t *testing.T) {
 		}
 
 		assert.Equal(t, actual, expected)
+
+		all, err := pairings.GetAllPairings(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, len(all), 7)
+	})
+}
+
+func TestFirestorePairingsClient_Matches(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	pairings := store.Pairings(client)
+
+	now := time.Now()
+
+	old := store.Match{
+		Recursers: []int64{1, 2},
+		Timestamp: now.Add(-10 * 24 * time.Hour).Unix(),
+	}
+	recent := store.Match{
+		Recursers: []int64{3, 4},
+		Timestamp: now.Add(-2 * 24 * time.Hour).Unix(),
+	}
+
+	for _, match := range []store.Match{old, recent} {
+		if err := pairings.AddMatch(ctx, match); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	actual, err := pairings.GetMatchesSince(ctx, now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, actual, []store.Match{recent})
+}
+
+func TestFirestorePairingsClient_LastMatchTimes(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	pairings := store.Pairings(client)
+
+	now := time.Now()
+	daysAgo := func(n int) int64 { return now.AddDate(0, 0, -n).Unix() }
+
+	for _, match := range []store.Match{
+		{Recursers: []int64{1, 2}, Timestamp: daysAgo(30)},
+		{Recursers: []int64{1, 3}, Timestamp: daysAgo(2)},
+		{Recursers: []int64{2, 4, 5}, Timestamp: daysAgo(10)},
+
+		// Other people with the same IDs in another realm.
+		{Recursers: []int64{1, 6}, Timestamp: daysAgo(1), Realm: "sister"},
+	} {
+		if err := pairings.AddMatch(ctx, match); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 6 has never been matched, and 4 and 5 weren't asked about.
+	last, err := pairings.LastMatchTimes(ctx, store.DefaultRealm, []int64{1, 2, 3, 6})
+	assert.NoError(t, err)
+	assert.Equal(t, last, map[int64]int64{1: daysAgo(2), 2: daysAgo(10), 3: daysAgo(2)})
+
+	last, err = pairings.LastMatchTimes(ctx, "sister", []int64{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, last, map[int64]int64{1: daysAgo(1)})
+}
+
+func TestFirestorePairingsClient_GetTotalPairings(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	pairings := store.Pairings(client)
+
+	// Nothing to add up yet.
+	total, err := pairings.GetTotalPairings(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, total, 0)
+
+	for i, value := range []int{12, 7, 30} {
+		pairing := store.Pairing{Value: value, Timestamp: int64(1_700_000_000 + i*86400)}
+		if err := pairings.SetNumPairings(ctx, pairing); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	total, err = pairings.GetTotalPairings(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, total, 49)
+}
+
+func TestFirestorePairingsClient_NoShows(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	pairings := store.Pairings(client)
+
+	now := time.Now()
+
+	older := store.Match{Recursers: []int64{1, 2}, Timestamp: now.Add(-3 * 24 * time.Hour).Unix()}
+	latest := store.Match{Recursers: []int64{1, 3}, Timestamp: now.Add(-1 * 24 * time.Hour).Unix()}
+	for _, match := range []store.Match{older, latest} {
+		if err := pairings.AddMatch(ctx, match); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	match, err := pairings.LatestMatchFor(ctx, store.DefaultRealm, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, match.Recursers, latest.Recursers)
+
+	// Reporting twice only counts once.
+	for range 2 {
+		if err := pairings.ReportNoShow(ctx, match.ID, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	noShows, err := pairings.ListNoShows(ctx, now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, noShows, []store.Match{{
+		Recursers:        []int64{1, 3},
+		Timestamp:        latest.Timestamp,
+		NoShowReportedBy: []int64{1},
+	}})
+
+	none, err := pairings.LatestMatchFor(ctx, store.DefaultRealm, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, none, nil)
+}
+
+func TestFirestorePairingsClient_Ratings(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	pairings := store.Pairings(client)
+
+	now := time.Now()
+	if err := pairings.AddMatch(ctx, store.Match{Recursers: []int64{1, 2}, Timestamp: now.Unix()}); err != nil {
+		t.Fatal(err)
+	}
+
+	match, err := pairings.LatestMatchFor(ctx, store.DefaultRealm, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rating again replaces the earlier rating.
+	assert.NoError(t, pairings.Rate(ctx, match.ID, 1, 2))
+	assert.NoError(t, pairings.Rate(ctx, match.ID, 1, 4))
+	assert.NoError(t, pairings.Rate(ctx, match.ID, 2, 5))
+
+	match, err = pairings.LatestMatchFor(ctx, store.DefaultRealm, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, match.Ratings, map[string]int{"1": 4, "2": 5})
+
+	rating, ok := match.RatingBy(1)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, rating, 4)
+
+	matches, err := pairings.GetMatchesSince(ctx, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	average, count := store.AverageRating(matches)
+	assert.Equal(t, average, 4.5)
+	assert.Equal(t, count, 2)
+}
+
+func TestAverageRating(t *testing.T) {
+	average, count := store.AverageRating([]store.Match{
+		{Ratings: map[string]int{"1": 5, "2": 4}},
+		{},
+		{Ratings: map[string]int{"3": 1}},
+	})
+	assert.Equal(t, average, 10.0/3)
+	assert.Equal(t, count, 3)
+
+	average, count = store.AverageRating(nil)
+	assert.Equal(t, average, 0.0)
+	assert.Equal(t, count, 0)
+}
+
+func TestFirestorePairingsClient_GetMatchesFor(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	pairings := store.Pairings(client)
+
+	now := time.Now()
+	id := pbtest.RandInt64(t)
+
+	mine := store.Match{
+		Recursers: []int64{id, 2},
+		Timestamp: now.Add(-24 * time.Hour).Unix(),
+	}
+	tooOld := store.Match{
+		Recursers: []int64{3, id},
+		Timestamp: now.Add(-10 * 24 * time.Hour).Unix(),
+	}
+	notMine := store.Match{
+		Recursers: []int64{4, 5},
+		Timestamp: now.Add(-24 * time.Hour).Unix(),
+	}
+
+	for _, match := range []store.Match{mine, tooOld, notMine} {
+		if err := pairings.AddMatch(ctx, match); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	actual, err := pairings.GetMatchesFor(ctx, store.DefaultRealm, id, now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, actual, []store.Match{mine})
+}
+
+func TestFirestorePairingsClient_PartnersOf(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	pairings := store.Pairings(client)
+
+	now := time.Now()
+	id := pbtest.RandInt64(t)
+	daysAgo := func(n int) time.Time { return now.AddDate(0, 0, -n) }
+
+	for _, pair := range []struct {
+		Partner int64
+		Date    time.Time
+	}{
+		{3, daysAgo(2)},
+		{2, daysAgo(1)},
+		{2, daysAgo(3)},
+		{4, daysAgo(10)},
+	} {
+		if err := pairings.AddMatch(ctx, store.Match{Recursers: []int64{id, pair.Partner}, Timestamp: pair.Date.Unix()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Groups of three count too.
+	if err := pairings.AddMatch(ctx, store.Match{Recursers: []int64{5, id, 6}, Timestamp: daysAgo(4).Unix()}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		matches, err := pairings.GetMatchesFor(ctx, store.DefaultRealm, id, daysAgo(2).Add(-time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices.SortFunc(matches, func(a, b store.Match) int { return cmp.Compare(a.Timestamp, b.Timestamp) })
+		assert.Equal(t, matches, []store.Match{
+			{Recursers: []int64{id, 3}, Timestamp: daysAgo(2).Unix()},
+			{Recursers: []int64{id, 2}, Timestamp: daysAgo(1).Unix()},
+		})
+	})
+
+	t.Run("partners", func(t *testing.T) {
+		partners, err := pairings.PartnersOf(ctx, store.DefaultRealm, id, daysAgo(7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, partners, []int64{2, 3, 5, 6})
+	})
+
+	t.Run("nobody", func(t *testing.T) {
+		partners, err := pairings.PartnersOf(ctx, store.DefaultRealm, pbtest.RandInt64(t), daysAgo(7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, partners, []int64(nil))
 	})
+
+	t.Run("with one partner", func(t *testing.T) {
+		matches, err := pairings.GetMatchesWith(ctx, store.DefaultRealm, id, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices.SortFunc(matches, func(a, b store.Match) int { return cmp.Compare(a.Timestamp, b.Timestamp) })
+		assert.Equal(t, matches, []store.Match{
+			{Recursers: []int64{id, 2}, Timestamp: daysAgo(3).Unix()},
+			{Recursers: []int64{id, 2}, Timestamp: daysAgo(1).Unix()},
+		})
+
+		matches, err = pairings.GetMatchesWith(ctx, store.DefaultRealm, id, 6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, len(matches), 1)
+
+		matches, err = pairings.GetMatchesWith(ctx, store.DefaultRealm, id, 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, len(matches), 0)
+	})
+}
+
+func TestFirestorePairingsClient_AnonymizeMatchesFor(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	pairings := store.Pairings(client)
+
+	id := pbtest.RandInt64(t)
+	for _, match := range []store.Match{
+		{Recursers: []int64{id, 2}, Timestamp: 100, NoShowReportedBy: []int64{id, 2}, Ratings: map[string]int{strconv.FormatInt(id, 10): 1, "2": 5}},
+		{Recursers: []int64{3, id, 4}, Timestamp: 200},
+		{Recursers: []int64{5, 6}, Timestamp: 300},
+
+		// Someone else with the same ID in another realm.
+		{Recursers: []int64{id, 7}, Timestamp: 400, Realm: "sister"},
+	} {
+		if err := pairings.AddMatch(ctx, match); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count, err := pairings.AnonymizeMatchesFor(ctx, store.DefaultRealm, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, count, 2)
+
+	mine, err := pairings.GetAllMatchesFor(ctx, store.DefaultRealm, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(mine), 0)
+
+	// The partners keep their matches, with the Recurser's ID replaced.
+	theirs, err := pairings.GetAllMatchesFor(ctx, store.DefaultRealm, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, theirs, []store.Match{{Recursers: []int64{0, 2}, Timestamp: 100, NoShowReportedBy: []int64{2}, Ratings: map[string]int{"2": 5}}})
+
+	theirs, err = pairings.GetAllMatchesFor(ctx, store.DefaultRealm, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, theirs[0].Recursers, []int64{3, 0, 4})
+
+	lookalike, err := pairings.GetAllMatchesFor(ctx, "sister", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, lookalike, []store.Match{{Recursers: []int64{id, 7}, Timestamp: 400, Realm: "sister"}})
 }
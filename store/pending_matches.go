@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A PendingMatch is a match that isn't recorded until the Recursers who asked
+// to confirm their matches (see Recurser.ConfirmMatches) have confirmed it.
+type PendingMatch struct {
+	// ID is the Firestore document ID. It is not written to or read from the
+	// document itself.
+	ID string `firestore:"-"`
+
+	Recursers []int64 `firestore:"recursers"`
+	Timestamp int64   `firestore:"timestamp"`
+
+	// Names are the Recursers' names, in the same order as Recursers.
+	Names []string `firestore:"names"`
+
+	// Unconfirmed are the Recursers who still need to confirm.
+	Unconfirmed []int64 `firestore:"unconfirmed"`
+
+	// ExpiresAt is the Unix timestamp after which the match can no longer be
+	// confirmed.
+	ExpiresAt int64 `firestore:"expiresAt"`
+
+	// Realm is the Zulip realm of the Recursers (see Recurser.Realm).
+	Realm string `firestore:"realm,omitempty"`
+}
+
+// IsExpired returns whether it's too late to confirm the match as of `now`.
+func (m *PendingMatch) IsExpired(now time.Time) bool {
+	return now.Unix() >= m.ExpiresAt
+}
+
+// Confirm marks the Recurser as having confirmed the match, and returns
+// whether everyone has confirmed now.
+func (m *PendingMatch) Confirm(userID int64) bool {
+	m.Unconfirmed = slices.DeleteFunc(m.Unconfirmed, func(id int64) bool { return id == userID })
+	return len(m.Unconfirmed) == 0
+}
+
+// Match returns the Match to record once this one is confirmed.
+func (m *PendingMatch) Match() Match {
+	return Match{Recursers: m.Recursers, Timestamp: m.Timestamp, Realm: m.Realm}
+}
+
+// PendingMatchesClient manages matches that are waiting for confirmation.
+type PendingMatchesClient struct {
+	client *firestore.Client
+}
+
+func PendingMatches(client *firestore.Client) *PendingMatchesClient {
+	return &PendingMatchesClient{client}
+}
+
+// Add saves a new pending match.
+func (p *PendingMatchesClient) Add(ctx context.Context, match PendingMatch) error {
+	// Pick the document ID up front, so a retry can't save the match twice.
+	doc := p.client.Collection("pendingMatches").NewDoc()
+	return withRetry(ctx, func() error {
+		_, err := doc.Set(ctx, match)
+		return err
+	})
+}
+
+// GetUnconfirmed returns the most recent pending match that the Recurser in the
+// realm still needs to confirm as of `now`, or nil if there isn't one.
+func (p *PendingMatchesClient) GetUnconfirmed(ctx context.Context, realm string, userID int64, now time.Time) (*PendingMatch, error) {
+	iter := p.client.
+		Collection("pendingMatches").
+		Where("unconfirmed", "array-contains", userID).
+		Documents(ctx)
+	matches, err := fetchPendingMatches(iter)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *PendingMatch
+	for i := range matches {
+		match := &matches[i]
+		if match.Realm != realm || match.IsExpired(now) {
+			continue
+		}
+		if latest == nil || match.Timestamp > latest.Timestamp {
+			latest = match
+		}
+	}
+	return latest, nil
+}
+
+// ListFor returns every pending match the Recurser in the realm is in, whether
+// or not they still need to confirm it.
+func (p *PendingMatchesClient) ListFor(ctx context.Context, realm string, userID int64) ([]PendingMatch, error) {
+	iter := p.client.
+		Collection("pendingMatches").
+		Where("recursers", "array-contains", userID).
+		Documents(ctx)
+	matches, err := fetchPendingMatches(iter)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(matches, func(m PendingMatch) bool { return m.Realm != realm }), nil
+}
+
+// Confirm marks the Recurser as having confirmed the pending match, and returns
+// it. Once everyone has confirmed, the pending match is deleted and its Match
+// is recorded. It returns nil if the match can no longer be confirmed by them
+// as of `now`: it has expired, been called off, or they've already confirmed.
+func (p *PendingMatchesClient) Confirm(ctx context.Context, id string, userID int64, now time.Time) (*PendingMatch, error) {
+	doc := p.client.Collection("pendingMatches").Doc(id)
+
+	// A transaction, so that confirmations at once can't overwrite each other,
+	// and the match can't be recorded after it's been called off.
+	var match *PendingMatch
+	err := p.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		match = nil
+
+		snapshot, err := tx.Get(doc)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var pending PendingMatch
+		if err := snapshot.DataTo(&pending); err != nil {
+			return fmt.Errorf("parse document %q: %w", doc.Path, err)
+		}
+		pending.ID = id
+
+		if pending.IsExpired(now) || !slices.Contains(pending.Unconfirmed, userID) {
+			return nil
+		}
+
+		match = &pending
+		if !pending.Confirm(userID) {
+			return tx.Set(doc, pending)
+		}
+		if err := tx.Delete(doc); err != nil {
+			return err
+		}
+		return tx.Create(p.client.Collection("matches").NewDoc(), pending.Match())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// Claim deletes the pending match so that it can be called off, and returns
+// whether it was still there. If it returns false, it's already been confirmed
+// or called off.
+func (p *PendingMatchesClient) Claim(ctx context.Context, id string) (bool, error) {
+	doc := p.client.Collection("pendingMatches").Doc(id)
+
+	// A transaction, so that the expiry job, a decline, and the last
+	// confirmation can't all go through at once.
+	var claimed bool
+	err := p.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		_, err := tx.Get(doc)
+		if status.Code(err) == codes.NotFound {
+			claimed = false
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		claimed = true
+		return tx.Delete(doc)
+	})
+	if err != nil {
+		return false, err
+	}
+	return claimed, nil
+}
+
+// ListExpired returns the pending matches that can no longer be confirmed as of
+// `now`.
+func (p *PendingMatchesClient) ListExpired(ctx context.Context, now time.Time) ([]PendingMatch, error) {
+	iter := p.client.
+		Collection("pendingMatches").
+		Where("expiresAt", "<=", now.Unix()).
+		Documents(ctx)
+	return fetchPendingMatches(iter)
+}
+
+// fetchPendingMatches is like fetchAll, but also fills in each ID.
+func fetchPendingMatches(iter *firestore.DocumentIterator) ([]PendingMatch, error) {
+	defer iter.Stop()
+
+	var all []PendingMatch
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return all, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		var match PendingMatch
+		if err := doc.DataTo(&match); err != nil {
+			log.Printf("Skipping %q: %s", doc.Ref.Path, err)
+			continue
+		}
+		match.ID = doc.Ref.ID
+
+		all = append(all, match)
+	}
+}
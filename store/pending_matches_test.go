@@ -0,0 +1,190 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestPendingMatch_Confirm(t *testing.T) {
+	match := store.PendingMatch{Recursers: []int64{1, 2, 3}, Unconfirmed: []int64{1, 3}}
+
+	assert.Equal(t, match.Confirm(2), false)
+	assert.Equal(t, match.Confirm(1), false)
+	assert.Equal(t, match.Unconfirmed, []int64{3})
+	assert.Equal(t, match.Confirm(3), true)
+}
+
+func TestPendingMatch_IsExpired(t *testing.T) {
+	now := time.Now()
+	match := store.PendingMatch{ExpiresAt: now.Unix()}
+
+	assert.Equal(t, match.IsExpired(now.Add(-time.Second)), false)
+	assert.Equal(t, match.IsExpired(now), true)
+}
+
+func TestFirestorePendingMatchesClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	pending := store.PendingMatches(client)
+
+	now := time.Now()
+
+	older := store.PendingMatch{
+		Recursers:   []int64{1, 2},
+		Names:       []string{"Ada", "Grace"},
+		Timestamp:   now.Add(-2 * time.Hour).Unix(),
+		Unconfirmed: []int64{1},
+		ExpiresAt:   now.Add(time.Hour).Unix(),
+	}
+	newer := store.PendingMatch{
+		Recursers:   []int64{1, 3},
+		Names:       []string{"Ada", "Alan"},
+		Timestamp:   now.Add(-time.Hour).Unix(),
+		Unconfirmed: []int64{1, 3},
+		ExpiresAt:   now.Add(2 * time.Hour).Unix(),
+	}
+	expired := store.PendingMatch{
+		Recursers:   []int64{1, 4},
+		Names:       []string{"Ada", "Barbara"},
+		Timestamp:   now.Add(-time.Minute).Unix(),
+		Unconfirmed: []int64{1},
+		ExpiresAt:   now.Add(-time.Second).Unix(),
+	}
+
+	for _, match := range []store.PendingMatch{older, newer, expired} {
+		if err := pending.Add(ctx, match); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("gets the latest unexpired match", func(t *testing.T) {
+		got, err := pending.GetUnconfirmed(ctx, store.DefaultRealm, 1, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got.ID = ""
+		assert.Equal(t, got, &newer)
+	})
+
+	t.Run("nothing for someone who doesn't need to confirm", func(t *testing.T) {
+		got, err := pending.GetUnconfirmed(ctx, store.DefaultRealm, 2, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var none *store.PendingMatch
+		assert.Equal(t, got, none)
+	})
+
+	t.Run("nothing for the same ID in another realm", func(t *testing.T) {
+		got, err := pending.GetUnconfirmed(ctx, "sister", 1, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var none *store.PendingMatch
+		assert.Equal(t, got, none)
+	})
+
+	t.Run("saves confirmations", func(t *testing.T) {
+		got, err := pending.GetUnconfirmed(ctx, store.DefaultRealm, 3, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		confirmed, err := pending.Confirm(ctx, got.ID, 3, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, confirmed.Unconfirmed, []int64{1})
+
+		// They can't confirm twice.
+		confirmed, err = pending.Confirm(ctx, got.ID, 3, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var none *store.PendingMatch
+		assert.Equal(t, confirmed, none)
+
+		got, err = pending.GetUnconfirmed(ctx, store.DefaultRealm, 3, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, got, none)
+	})
+
+	t.Run("records the match once everyone confirms", func(t *testing.T) {
+		got, err := pending.GetUnconfirmed(ctx, store.DefaultRealm, 1, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		confirmed, err := pending.Confirm(ctx, got.ID, 1, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, len(confirmed.Unconfirmed), 0)
+
+		matches, err := store.Pairings(client).GetAllMatchesFor(ctx, store.DefaultRealm, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, matches, []store.Match{newer.Match()})
+
+		// It's no longer pending, so it can't be called off.
+		claimed, err := pending.Claim(ctx, got.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, claimed, false)
+	})
+
+	t.Run("can't confirm an expired match", func(t *testing.T) {
+		all, err := pending.ListExpired(ctx, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		confirmed, err := pending.Confirm(ctx, all[0].ID, 1, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var none *store.PendingMatch
+		assert.Equal(t, confirmed, none)
+	})
+
+	t.Run("lists and claims expired matches", func(t *testing.T) {
+		all, err := pending.ListExpired(ctx, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, len(all), 1)
+		assert.Equal(t, all[0].Recursers, expired.Recursers)
+
+		claimed, err := pending.Claim(ctx, all[0].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, claimed, true)
+
+		// Only once.
+		claimed, err = pending.Claim(ctx, all[0].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, claimed, false)
+
+		all, err = pending.ListExpired(ctx, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, len(all), 0)
+	})
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ProcessedMessageTTL is how long a Zulip message is remembered after it's
+// handled. Redeliveries come within seconds or minutes, so this doesn't need
+// to be long.
+const ProcessedMessageTTL = time.Hour
+
+// A ProcessedMessage records that a Zulip message was already handled, so that
+// a redelivered webhook for it is ignored.
+type ProcessedMessage struct {
+	// MessageID is the Zulip message ID. Like Zulip user IDs, these are only
+	// unique within a realm, so the document ID is the realm and the message
+	// ID, the same way as for Recursers (see recurserDocID).
+	MessageID int64 `firestore:"messageId"`
+
+	// Realm is the Zulip realm the message came from (see Recurser.Realm).
+	Realm string `firestore:"realm,omitempty"`
+
+	// ExpiresAt is when the record stops counting. It's a Firestore
+	// timestamp, so a TTL policy on this field can clean up old records.
+	ExpiresAt time.Time `firestore:"expiresAt"`
+}
+
+// ProcessedMessagesClient manages the record of recently handled messages.
+type ProcessedMessagesClient struct {
+	client *firestore.Client
+}
+
+func ProcessedMessages(client *firestore.Client) *ProcessedMessagesClient {
+	return &ProcessedMessagesClient{client}
+}
+
+// Claim records that the message is being handled as of `now`, and returns
+// whether this is the first time. If it returns false, the message was already
+// claimed in the last ProcessedMessageTTL and shouldn't be handled again.
+func (p *ProcessedMessagesClient) Claim(ctx context.Context, realm string, messageID int64, now time.Time) (bool, error) {
+	doc := p.client.Collection("processedMessages").Doc(recurserDocID(realm, messageID))
+
+	// A transaction, so that two deliveries at once can't both claim it.
+	var claimed bool
+	err := p.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snapshot, err := tx.Get(doc)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		if snapshot.Exists() {
+			var processed ProcessedMessage
+			if err := snapshot.DataTo(&processed); err != nil {
+				return fmt.Errorf("parse document %q: %w", doc.Path, err)
+			}
+			if now.Before(processed.ExpiresAt) {
+				claimed = false
+				return nil
+			}
+		}
+
+		claimed = true
+		return tx.Set(doc, ProcessedMessage{
+			MessageID: messageID,
+			Realm:     realm,
+			ExpiresAt: now.Add(ProcessedMessageTTL),
+		})
+	})
+	if err != nil {
+		return false, err
+	}
+	return claimed, nil
+}
@@ -0,0 +1,59 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreProcessedMessagesClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	processed := store.ProcessedMessages(client)
+
+	now := time.Now()
+	id := pbtest.RandInt64(t)
+
+	claimed, err := processed.Claim(ctx, store.DefaultRealm, id, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, claimed, true)
+
+	t.Run("repeat", func(t *testing.T) {
+		claimed, err := processed.Claim(ctx, store.DefaultRealm, id, now.Add(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, claimed, false)
+	})
+
+	t.Run("another message", func(t *testing.T) {
+		claimed, err := processed.Claim(ctx, store.DefaultRealm, id+1, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, claimed, true)
+	})
+
+	t.Run("same ID in another realm", func(t *testing.T) {
+		claimed, err := processed.Claim(ctx, "sister", id, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, claimed, true)
+	})
+
+	t.Run("after it expires", func(t *testing.T) {
+		claimed, err := processed.Claim(ctx, store.DefaultRealm, id, now.Add(store.ProcessedMessageTTL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, claimed, true)
+	})
+}
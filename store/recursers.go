@@ -3,6 +3,8 @@ package store
 import (
 	"context"
 	"fmt"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -13,9 +15,16 @@ import (
 )
 
 // A Schedule determines whether to pair a Recurser on each day.
-// The only valid keys are all-lowercase day names (e.g., "monday").
+// The keys are all-lowercase day names (e.g., "monday") for the whole day, or
+// day names with a segment suffix (e.g., "monday-am") for half of it.
 type Schedule map[string]bool
 
+// The half-day segments that a day in a Schedule can be limited to.
+const (
+	SegmentAM = "am"
+	SegmentPM = "pm"
+)
+
 func DefaultSchedule() map[string]bool {
 	return map[string]bool{
 		"monday":    true,
@@ -34,6 +43,19 @@ func NewSchedule(days []string) map[string]bool {
 	for _, day := range days {
 		schedule[day] = true
 	}
+
+	// Keep one entry per day: both halves are the same as the whole day, and
+	// the whole day already includes either half.
+	for day := range EmptySchedule() {
+		am, pm := day+"-"+SegmentAM, day+"-"+SegmentPM
+		if schedule[am] && schedule[pm] {
+			schedule[day] = true
+		}
+		if schedule[day] {
+			delete(schedule, am)
+			delete(schedule, pm)
+		}
+	}
 	return schedule
 }
 
@@ -49,7 +71,33 @@ func EmptySchedule() map[string]bool {
 	}
 }
 
+// NewTopics normalizes a list of topic tags by lowercasing them and removing
+// blanks and duplicates. The original order is otherwise preserved.
+func NewTopics(tags []string) []string {
+	var topics []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || slices.Contains(topics, tag) {
+			continue
+		}
+		topics = append(topics, tag)
+	}
+	return topics
+}
+
+// SharesTopicWith returns whether the two Recursers have any topics in common.
+func (r *Recurser) SharesTopicWith(other *Recurser) bool {
+	for _, topic := range r.Topics {
+		if slices.Contains(other.Topics, topic) {
+			return true
+		}
+	}
+	return false
+}
+
 type Recurser struct {
+	// ID is the Recurser's Zulip user ID, which is only unique within their
+	// Realm.
 	ID                 int64           `firestore:"id"`
 	Name               string          `firestore:"name"`
 	Email              string          `firestore:"email"`
@@ -57,11 +105,635 @@ type Recurser struct {
 	Schedule           map[string]bool `firestore:"schedule"`
 	CurrentlyAtRC      bool            `firestore:"currentlyAtRC"`
 
-	// IsSubscribed really means "already had an entry in the database".
-	// It is not written to or read from the Firestore document.
+	// Timezone is an IANA time zone name (e.g., "Europe/Berlin"). An empty
+	// value means UTC.
+	Timezone string `firestore:"timezone"`
+
+	// PausedUntil is the Unix timestamp after which a paused Recurser will be
+	// matched again. Zero means they aren't paused, and PausedIndefinitely
+	// means they're paused until they resume.
+	PausedUntil int64 `firestore:"pausedUntil"`
+
+	// SkipDates is the set of one-off dates (formatted as time.DateOnly in
+	// the Recurser's time zone) that the Recurser doesn't want to pair on.
+	SkipDates map[string]bool `firestore:"skipDates"`
+
+	// SkipUntil is the Unix timestamp of midnight (in the Recurser's time
+	// zone) at the end of the days they skipped with SkipDays. Zero means they
+	// aren't skipping any.
+	SkipUntil int64 `firestore:"skipUntil,omitempty"`
+
+	// Bio is a short introduction to share with the Recurser's pairing
+	// partners.
+	Bio string `firestore:"bio"`
+
+	// Topics are the Recurser's interests, used to nudge matching toward
+	// people with something in common. See NewTopics.
+	Topics []string `firestore:"topics"`
+
+	// WeeklySummaryOptOut is set if the Recurser doesn't want the weekly
+	// summary of who they paired with.
+	WeeklySummaryOptOut bool `firestore:"weeklySummaryOptOut"`
+
+	// ShowOnLeaderboard is set if the Recurser has opted in to appearing on
+	// the pairing leaderboard.
+	ShowOnLeaderboard bool `firestore:"showOnLeaderboard"`
+
+	// Nudge is set if the Recurser wants a reminder a few hours after their
+	// match message to get in touch with their partner. See Nudge.
+	Nudge bool `firestore:"nudge"`
+
+	// EmailFallback is set if the Recurser wants their match message emailed
+	// to them when it can't be sent on Zulip.
+	EmailFallback bool `firestore:"emailFallback"`
+
+	// Discoverable is set if the Recurser has opted in to sharing their bio,
+	// topics, and whether they're at RC with anyone who asks with `who`.
+	Discoverable bool `firestore:"discoverable"`
+
+	// MatchTime is the local time of day (formatted like "09:00") when the
+	// Recurser would like to hear about their match. Empty means as soon as
+	// matches are made.
+	MatchTime string `firestore:"matchTime"`
+
+	// MaxWeekly is the most matches the Recurser wants in any 7-day period.
+	// Zero means no limit.
+	MaxWeekly int `firestore:"maxWeekly"`
+
+	// BatchPref is whether the Recurser would rather pair with people from
+	// their own batch (BatchPrefSame) or other batches (BatchPrefCross).
+	// Empty means BatchPrefAny.
+	BatchPref string `firestore:"batchPref"`
+
+	// Duration is how many minutes the Recurser would like to pair for. Zero
+	// means no preference. It's only shared in match messages, not used for
+	// matching.
+	Duration int `firestore:"duration"`
+
+	// Mode is how the Recurser would like to pair (ModeVoice, ModeText, or
+	// ModeInPerson). Empty means ModeAny. It's shared in match messages, and
+	// matching prefers partners with a compatible mode (see ModeCompatible),
+	// except that ModeInPerson is a hard constraint (see CanPairWith).
+	Mode string `firestore:"mode,omitempty"`
+
+	// Verbosity is how much the Recurser's match messages include
+	// (VerbosityMinimal, VerbosityNormal, or VerbosityFull). Empty means
+	// VerbosityNormal.
+	Verbosity string `firestore:"verbosity"`
+
+	// Segment is the part of the day (SegmentAM or SegmentPM) that the
+	// Recurser is available for the match run being planned, or empty for all
+	// day. ListPairingTomorrow fills it in, and it is not written to or read
+	// from the Firestore document.
+	Segment string `firestore:"-"`
+
+	// BatchID is the Recurse batch the Recurser is currently in, or zero if
+	// we don't know. It comes from the Recurse API when making matches and
+	// is not written to or read from the Firestore document.
+	BatchID int64 `firestore:"-"`
+
+	// KeepHistory is set if the Recurser has opted in to recording the
+	// commands they send, so they can look back at them with `history`.
+	KeepHistory bool `firestore:"keepHistory"`
+
+	// Biweekly has the days of the Schedule that the Recurser only pairs on
+	// every other week, mapped to the parity (see weekParity) of the weeks they
+	// pair in. Days that aren't listed are every week. See SetBiweekly.
+	Biweekly map[string]int `firestore:"biweekly,omitempty"`
+
+	// MutedDays are days of the week the Recurser isn't matched on until they
+	// unmute them, whatever their Schedule says. Unlike taking the days out of
+	// the Schedule, this keeps them for later. See MatchSegment.
+	MutedDays map[string]bool `firestore:"mutedDays,omitempty"`
+
+	// ThisWeek temporarily changes the Schedule for a single week. It only
+	// counts during the week it's for. See MatchSegment.
+	ThisWeek WeekOverride `firestore:"thisWeek"`
+
+	// Blocks are the people the Recurser never wants to be matched with.
+	// Blocks work in both directions, and the blocked person isn't told.
+	Blocks []Block `firestore:"blocks"`
+
+	// Aliases are the Recurser's own shortcuts for commands, mapping a name
+	// (like "sk") to the command it stands for (like "skip tomorrow").
+	Aliases map[string]string `firestore:"aliases,omitempty"`
+
+	// SchedulePresets are schedules the Recurser has saved to switch back to
+	// later, by name. See SaveSchedule.
+	SchedulePresets map[string]map[string]bool `firestore:"schedulePresets,omitempty"`
+
+	// IsMentor is set if the Recurser (an alum) is in the mentor pool. Mentors
+	// are left out of the daily matches, and are only matched with current
+	// Recursers by the mentor matching job.
+	IsMentor bool `firestore:"isMentor"`
+
+	// Realm is the Zulip organization the Recurser uses Pairing Bot from. It's
+	// DefaultRealm for Recurse Center's own.
+	Realm string `firestore:"realm,omitempty"`
+
+	// ConfirmMatches is set if the Recurser wants to confirm each match
+	// before it counts. Their matches are kept as a PendingMatch until
+	// everyone who asked has confirmed.
+	ConfirmMatches bool `firestore:"confirmMatches"`
+
+	// UnsubscribedAt is the Unix timestamp of when the Recurser unsubscribed.
+	// Zero means they're subscribed. Unsubscribed Recursers keep their record
+	// for UnsubscribeGracePeriod so they can change their mind.
+	UnsubscribedAt int64 `firestore:"unsubscribedAt"`
+
+	// LastActive is the Unix timestamp of when the Recurser last sent a
+	// command or was matched. Zero means we don't know, since the record is
+	// from before this was tracked. See MarkActive.
+	LastActive int64 `firestore:"lastActive,omitempty"`
+
+	// StaleWarnedAt is the Unix timestamp of when the Recurser was last told
+	// they'd be unsubscribed for being inactive. It only counts if it's after
+	// LastActive.
+	StaleWarnedAt int64 `firestore:"staleWarnedAt,omitempty"`
+
+	// SubscriptionChangedAt is the Unix timestamp of when the Recurser last
+	// subscribed, unsubscribed, or restored their record. See
+	// InSubscriptionCooldown.
+	SubscriptionChangedAt int64 `firestore:"subscriptionChangedAt,omitempty"`
+
+	// IsSubscribed really means "already had an entry in the database" that
+	// hasn't been unsubscribed. It is not written to or read from the
+	// Firestore document.
 	IsSubscribed bool `firestore:"-"`
 }
 
+// ScheduledSegment returns whether the Recurser's schedule includes the day and,
+// if so, which segment of it. An empty segment means the whole day.
+func (r *Recurser) ScheduledSegment(day string) (string, bool) {
+	if r.Schedule[day] {
+		return "", true
+	}
+
+	am, pm := r.Schedule[day+"-"+SegmentAM], r.Schedule[day+"-"+SegmentPM]
+	switch {
+	case am && pm:
+		return "", true
+	case am:
+		return SegmentAM, true
+	case pm:
+		return SegmentPM, true
+	}
+	return "", false
+}
+
+// A WeekOverride changes a Recurser's Schedule for one week, without touching
+// the Schedule itself.
+type WeekOverride struct {
+	// WeekOf is the Monday that starts the week the override is for,
+	// formatted as time.DateOnly in the Recurser's time zone.
+	WeekOf string `firestore:"weekOf"`
+
+	// Days maps lowercase day names to whether to pair on that day. Days that
+	// aren't listed follow the Schedule.
+	Days map[string]bool `firestore:"days"`
+}
+
+// ActiveWeekOverride returns the Recurser's schedule changes for the week of
+// their MatchDate for `now`, or nil if there aren't any.
+func (r *Recurser) ActiveWeekOverride(now time.Time) map[string]bool {
+	if r.ThisWeek.WeekOf != r.weekOf(now) {
+		return nil
+	}
+	return r.ThisWeek.Days
+}
+
+// OverrideThisWeek changes whether the Recurser pairs on the day, but only for
+// the week of their MatchDate for `now`. Changes for an earlier week are
+// dropped.
+func (r *Recurser) OverrideThisWeek(now time.Time, day string, pair bool) {
+	if week := r.weekOf(now); r.ThisWeek.WeekOf != week {
+		r.ThisWeek = WeekOverride{WeekOf: week, Days: make(map[string]bool)}
+	}
+	r.ThisWeek.Days[day] = pair
+}
+
+// ThisWeekDate returns the date of the day in the week of the Recurser's
+// MatchDate for `now`, formatted as time.DateOnly.
+func (r *Recurser) ThisWeekDate(now time.Time, day string) string {
+	monday := weekStart(r.matchTime(now))
+	for i := range 7 {
+		date := monday.AddDate(0, 0, i)
+		if strings.ToLower(date.Weekday().String()) == day {
+			return date.Format(time.DateOnly)
+		}
+	}
+	return ""
+}
+
+// RemovePastWeekOverride forgets about the Recurser's WeekOverride once its
+// week is over.
+func (r *Recurser) RemovePastWeekOverride(now time.Time) {
+	// DateOnly strings sort chronologically.
+	if r.ThisWeek.WeekOf != "" && r.ThisWeek.WeekOf < r.weekOf(now) {
+		r.ThisWeek = WeekOverride{}
+	}
+}
+
+// MatchSegment is like ScheduledSegment for the Recurser's MatchDay, but takes
+// their WeekOverride, MutedDays, and Biweekly days into account. Days added for
+// the week are for the whole day, even on an off week or a muted day.
+func (r *Recurser) MatchSegment(now time.Time) (string, bool) {
+	day := r.MatchDay(now)
+	if pair, ok := r.ActiveWeekOverride(now)[day]; ok {
+		return "", pair
+	}
+	if r.MutedDays[day] {
+		return "", false
+	}
+	if parity, ok := r.Biweekly[day]; ok && parity != weekParity(r.matchTime(now)) {
+		return "", false
+	}
+	return r.ScheduledSegment(day)
+}
+
+// SetBiweekly makes the days every other week, starting with each one's next
+// match run after `now`. Every other day of the Schedule is every week.
+func (r *Recurser) SetBiweekly(now time.Time, days []string) {
+	r.Biweekly = nil
+	for _, day := range days {
+		run, ok := r.nextRunOn(now, day)
+		if !ok {
+			continue
+		}
+		if r.Biweekly == nil {
+			r.Biweekly = make(map[string]int)
+		}
+		r.Biweekly[day] = weekParity(r.matchTime(run))
+	}
+}
+
+// weekParity returns 0 or 1, alternating each week. Weeks start on Monday, like
+// ISO weeks, but they're counted from 1970 rather than numbered within each
+// year, so the parity keeps alternating after years with 53 ISO weeks.
+func weekParity(t time.Time) int {
+	// Compare the dates as UTC midnights so DST changes don't get in the way.
+	y, m, d := t.Date()
+	days := int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Sub(firstMonday).Hours() / 24)
+	return (days / 7) % 2
+}
+
+// firstMonday is the first Monday of 1970, where weekParity starts counting.
+var firstMonday = time.Date(1970, time.January, 5, 0, 0, 0, 0, time.UTC)
+
+// weekOf returns the Monday that starts the week of the Recurser's MatchDate
+// for `now`, formatted as time.DateOnly.
+func (r *Recurser) weekOf(now time.Time) string {
+	return weekStart(r.matchTime(now)).Format(time.DateOnly)
+}
+
+// weekStart returns the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -daysSinceMonday)
+}
+
+// IsScheduledOn returns whether the Recurser wants to be matched on the day,
+// for all or part of it.
+func (r *Recurser) IsScheduledOn(day string) bool {
+	_, ok := r.ScheduledSegment(day)
+	return ok
+}
+
+// SegmentOverlaps returns whether the two Recursers are available at the same
+// time of day, based on their Segments.
+func (r *Recurser) SegmentOverlaps(other *Recurser) bool {
+	return r.Segment == "" || other.Segment == "" || r.Segment == other.Segment
+}
+
+// A Block is someone a Recurser never wants to be matched with. The name is
+// only kept so the Recurser can see who they've blocked.
+type Block struct {
+	ID   int64  `firestore:"id"`
+	Name string `firestore:"name"`
+}
+
+// HasBlocked returns whether the Recurser has blocked the user.
+func (r *Recurser) HasBlocked(userID int64) bool {
+	return slices.ContainsFunc(r.Blocks, func(b Block) bool { return b.ID == userID })
+}
+
+// BlockedIDs returns the user IDs of everyone the Recurser has blocked.
+func (r *Recurser) BlockedIDs() []int64 {
+	var ids []int64
+	for _, b := range r.Blocks {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+// CanPairWith returns whether the Recursers are different people in the same
+// realm, neither has blocked the other, and anyone who wants to pair in person
+// is matched with someone who's at RC. Unlike the other matching preferences,
+// this is never relaxed.
+func (r *Recurser) CanPairWith(other *Recurser) bool {
+	if (r.Mode == ModeInPerson && !other.CurrentlyAtRC) || (other.Mode == ModeInPerson && !r.CurrentlyAtRC) {
+		return false
+	}
+	return r.Realm == other.Realm && r.ID != other.ID && !r.HasBlocked(other.ID) && !other.HasBlocked(r.ID)
+}
+
+// DefaultRealm is the Realm of Recursers in Recurse Center's own Zulip
+// organization. Every Recurser from before there were other realms is in it.
+const DefaultRealm = ""
+
+// recurserDocID returns the document ID for a Recurser. Zulip IDs are only
+// unique within a realm, so documents for the other realms are prefixed with
+// the realm's name. The default realm's IDs are left alone so that existing
+// records keep working.
+func recurserDocID(realm string, userID int64) string {
+	if realm == DefaultRealm {
+		return strconv.FormatInt(userID, 10)
+	}
+	return realm + "-" + strconv.FormatInt(userID, 10)
+}
+
+// The values for Recurser.BatchPref.
+const (
+	BatchPrefAny   = "any"
+	BatchPrefSame  = "same"
+	BatchPrefCross = "cross"
+)
+
+// The values for Recurser.Mode.
+const (
+	ModeAny      = "any"
+	ModeVoice    = "voice"
+	ModeText     = "text"
+	ModeInPerson = "inperson"
+)
+
+// ModeCompatible returns whether the two Recursers would like to pair the same
+// way. Someone without a preference is compatible with anyone.
+func (r *Recurser) ModeCompatible(other *Recurser) bool {
+	if r.Mode == "" || r.Mode == ModeAny || other.Mode == "" || other.Mode == ModeAny {
+		return true
+	}
+	return r.Mode == other.Mode
+}
+
+// The values for Recurser.Verbosity, from least to most verbose.
+const (
+	VerbosityMinimal = "minimal"
+	VerbosityNormal  = "normal"
+	VerbosityFull    = "full"
+)
+
+// BatchCompatible returns whether pairing the two Recursers respects both of
+// their batch preferences. If we don't know someone's batch, or the two
+// preferences contradict each other (one wants "same" and the other wants
+// "cross"), any pairing is fine.
+func (r *Recurser) BatchCompatible(other *Recurser) bool {
+	if r.BatchID == 0 || other.BatchID == 0 {
+		return true
+	}
+
+	prefs := []string{r.BatchPref, other.BatchPref}
+	if slices.Contains(prefs, BatchPrefSame) && slices.Contains(prefs, BatchPrefCross) {
+		return true
+	}
+
+	sameBatch := r.BatchID == other.BatchID
+	for _, pref := range prefs {
+		switch pref {
+		case BatchPrefSame:
+			if !sameBatch {
+				return false
+			}
+		case BatchPrefCross:
+			if sameBatch {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// UnsubscribeGracePeriod is how long an unsubscribed Recurser can restore their
+// old settings before their record is purged.
+const UnsubscribeGracePeriod = 14 * 24 * time.Hour
+
+// CanRestore returns whether the Recurser unsubscribed recently enough to get
+// their old settings back as of `now`.
+func (r *Recurser) CanRestore(now time.Time) bool {
+	if r.UnsubscribedAt == 0 {
+		return false
+	}
+	return now.Before(time.Unix(r.UnsubscribedAt, 0).Add(UnsubscribeGracePeriod))
+}
+
+// SubscriptionCooldown is how long a Recurser has to wait after subscribing or
+// unsubscribing before they can change it again.
+const SubscriptionCooldown = time.Minute
+
+// InSubscriptionCooldown returns whether the Recurser changed their
+// subscription less than SubscriptionCooldown before `now`.
+func (r *Recurser) InSubscriptionCooldown(now time.Time) bool {
+	if r.SubscriptionChangedAt == 0 {
+		return false
+	}
+	return now.Before(time.Unix(r.SubscriptionChangedAt, 0).Add(SubscriptionCooldown))
+}
+
+// PausedIndefinitely is the PausedUntil value for a pause with no end date.
+const PausedIndefinitely int64 = math.MaxInt64
+
+// IsPaused returns whether the Recurser has paused matching as of `now`.
+func (r *Recurser) IsPaused(now time.Time) bool {
+	return r.PausedUntil > now.Unix()
+}
+
+// Location returns the Recurser's time zone, falling back to UTC if it's unset
+// or unrecognized.
+func (r *Recurser) Location() *time.Location {
+	if r.Timezone == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// MatchDay returns the (lowercase) name of the day that a match run at `now`
+// would be pairing this Recurser for.
+//
+// Matches go out once a day at a fixed UTC time, which could be any time of
+// day locally. We pair for the local day 12 hours after the match run: "today"
+// where the run is in the morning, and "tomorrow" where it's in the afternoon
+// or evening. With the run at 04:00 UTC, that's tomorrow for anyone at UTC+8
+// or further east (where it's the afternoon), and for anyone at UTC-5 or
+// further west (where it's still the evening before). Either way, the date is
+// the UTC date of the run, except at UTC+8 or further east.
+func (r *Recurser) MatchDay(now time.Time) string {
+	return strings.ToLower(r.matchTime(now).Weekday().String())
+}
+
+// MatchDate is like MatchDay, but returns the full date formatted as
+// time.DateOnly.
+func (r *Recurser) MatchDate(now time.Time) string {
+	return r.matchTime(now).Format(time.DateOnly)
+}
+
+func (r *Recurser) matchTime(now time.Time) time.Time {
+	return MatchTimeIn(now, r.Location())
+}
+
+// MatchTimeIn is the time in loc that a match run at `now` is pairing for. Its
+// date is the one MatchDay and MatchDate use for a Recurser in loc.
+func MatchTimeIn(now time.Time, loc *time.Location) time.Time {
+	return now.In(loc).Add(12 * time.Hour)
+}
+
+// MatchRunHour is the hour (UTC) when the daily match run happens. This needs
+// to agree with the /match schedule in cron.yaml.
+const MatchRunHour = 4
+
+// nextMatchSearchDays is how many upcoming match runs NextMatchDate checks
+// before giving up. A year covers any schedule plus a long list of skips.
+const nextMatchSearchDays = 366
+
+// NextMatchDate returns the MatchDate of the first match run after `now` that
+// would match this Recurser, taking their schedule (and any WeekOverride),
+// pause, and skips into account. It returns false if there isn't one coming up
+// (like with an empty schedule or an indefinite pause).
+func (r *Recurser) NextMatchDate(now time.Time) (string, bool) {
+	run := nextMatchRun(now)
+	for i := 0; i < nextMatchSearchDays; i, run = i+1, run.AddDate(0, 0, 1) {
+		if r.pairsAt(run, i == 0) {
+			return r.MatchDate(run), true
+		}
+	}
+	return "", false
+}
+
+// NextRunDate returns the MatchDate of the next match run after `now`, whether
+// or not it would match this Recurser. That's the day IsSkippingTomorrow skips,
+// which isn't always tomorrow locally.
+func (r *Recurser) NextRunDate(now time.Time) string {
+	return r.MatchDate(nextMatchRun(now))
+}
+
+// PairsOn returns whether the next match run after `now` with the day as the
+// Recurser's MatchDay would match them, like NextMatchDate.
+func (r *Recurser) PairsOn(now time.Time, day string) bool {
+	run, ok := r.nextRunOn(now, day)
+	return ok && r.pairsAt(run, run.Equal(nextMatchRun(now)))
+}
+
+// RestOfWeek returns the Recurser's MatchDays from the next match run after
+// `now` through the end of that run's week (Sunday), in order.
+func (r *Recurser) RestOfWeek(now time.Time) []string {
+	run := nextMatchRun(now)
+	week := r.weekOf(run)
+
+	var days []string
+	for ; r.weekOf(run) == week; run = run.AddDate(0, 0, 1) {
+		days = append(days, r.MatchDay(run))
+	}
+	return days
+}
+
+// nextRunOn returns the first match run after `now` with the day as the
+// Recurser's MatchDay.
+func (r *Recurser) nextRunOn(now time.Time, day string) (time.Time, bool) {
+	run := nextMatchRun(now)
+	for range 7 {
+		if r.MatchDay(run) == day {
+			return run, true
+		}
+		run = run.AddDate(0, 0, 1)
+	}
+	return time.Time{}, false
+}
+
+// pairsAt returns whether the match run at `run` would match the Recurser.
+// next is whether it's the very next run, which is the only one that "skip
+// tomorrow" applies to.
+func (r *Recurser) pairsAt(run time.Time, next bool) bool {
+	if next && r.IsSkippingTomorrow {
+		return false
+	}
+	_, ok := r.MatchSegment(run)
+	return ok && !r.IsPaused(run) && !r.SkipDates[r.MatchDate(run)] && !r.IsSkippingDays(run)
+}
+
+// SkipDays skips the Recurser's next n MatchDates after `now`, starting with
+// the one for the next match run.
+func (r *Recurser) SkipDays(now time.Time, n int) {
+	y, m, d := r.matchTime(nextMatchRun(now)).Date()
+	r.SkipUntil = time.Date(y, m, d+n, 0, 0, 0, 0, r.Location()).Unix()
+}
+
+// IsSkippingDays returns whether the match run at `run` is for one of the days
+// skipped with SkipDays.
+func (r *Recurser) IsSkippingDays(run time.Time) bool {
+	return r.matchTime(run).Unix() < r.SkipUntil
+}
+
+// SkipDaysLeft returns how many of the days skipped with SkipDays are still to
+// come after `now`.
+func (r *Recurser) SkipDaysLeft(now time.Time) int {
+	if r.SkipUntil == 0 {
+		return 0
+	}
+	first := r.matchTime(nextMatchRun(now))
+	until := time.Unix(r.SkipUntil, 0).In(r.Location())
+
+	// Compare the dates as UTC midnights so DST changes don't get in the way.
+	fy, fm, fd := first.Date()
+	uy, um, ud := until.Date()
+	days := time.Date(uy, um, ud, 0, 0, 0, 0, time.UTC).Sub(time.Date(fy, fm, fd, 0, 0, 0, 0, time.UTC)).Hours() / 24
+	return max(0, int(days))
+}
+
+// nextMatchRun returns the time of the first match run after `now`.
+func nextMatchRun(now time.Time) time.Time {
+	utc := now.UTC()
+	run := time.Date(utc.Year(), utc.Month(), utc.Day(), MatchRunHour, 0, 0, 0, time.UTC)
+	if !run.After(now) {
+		run = run.AddDate(0, 0, 1)
+	}
+	return run
+}
+
+// NotifyAt returns when to tell the Recurser about a match made at `now`,
+// based on their preferred MatchTime on their MatchDate. This is never before
+// `now`.
+func (r *Recurser) NotifyAt(now time.Time) time.Time {
+	if r.MatchTime == "" {
+		return now
+	}
+
+	notifyAt, err := time.ParseInLocation(time.DateOnly+" 15:04", r.MatchDate(now)+" "+r.MatchTime, r.Location())
+	if err != nil || notifyAt.Before(now) {
+		return now
+	}
+	return notifyAt
+}
+
+// RemovePastSkipDates forgets about any SkipDates before the MatchDate for
+// `now`, and about SkipUntil once it's over, since they can't affect any future
+// matches.
+func (r *Recurser) RemovePastSkipDates(now time.Time) {
+	today := r.MatchDate(now)
+	for date := range r.SkipDates {
+		// DateOnly strings sort chronologically.
+		if date < today {
+			delete(r.SkipDates, date)
+		}
+	}
+	if r.SkipUntil != 0 && !r.IsSkippingDays(now) {
+		r.SkipUntil = 0
+	}
+}
+
 // RecursersClient manages Pairing Bot subscribers ("Recursers").
 type RecursersClient struct {
 	client *firestore.Client
@@ -71,8 +743,10 @@ func Recursers(client *firestore.Client) *RecursersClient {
 	return &RecursersClient{client}
 }
 
-func (r *RecursersClient) GetByUserID(ctx context.Context, userID int64, userEmail, userName string) (*Recurser, error) {
-	docID := strconv.FormatInt(userID, 10)
+// GetByUserID returns the Recurser with the Zulip ID in the realm. If there's
+// no record, this returns a new, unsubscribed one.
+func (r *RecursersClient) GetByUserID(ctx context.Context, realm string, userID int64, userEmail, userName string) (*Recurser, error) {
+	docID := recurserDocID(realm, userID)
 	doc, err := r.client.Collection("recursers").Doc(docID).Get(ctx)
 
 	// A missing document still returns a non-nil doc with its NotFound error.
@@ -88,6 +762,7 @@ func (r *RecursersClient) GetByUserID(ctx context.Context, userID int64, userEma
 			Name:     userName,
 			Email:    userEmail,
 			Schedule: DefaultSchedule(),
+			Realm:    realm,
 		}, nil
 	}
 
@@ -97,7 +772,7 @@ func (r *RecursersClient) GetByUserID(ctx context.Context, userID int64, userEma
 	}
 
 	// This field isn't stored in the DB, so populate it now.
-	recurser.IsSubscribed = true
+	recurser.IsSubscribed = recurser.UnsubscribedAt == 0
 
 	// Prefer the Zulip values for these fields over our cached ones.
 	recurser.Name = userName
@@ -106,41 +781,229 @@ func (r *RecursersClient) GetByUserID(ctx context.Context, userID int64, userEma
 	return &recurser, nil
 }
 
-func (r *RecursersClient) GetAllUsers(ctx context.Context) ([]Recurser, error) {
+// GetAllSubscribed returns every subscribed Recurser with a single collection
+// query. Recursers who have unsubscribed but haven't been purged yet are left
+// out.
+//
+// Unlike GetByUserID, there's no fresh Zulip data to prefer here, so Name and
+// Email are whatever was stored the last time each Recurser was written.
+func (r *RecursersClient) GetAllSubscribed(ctx context.Context) ([]Recurser, error) {
+	// Records from before soft-deletes don't have an unsubscribedAt field at
+	// all, and Firestore queries never match missing fields. So this filters
+	// after reading instead of in the query.
 	iter := r.client.Collection("recursers").Documents(ctx)
-	return fetchAll[Recurser](iter)
+	all, err := fetchAll[Recurser](iter)
+	if err != nil {
+		return nil, err
+	}
+
+	var subscribed []Recurser
+	for _, rec := range all {
+		if rec.UnsubscribedAt == 0 {
+			rec.IsSubscribed = true
+			subscribed = append(subscribed, rec)
+		}
+	}
+	return subscribed, nil
+}
+
+// GetAll returns every Recurser record, including the ones who have
+// unsubscribed but haven't been purged yet.
+func (r *RecursersClient) GetAll(ctx context.Context) ([]Recurser, error) {
+	iter := r.client.Collection("recursers").Documents(ctx)
+	all, err := fetchAll[Recurser](iter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range all {
+		all[i].IsSubscribed = all[i].UnsubscribedAt == 0
+	}
+	return all, nil
 }
 
-func (r *RecursersClient) Set(ctx context.Context, _ int64, recurser *Recurser) error {
-	docID := strconv.FormatInt(recurser.ID, 10)
+// Set saves the Recurser's record, replacing any that's already there.
+func (r *RecursersClient) Set(ctx context.Context, recurser *Recurser) error {
+	docID := recurserDocID(recurser.Realm, recurser.ID)
 
-	// Merging isn't supported when using struct data, but we never do partial
-	// writes in the first place. So this will completely overwrite an existing
+	// Merging isn't supported when using struct data, and the only partial
+	// write is MarkActive. So this will completely overwrite an existing
 	// document.
-	_, err := r.client.Collection("recursers").Doc(docID).Set(ctx, recurser)
-	return err
+	return withRetry(ctx, func() error {
+		_, err := r.client.Collection("recursers").Doc(docID).Set(ctx, recurser)
+		return err
+	})
+
+}
 
+// EnsureSubscribed saves the record of a Recurser who's subscribing, unless
+// they're already subscribed. It reports whether it saved it.
+//
+// A transaction, so that when the first few commands from someone new arrive
+// at once, only one of them subscribes them: the others see the subscription
+// and keep whatever else was changed in the meantime, rather than overwriting
+// it with the record they read before it existed.
+func (r *RecursersClient) EnsureSubscribed(ctx context.Context, recurser *Recurser) (bool, error) {
+	doc := r.client.Collection("recursers").Doc(recurserDocID(recurser.Realm, recurser.ID))
+
+	var saved bool
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snapshot, err := tx.Get(doc)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		if snapshot.Exists() {
+			var existing Recurser
+			if err := snapshot.DataTo(&existing); err != nil {
+				return fmt.Errorf("parse document %q: %w", doc.Path, err)
+			}
+			if existing.UnsubscribedAt == 0 {
+				saved = false
+				return nil
+			}
+		}
+
+		saved = true
+		return tx.Set(doc, recurser)
+	})
+	if err != nil {
+		return false, err
+	}
+	return saved, nil
 }
 
-func (r *RecursersClient) Delete(ctx context.Context, userID int64) error {
-	docID := strconv.FormatInt(userID, 10)
-	_, err := r.client.Collection("recursers").Doc(docID).Delete(ctx)
-	return err
+// MarkActive sets the Recurser's LastActive to `now`, without touching the rest
+// of their record. It does nothing if they don't have a record.
+func (r *RecursersClient) MarkActive(ctx context.Context, realm string, userID int64, now time.Time) error {
+	docID := recurserDocID(realm, userID)
+	return withRetry(ctx, func() error {
+		_, err := r.client.Collection("recursers").Doc(docID).Update(ctx, []firestore.Update{
+			{Path: "lastActive", Value: now.Unix()},
+		})
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		return err
+	})
 }
 
-func (r *RecursersClient) ListPairingTomorrow(ctx context.Context) ([]Recurser, error) {
-	// this gets the time from system time, which is UTC
-	// on app engine (and most other places). This works
-	// fine for us in NYC, but might not if pairing bot
-	// were ever running in another time zone
-	today := strings.ToLower(time.Now().Weekday().String())
+func (r *RecursersClient) Delete(ctx context.Context, realm string, userID int64) error {
+	docID := recurserDocID(realm, userID)
+	return withRetry(ctx, func() error {
+		_, err := r.client.Collection("recursers").Doc(docID).Delete(ctx)
+		return err
+	})
+}
+
+// PurgeUnsubscribed permanently deletes the records of Recursers who
+// unsubscribed more than UnsubscribeGracePeriod before `now`. It returns the
+// purged Recursers.
+func (r *RecursersClient) PurgeUnsubscribed(ctx context.Context, now time.Time) ([]Recurser, error) {
+	cutoff := now.Add(-UnsubscribeGracePeriod).Unix()
 
 	iter := r.client.
 		Collection("recursers").
-		Where("isSkippingTomorrow", "==", false).
-		Where("schedule."+today, "==", true).
+		Where("unsubscribedAt", ">", 0).
+		Where("unsubscribedAt", "<=", cutoff).
 		Documents(ctx)
-	return fetchAll[Recurser](iter)
+	expired, err := fetchAll[Recurser](iter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range expired {
+		if err := r.Delete(ctx, rec.Realm, rec.ID); err != nil {
+			return nil, fmt.Errorf("delete %d: %w", rec.ID, err)
+		}
+	}
+	return expired, nil
+}
+
+// CountScheduledDays returns how many subscribed Recursers, other than the one
+// with ID `except`, are scheduled on each day (for all or part of it). Paused
+// Recursers are counted, since they'll be back.
+func (r *RecursersClient) CountScheduledDays(ctx context.Context, except int64) (map[string]int, error) {
+	all, err := r.GetAllSubscribed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, rec := range all {
+		if rec.ID == except {
+			continue
+		}
+		for day := range EmptySchedule() {
+			if rec.IsScheduledOn(day) {
+				counts[day]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// ListPairingTomorrow returns the Recursers who should be matched by a match
+// run at `now`, based on their MatchSegment for their local MatchDay. Paused and
+// unsubscribed Recursers and anyone skipping their MatchDate are left out.
+// Each Recurser's Segment is set from their schedule for that day.
+func (r *RecursersClient) ListPairingTomorrow(ctx context.Context, now time.Time) ([]Recurser, error) {
+	// Each Recurser's match day depends on their time zone, so we can't filter
+	// the schedule in the query itself.
+	all, err := r.GetAllSubscribed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pairing []Recurser
+	for _, rec := range all {
+		if rec.pairsAt(now, true) {
+			rec.Segment, _ = rec.MatchSegment(now)
+			pairing = append(pairing, rec)
+		}
+	}
+	return pairing, nil
+}
+
+// ListScheduledOn returns the subscribed Recursers who will be matched on the
+// next day (after `now`) with that name, in each one's own time zone. See
+// Recurser.PairsOn.
+func (r *RecursersClient) ListScheduledOn(ctx context.Context, now time.Time, day string) ([]Recurser, error) {
+	all, err := r.GetAllSubscribed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var scheduled []Recurser
+	for _, rec := range all {
+		if rec.PairsOn(now, day) {
+			scheduled = append(scheduled, rec)
+		}
+	}
+	return scheduled, nil
+}
+
+// CountAvailable returns how many subscribed Recursers, other than the one
+// with ID `except`, will be matched on the next day (after `now`) with each of
+// the names, like ListScheduledOn. Mentors aren't counted, since they're only
+// matched by the mentor matching job.
+func (r *RecursersClient) CountAvailable(ctx context.Context, now time.Time, days []string, except int64) (map[string]int, error) {
+	all, err := r.GetAllSubscribed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, rec := range all {
+		if rec.ID == except || rec.IsMentor {
+			continue
+		}
+		for _, day := range days {
+			if rec.PairsOn(now, day) {
+				counts[day]++
+			}
+		}
+	}
+	return counts, nil
 }
 
 func (r *RecursersClient) ListSkippingTomorrow(ctx context.Context) ([]Recurser, error) {
@@ -153,5 +1016,5 @@ func (r *RecursersClient) ListSkippingTomorrow(ctx context.Context) ([]Recurser,
 
 func (r *RecursersClient) UnsetSkippingTomorrow(ctx context.Context, recurser *Recurser) error {
 	recurser.IsSkippingTomorrow = false
-	return r.Set(ctx, recurser.ID, recurser)
+	return r.Set(ctx, recurser)
 }
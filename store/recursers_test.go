@@ -2,8 +2,12 @@ package store_test
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/recursecenter/pairing-bot/internal/assert"
 	"github.com/recursecenter/pairing-bot/internal/pbtest"
@@ -27,7 +31,7 @@ func TestFirestoreRecursersClient(t *testing.T) {
 			CurrentlyAtRC:      false,
 		}
 
-		err := recursers.Set(ctx, recurser.ID, &recurser)
+		err := recursers.Set(ctx, &recurser)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -40,7 +44,7 @@ func TestFirestoreRecursersClient(t *testing.T) {
 		// GetByUserID will prefer the argument values for email and name if
 		// they differ from what's stored in the DB. These values are the same,
 		// so we wouldn't be able to tell from this call.
-		unchanged, err := recursers.GetByUserID(ctx, recurser.ID, recurser.Email, recurser.Name)
+		unchanged, err := recursers.GetByUserID(ctx, store.DefaultRealm, recurser.ID, recurser.Email, recurser.Name)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -49,7 +53,7 @@ func TestFirestoreRecursersClient(t *testing.T) {
 
 		// These values are different, so this call *does* tell us whether we
 		// used the arguments.
-		changed, err := recursers.GetByUserID(ctx, recurser.ID, "changed@recurse.example.net", "My Name")
+		changed, err := recursers.GetByUserID(ctx, store.DefaultRealm, recurser.ID, "changed@recurse.example.net", "My Name")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -74,5 +78,957 @@ func TestFirestoreRecursersClient(t *testing.T) {
 
 		assert.Equal(t, actual, recurser)
 	})
+
+	t.Run("restore within grace period", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		recursers := store.Recursers(client)
+
+		recurser := store.Recurser{
+			ID:             pbtest.RandInt64(t),
+			Name:           "Your Name",
+			Email:          "test@recurse.example.net",
+			Schedule:       store.NewSchedule([]string{"tuesday"}),
+			UnsubscribedAt: time.Now().Add(-24 * time.Hour).Unix(),
+		}
+		if err := recursers.Set(ctx, &recurser); err != nil {
+			t.Fatal(err)
+		}
+
+		// The old settings are still there, but the Recurser isn't subscribed.
+		unsubscribed, err := recursers.GetByUserID(ctx, store.DefaultRealm, recurser.ID, recurser.Email, recurser.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, unsubscribed.IsSubscribed, false)
+		assert.Equal(t, unsubscribed.CanRestore(time.Now()), true)
+		assert.Equal(t, unsubscribed.Schedule, recurser.Schedule)
+
+		// Nothing to purge yet.
+		purged, err := recursers.PurgeUnsubscribed(ctx, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rec := range purged {
+			if rec.ID == recurser.ID {
+				t.Errorf("purged %d too early", rec.ID)
+			}
+		}
+
+		unsubscribed.UnsubscribedAt = 0
+		if err := recursers.Set(ctx, unsubscribed); err != nil {
+			t.Fatal(err)
+		}
+
+		restored, err := recursers.GetByUserID(ctx, store.DefaultRealm, recurser.ID, recurser.Email, recurser.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, restored.IsSubscribed, true)
+		assert.Equal(t, restored.Schedule, recurser.Schedule)
+	})
+
+	t.Run("same ID in another realm", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		recursers := store.Recursers(client)
+
+		id := pbtest.RandInt64(t)
+		here := store.Recurser{ID: id, Name: "Here", Schedule: store.DefaultSchedule()}
+		there := store.Recurser{ID: id, Name: "There", Schedule: store.DefaultSchedule(), Realm: "sister"}
+		for _, rec := range []store.Recurser{here, there} {
+			if err := recursers.Set(ctx, &rec); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// They're separate records, and deleting one leaves the other.
+		if err := recursers.Delete(ctx, store.DefaultRealm, id); err != nil {
+			t.Fatal(err)
+		}
+
+		gone, err := recursers.GetByUserID(ctx, store.DefaultRealm, id, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, gone.IsSubscribed, false)
+
+		found, err := recursers.GetByUserID(ctx, "sister", id, "", "There")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, found.IsSubscribed, true)
+		assert.Equal(t, found.Realm, "sister")
+	})
+
+	t.Run("purge after grace period", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		recursers := store.Recursers(client)
+
+		recurser := store.Recurser{
+			ID:             pbtest.RandInt64(t),
+			Name:           "Your Name",
+			Email:          "test@recurse.example.net",
+			Schedule:       store.DefaultSchedule(),
+			UnsubscribedAt: time.Now().Add(-store.UnsubscribeGracePeriod - time.Hour).Unix(),
+		}
+		if err := recursers.Set(ctx, &recurser); err != nil {
+			t.Fatal(err)
+		}
+
+		purged, err := recursers.PurgeUnsubscribed(ctx, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var found bool
+		for _, rec := range purged {
+			found = found || rec.ID == recurser.ID
+		}
+		assert.Equal(t, found, true)
+
+		// Now it's as if they were never subscribed.
+		gone, err := recursers.GetByUserID(ctx, store.DefaultRealm, recurser.ID, recurser.Email, recurser.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, gone.UnsubscribedAt, int64(0))
+		assert.Equal(t, gone.Schedule, store.DefaultSchedule())
+	})
+
+	t.Run("ensure subscribed", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		recursers := store.Recursers(client)
+		id := pbtest.RandInt64(t)
+
+		// Several first commands at once, each with the record it read
+		// before there was one.
+		days := []string{"monday", "tuesday", "wednesday", "thursday", "friday"}
+		saved := make([]bool, len(days))
+		var wg sync.WaitGroup
+		for i, day := range days {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				rec := store.Recurser{ID: id, Name: "Your Name", Schedule: store.NewSchedule([]string{day})}
+				var err error
+				saved[i], err = recursers.EnsureSubscribed(ctx, &rec)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		// Exactly one of them subscribed them, and that's the record that
+		// was kept.
+		winner := slices.Index(saved, true)
+		assert.Equal(t, winner >= 0, true)
+		assert.Equal(t, slices.Index(saved[winner+1:], true), -1)
+
+		got, err := recursers.GetByUserID(ctx, store.DefaultRealm, id, "", "Your Name")
+		assert.NoError(t, err)
+		assert.Equal(t, got.IsSubscribed, true)
+		assert.Equal(t, got.Schedule, store.NewSchedule([]string{days[winner]}))
+
+		// A schedule change after subscribing survives a late subscribe with
+		// the stale record.
+		got.Schedule = store.NewSchedule([]string{"saturday"})
+		assert.NoError(t, recursers.Set(ctx, got))
+
+		stale := store.Recurser{ID: id, Name: "Your Name", Schedule: store.DefaultSchedule()}
+		ok, err := recursers.EnsureSubscribed(ctx, &stale)
+		assert.NoError(t, err)
+		assert.Equal(t, ok, false)
+
+		got, err = recursers.GetByUserID(ctx, store.DefaultRealm, id, "", "Your Name")
+		assert.NoError(t, err)
+		assert.Equal(t, got.Schedule, store.NewSchedule([]string{"saturday"}))
+
+		// Someone who unsubscribed can subscribe again.
+		got.UnsubscribedAt = time.Now().Unix()
+		assert.NoError(t, recursers.Set(ctx, got))
+		ok, err = recursers.EnsureSubscribed(ctx, &stale)
+		assert.NoError(t, err)
+		assert.Equal(t, ok, true)
+	})
+
+	t.Run("mark active", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		recursers := store.Recursers(client)
+
+		recurser := store.Recurser{
+			ID:       pbtest.RandInt64(t),
+			Name:     "Your Name",
+			Bio:      "Engines",
+			Schedule: store.DefaultSchedule(),
+		}
+		if err := recursers.Set(ctx, &recurser); err != nil {
+			t.Fatal(err)
+		}
+
+		now := time.Unix(1_700_000_000, 0)
+		assert.NoError(t, recursers.MarkActive(ctx, store.DefaultRealm, recurser.ID, now))
+
+		// Only LastActive changes.
+		got, err := recursers.GetByUserID(ctx, store.DefaultRealm, recurser.ID, "", recurser.Name)
+		assert.NoError(t, err)
+		assert.Equal(t, got.LastActive, now.Unix())
+		assert.Equal(t, got.Bio, recurser.Bio)
+
+		// Someone without a record stays without one.
+		other := pbtest.RandInt64(t)
+		assert.NoError(t, recursers.MarkActive(ctx, store.DefaultRealm, other, now))
+		got, err = recursers.GetByUserID(ctx, store.DefaultRealm, other, "", "")
+		assert.NoError(t, err)
+		assert.Equal(t, got.IsSubscribed, false)
+	})
+}
+
+func TestRecurser_MatchDay(t *testing.T) {
+	// The daily match runs at 04:00 UTC.
+	matchRun := time.Date(2024, time.March, 12, 4, 0, 0, 0, time.UTC) // Tuesday
+
+	for timezone, expected := range map[string]string{
+		"":                    "tuesday",
+		"UTC":                 "tuesday",
+		"America/New_York":    "tuesday", // 00:00
+		"Europe/Berlin":       "tuesday", // 05:00
+		"America/Los_Angeles": "tuesday", // 21:00 Monday
+		"Asia/Kolkata":        "tuesday", // 09:30
+		"Pacific/Kiritimati":  "wednesday",
+		"not/a-zone":          "tuesday", // Falls back to UTC
+	} {
+		t.Run(timezone, func(t *testing.T) {
+			rec := store.Recurser{Timezone: timezone}
+			assert.Equal(t, rec.MatchDay(matchRun), expected)
+		})
+	}
+}
+
+func TestRecurser_IsPaused(t *testing.T) {
+	now := time.Now()
+
+	for name, tc := range map[string]struct {
+		PausedUntil int64
+		Expected    bool
+	}{
+		"not paused":   {0, false},
+		"pause ended":  {now.Add(-time.Hour).Unix(), false},
+		"paused":       {now.Add(time.Hour).Unix(), true},
+		"indefinitely": {store.PausedIndefinitely, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := store.Recurser{PausedUntil: tc.PausedUntil}
+			assert.Equal(t, rec.IsPaused(now), tc.Expected)
+		})
+	}
+}
+
+func TestRecurser_NextRunDate(t *testing.T) {
+	// Not on their schedule, but it's still the next run.
+	rec := store.Recurser{Schedule: store.NewSchedule([]string{"monday"})}
+	assert.Equal(t, rec.NextRunDate(time.Date(2024, time.March, 12, 2, 0, 0, 0, time.UTC)), "2024-03-12")
+	assert.Equal(t, rec.NextRunDate(time.Date(2024, time.March, 12, 5, 0, 0, 0, time.UTC)), "2024-03-13")
+
+	// The run is in the afternoon in Tokyo, so it's for tomorrow there. Late
+	// in the evening, that's the day after tomorrow.
+	tokyo := store.Recurser{Timezone: "Asia/Tokyo"}
+	assert.Equal(t, tokyo.NextRunDate(time.Date(2024, time.March, 12, 2, 0, 0, 0, time.UTC)), "2024-03-13")
+	assert.Equal(t, tokyo.NextRunDate(time.Date(2024, time.March, 12, 14, 0, 0, 0, time.UTC)), "2024-03-14")
+}
+
+func TestRecurser_NextMatchDate(t *testing.T) {
+	// Matched on Mondays, Wednesdays, and Fridays.
+	schedule := store.NewSchedule([]string{"monday", "wednesday", "friday"})
+
+	for name, tc := range map[string]struct {
+		Now      time.Time
+		Expected string
+	}{
+		"monday before the run": {time.Date(2024, time.March, 11, 2, 0, 0, 0, time.UTC), "2024-03-11"},
+		"monday after the run":  {time.Date(2024, time.March, 11, 5, 0, 0, 0, time.UTC), "2024-03-13"},
+		"tuesday":               {time.Date(2024, time.March, 12, 12, 0, 0, 0, time.UTC), "2024-03-13"},
+		"thursday":              {time.Date(2024, time.March, 14, 12, 0, 0, 0, time.UTC), "2024-03-15"},
+		"friday after the run":  {time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC), "2024-03-18"},
+		"saturday":              {time.Date(2024, time.March, 16, 12, 0, 0, 0, time.UTC), "2024-03-18"},
+		"sunday":                {time.Date(2024, time.March, 17, 12, 0, 0, 0, time.UTC), "2024-03-18"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := store.Recurser{Schedule: schedule}
+			date, ok := rec.NextMatchDate(tc.Now)
+			assert.Equal(t, ok, true)
+			assert.Equal(t, date, tc.Expected)
+		})
+	}
+
+	tuesday := time.Date(2024, time.March, 12, 12, 0, 0, 0, time.UTC)
+
+	t.Run("skips", func(t *testing.T) {
+		rec := store.Recurser{
+			Schedule:  schedule,
+			SkipDates: map[string]bool{"2024-03-13": true, "2024-03-15": true},
+		}
+		date, _ := rec.NextMatchDate(tuesday)
+		assert.Equal(t, date, "2024-03-18")
+	})
+
+	t.Run("skipping tomorrow", func(t *testing.T) {
+		rec := store.Recurser{Schedule: schedule, IsSkippingTomorrow: true}
+		date, _ := rec.NextMatchDate(tuesday)
+		assert.Equal(t, date, "2024-03-15")
+	})
+
+	t.Run("paused", func(t *testing.T) {
+		rec := store.Recurser{
+			Schedule:    schedule,
+			PausedUntil: time.Date(2024, time.March, 20, 12, 0, 0, 0, time.UTC).Unix(),
+		}
+		date, _ := rec.NextMatchDate(tuesday)
+		assert.Equal(t, date, "2024-03-22")
+	})
+
+	t.Run("muted", func(t *testing.T) {
+		rec := store.Recurser{Schedule: schedule, MutedDays: map[string]bool{"wednesday": true, "friday": true}}
+		date, _ := rec.NextMatchDate(tuesday)
+		assert.Equal(t, date, "2024-03-18")
+
+		// Unmuting a day brings it back, since the Schedule still has it.
+		delete(rec.MutedDays, "wednesday")
+		date, _ = rec.NextMatchDate(tuesday)
+		assert.Equal(t, date, "2024-03-13")
+	})
+
+	t.Run("time zone", func(t *testing.T) {
+		// The Tuesday 04:00 UTC run is for Wednesday in Kiritimati.
+		rec := store.Recurser{Schedule: schedule, Timezone: "Pacific/Kiritimati"}
+		date, _ := rec.NextMatchDate(time.Date(2024, time.March, 12, 2, 0, 0, 0, time.UTC))
+		assert.Equal(t, date, "2024-03-13")
+	})
+
+	t.Run("empty schedule", func(t *testing.T) {
+		rec := store.Recurser{Schedule: store.EmptySchedule()}
+		_, ok := rec.NextMatchDate(tuesday)
+		assert.Equal(t, ok, false)
+	})
+
+	t.Run("paused indefinitely", func(t *testing.T) {
+		rec := store.Recurser{Schedule: schedule, PausedUntil: store.PausedIndefinitely}
+		_, ok := rec.NextMatchDate(tuesday)
+		assert.Equal(t, ok, false)
+	})
+}
+
+func TestRecurser_PairsOn(t *testing.T) {
+	schedule := store.NewSchedule([]string{"monday", "wednesday-am"})
+	tuesday := time.Date(2024, time.March, 12, 12, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		Recurser store.Recurser
+		Day      string
+		Expected bool
+	}{
+		"scheduled":       {store.Recurser{Schedule: schedule}, "monday", true},
+		"part of the day": {store.Recurser{Schedule: schedule}, "wednesday", true},
+		"not scheduled":   {store.Recurser{Schedule: schedule}, "friday", false},
+		"skipping the date": {
+			store.Recurser{Schedule: schedule, SkipDates: map[string]bool{"2024-03-13": true}},
+			"wednesday", false,
+		},
+		"skip is for another week": {
+			store.Recurser{Schedule: schedule, SkipDates: map[string]bool{"2024-03-20": true}},
+			"wednesday", true,
+		},
+		"skipping tomorrow":       {store.Recurser{Schedule: schedule, IsSkippingTomorrow: true}, "wednesday", false},
+		"skipping tomorrow later": {store.Recurser{Schedule: schedule, IsSkippingTomorrow: true}, "monday", true},
+		"paused": {
+			store.Recurser{Schedule: schedule, PausedUntil: store.PausedIndefinitely},
+			"monday", false,
+		},
+		"muted":         {store.Recurser{Schedule: schedule, MutedDays: map[string]bool{"monday": true}}, "monday", false},
+		"another muted": {store.Recurser{Schedule: schedule, MutedDays: map[string]bool{"monday": true}}, "wednesday", true},
+		"muted but added this week": {
+			store.Recurser{
+				Schedule:  schedule,
+				MutedDays: map[string]bool{"monday": true},
+				ThisWeek:  store.WeekOverride{WeekOf: "2024-03-18", Days: map[string]bool{"monday": true}},
+			},
+			"monday", true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.Recurser.PairsOn(tuesday, tc.Day), tc.Expected)
+		})
+	}
 }
 
+func TestRecurser_RestOfWeek(t *testing.T) {
+	// The Tuesday, March 12 run is the next one.
+	tuesday := time.Date(2024, time.March, 12, 2, 0, 0, 0, time.UTC)
+
+	rec := store.Recurser{}
+	assert.Equal(t, rec.RestOfWeek(tuesday), []string{"tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"})
+
+	// After the Sunday run, the rest of the week is all of the next one.
+	sunday := time.Date(2024, time.March, 17, 5, 0, 0, 0, time.UTC)
+	assert.Equal(t, len(rec.RestOfWeek(sunday)), 7)
+
+	t.Run("time zone", func(t *testing.T) {
+		// It's already Tuesday afternoon in Tokyo, so the Tuesday run is
+		// for Wednesday there.
+		rec := store.Recurser{Timezone: "Asia/Tokyo"}
+		assert.Equal(t, rec.RestOfWeek(tuesday), []string{"wednesday", "thursday", "friday", "saturday", "sunday"})
+	})
+}
+
+func TestRecurser_Biweekly(t *testing.T) {
+	schedule := store.NewSchedule([]string{"monday", "wednesday"})
+	tuesday := time.Date(2024, time.March, 12, 12, 0, 0, 0, time.UTC)
+
+	rec := store.Recurser{Schedule: schedule}
+	rec.SetBiweekly(tuesday, []string{"wednesday"})
+
+	// Wednesdays start with the next one, then alternate. Mondays stay weekly.
+	for week := range 6 {
+		now := tuesday.AddDate(0, 0, 7*week)
+		assert.Equal(t, rec.PairsOn(now, "wednesday"), week%2 == 0)
+		assert.Equal(t, rec.PairsOn(now, "monday"), true)
+	}
+
+	t.Run("next match date", func(t *testing.T) {
+		rec := store.Recurser{Schedule: store.NewSchedule([]string{"wednesday"})}
+		rec.SetBiweekly(tuesday, []string{"wednesday"})
+
+		var dates []string
+		now := tuesday
+		for range 3 {
+			date, ok := rec.NextMatchDate(now)
+			assert.Equal(t, ok, true)
+			dates = append(dates, date)
+
+			// Look again from the middle of that day.
+			matched, err := time.Parse(time.DateOnly, date)
+			assert.NoError(t, err)
+			now = matched.Add(12 * time.Hour)
+		}
+		assert.Equal(t, dates, []string{"2024-03-13", "2024-03-27", "2024-04-10"})
+	})
+
+	t.Run("across a 53-week year", func(t *testing.T) {
+		// 2026 has an ISO week 53, which is followed by week 1. Both are
+		// odd, but the weeks still alternate.
+		tuesday := time.Date(2026, time.December, 22, 12, 0, 0, 0, time.UTC)
+		rec := store.Recurser{Schedule: store.NewSchedule([]string{"wednesday"})}
+		rec.SetBiweekly(tuesday, []string{"wednesday"})
+
+		for week := range 4 {
+			assert.Equal(t, rec.PairsOn(tuesday.AddDate(0, 0, 7*week), "wednesday"), week%2 == 0)
+		}
+	})
+
+	t.Run("week override", func(t *testing.T) {
+		rec := store.Recurser{Schedule: schedule}
+		rec.SetBiweekly(tuesday, []string{"wednesday"})
+
+		offWeek := tuesday.AddDate(0, 0, 7)
+		rec.OverrideThisWeek(offWeek, "wednesday", true)
+		assert.Equal(t, rec.PairsOn(offWeek, "wednesday"), true)
+	})
+
+	t.Run("every week", func(t *testing.T) {
+		rec := store.Recurser{Schedule: schedule, Biweekly: map[string]int{"wednesday": 0}}
+		rec.SetBiweekly(tuesday, nil)
+		assert.Equal(t, rec.Biweekly, map[string]int(nil))
+	})
+}
+
+func TestRecurser_WeekOverride(t *testing.T) {
+	// Matched on Mondays, Wednesdays, and Fridays.
+	schedule := store.NewSchedule([]string{"monday", "wednesday", "friday"})
+
+	// The Tuesday, March 12 run, and the runs later that week and the next.
+	tuesday := time.Date(2024, time.March, 12, 4, 0, 0, 0, time.UTC)
+	thursday := tuesday.AddDate(0, 0, 2)
+	friday := tuesday.AddDate(0, 0, 3)
+	nextThursday := thursday.AddDate(0, 0, 7)
+	nextFriday := friday.AddDate(0, 0, 7)
+
+	rec := store.Recurser{Schedule: schedule}
+	rec.OverrideThisWeek(tuesday, "thursday", true)
+	rec.OverrideThisWeek(tuesday, "friday", false)
+
+	t.Run("applies during the week", func(t *testing.T) {
+		_, ok := rec.MatchSegment(thursday)
+		assert.Equal(t, ok, true)
+		_, ok = rec.MatchSegment(friday)
+		assert.Equal(t, ok, false)
+
+		date, _ := rec.NextMatchDate(tuesday)
+		assert.Equal(t, date, "2024-03-13")
+		date, _ = rec.NextMatchDate(thursday.Add(-time.Hour))
+		assert.Equal(t, date, "2024-03-14")
+		date, _ = rec.NextMatchDate(friday.Add(-time.Hour))
+		assert.Equal(t, date, "2024-03-18")
+	})
+
+	t.Run("doesn't apply the next week", func(t *testing.T) {
+		_, ok := rec.MatchSegment(nextThursday)
+		assert.Equal(t, ok, false)
+		_, ok = rec.MatchSegment(nextFriday)
+		assert.Equal(t, ok, true)
+	})
+
+	t.Run("sunday is still this week", func(t *testing.T) {
+		sunday := tuesday.AddDate(0, 0, 5)
+		assert.Equal(t, rec.ActiveWeekOverride(sunday), map[string]bool{"thursday": true, "friday": false})
+		assert.Equal(t, rec.ThisWeekDate(sunday, "monday"), "2024-03-11")
+	})
+
+	t.Run("cleared on the week boundary", func(t *testing.T) {
+		cleaned := rec
+		cleaned.RemovePastWeekOverride(friday)
+		assert.Equal(t, cleaned.ThisWeek, rec.ThisWeek)
+
+		cleaned.RemovePastWeekOverride(tuesday.AddDate(0, 0, 6))
+		assert.Equal(t, cleaned.ThisWeek, store.WeekOverride{})
+	})
+
+	t.Run("a new week starts over", func(t *testing.T) {
+		next := rec
+		next.OverrideThisWeek(nextThursday, "saturday", true)
+		assert.Equal(t, next.ThisWeek, store.WeekOverride{
+			WeekOf: "2024-03-18",
+			Days:   map[string]bool{"saturday": true},
+		})
+	})
+}
+
+func TestRecurser_RemovePastSkipDates(t *testing.T) {
+	now := time.Date(2024, time.March, 12, 4, 0, 0, 0, time.UTC)
+
+	rec := store.Recurser{
+		SkipDates: map[string]bool{
+			"2024-03-01": true,
+			"2024-03-11": true,
+			"2024-03-12": true,
+			"2024-04-01": true,
+		},
+	}
+
+	rec.RemovePastSkipDates(now)
+
+	assert.Equal(t, rec.SkipDates, map[string]bool{
+		"2024-03-12": true,
+		"2024-04-01": true,
+	})
+}
+
+func TestRecurser_SkipDays(t *testing.T) {
+	everyday := store.NewSchedule([]string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"})
+
+	// The next run is Wednesday, March 13, so this skips the 13th through the
+	// 15th.
+	tuesday := time.Date(2024, time.March, 12, 12, 0, 0, 0, time.UTC)
+	run := func(day int) time.Time { return time.Date(2024, time.March, day, 4, 0, 0, 0, time.UTC) }
+
+	rec := store.Recurser{Schedule: everyday}
+	rec.SkipDays(tuesday, 3)
+
+	t.Run("skips each day", func(t *testing.T) {
+		assert.Equal(t, rec.IsSkippingDays(run(13)), true)
+		assert.Equal(t, rec.IsSkippingDays(run(15)), true)
+		date, _ := rec.NextMatchDate(tuesday)
+		assert.Equal(t, date, "2024-03-16")
+	})
+
+	t.Run("expires after the last day", func(t *testing.T) {
+		assert.Equal(t, rec.IsSkippingDays(run(16)), false)
+		assert.Equal(t, rec.PairsOn(tuesday, "saturday"), true)
+	})
+
+	t.Run("days left", func(t *testing.T) {
+		assert.Equal(t, rec.SkipDaysLeft(tuesday), 3)
+		assert.Equal(t, rec.SkipDaysLeft(run(14).Add(time.Hour)), 1)
+		assert.Equal(t, rec.SkipDaysLeft(run(15).Add(time.Hour)), 0)
+		assert.Equal(t, (&store.Recurser{}).SkipDaysLeft(tuesday), 0)
+	})
+
+	t.Run("cleaned up once it's over", func(t *testing.T) {
+		r := rec
+		r.RemovePastSkipDates(run(15))
+		assert.Equal(t, r.SkipUntil, rec.SkipUntil)
+		r.RemovePastSkipDates(run(16))
+		assert.Equal(t, r.SkipUntil, int64(0))
+	})
+
+	t.Run("time zone", func(t *testing.T) {
+		// The Wednesday 04:00 UTC run is for Thursday in Kiritimati.
+		rec := store.Recurser{Schedule: everyday, Timezone: "Pacific/Kiritimati"}
+		rec.SkipDays(tuesday, 1)
+		date, _ := rec.NextMatchDate(tuesday)
+		assert.Equal(t, date, "2024-03-15")
+	})
+}
+
+func TestNewSchedule_segments(t *testing.T) {
+	schedule := store.NewSchedule([]string{"monday-am", "tuesday-am", "tuesday-pm", "wednesday", "wednesday-pm", "friday-pm"})
+
+	expected := store.EmptySchedule()
+	expected["monday-am"] = true
+	expected["tuesday"] = true
+	expected["wednesday"] = true
+	expected["friday-pm"] = true
+	assert.Equal(t, schedule, expected)
+
+	rec := store.Recurser{Schedule: schedule}
+	for day, want := range map[string]struct {
+		Segment   string
+		Scheduled bool
+	}{
+		"monday":    {store.SegmentAM, true},
+		"tuesday":   {"", true},
+		"wednesday": {"", true},
+		"thursday":  {"", false},
+		"friday":    {store.SegmentPM, true},
+	} {
+		segment, scheduled := rec.ScheduledSegment(day)
+		assert.Equal(t, segment, want.Segment)
+		assert.Equal(t, scheduled, want.Scheduled)
+	}
+}
+
+func TestRecurser_SegmentOverlaps(t *testing.T) {
+	for name, tc := range map[string]struct {
+		A, B     string
+		Expected bool
+	}{
+		"both all day":     {"", "", true},
+		"one all day":      {"", store.SegmentPM, true},
+		"same half":        {store.SegmentAM, store.SegmentAM, true},
+		"different halves": {store.SegmentAM, store.SegmentPM, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			a := store.Recurser{Segment: tc.A}
+			b := store.Recurser{Segment: tc.B}
+			assert.Equal(t, a.SegmentOverlaps(&b), tc.Expected)
+			assert.Equal(t, b.SegmentOverlaps(&a), tc.Expected)
+		})
+	}
+}
+
+func TestRecurser_CanPairWith(t *testing.T) {
+	ada := store.Recurser{ID: 1, Blocks: []store.Block{{ID: 2, Name: "Grace"}}}
+	grace := store.Recurser{ID: 2}
+	alan := store.Recurser{ID: 3}
+
+	// Blocks work both ways.
+	assert.Equal(t, ada.CanPairWith(&grace), false)
+	assert.Equal(t, grace.CanPairWith(&ada), false)
+
+	assert.Equal(t, ada.CanPairWith(&alan), true)
+	assert.Equal(t, grace.CanPairWith(&alan), true)
+	assert.Equal(t, ada.BlockedIDs(), []int64{2})
+
+	t.Run("themselves", func(t *testing.T) {
+		again := alan
+		assert.Equal(t, alan.CanPairWith(&again), false)
+	})
+
+	t.Run("different realms", func(t *testing.T) {
+		elsewhere := store.Recurser{ID: 3, Realm: "sister"}
+		assert.Equal(t, alan.CanPairWith(&elsewhere), false)
+		assert.Equal(t, elsewhere.CanPairWith(&alan), false)
+	})
+
+	t.Run("in person", func(t *testing.T) {
+		inPerson := store.Recurser{ID: 4, Mode: store.ModeInPerson, CurrentlyAtRC: true}
+		atRC := store.Recurser{ID: 5, CurrentlyAtRC: true}
+		remote := store.Recurser{ID: 6, Mode: store.ModeVoice}
+
+		// Only with people at RC, whichever way around.
+		assert.Equal(t, inPerson.CanPairWith(&atRC), true)
+		assert.Equal(t, atRC.CanPairWith(&inPerson), true)
+		assert.Equal(t, inPerson.CanPairWith(&remote), false)
+		assert.Equal(t, remote.CanPairWith(&inPerson), false)
+
+		// Someone who isn't at RC themselves can't be matched in person.
+		away := store.Recurser{ID: 7, Mode: store.ModeInPerson}
+		assert.Equal(t, away.CanPairWith(&inPerson), false)
+		assert.Equal(t, inPerson.CanPairWith(&away), false)
+	})
+}
+
+func TestRecurser_ModeCompatible(t *testing.T) {
+	for name, tc := range map[string]struct {
+		A, B     string
+		Expected bool
+	}{
+		"no preferences": {"", "", true},
+		"one any":        {store.ModeAny, store.ModeVoice, true},
+		"one unset":      {store.ModeText, "", true},
+		"same":           {store.ModeVoice, store.ModeVoice, true},
+		"different":      {store.ModeVoice, store.ModeText, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			a, b := store.Recurser{Mode: tc.A}, store.Recurser{Mode: tc.B}
+			assert.Equal(t, a.ModeCompatible(&b), tc.Expected)
+			assert.Equal(t, b.ModeCompatible(&a), tc.Expected)
+		})
+	}
+}
+
+func TestNewTopics(t *testing.T) {
+	topics := store.NewTopics([]string{"Go", " rust ", "", "go", "Distributed-Systems"})
+	assert.Equal(t, topics, []string{"go", "rust", "distributed-systems"})
+}
+
+func TestRecurser_NotifyAt(t *testing.T) {
+	// The daily match runs at 04:00 UTC.
+	matchRun := time.Date(2024, time.March, 12, 4, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		Timezone  string
+		MatchTime string
+		Expected  time.Time
+	}{
+		"no preference": {"America/New_York", "", matchRun},
+		"later today":   {"America/New_York", "09:00", time.Date(2024, time.March, 12, 13, 0, 0, 0, time.UTC)},
+		"already past":  {"Europe/Berlin", "03:00", matchRun},
+		"tomorrow":      {"America/Los_Angeles", "08:30", time.Date(2024, time.March, 12, 15, 30, 0, 0, time.UTC)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := store.Recurser{Timezone: tc.Timezone, MatchTime: tc.MatchTime}
+			assert.Equal(t, rec.NotifyAt(matchRun).Equal(tc.Expected), true)
+		})
+	}
+}
+
+func TestRecurser_BatchCompatible(t *testing.T) {
+	for name, tc := range map[string]struct {
+		A, B     store.Recurser
+		Expected bool
+	}{
+		"no preferences":     {store.Recurser{BatchID: 1}, store.Recurser{BatchID: 2}, true},
+		"same, same batch":   {store.Recurser{BatchID: 1, BatchPref: "same"}, store.Recurser{BatchID: 1}, true},
+		"same, other batch":  {store.Recurser{BatchID: 1, BatchPref: "same"}, store.Recurser{BatchID: 2}, false},
+		"cross, same batch":  {store.Recurser{BatchID: 1}, store.Recurser{BatchID: 1, BatchPref: "cross"}, false},
+		"cross, other batch": {store.Recurser{BatchID: 1, BatchPref: "cross"}, store.Recurser{BatchID: 2}, true},
+		"conflicting":        {store.Recurser{BatchID: 1, BatchPref: "same"}, store.Recurser{BatchID: 2, BatchPref: "cross"}, true},
+		"unknown batch":      {store.Recurser{BatchID: 1, BatchPref: "same"}, store.Recurser{}, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.A.BatchCompatible(&tc.B), tc.Expected)
+			assert.Equal(t, tc.B.BatchCompatible(&tc.A), tc.Expected)
+		})
+	}
+}
+
+func TestRecurser_CanRestore(t *testing.T) {
+	now := time.Now()
+
+	for name, tc := range map[string]struct {
+		UnsubscribedAt int64
+		Expected       bool
+	}{
+		"subscribed":   {0, false},
+		"recently":     {now.Add(-24 * time.Hour).Unix(), true},
+		"too long ago": {now.Add(-store.UnsubscribeGracePeriod - time.Hour).Unix(), false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := store.Recurser{UnsubscribedAt: tc.UnsubscribedAt}
+			assert.Equal(t, rec.CanRestore(now), tc.Expected)
+		})
+	}
+}
+
+func TestRecurser_InSubscriptionCooldown(t *testing.T) {
+	now := time.Now()
+
+	for name, tc := range map[string]struct {
+		ChangedAt int64
+		Expected  bool
+	}{
+		"never changed":     {0, false},
+		"just now":          {now.Unix(), true},
+		"within the window": {now.Add(-store.SubscriptionCooldown / 2).Unix(), true},
+		"after the window":  {now.Add(-store.SubscriptionCooldown - time.Second).Unix(), false},
+		"long after":        {now.Add(-24 * time.Hour).Unix(), false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := store.Recurser{SubscriptionChangedAt: tc.ChangedAt}
+			assert.Equal(t, rec.InSubscriptionCooldown(now), tc.Expected)
+		})
+	}
+}
+
+func TestRecursersClient_GetAllSubscribed(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	recursers := store.Recursers(client)
+
+	subscribed := store.Recurser{ID: 1, Name: "Subscribed", Schedule: store.DefaultSchedule()}
+	unsubscribed := store.Recurser{ID: 2, Name: "Unsubscribed", Schedule: store.DefaultSchedule(), UnsubscribedAt: time.Now().Unix()}
+
+	for _, rec := range []store.Recurser{subscribed, unsubscribed} {
+		if err := recursers.Set(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := recursers.GetAllSubscribed(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subscribed.IsSubscribed = true
+	assert.Equal(t, all, []store.Recurser{subscribed})
+
+	t.Run("including unsubscribed", func(t *testing.T) {
+		all, err := recursers.GetAll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, all, []store.Recurser{subscribed, unsubscribed})
+	})
+}
+
+func TestRecursersClient_CountScheduledDays(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	recursers := store.Recursers(client)
+
+	for _, rec := range []store.Recurser{
+		{ID: 1, Schedule: store.NewSchedule([]string{"sunday"})},
+		{ID: 2, Schedule: store.NewSchedule([]string{"monday", "tuesday-pm"})},
+		{ID: 3, Schedule: store.NewSchedule([]string{"monday"})},
+		{ID: 4, Schedule: store.NewSchedule([]string{"sunday"}), UnsubscribedAt: time.Now().Unix()},
+	} {
+		if err := recursers.Set(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The lone Sunday Recurser doesn't count themselves, and unsubscribed
+	// Recursers don't count.
+	counts, err := recursers.CountScheduledDays(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, counts, map[string]int{"monday": 2, "tuesday": 1})
+}
+
+func TestRecursersClient_ListScheduledOn(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	recursers := store.Recursers(client)
+
+	// The Tuesday, March 12 run is the next one, so Wednesday is the 13th.
+	tuesday := time.Date(2024, time.March, 12, 2, 0, 0, 0, time.UTC)
+
+	for _, rec := range []store.Recurser{
+		{ID: 1, Name: "Wednesdays", Schedule: store.NewSchedule([]string{"wednesday"})},
+		{ID: 2, Name: "Mornings", Schedule: store.NewSchedule([]string{"wednesday-am", "thursday"})},
+		{ID: 3, Name: "Mondays", Schedule: store.NewSchedule([]string{"monday"})},
+		{ID: 4, Name: "Skipping", Schedule: store.NewSchedule([]string{"wednesday"}), SkipDates: map[string]bool{"2024-03-13": true}},
+		{ID: 5, Name: "Unsubscribed", Schedule: store.NewSchedule([]string{"wednesday"}), UnsubscribedAt: time.Now().Unix()},
+	} {
+		if err := recursers.Set(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scheduled, err := recursers.ListScheduledOn(ctx, tuesday, "wednesday")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, rec := range scheduled {
+		names = append(names, rec.Name)
+	}
+	slices.Sort(names)
+	assert.Equal(t, names, []string{"Mornings", "Wednesdays"})
+}
+
+func TestRecursersClient_CountAvailable(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	recursers := store.Recursers(client)
+
+	// The Tuesday, March 12 run is the next one.
+	tuesday := time.Date(2024, time.March, 12, 2, 0, 0, 0, time.UTC)
+
+	for _, rec := range []store.Recurser{
+		{ID: 1, Name: "Asking", Schedule: store.NewSchedule([]string{"tuesday", "wednesday"})},
+		{ID: 2, Name: "Weekdays", Schedule: store.DefaultSchedule()},
+		{ID: 3, Name: "Mornings", Schedule: store.NewSchedule([]string{"wednesday-am", "thursday"})},
+		{ID: 4, Name: "Skipping", Schedule: store.NewSchedule([]string{"wednesday"}), SkipDates: map[string]bool{"2024-03-13": true}},
+		{ID: 5, Name: "Mentor", Schedule: store.DefaultSchedule(), IsMentor: true},
+		{ID: 6, Name: "Unsubscribed", Schedule: store.DefaultSchedule(), UnsubscribedAt: time.Now().Unix()},
+	} {
+		if err := recursers.Set(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	days := []string{"tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+	counts, err := recursers.CountAvailable(ctx, tuesday, days, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, counts, map[string]int{"tuesday": 1, "wednesday": 2, "thursday": 2, "friday": 1})
+}
+
+// seedRecursers writes n subscribed Recursers and returns their IDs.
+func seedRecursers(b *testing.B, ctx context.Context, recursers *store.RecursersClient, n int) []int64 {
+	var ids []int64
+	for i := range n {
+		rec := store.Recurser{
+			ID:       int64(i + 1),
+			Name:     fmt.Sprintf("Recurser %d", i+1),
+			Schedule: store.DefaultSchedule(),
+		}
+		if err := recursers.Set(ctx, &rec); err != nil {
+			b.Fatal(err)
+		}
+		ids = append(ids, rec.ID)
+	}
+	return ids
+}
+
+// Compare reading everyone with one query against one read per Recurser.
+func BenchmarkRecursersClient_GetAllSubscribed(b *testing.B) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(b, ctx)
+	recursers := store.Recursers(client)
+	ids := seedRecursers(b, ctx, recursers, 100)
+
+	b.Run("single query", func(b *testing.B) {
+		for range b.N {
+			if _, err := recursers.GetAllSubscribed(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("individual gets", func(b *testing.B) {
+		for range b.N {
+			for _, id := range ids {
+				if _, err := recursers.GetByUserID(ctx, store.DefaultRealm, id, "", ""); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -2,15 +2,25 @@ package store
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"math/rand"
+	"strconv"
 
 	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Review struct {
-	Content   string `firestore:"content"`
+	Content string `firestore:"content"`
+
+	// Email is empty for anonymous reviews.
 	Email     string `firestore:"email"`
 	Timestamp int64  `firestore:"timestamp"`
+
+	// Category is an optional tag like "bug", "praise", or "idea".
+	Category string `firestore:"category"`
 }
 
 // ReviewsClient manages user-submitted Pairing Bot reviews.
@@ -36,6 +46,108 @@ func (r *ReviewsClient) GetLastN(ctx context.Context, n int) ([]Review, error) {
 	return fetchAll[Review](iter)
 }
 
+// A ReviewPosition is a place in the reviews, newest first: the timestamp and
+// document ID of a review. The document ID tells apart reviews written in the
+// same second. The zero ReviewPosition is the start (or end) of the reviews.
+type ReviewPosition struct {
+	Timestamp int64
+	ID        string
+}
+
+// GetPage returns up to pageSize reviews, newest first, starting after the
+// review at startAfter (or with the newest review if startAfter is zero). It
+// also returns the position to pass as startAfter for the next page, which is
+// zero if this is the last page.
+func (r *ReviewsClient) GetPage(ctx context.Context, pageSize int, startAfter ReviewPosition) ([]Review, ReviewPosition, error) {
+	query := r.client.
+		Collection("reviews").
+		OrderBy("timestamp", firestore.Desc).
+		OrderBy(firestore.DocumentID, firestore.Desc)
+	if startAfter != (ReviewPosition{}) {
+		query = query.StartAfter(startAfter.Timestamp, startAfter.ID)
+	}
+
+	// One extra review tells us whether there's another page.
+	docs, err := query.Limit(pageSize + 1).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, ReviewPosition{}, err
+	}
+
+	var page []Review
+	var last ReviewPosition
+	for i, doc := range docs {
+		if i == pageSize {
+			return page, last, nil
+		}
+
+		var review Review
+		if err := doc.DataTo(&review); err != nil {
+			log.Printf("Skipping %q: %s", doc.Ref.Path, err)
+			continue
+		}
+		page = append(page, review)
+		last = ReviewPosition{Timestamp: review.Timestamp, ID: doc.Ref.ID}
+	}
+	return page, ReviewPosition{}, nil
+}
+
+// A ReviewCursor is where a maintainer is up to in paging through the reviews
+// (see GetPage).
+type ReviewCursor struct {
+	UserID       int64  `firestore:"userId"`
+	StartAfter   int64  `firestore:"startAfter"`
+	StartAfterID string `firestore:"startAfterId"`
+}
+
+// SetCursor saves where the maintainer is up to in the reviews. A zero
+// startAfter means they've reached the end.
+func (r *ReviewsClient) SetCursor(ctx context.Context, userID int64, startAfter ReviewPosition) error {
+	docID := strconv.FormatInt(userID, 10)
+	cursor := ReviewCursor{UserID: userID, StartAfter: startAfter.Timestamp, StartAfterID: startAfter.ID}
+	return withRetry(ctx, func() error {
+		_, err := r.client.Collection("reviewCursors").Doc(docID).Set(ctx, cursor)
+		return err
+	})
+}
+
+// GetCursor returns where the maintainer is up to in the reviews, or zero if
+// they haven't started or have reached the end.
+func (r *ReviewsClient) GetCursor(ctx context.Context, userID int64) (ReviewPosition, error) {
+	docID := strconv.FormatInt(userID, 10)
+	doc, err := r.client.Collection("reviewCursors").Doc(docID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return ReviewPosition{}, nil
+	} else if err != nil {
+		return ReviewPosition{}, err
+	}
+
+	var cursor ReviewCursor
+	if err := doc.DataTo(&cursor); err != nil {
+		return ReviewPosition{}, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return ReviewPosition{Timestamp: cursor.StartAfter, ID: cursor.StartAfterID}, nil
+}
+
+// GetByEmail returns the reviews written by the Recurser with this email.
+// Anonymous reviews don't record an email, so they're never included.
+func (r *ReviewsClient) GetByEmail(ctx context.Context, email string) ([]Review, error) {
+	iter := r.client.
+		Collection("reviews").
+		Where("email", "==", email).
+		Documents(ctx)
+	return fetchAll[Review](iter)
+}
+
+// DeleteByEmail deletes the reviews written by the Recurser with this email,
+// and returns how many there were.
+func (r *ReviewsClient) DeleteByEmail(ctx context.Context, email string) (int, error) {
+	iter := r.client.
+		Collection("reviews").
+		Where("email", "==", email).
+		Documents(ctx)
+	return deleteAll(ctx, iter)
+}
+
 func (r *ReviewsClient) GetRandom(ctx context.Context) (Review, error) {
 	allReviews, err := r.GetAll(ctx)
 
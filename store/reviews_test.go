@@ -2,6 +2,7 @@ package store_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/recursecenter/pairing-bot/internal/assert"
@@ -37,4 +38,165 @@ func TestFirestoreReviewsClient(t *testing.T) {
 
 		assert.Equal(t, actual, expected)
 	})
+
+	t.Run("anonymous and categorized", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		reviews := store.Reviews(client)
+
+		review := store.Review{
+			Content:   "more cookies",
+			Timestamp: pbtest.RandInt64(t),
+			Category:  "idea",
+		}
+
+		err := reviews.Insert(ctx, review)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := reviews.GetLastN(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, actual, []store.Review{review})
+		assert.Equal(t, actual[0].Email, "")
+	})
+	t.Run("by email", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		reviews := store.Reviews(client)
+
+		mine := store.Review{Content: "love it", Email: "me@recurse.example.net", Timestamp: 100}
+		for _, review := range []store.Review{
+			mine,
+			{Content: "not mine", Email: "you@recurse.example.net", Timestamp: 200},
+			{Content: "anonymous", Timestamp: 300},
+		} {
+			if err := reviews.Insert(ctx, review); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		actual, err := reviews.GetByEmail(ctx, mine.Email)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, actual, []store.Review{mine})
+	})
+	t.Run("pages", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		reviews := store.Reviews(client)
+
+		var all []store.Review
+		for i := range 5 {
+			review := store.Review{Content: fmt.Sprintf("review %d", i), Timestamp: int64(100 * (i + 1))}
+			if err := reviews.Insert(ctx, review); err != nil {
+				t.Fatal(err)
+			}
+			all = append(all, review)
+		}
+
+		// Newest first, two at a time.
+		page, next, err := reviews.GetPage(ctx, 2, store.ReviewPosition{})
+		assert.NoError(t, err)
+		assert.Equal(t, page, []store.Review{all[4], all[3]})
+		assert.Equal(t, next.Timestamp, int64(400))
+
+		page, next, err = reviews.GetPage(ctx, 2, next)
+		assert.NoError(t, err)
+		assert.Equal(t, page, []store.Review{all[2], all[1]})
+		assert.Equal(t, next.Timestamp, int64(200))
+
+		page, next, err = reviews.GetPage(ctx, 2, next)
+		assert.NoError(t, err)
+		assert.Equal(t, page, []store.Review{all[0]})
+		assert.Equal(t, next, store.ReviewPosition{})
+
+		// A page that ends exactly at the last review is the last page.
+		page, next, err = reviews.GetPage(ctx, 5, store.ReviewPosition{})
+		assert.NoError(t, err)
+		assert.Equal(t, len(page), 5)
+		assert.Equal(t, next, store.ReviewPosition{})
+	})
+	t.Run("pages with tied timestamps", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		reviews := store.Reviews(client)
+
+		// Written in the same second, so only the document IDs tell them
+		// apart.
+		for i := range 5 {
+			review := store.Review{Content: fmt.Sprintf("review %d", i), Timestamp: 100}
+			if err := reviews.Insert(ctx, review); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		seen := make(map[string]bool)
+		var next store.ReviewPosition
+		for range 3 {
+			var page []store.Review
+			var err error
+			page, next, err = reviews.GetPage(ctx, 2, next)
+			assert.NoError(t, err)
+			for _, review := range page {
+				seen[review.Content] = true
+			}
+		}
+		assert.Equal(t, next, store.ReviewPosition{})
+		assert.Equal(t, len(seen), 5)
+	})
+	t.Run("cursors", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		reviews := store.Reviews(client)
+
+		cursor, err := reviews.GetCursor(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, cursor, store.ReviewPosition{})
+
+		position := store.ReviewPosition{Timestamp: 400, ID: "abc"}
+		assert.NoError(t, reviews.SetCursor(ctx, 1, position))
+		cursor, err = reviews.GetCursor(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, cursor, position)
+	})
+	t.Run("delete by email", func(t *testing.T) {
+		ctx := context.Background()
+
+		client := pbtest.FirestoreClient(t, ctx)
+		reviews := store.Reviews(client)
+
+		theirs := store.Review{Content: "not mine", Email: "you@recurse.example.net", Timestamp: 300}
+		for _, review := range []store.Review{
+			{Content: "love it", Email: "me@recurse.example.net", Timestamp: 100},
+			{Content: "love it more", Email: "me@recurse.example.net", Timestamp: 200},
+			theirs,
+		} {
+			if err := reviews.Insert(ctx, review); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		deleted, err := reviews.DeleteByEmail(ctx, "me@recurse.example.net")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, deleted, 2)
+
+		all, err := reviews.GetAll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, all, []store.Review{theirs})
+	})
 }
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// A ScheduledMessage is a direct message to send at a later time.
+type ScheduledMessage struct {
+	// ID is the Firestore document ID. It is not written to or read from the
+	// document itself.
+	ID string `firestore:"-"`
+
+	Recipients []int64 `firestore:"recipients"`
+	Content    string  `firestore:"content"`
+	SendAt     int64   `firestore:"sendAt"`
+
+	// Realm is the Zulip realm of the recipients (see Recurser.Realm).
+	Realm string `firestore:"realm,omitempty"`
+}
+
+// ScheduledMessagesClient manages messages waiting to be sent.
+type ScheduledMessagesClient struct {
+	client *firestore.Client
+}
+
+func ScheduledMessages(client *firestore.Client) *ScheduledMessagesClient {
+	return &ScheduledMessagesClient{client}
+}
+
+// Add schedules a new message.
+func (s *ScheduledMessagesClient) Add(ctx context.Context, msg ScheduledMessage) error {
+	_, _, err := s.client.Collection("scheduledMessages").Add(ctx, msg)
+	return err
+}
+
+// ListDue returns the messages that should have been sent by `now`.
+func (s *ScheduledMessagesClient) ListDue(ctx context.Context, now time.Time) ([]ScheduledMessage, error) {
+	iter := s.client.
+		Collection("scheduledMessages").
+		Where("sendAt", "<=", now.Unix()).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var due []ScheduledMessage
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return due, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		var msg ScheduledMessage
+		if err := doc.DataTo(&msg); err != nil {
+			log.Printf("Skipping %q: %s", doc.Ref.Path, err)
+			continue
+		}
+		msg.ID = doc.Ref.ID
+
+		due = append(due, msg)
+	}
+}
+
+// DeleteAllFor deletes every message waiting to be sent to the Recurser (even
+// the ones to a group they're in), and returns how many there were.
+func (s *ScheduledMessagesClient) DeleteAllFor(ctx context.Context, realm string, userID int64) (int, error) {
+	iter := s.client.
+		Collection("scheduledMessages").
+		Where("recipients", "array-contains", userID).
+		Documents(ctx)
+	return deleteAllIn(ctx, iter, realm)
+}
+
+// Delete removes a message, usually after it's been sent.
+func (s *ScheduledMessagesClient) Delete(ctx context.Context, id string) error {
+	_, err := s.client.Collection("scheduledMessages").Doc(id).Delete(ctx)
+	return err
+}
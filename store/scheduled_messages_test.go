@@ -0,0 +1,59 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreScheduledMessagesClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	messages := store.ScheduledMessages(client)
+
+	now := time.Now()
+
+	due := store.ScheduledMessage{
+		Recipients: []int64{1, 2},
+		Content:    "Time to pair!",
+		SendAt:     now.Add(-time.Minute).Unix(),
+	}
+	later := store.ScheduledMessage{
+		Recipients: []int64{3, 4},
+		Content:    "Not yet!",
+		SendAt:     now.Add(time.Hour).Unix(),
+	}
+
+	for _, msg := range []store.ScheduledMessage{due, later} {
+		if err := messages.Add(ctx, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	actual, err := messages.ListDue(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Equal(t, len(actual), 1) {
+		sent := actual[0]
+
+		due.ID = sent.ID
+		assert.Equal(t, sent, due)
+
+		if err := messages.Delete(ctx, sent.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	actual, err = messages.ListDue(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(actual), 0)
+}
@@ -1,10 +1,14 @@
 package store
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // fetchAll converts all documents in iter to values of type T. Documents that
@@ -33,3 +37,101 @@ func fetchAll[T any](iter *firestore.DocumentIterator) ([]T, error) {
 		all = append(all, item)
 	}
 }
+
+// deleteAll deletes every document in iter, and returns how many there were.
+func deleteAll(ctx context.Context, iter *firestore.DocumentIterator) (int, error) {
+	docs, err := iter.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, doc := range docs {
+		err := withRetry(ctx, func() error {
+			_, err := doc.Ref.Delete(ctx)
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(docs), nil
+}
+
+// deleteAllIn is deleteAll for only the documents in the realm (see
+// Recurser.Realm). Documents in the default realm don't have a realm field.
+func deleteAllIn(ctx context.Context, iter *firestore.DocumentIterator, realm string) (int, error) {
+	docs, err := iter.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, doc := range docs {
+		if docRealm, _ := doc.Data()["realm"].(string); docRealm != realm {
+			continue
+		}
+
+		err := withRetry(ctx, func() error {
+			_, err := doc.Ref.Delete(ctx)
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Ping checks that Firestore is reachable by reading a sentinel document. The
+// document doesn't need to exist: "not found" still means Firestore answered.
+func Ping(ctx context.Context, db *firestore.Client) error {
+	_, err := db.Collection("health").Doc("ping").Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	return err
+}
+
+// writeAttempts is how many times withRetry tries a write before giving up.
+const writeAttempts = 4
+
+// retryDelay is how long withRetry waits after the first failed attempt. The
+// delay doubles after each attempt after that.
+var retryDelay = 100 * time.Millisecond
+
+// withRetry runs the write, retrying with exponential backoff if it fails with
+// an error that's likely to be temporary. Any other error is returned
+// immediately.
+//
+// The write must be safe to repeat, since a write that timed out may have
+// succeeded anyway.
+func withRetry(ctx context.Context, write func() error) error {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err == nil || !isRetryable(err) || attempt == writeAttempts {
+			return err
+		}
+
+		log.Printf("Firestore write failed (attempt %d of %d), retrying in %s: %s", attempt, writeAttempts, delay, err)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		delay *= 2
+	}
+}
+
+// isRetryable returns whether the error's gRPC status code means that trying
+// again later might work.
+func isRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
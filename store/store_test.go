@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// flakyWrite returns a write function that fails with each of the errors in
+// turn and then succeeds. It counts how many times it was called.
+func flakyWrite(errs ...error) (func() error, *int) {
+	var calls int
+	return func() error {
+		calls++
+		if calls <= len(errs) {
+			return errs[calls-1]
+		}
+		return nil
+	}, &calls
+}
+
+func Test_withRetry(t *testing.T) {
+	oldDelay := retryDelay
+	retryDelay = time.Millisecond
+	t.Cleanup(func() { retryDelay = oldDelay })
+
+	ctx := context.Background()
+
+	t.Run("transient failure then success", func(t *testing.T) {
+		write, calls := flakyWrite(
+			status.Error(codes.Unavailable, "try again"),
+			status.Error(codes.DeadlineExceeded, "too slow"),
+		)
+
+		err := withRetry(ctx, write)
+		assert.NoError(t, err)
+		assert.Equal(t, *calls, 3)
+	})
+
+	t.Run("fails fast on permanent errors", func(t *testing.T) {
+		notFound := status.Error(codes.NotFound, "no such document")
+		write, calls := flakyWrite(notFound)
+
+		err := withRetry(ctx, write)
+		assert.ErrorIs(t, err, notFound)
+		assert.Equal(t, *calls, 1)
+	})
+
+	t.Run("gives up eventually", func(t *testing.T) {
+		unavailable := status.Error(codes.Unavailable, "down")
+		write, calls := flakyWrite(unavailable, unavailable, unavailable, unavailable, unavailable)
+
+		err := withRetry(ctx, write)
+		assert.ErrorIs(t, err, unavailable)
+		assert.Equal(t, *calls, writeAttempts)
+	})
+
+	t.Run("stops when canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		unavailable := status.Error(codes.Unavailable, "down")
+		write, calls := flakyWrite(unavailable, unavailable)
+
+		err := withRetry(ctx, write)
+		assert.ErrorIs(t, err, unavailable)
+		assert.Equal(t, *calls, 1)
+	})
+}
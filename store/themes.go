@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A Theme is a conversation starter that a maintainer has set for everyone's
+// match messages, like "debugging war stories".
+type Theme struct {
+	Text      string `firestore:"text"`
+	SetBy     int64  `firestore:"setBy"`
+	Timestamp int64  `firestore:"timestamp"`
+}
+
+// ThemesClient manages the current theme. There's only ever one at a time.
+type ThemesClient struct {
+	client *firestore.Client
+}
+
+func Themes(client *firestore.Client) *ThemesClient {
+	return &ThemesClient{client}
+}
+
+func (t *ThemesClient) current() *firestore.DocumentRef {
+	return t.client.Collection("themes").Doc("current")
+}
+
+// Set replaces the current theme.
+func (t *ThemesClient) Set(ctx context.Context, theme Theme) error {
+	_, err := t.current().Set(ctx, theme)
+	return err
+}
+
+// Get returns the current theme, or nil if there isn't one.
+func (t *ThemesClient) Get(ctx context.Context) (*Theme, error) {
+	doc, err := t.current().Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var theme Theme
+	if err := doc.DataTo(&theme); err != nil {
+		return nil, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return &theme, nil
+}
+
+// Clear removes the current theme, if there is one.
+func (t *ThemesClient) Clear(ctx context.Context) error {
+	_, err := t.current().Delete(ctx)
+	return err
+}
@@ -0,0 +1,50 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreThemesClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	themes := store.Themes(client)
+
+	var none *store.Theme
+
+	theme, err := themes.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, theme, none)
+
+	first := store.Theme{Text: "Debugging war stories", SetBy: 1, Timestamp: pbtest.RandInt64(t)}
+	second := store.Theme{Text: "Your favorite data structure", SetBy: 2, Timestamp: pbtest.RandInt64(t)}
+
+	for _, want := range []store.Theme{first, second} {
+		if err := themes.Set(ctx, want); err != nil {
+			t.Fatal(err)
+		}
+
+		theme, err := themes.Get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, theme, &want)
+	}
+
+	if err := themes.Clear(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	theme, err = themes.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, theme, none)
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A UsageDay is how much Pairing Bot was used on one UTC day, recorded by the
+// daily usage rollup.
+type UsageDay struct {
+	// Date is the UTC day, formatted as time.DateOnly. This is also the
+	// document ID, so the days sort in order.
+	Date string `firestore:"date"`
+
+	// Subscribers is how many Recursers were subscribed when the day was
+	// rolled up.
+	Subscribers int `firestore:"subscribers"`
+
+	// Matches is how many matches were made during the day, and Commands is
+	// how many commands Pairing Bot handled.
+	Matches  int `firestore:"matches"`
+	Commands int `firestore:"commands"`
+
+	Timestamp int64 `firestore:"timestamp"`
+}
+
+// UsageClient manages the daily usage records, in the usage collection, and
+// the running count of commands for each day, in commandCounts.
+type UsageClient struct {
+	client *firestore.Client
+}
+
+func Usage(client *firestore.Client) *UsageClient {
+	return &UsageClient{client}
+}
+
+// CountCommand adds one to the number of commands handled on now's UTC day.
+func (u *UsageClient) CountCommand(ctx context.Context, now time.Time) error {
+	date := now.UTC().Format(time.DateOnly)
+	doc := u.client.Collection("commandCounts").Doc(date)
+	return withRetry(ctx, func() error {
+		_, err := doc.Set(ctx, map[string]any{
+			"date":     date,
+			"commands": firestore.Increment(1),
+		}, firestore.MergeAll)
+		return err
+	})
+}
+
+// CommandCount returns the number of commands handled on the UTC day (as
+// time.DateOnly), or zero if there weren't any.
+func (u *UsageClient) CommandCount(ctx context.Context, date string) (int, error) {
+	doc, err := u.client.Collection("commandCounts").Doc(date).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	var count struct {
+		Commands int `firestore:"commands"`
+	}
+	if err := doc.DataTo(&count); err != nil {
+		return 0, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return count.Commands, nil
+}
+
+// Insert records a day's usage, replacing any earlier record for the same day.
+func (u *UsageClient) Insert(ctx context.Context, day UsageDay) error {
+	return withRetry(ctx, func() error {
+		_, err := u.client.Collection("usage").Doc(day.Date).Set(ctx, day)
+		return err
+	})
+}
+
+// GetRange returns the days from start to end (both as time.DateOnly, and both
+// included) that have usage records, oldest first.
+func (u *UsageClient) GetRange(ctx context.Context, start, end string) ([]UsageDay, error) {
+	iter := u.client.
+		Collection("usage").
+		Where("date", ">=", start).
+		Where("date", "<=", end).
+		OrderBy("date", firestore.Asc).
+		Documents(ctx)
+	return fetchAll[UsageDay](iter)
+}
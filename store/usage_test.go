@@ -0,0 +1,59 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreUsageClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	usage := store.Usage(client)
+
+	t.Run("insert and get range", func(t *testing.T) {
+		days := []store.UsageDay{
+			{Date: "2024-03-12", Subscribers: 41, Matches: 20, Commands: 30},
+			{Date: "2024-03-10", Subscribers: 39, Matches: 18, Commands: 12},
+			{Date: "2024-03-11", Subscribers: 40, Matches: 19, Commands: 25},
+			{Date: "2024-03-13", Subscribers: 42, Matches: 21, Commands: 9},
+		}
+		for _, day := range days {
+			assert.NoError(t, usage.Insert(ctx, day))
+		}
+
+		got, err := usage.GetRange(ctx, "2024-03-11", "2024-03-12")
+		assert.NoError(t, err)
+		assert.Equal(t, got, []store.UsageDay{days[2], days[0]})
+
+		// Inserting a day again replaces it.
+		days[2].Commands = 26
+		assert.NoError(t, usage.Insert(ctx, days[2]))
+
+		got, err = usage.GetRange(ctx, "2024-03-01", "2024-03-11")
+		assert.NoError(t, err)
+		assert.Equal(t, got, []store.UsageDay{days[1], days[2]})
+	})
+
+	t.Run("count commands", func(t *testing.T) {
+		count, err := usage.CommandCount(ctx, "2024-03-11")
+		assert.NoError(t, err)
+		assert.Equal(t, count, 0)
+
+		day := time.Date(2024, time.March, 11, 23, 0, 0, 0, time.UTC)
+		for range 3 {
+			assert.NoError(t, usage.CountCommand(ctx, day))
+		}
+		// Midnight UTC starts a new day.
+		assert.NoError(t, usage.CountCommand(ctx, day.Add(time.Hour)))
+
+		count, err = usage.CommandCount(ctx, "2024-03-11")
+		assert.NoError(t, err)
+		assert.Equal(t, count, 3)
+	})
+}
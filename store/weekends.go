@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WeekendConfig is whether there's any matching on weekends, whatever
+// people's schedules say. Maintainers can change it without a deploy.
+type WeekendConfig struct {
+	// Skip is set if match runs on Saturdays and Sundays do nothing.
+	Skip bool `firestore:"skip"`
+
+	// Timezone is where it's the weekend, like "America/New_York". Empty
+	// means UTC.
+	Timezone string `firestore:"timezone"`
+
+	// UpdatedBy is the Zulip ID of the maintainer who last changed it.
+	UpdatedBy int64 `firestore:"updatedBy"`
+	Timestamp int64 `firestore:"timestamp"`
+}
+
+// WeekendConfigClient manages the weekend setting.
+type WeekendConfigClient struct {
+	client *firestore.Client
+}
+
+func WeekendConfigs(client *firestore.Client) *WeekendConfigClient {
+	return &WeekendConfigClient{client}
+}
+
+// Get returns the weekend setting. It's all zero (so weekends are matched
+// like any other day) if it's never been set.
+func (w *WeekendConfigClient) Get(ctx context.Context) (WeekendConfig, error) {
+	doc, err := w.client.Collection("config").Doc("weekends").Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return WeekendConfig{}, nil
+	} else if err != nil {
+		return WeekendConfig{}, err
+	}
+
+	var config WeekendConfig
+	if err := doc.DataTo(&config); err != nil {
+		return WeekendConfig{}, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return config, nil
+}
+
+// Set replaces the weekend setting.
+func (w *WeekendConfigClient) Set(ctx context.Context, config WeekendConfig) error {
+	return withRetry(ctx, func() error {
+		_, err := w.client.Collection("config").Doc("weekends").Set(ctx, config)
+		return err
+	})
+}
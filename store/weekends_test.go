@@ -0,0 +1,32 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreWeekendConfigClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	configs := store.WeekendConfigs(client)
+
+	t.Run("weekends are matched by default", func(t *testing.T) {
+		got, err := configs.Get(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, got, store.WeekendConfig{})
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		want := store.WeekendConfig{Skip: true, Timezone: "America/New_York", UpdatedBy: 1, Timestamp: 100}
+		assert.NoError(t, configs.Set(ctx, want))
+
+		got, err := configs.Get(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, got, want)
+	})
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// welcomeProgress is the single document that records how far along the
+// welcome DMs are.
+type welcomeProgress struct {
+	// LastStart is the latest stint start date (Unix seconds) of anyone who
+	// was welcomed.
+	LastStart int64 `firestore:"lastStart"`
+}
+
+// WelcomesClient keeps track of which new arrivals have been welcomed.
+type WelcomesClient struct {
+	client *firestore.Client
+}
+
+func Welcomes(client *firestore.Client) *WelcomesClient {
+	return &WelcomesClient{client}
+}
+
+// LastStart returns the latest start date of anyone who was welcomed, or the
+// zero time if nobody has been welcomed yet.
+func (w *WelcomesClient) LastStart(ctx context.Context) (time.Time, error) {
+	doc, err := w.client.Collection("welcomes").Doc("progress").Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+
+	var progress welcomeProgress
+	if err := doc.DataTo(&progress); err != nil {
+		return time.Time{}, fmt.Errorf("parse document %q: %w", doc.Ref.Path, err)
+	}
+	return time.Unix(progress.LastStart, 0).UTC(), nil
+}
+
+// SetLastStart records that everyone who started up to and including the
+// given time has been welcomed.
+func (w *WelcomesClient) SetLastStart(ctx context.Context, start time.Time) error {
+	return withRetry(ctx, func() error {
+		_, err := w.client.Collection("welcomes").Doc("progress").Set(ctx, welcomeProgress{LastStart: start.Unix()})
+		return err
+	})
+}
@@ -0,0 +1,33 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func TestFirestoreWelcomesClient(t *testing.T) {
+	ctx := context.Background()
+
+	client := pbtest.FirestoreClient(t, ctx)
+	welcomes := store.Welcomes(client)
+
+	t.Run("nobody welcomed yet", func(t *testing.T) {
+		last, err := welcomes.LastStart(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, last.IsZero(), true)
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		start := time.Date(2024, time.May, 20, 0, 0, 0, 0, time.UTC)
+		assert.NoError(t, welcomes.SetLastStart(ctx, start))
+
+		last, err := welcomes.LastStart(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, last, start)
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import "fmt"
+
+// knownCommands is every command name that parseCmd accepts (not counting
+// aliases like "thank"), used to suggest corrections for typos.
+var knownCommands = []string{
+	"subscribe", "unsubscribe", "restore", "schedule", "thisweek", "mute", "unmute", "skip", "unskip",
+	"pause", "resume", "status", "next", "availability", "set", "clear", "topics", "theme", "stats",
+	"noshow", "rate", "leaderboard", "milestones", "history", "match", "cancel", "confirm", "decline",
+	"block", "unblock", "blocks", "who", "met", "pair", "export", "reset", "delete", "alias", "unalias",
+	"aliases", "save", "load", "schedules", "add-review", "anonymous", "get-reviews", "bug",
+	"cookie", "help", "version", "thanks", "dedupe", "maintenance", "blindintros", "minparticipants",
+	"weekends", "holidays", "dailypost", "trends", "fun", "roster", "reviews", "announce",
+}
+
+// suggestionThreshold is the largest edit distance from a known command that
+// still gets a suggestion. Commands as short as shortCommand only get one
+// edit, since two would change most of the word (and "met" would look like a
+// typo of "set").
+const (
+	suggestionThreshold = 2
+	shortCommand        = 3
+)
+
+// suggestCommand returns the known command that `name` is probably a typo of:
+// the nearest one that's close enough. It doesn't make a suggestion when two
+// commands are tied for nearest, since a coin flip isn't much help.
+func suggestCommand(name string) (string, bool) {
+	var suggestion string
+	best, tied := -1, false
+	for _, command := range knownCommands {
+		threshold := suggestionThreshold
+		if len(command) <= shortCommand {
+			threshold = 1
+		}
+		distance := levenshtein(name, command)
+		switch {
+		case distance > threshold:
+		case best == -1 || distance < best:
+			suggestion, best, tied = command, distance, false
+		case distance == best:
+			tied = true
+		}
+	}
+	return suggestion, best != -1 && !tied
+}
+
+// didYouMean returns a "Did you mean ...?" prefix for a reply to an unknown
+// command, or the empty string if there's no good suggestion.
+func didYouMean(name string) string {
+	suggestion, ok := suggestCommand(name)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("Did you mean `%s`?\n\n", suggestion)
+}
+
+// levenshtein returns the edit distance between two strings: the number of
+// single-character insertions, deletions, and substitutions needed to turn
+// one into the other.
+func levenshtein(a, b string) int {
+	s, t := []rune(a), []rune(b)
+
+	// prev[j] is the distance between the first i-1 runes of s and the first
+	// j runes of t; curr is the same for the first i runes of s.
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := range s {
+		curr[0] = i + 1
+		for j := range t {
+			cost := 1
+			if s[i] == t[j] {
+				cost = 0
+			}
+			curr[j+1] = min(prev[j+1]+1, curr[j]+1, prev[j]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(t)]
+}
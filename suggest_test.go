@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+)
+
+func Test_levenshtein(t *testing.T) {
+	for _, tc := range []struct {
+		A, B     string
+		Expected int
+	}{
+		{"", "", 0},
+		{"", "skip", 4},
+		{"skip", "skip", 0},
+		{"skip", "skp", 1},
+		{"skip", "skiip", 1},
+		{"skip", "slip", 1},
+		{"kitten", "sitting", 3},
+		{"subscirbe", "subscribe", 2},
+	} {
+		assert.Equal(t, levenshtein(tc.A, tc.B), tc.Expected)
+		assert.Equal(t, levenshtein(tc.B, tc.A), tc.Expected)
+	}
+}
+
+func Test_suggestCommand(t *testing.T) {
+	typos := map[string]string{
+		"subscirbe":      "subscribe",
+		"unsubscrib":     "unsubscribe",
+		"restor":         "restore",
+		"shcedule":       "schedule",
+		"skpi":           "skip",
+		"unskp":          "unskip",
+		"puase":          "pause",
+		"resumee":        "resume",
+		"sattus":         "status",
+		"nextt":          "next",
+		"setr":           "set",
+		"claer":          "clear",
+		"topcis":         "topics",
+		"stast":          "stats",
+		"noshwo":         "noshow",
+		"ratee":          "rate",
+		"blcok":          "block",
+		"blcoks":         "blocks",
+		"exprot":         "export",
+		"delte":          "delete",
+		"unblcok":        "unblock",
+		"theem":          "theme",
+		"thisweak":       "thisweek",
+		"mutr":           "mute",
+		"unmte":          "unmute",
+		"confrim":        "confirm",
+		"delcine":        "decline",
+		"leaderbord":     "leaderboard",
+		"milestnes":      "milestones",
+		"whoo":           "who",
+		"mer":            "met",
+		"piar":           "pair",
+		"alais":          "alias",
+		"unalais":        "unalias",
+		"aliasess":       "aliases",
+		"svae":           "save",
+		"lod":            "load",
+		"schedlues":      "schedules",
+		"resett":         "reset",
+		"availabily":     "availability",
+		"histroy":        "history",
+		"mach":           "match",
+		"cancle":         "cancel",
+		"add-reveiw":     "add-review",
+		"anonymus":       "anonymous",
+		"get-revies":     "get-reviews",
+		"bugg":           "bug",
+		"cokie":          "cookie",
+		"hlep":           "help",
+		"verison":        "version",
+		"thansk":         "thanks",
+		"dedup":          "dedupe",
+		"maintenence":    "maintenance",
+		"blindintro":     "blindintros",
+		"minparticipant": "minparticipants",
+		"weekend":        "weekends",
+		"holiday":        "holidays",
+		"dailypst":       "dailypost",
+		"trend":          "trends",
+		"funn":           "fun",
+		"rostr":          "roster",
+		"reviws":         "reviews",
+		"anounce":        "announce",
+		"mt":             "met",
+		"resme":          "resume",
+		"schedul":        "schedule",
+		"sett":           "set",
+	}
+
+	for typo, expected := range typos {
+		t.Run(typo, func(t *testing.T) {
+			suggestion, ok := suggestCommand(typo)
+			assert.Equal(t, ok, true)
+			assert.Equal(t, suggestion, expected)
+		})
+	}
+
+	t.Run("every command has a typo", func(t *testing.T) {
+		covered := make(map[string]bool)
+		for _, command := range typos {
+			covered[command] = true
+		}
+		for _, command := range knownCommands {
+			if !covered[command] {
+				t.Errorf("no typo test for %q", command)
+			}
+		}
+	})
+
+	t.Run("every command is known", func(t *testing.T) {
+		for _, command := range knownCommands {
+			_, _, err := parseCmd(command)
+			if errors.Is(err, ErrUnknownCommand) {
+				t.Errorf("parseCmd doesn't know %q", command)
+			}
+		}
+	})
+
+	t.Run("every parseCmd case is known", func(t *testing.T) {
+		// Aliases of other commands don't need suggestions.
+		aliases := map[string]bool{"thank": true}
+
+		for _, name := range parseCmdCases(t) {
+			if !aliases[name] && !slices.Contains(knownCommands, name) {
+				t.Errorf("knownCommands is missing %q", name)
+			}
+		}
+	})
+
+	for typo, expected := range map[string]string{
+		"stat":   "stats",  // Closer than "status"
+		"unmtue": "unmute", // Closer than "mute"
+	} {
+		t.Run(typo, func(t *testing.T) {
+			suggestion, ok := suggestCommand(typo)
+			assert.Equal(t, ok, true)
+			assert.Equal(t, suggestion, expected)
+		})
+	}
+
+	for _, name := range []string{
+		"statu", // Could be "stats" or "status"
+		"mut",   // Could be "mute" or "met"
+		"xyzzy", // Not close to anything
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, ok := suggestCommand(name)
+			assert.Equal(t, ok, false)
+		})
+	}
+}
+
+// parseCmdCases returns the command names in the cases of parseCmd's switch.
+func parseCmdCases(t *testing.T) []string {
+	t.Helper()
+
+	file, err := parser.ParseFile(token.NewFileSet(), "parse_cmd.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "parseCmd" {
+			continue
+		}
+		for _, stmt := range fn.Body.List {
+			sw, ok := stmt.(*ast.SwitchStmt)
+			if !ok {
+				continue
+			}
+			for _, clause := range sw.Body.List {
+				for _, expr := range clause.(*ast.CaseClause).List {
+					lit, ok := expr.(*ast.BasicLit)
+					if !ok || lit.Kind != token.STRING {
+						continue
+					}
+					name, err := strconv.Unquote(lit.Value)
+					if err != nil {
+						t.Fatal(err)
+					}
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	if len(names) == 0 {
+		t.Fatal("no cases found in parseCmd")
+	}
+	return names
+}
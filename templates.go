@@ -1,20 +1,125 @@
 package main
 
 import (
+	"cmp"
 	"embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
 	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
 )
 
 //go:embed templates
 var templatesFS embed.FS
-var templates = template.Must(template.ParseFS(templatesFS, "templates/*.tmpl"))
+
+// defaultTemplates are the built-in templates. templates starts out the same,
+// but operators can override some of them (see overrideTemplates).
+// Referring to a parameter the template doesn't get is an error, so typos in
+// overrides are caught.
+var defaultTemplates = template.Must(template.New("").Option("missingkey=error").ParseFS(templatesFS, "templates/*.tmpl"))
+var templates = defaultTemplates
+
+// templateSamples has sample data for each template that isn't one of the
+// staticMessages, so overrides can be checked before they're used.
+var templateSamples = map[string]any{
+	"batch_recap.md.tmpl":      map[string]any{"Pairings": 12, "Partners": 9},
+	"bios.md.tmpl":             map[string]any{"Recursers": []store.Recurser{{Name: "Ada", Bio: "Engines", Topics: []string{"math"}}}, "Topics": true},
+	"checkin.md.tmpl":          map[string]any{"Now": time.Now(), "Recursers": 12, "Pairings": 30, "Review": "Love it"},
+	"confirm_match.md.tmpl":    map[string]any{"Names": []string{"Ada"}, "Hours": 6},
+	"daily_post.md.tmpl":       map[string]any{"Pairs": 5, "Recursers": 11},
+	"onboarding.md.tmpl":       map[string]any{"Name": "Ada Lovelace", "FirstName": "Ada", "Batch": "Summer 1, 2024"},
+	"roster.md.tmpl":           map[string]any{"Day": "Monday", "Names": []string{"Ada", "Grace"}},
+	"match_failures.md.tmpl":   map[string]any{"Date": "2024-03-11", "Failures": []matchFailure{{Recursers: []int64{1, 2}, Message: "match", Error: "oops"}}},
+	"matched.md.tmpl":          map[string]any{"Names": []string{"Ada", "Grace"}},
+	"matched_group.md.tmpl":    map[string]any{"Names": []string{"Ada", "Grace", "Alan"}},
+	"matched_minimal.md.tmpl":  map[string]any{"Names": []string{"Ada", "Grace"}},
+	"nudge.md.tmpl":            map[string]any{"Partners": []int64{2}},
+	"unknown_timezone.md.tmpl": map[string]any{"Zone": "Mars/Olympus_Mons"},
+	"weekly_summary.md.tmpl":   map[string]any{"Matches": []summaryMatch{{Day: "Monday", Partners: []string{"@_**|2**"}}}},
+	"welcome.md.tmpl":          map[string]any{"Now": time.Now()},
+}
+
+// overrideTemplates replaces the built-in templates with the ones of the same
+// name in dir, so operators can change the wording without a new build. dir
+// doesn't need to have all of them.
+func overrideTemplates(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no templates in %s", dir)
+	}
+	for _, file := range files {
+		if templates.Lookup(filepath.Base(file)) == nil {
+			return fmt.Errorf("unknown template %s", filepath.Base(file))
+		}
+	}
+
+	overridden, err := templates.Clone()
+	if err != nil {
+		return err
+	}
+	if _, err := overridden.ParseFiles(files...); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		name := filepath.Base(file)
+		if data, ok := templateSamples[name]; ok {
+			if err := overridden.ExecuteTemplate(io.Discard, name, data); err != nil {
+				return err
+			}
+		}
+	}
+
+	defaults := templates
+	templates = overridden
+	if err := renderStaticMessages(); err != nil {
+		templates = defaults
+		_ = renderStaticMessages()
+		return err
+	}
+	return nil
+}
+
+// renderStaticMessages renders each of the staticMessages from its template.
+// They can only mention the maintainers.
+func renderStaticMessages() error {
+	data := map[string]any{
+		"Maintainers": maintainersMention(store.DefaultRealm),
+	}
+	for name, message := range staticMessages {
+		s, err := execute(templates, name, data)
+		if err != nil {
+			return fmt.Errorf("render %s: %w", name, err)
+		}
+		*message = s
+	}
+	return nil
+}
 
 // renderTemplate executes the template and returns the resulting string.
+// If an override fails (overrideTemplates only checks them with sample data),
+// it falls back to the built-in template.
 func renderTemplate(path string, data any) (string, error) {
+	s, err := execute(templates, path, data)
+	if err != nil && templates != defaultTemplates {
+		slog.Warn("Could not render an overridden template, so using the default", slog.String("template", path), slog.Any("error", err))
+		return execute(defaultTemplates, path, data)
+	}
+	return s, err
+}
+
+func execute(t *template.Template, path string, data any) (string, error) {
 	var sb strings.Builder
-	if err := templates.ExecuteTemplate(&sb, path, data); err != nil {
+	if err := t.ExecuteTemplate(&sb, path, data); err != nil {
 		return "", err
 	}
 	return sb.String(), nil
@@ -26,6 +131,18 @@ func renderWelcome(now time.Time) (string, error) {
 	})
 }
 
+// renderOnboarding tells someone new to RC how to start using Pairing Bot.
+// batch is the name of their batch, if they're in one. The schedule examples
+// use their first name, to read like a note from a person.
+func renderOnboarding(name, batch string) (string, error) {
+	firstName, _, _ := strings.Cut(strings.TrimSpace(name), " ")
+	return renderTemplate("onboarding.md.tmpl", map[string]any{
+		"Name":      name,
+		"FirstName": firstName,
+		"Batch":     batch,
+	})
+}
+
 func renderCheckin(now time.Time, numPairings int, numRecursers int, review string) (string, error) {
 	return renderTemplate("checkin.md.tmpl", map[string]any{
 		"Now":       now,
@@ -34,3 +151,233 @@ func renderCheckin(now time.Time, numPairings int, numRecursers int, review stri
 		"Review":    review,
 	})
 }
+
+// renderBios lists the bios of everyone in the match, and their topics if
+// withTopics is set, or returns an empty string if there's nothing to list.
+func renderBios(group []store.Recurser, withTopics bool) (string, error) {
+	var withBios []store.Recurser
+	for _, rec := range group {
+		if rec.Bio != "" || (withTopics && len(rec.Topics) > 0) {
+			withBios = append(withBios, rec)
+		}
+	}
+
+	if len(withBios) == 0 {
+		return "", nil
+	}
+
+	return renderTemplate("bios.md.tmpl", map[string]any{
+		"Recursers": withBios,
+		"Topics":    withTopics,
+	})
+}
+
+// summaryMatch is one line of the weekly summary.
+type summaryMatch struct {
+	Day      string
+	Partners []string
+}
+
+// renderWeeklySummary lists rec's partners from the given matches, as silent
+// mentions with their names. Matches don't keep names, so they come from
+// `names`, and anyone missing from it is mentioned by ID alone.
+func renderWeeklySummary(rec *store.Recurser, matches []store.Match, names map[recurserKey]string) (string, error) {
+	slices.SortFunc(matches, func(a, b store.Match) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+
+	var lines []summaryMatch
+	for _, match := range matches {
+		var partners []string
+		for _, id := range match.Recursers {
+			if id != rec.ID {
+				partners = append(partners, fmt.Sprintf("@_**%s|%d**", names[recurserKey{rec.Realm, id}], id))
+			}
+		}
+
+		// The day it was pairing for, which can be the day after the
+		// match run locally.
+		day := dayName(rec.MatchDay(time.Unix(match.Timestamp, 0)))
+		lines = append(lines, summaryMatch{Day: day, Partners: partners})
+	}
+
+	return renderTemplate("weekly_summary.md.tmpl", map[string]any{
+		"Matches": lines,
+	})
+}
+
+// renderBatchRecap thanks someone for pairing at the end of their batch.
+func renderBatchRecap(recap batchRecap) (string, error) {
+	return renderTemplate("batch_recap.md.tmpl", map[string]any{
+		"Pairings": recap.Pairings,
+		"Partners": recap.Partners,
+	})
+}
+
+// renderDailyPost announces how many pairs a match run made.
+func renderDailyPost(pairing store.Pairing) (string, error) {
+	return renderTemplate("daily_post.md.tmpl", map[string]any{
+		"Pairs":     pairing.Value,
+		"Recursers": pairing.NumRecursers,
+	})
+}
+
+// renderMatched announces a match to the people in it. Groups of three are
+// told why there are three of them.
+func renderMatched(names []string) (string, error) {
+	name := "matched.md.tmpl"
+	if len(names) > 2 {
+		name = "matched_group.md.tmpl"
+	}
+	return renderTemplate(name, map[string]any{
+		"Names": names,
+	})
+}
+
+// groupVerbosity is the least verbose setting of anyone in the group, since
+// they all get the same match message.
+func groupVerbosity(group []store.Recurser) string {
+	levels := []string{store.VerbosityMinimal, store.VerbosityNormal, store.VerbosityFull}
+	least := len(levels) - 1
+	for _, rec := range group {
+		level := slices.Index(levels, cmp.Or(rec.Verbosity, store.VerbosityNormal))
+		if level >= 0 && level < least {
+			least = level
+		}
+	}
+	return levels[least]
+}
+
+// durationNote is the part of a match message that says how long everyone would
+// like to pair (see store.Recurser.Duration), or "" if nobody said. If they
+// don't all agree, it lists each preference.
+func durationNote(group []store.Recurser) string {
+	var prefs []string
+	agree := true
+	for _, rec := range group {
+		if rec.Duration == 0 {
+			agree = false
+			continue
+		}
+		if rec.Duration != group[0].Duration {
+			agree = false
+		}
+		prefs = append(prefs, fmt.Sprintf("%s: %d minutes", rec.Name, rec.Duration))
+	}
+
+	if len(prefs) == 0 {
+		return ""
+	}
+	if agree {
+		return fmt.Sprintf("\n\n:stopwatch: You'd all like to pair for about **%d minutes**.", group[0].Duration)
+	}
+	return "\n\n:stopwatch: **How long you'd like to pair:** " + strings.Join(prefs, ", ")
+}
+
+// modeNames say how someone would like to pair, for each store.Recurser.Mode.
+var modeNames = map[string]string{
+	store.ModeVoice:    "on a voice call",
+	store.ModeText:     "over text chat",
+	store.ModeInPerson: "in person",
+}
+
+// modeNote is the part of a match message that says how everyone would like to
+// pair (see store.Recurser.Mode), or "" if nobody said. Like durationNote, it
+// lists each preference unless they all agree.
+func modeNote(group []store.Recurser) string {
+	var prefs []string
+	agree := true
+	for _, rec := range group {
+		name, ok := modeNames[rec.Mode]
+		if !ok {
+			agree = false
+			continue
+		}
+		if rec.Mode != group[0].Mode {
+			agree = false
+		}
+		prefs = append(prefs, fmt.Sprintf("%s: %s", rec.Name, name))
+	}
+
+	if len(prefs) == 0 {
+		return ""
+	}
+	if agree {
+		return fmt.Sprintf("\n\n:speech_balloon: You'd all like to pair **%s**.", modeNames[group[0].Mode])
+	}
+	return "\n\n:speech_balloon: **How you'd like to pair:** " + strings.Join(prefs, ", ")
+}
+
+// renderMatchMessage is the message a group gets when they're matched. Minimal
+// messages just say who's in the group, normal ones add the theme (if there is
+// one) and everyone's bios, and full ones add everyone's topics too. All of
+// them say how long and how everyone would like to pair, if anyone said.
+func renderMatchMessage(group []store.Recurser, theme string) (string, error) {
+	var names []string
+	for _, rec := range group {
+		names = append(names, rec.Name)
+	}
+
+	verbosity := groupVerbosity(group)
+	if verbosity == store.VerbosityMinimal {
+		message, err := renderTemplate("matched_minimal.md.tmpl", map[string]any{
+			"Names": names,
+		})
+		return message + durationNote(group) + modeNote(group), err
+	}
+
+	message, err := renderMatched(names)
+	if err != nil {
+		return "", err
+	}
+	message += theme + durationNote(group) + modeNote(group)
+
+	bios, err := renderBios(group, verbosity == store.VerbosityFull)
+	if err != nil {
+		return message, fmt.Errorf("render bios: %w", err)
+	}
+	return message + bios, nil
+}
+
+// renderNudge reminds someone to get in touch with their partners.
+func renderNudge(partners []int64) (string, error) {
+	return renderTemplate("nudge.md.tmpl", map[string]any{
+		"Partners": partners,
+	})
+}
+
+func renderUnknownTimezone(zone string) (string, error) {
+	return renderTemplate("unknown_timezone.md.tmpl", map[string]any{
+		"Zone": zone,
+	})
+}
+
+// renderMatchFailures summarizes the messages that didn't go out during the
+// match run at `now`.
+func renderMatchFailures(now time.Time, failures []matchFailure) (string, error) {
+	return renderTemplate("match_failures.md.tmpl", map[string]any{
+		"Date":     now.UTC().Format(time.DateOnly),
+		"Failures": failures,
+	})
+}
+
+// renderRoster lists who's scheduled to pair on the day, sorted by name.
+func renderRoster(day string, names []string) (string, error) {
+	names = slices.Clone(names)
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(strings.ToLower(a), strings.ToLower(b)), cmp.Compare(a, b))
+	})
+	return renderTemplate("roster.md.tmpl", map[string]any{
+		"Day":   strings.ToUpper(day[:1]) + day[1:],
+		"Names": names,
+	})
+}
+
+// renderConfirmMatch asks the people who want to confirm their matches to do
+// so within the window.
+func renderConfirmMatch(names []string, window time.Duration) (string, error) {
+	return renderTemplate("confirm_match.md.tmpl", map[string]any{
+		"Names": names,
+		"Hours": int(window.Hours()),
+	})
+}
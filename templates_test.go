@@ -0,0 +1,337 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_renderBios(t *testing.T) {
+	t.Run("nobody has a bio", func(t *testing.T) {
+		bios, err := renderBios([]store.Recurser{
+			{Name: "Your Name"},
+			{Name: "My Name"},
+		}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, bios, "")
+	})
+
+	t.Run("empty bios are left out", func(t *testing.T) {
+		bios, err := renderBios([]store.Recurser{
+			{Name: "Your Name", Bio: "Writing a ray tracer"},
+			{Name: "My Name"},
+		}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, bios, "\n\n**A little about you:**\n\n* **Your Name**: Writing a ray tracer\n")
+	})
+
+	t.Run("with topics", func(t *testing.T) {
+		group := []store.Recurser{
+			{Name: "Your Name", Bio: "Writing a ray tracer", Topics: []string{"graphics", "rust"}},
+			{Name: "My Name", Topics: []string{"go"}},
+		}
+
+		bios, err := renderBios(group, true)
+		assert.NoError(t, err)
+		assert.Equal(t, bios, "\n\n**A little about you:**\n\n* **Your Name**: Writing a ray tracer _(Topics: graphics, rust)_\n* **My Name**: _(Topics: go)_\n")
+
+		// Topics alone don't count without withTopics.
+		bios, err = renderBios(group, false)
+		assert.NoError(t, err)
+		assert.Equal(t, bios, "\n\n**A little about you:**\n\n* **Your Name**: Writing a ray tracer\n")
+	})
+}
+
+func Test_renderWeeklySummary(t *testing.T) {
+	rec := &store.Recurser{ID: 1, Timezone: "America/New_York"}
+	names := map[recurserKey]string{{id: 2}: "Grace", {id: 3}: "Alan", {realm: "sister", id: 4}: "Barbara"}
+
+	t.Run("with matches", func(t *testing.T) {
+		monday := time.Date(2024, time.March, 11, 4, 0, 0, 0, time.UTC)
+		tuesday := monday.AddDate(0, 0, 1)
+
+		summary, err := renderWeeklySummary(rec, []store.Match{
+			{Recursers: []int64{1, 3, 4}, Timestamp: tuesday.Unix()},
+			{Recursers: []int64{2, 1}, Timestamp: monday.Unix()},
+		}, names)
+		assert.NoError(t, err)
+
+		// There's no name for 4 in this realm, so it's just the ID.
+		assert.Equal(t, strings.Contains(summary, "matched 2 times"), true)
+		assert.Equal(t, strings.Contains(summary, "* Monday: @_**Grace|2**\n* Tuesday: @_**Alan|3**, @_**|4**\n"), true)
+	})
+
+	t.Run("in winter", func(t *testing.T) {
+		// The match run is the evening before in New York (EST).
+		monday := time.Date(2024, time.January, 15, store.MatchRunHour, 0, 0, 0, time.UTC)
+
+		summary, err := renderWeeklySummary(rec, []store.Match{
+			{Recursers: []int64{1, 2}, Timestamp: monday.Unix()},
+		}, names)
+		assert.NoError(t, err)
+
+		assert.Equal(t, strings.Contains(summary, "* Monday: @_**Grace|2**\n"), true)
+	})
+
+	t.Run("without matches", func(t *testing.T) {
+		summary, err := renderWeeklySummary(rec, nil, names)
+		assert.NoError(t, err)
+
+		assert.Equal(t, strings.Contains(summary, "You didn't get matched"), true)
+	})
+}
+
+func Test_renderConfirmMatch(t *testing.T) {
+	note, err := renderConfirmMatch([]string{"Ada", "Grace"}, 6*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, note, "\n\n@_**Ada** and @_**Grace** asked to confirm matches before they count. Send me `confirm` within 6 hours if you're in, or `decline` if you can't make it.\n")
+}
+
+func Test_templates(t *testing.T) {
+	for _, tmpl := range templates.Templates() {
+		name := tmpl.Name()
+		t.Run(name, func(t *testing.T) {
+			data, ok := templateSamples[name]
+			if !ok {
+				if _, ok := staticMessages[name]; !ok {
+					t.Fatalf("no sample data for %s", name)
+				}
+				data = map[string]any{"Maintainers": "@_**|1**"}
+			}
+
+			msg, err := renderTemplate(name, data)
+			assert.NoError(t, err)
+			if msg == "" || strings.Contains(msg, "<no value>") {
+				t.Errorf("bad render of %s: %q", name, msg)
+			}
+		})
+	}
+}
+
+func Test_renderMatched(t *testing.T) {
+	t.Run("pair", func(t *testing.T) {
+		msg, err := renderMatched([]string{"Ada", "Grace"})
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Hi you two! You've been matched for pairing :)\n\nHave fun!\n")
+	})
+
+	t.Run("group", func(t *testing.T) {
+		msg, err := renderMatched([]string{"Ada", "Grace", "Alan"})
+		assert.NoError(t, err)
+		assert.Equal(t, strings.HasPrefix(msg, "Hi Ada, Grace, Alan! You've been matched for pairing :)"), true)
+		assert.Equal(t, strings.Contains(msg, "you're a group of 3."), true)
+	})
+}
+
+func Test_renderMatchMessage(t *testing.T) {
+	const theme = "\n\nThis week's theme is: **testing**"
+	group := func(verbosity ...string) []store.Recurser {
+		return []store.Recurser{
+			{Name: "Ada", Bio: "Engines", Topics: []string{"math"}, Verbosity: verbosity[0]},
+			{Name: "Grace", Verbosity: verbosity[1]},
+		}
+	}
+	matched := "Hi you two! You've been matched for pairing :)\n\nHave fun!\n"
+
+	t.Run("minimal", func(t *testing.T) {
+		msg, err := renderMatchMessage(group(store.VerbosityMinimal, store.VerbosityMinimal), theme)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "You've been matched for pairing: Ada, Grace.\n")
+	})
+
+	t.Run("normal", func(t *testing.T) {
+		for _, verbosity := range []string{"", store.VerbosityNormal} {
+			msg, err := renderMatchMessage(group(verbosity, verbosity), theme)
+			assert.NoError(t, err)
+			assert.Equal(t, msg, matched+theme+"\n\n**A little about you:**\n\n* **Ada**: Engines\n")
+		}
+	})
+
+	t.Run("full", func(t *testing.T) {
+		msg, err := renderMatchMessage(group(store.VerbosityFull, store.VerbosityFull), theme)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, matched+theme+"\n\n**A little about you:**\n\n* **Ada**: Engines _(Topics: math)_\n")
+	})
+
+	t.Run("least verbose wins", func(t *testing.T) {
+		msg, err := renderMatchMessage(group(store.VerbosityFull, ""), theme)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, matched+theme+"\n\n**A little about you:**\n\n* **Ada**: Engines\n")
+
+		msg, err = renderMatchMessage(group(store.VerbosityFull, store.VerbosityMinimal), theme)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "You've been matched for pairing: Ada, Grace.\n")
+	})
+
+	t.Run("durations", func(t *testing.T) {
+		g := group("", store.VerbosityMinimal)
+		g[0].Duration = 30
+		g[1].Duration = 90
+
+		msg, err := renderMatchMessage(g, theme)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "You've been matched for pairing: Ada, Grace.\n\n\n:stopwatch: **How long you'd like to pair:** Ada: 30 minutes, Grace: 90 minutes")
+
+		g[1].Verbosity = ""
+		msg, err = renderMatchMessage(g, theme)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Contains(msg, theme+"\n\n:stopwatch: **How long you'd like to pair:** Ada: 30 minutes, Grace: 90 minutes\n\n**A little about you:**"), true)
+	})
+
+	t.Run("modes", func(t *testing.T) {
+		g := group("", store.VerbosityMinimal)
+		g[0].Mode = store.ModeVoice
+		g[1].Mode = store.ModeInPerson
+
+		msg, err := renderMatchMessage(g, theme)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "You've been matched for pairing: Ada, Grace.\n\n\n:speech_balloon: **How you'd like to pair:** Ada: on a voice call, Grace: in person")
+
+		g[1].Verbosity = ""
+		msg, err = renderMatchMessage(g, theme)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Contains(msg, theme+"\n\n:speech_balloon: **How you'd like to pair:** Ada: on a voice call, Grace: in person\n\n**A little about you:**"), true)
+	})
+}
+
+func Test_durationNote(t *testing.T) {
+	pair := func(a, b int) []store.Recurser {
+		return []store.Recurser{{Name: "Ada", Duration: a}, {Name: "Grace", Duration: b}}
+	}
+
+	t.Run("nobody said", func(t *testing.T) {
+		assert.Equal(t, durationNote(pair(0, 0)), "")
+	})
+
+	t.Run("same", func(t *testing.T) {
+		assert.Equal(t, durationNote(pair(60, 60)), "\n\n:stopwatch: You'd all like to pair for about **60 minutes**.")
+	})
+
+	t.Run("different", func(t *testing.T) {
+		assert.Equal(t, durationNote(pair(30, 90)), "\n\n:stopwatch: **How long you'd like to pair:** Ada: 30 minutes, Grace: 90 minutes")
+	})
+
+	t.Run("only one said", func(t *testing.T) {
+		assert.Equal(t, durationNote(pair(0, 90)), "\n\n:stopwatch: **How long you'd like to pair:** Grace: 90 minutes")
+	})
+}
+
+func Test_modeNote(t *testing.T) {
+	pair := func(a, b string) []store.Recurser {
+		return []store.Recurser{{Name: "Ada", Mode: a}, {Name: "Grace", Mode: b}}
+	}
+
+	t.Run("nobody said", func(t *testing.T) {
+		assert.Equal(t, modeNote(pair("", store.ModeAny)), "")
+	})
+
+	t.Run("same", func(t *testing.T) {
+		assert.Equal(t, modeNote(pair(store.ModeVoice, store.ModeVoice)), "\n\n:speech_balloon: You'd all like to pair **on a voice call**.")
+	})
+
+	t.Run("different", func(t *testing.T) {
+		assert.Equal(t, modeNote(pair(store.ModeText, store.ModeInPerson)), "\n\n:speech_balloon: **How you'd like to pair:** Ada: over text chat, Grace: in person")
+	})
+
+	t.Run("only one said", func(t *testing.T) {
+		assert.Equal(t, modeNote(pair(store.ModeAny, store.ModeText)), "\n\n:speech_balloon: **How you'd like to pair:** Grace: over text chat")
+	})
+}
+
+func Test_renderOnboarding(t *testing.T) {
+	msg, err := renderOnboarding("Ada Lovelace", "Summer 1, 2024")
+	assert.NoError(t, err)
+	assert.Equal(t, strings.HasPrefix(msg, "Hi Ada Lovelace, welcome to RC and the Summer 1, 2024 batch!"), true)
+	assert.Equal(t, strings.Contains(msg, "For example, Ada, if you'd like to pair on Mondays, Wednesdays, and Fridays"), true)
+	assert.Equal(t, strings.Contains(msg, "schedule monday wednesday friday"), true)
+}
+
+func Test_renderUnknownTimezone(t *testing.T) {
+	msg, err := renderUnknownTimezone("Mars/Olympus_Mons")
+	assert.NoError(t, err)
+	assert.Equal(t, msg, "I don't recognize the time zone \"Mars/Olympus_Mons\" :thinking: Try a name from the [tz database](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones), like `America/New_York` or `Europe/Berlin`.")
+}
+
+func Test_overrideTemplates(t *testing.T) {
+	defaults := templates
+	t.Cleanup(func() {
+		templates = defaults
+		assert.NoError(t, renderStaticMessages())
+	})
+
+	write := func(t *testing.T, dir, name, text string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("unknown template", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "matchd.md.tmpl", "Hi!")
+
+		err := overrideTemplates(dir)
+		assert.Equal(t, err != nil, true)
+		assert.Equal(t, templates == defaults, true)
+	})
+
+	t.Run("bad static message", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "youre_welcome.md.tmpl", "{{ .Missing.Field }}")
+
+		err := overrideTemplates(dir)
+		assert.Equal(t, err != nil, true)
+		assert.Equal(t, templates == defaults, true)
+		assert.Equal(t, youreWelcomeMessage, "You're welcome!")
+	})
+
+	t.Run("override", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "youre_welcome.md.tmpl", "De rien !")
+		write(t, dir, "matched.md.tmpl", "Bonjour {{ index .Names 0 }} et {{ index .Names 1 }} !")
+
+		assert.NoError(t, overrideTemplates(dir))
+		assert.Equal(t, youreWelcomeMessage, "De rien !")
+
+		msg, err := renderMatched([]string{"Ada", "Grace"})
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "Bonjour Ada et Grace !")
+
+		// The others keep their defaults.
+		assert.Equal(t, notSubscribedMessage, "You're not subscribed to Pairing Bot <3")
+	})
+}
+
+func Test_renderMatchFailures(t *testing.T) {
+	now := time.Date(2024, time.March, 11, 4, 0, 0, 0, time.UTC)
+	summary, err := renderMatchFailures(now, []matchFailure{
+		{Recursers: []int64{1, 2}, Message: "match", Error: "rate limited"},
+		{Recursers: []int64{3}, Message: "odd one out", Error: "no such user"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, summary, ":warning: Some messages from the 2024-03-11 match run didn't go out:\n\n* match for @_**|1**, @_**|2**: rate limited\n* odd one out for @_**|3**: no such user\n")
+}
+
+func Test_renderRoster(t *testing.T) {
+	t.Run("sorted by name", func(t *testing.T) {
+		roster, err := renderRoster("monday", []string{"grace", "Alan", "Ada"})
+		assert.NoError(t, err)
+		assert.Equal(t, roster, "3 people are scheduled to pair on Monday:\n\n* Ada\n* Alan\n* grace\n")
+	})
+
+	t.Run("one person", func(t *testing.T) {
+		roster, err := renderRoster("friday", []string{"Ada"})
+		assert.NoError(t, err)
+		assert.Equal(t, roster, "1 person is scheduled to pair on Friday:\n\n* Ada\n")
+	})
+
+	t.Run("nobody", func(t *testing.T) {
+		roster, err := renderRoster("sunday", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, roster, "Nobody is scheduled to pair on Sunday.\n")
+	})
+}
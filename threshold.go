@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// size returns how many Recursers are in the plan, matched or not.
+func (p matchPlan) size() int {
+	n := len(p.Unmatched)
+	for _, group := range p.Groups {
+		n += len(group)
+	}
+	if p.OddOneOut != nil {
+		n++
+	}
+	return n
+}
+
+// tooFewToMatch returns whether the plan has too few people for the match run
+// to go ahead. An empty plan isn't "too few": there's just nothing to do.
+func tooFewToMatch(config store.MatchConfig, plan matchPlan) bool {
+	n := plan.size()
+	return n > 0 && n < config.MinParticipants
+}
+
+// skipQuietRun reports a match run that was skipped for having too few
+// people, and posts about it to the welcome stream if the maintainers asked
+// for that.
+func (pl *PairingLogic) skipQuietRun(ctx context.Context, config store.MatchConfig, plan matchPlan) {
+	logger(ctx).Info("Too few people to pair today, so skipping this run",
+		slog.Int("count", plan.size()),
+		slog.Int("minParticipants", config.MinParticipants),
+	)
+	if !config.AnnounceSkips {
+		return
+	}
+	if err := pl.zulip.PostToTopic(ctx, pl.welcomeStream, "🍐🤖", notEnoughMessage); err != nil {
+		logger(ctx).Error("Could not post about the skipped match run", slog.Any("error", err))
+	}
+}
+
+// MinParticipantsStatus shows the current minimum for a match run.
+func (pl *PairingLogic) MinParticipantsStatus(ctx context.Context, rec *store.Recurser) (string, error) {
+	config, err := store.MatchConfigs(pl.db).Get(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+
+	if config.MinParticipants == 0 {
+		return "There's no minimum, so matching runs with any number of people. Send `minparticipants <n>` to set one.", nil
+	}
+
+	changed := ""
+	if config.UpdatedBy != 0 {
+		changed = fmt.Sprintf(" (since @_**|%d** changed it at %s)", config.UpdatedBy, time.Unix(config.Timestamp, 0).In(rec.Location()).Format("2006-01-02 15:04"))
+	}
+	announce := "without posting about it"
+	if config.AnnounceSkips {
+		announce = "and post about it in the welcome stream"
+	}
+	return fmt.Sprintf("The minimum is **%d** people%s. With fewer, I'll skip that day's matches %s.", config.MinParticipants, changed, announce), nil
+}
+
+// SetMinParticipants changes the fewest Recursers a match run needs. Zero
+// removes the minimum.
+func (pl *PairingLogic) SetMinParticipants(ctx context.Context, rec *store.Recurser, args []string) (string, error) {
+	minimum, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "", err
+	}
+	announce := len(args) > 1
+
+	err = store.MatchConfigs(pl.db).Set(ctx, store.MatchConfig{
+		MinParticipants: minimum,
+		AnnounceSkips:   announce,
+		UpdatedBy:       rec.ID,
+		Timestamp:       time.Now().Unix(),
+	})
+	if err != nil {
+		return "", writeError(err)
+	}
+
+	if minimum == 0 {
+		return "Done. Matching runs with any number of people again.", nil
+	}
+	if announce {
+		return fmt.Sprintf("Done. I'll skip matching on days with fewer than %d people, and say so in the welcome stream.", minimum), nil
+	}
+	return fmt.Sprintf("Done. I'll skip matching on days with fewer than %d people. (Add `announce` to also say so in the welcome stream.)", minimum), nil
+}
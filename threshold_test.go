@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_tooFewToMatch(t *testing.T) {
+	three := matchPlan{
+		Groups:    [][]store.Recurser{{{ID: 1}, {ID: 2}}},
+		Unmatched: []store.Recurser{{ID: 3}},
+	}
+	alone := matchPlan{OddOneOut: &store.Recurser{ID: 1}}
+
+	for name, tc := range map[string]struct {
+		Minimum int
+		Plan    matchPlan
+		Want    bool
+	}{
+		"no minimum":      {0, alone, false},
+		"below":           {4, three, true},
+		"at the minimum":  {3, three, false},
+		"above":           {2, three, false},
+		"odd one out":     {2, alone, true},
+		"nobody to match": {2, matchPlan{}, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tooFewToMatch(store.MatchConfig{MinParticipants: tc.Minimum}, tc.Plan), tc.Want)
+		})
+	}
+}
+
+func TestPairingLogic_match_minParticipants(t *testing.T) {
+	ctx := context.Background()
+	db := pbtest.FirestoreClient(t, ctx)
+
+	// Stream messages are only sent in production.
+	t.Setenv("APP_ENV", "production")
+
+	fake := pbtest.NewFakeZulip(t)
+	// sent returns the recipients of each DM and the content of each post
+	// (all to the welcome stream) so far.
+	sent := func(t *testing.T) (dms, posts []string) {
+		for _, msg := range fake.Messages() {
+			if msg.Type == "stream" {
+				assert.Equal(t, msg.To, "welcome")
+				posts = append(posts, msg.Content)
+			} else {
+				dms = append(dms, msg.To)
+			}
+		}
+		return dms, posts
+	}
+
+	pl := &PairingLogic{db: db, zulip: fake.Client, welcomeStream: "welcome", repeatWindowDays: 7, seeds: rand.New(rand.NewSource(1))}
+
+	everyDay := store.NewSchedule([]string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"})
+	for _, rec := range []store.Recurser{
+		{ID: 1, Name: "One", Schedule: everyDay},
+		{ID: 2, Name: "Two", Schedule: everyDay},
+	} {
+		if err := store.Recursers(db).Set(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	setMinimum := func(t *testing.T, minimum int) {
+		err := store.MatchConfigs(db).Set(ctx, store.MatchConfig{MinParticipants: minimum, AnnounceSkips: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	countMatches := func(t *testing.T) int {
+		matches, err := store.Pairings(db).GetMatchesSince(ctx, time.Now().Add(-time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(matches)
+	}
+
+	t.Run("below the minimum", func(t *testing.T) {
+		setMinimum(t, 3)
+		if err := pl.match(ctx, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, countMatches(t), 0)
+		dms, posts := sent(t)
+		assert.Equal(t, len(dms), 0)
+		assert.Equal(t, posts, []string{notEnoughMessage})
+	})
+
+	t.Run("at the minimum", func(t *testing.T) {
+		setMinimum(t, 2)
+		if err := pl.match(ctx, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, countMatches(t), 1)
+		dms, posts := sent(t)
+		assert.Equal(t, len(dms), 1)
+		assert.Equal(t, len(posts), 1)
+	})
+}
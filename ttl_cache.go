@@ -0,0 +1,36 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ttlCache holds the most recently computed value of something that's slow to
+// compute, like the leaderboard. The zero value is an empty cache.
+type ttlCache[T any] struct {
+	mu         sync.Mutex
+	computedAt time.Time
+	value      T
+}
+
+// get returns the cached value if it's been less than ttl since it was computed
+// as of `now`, and otherwise replaces it with the result of compute. Errors
+// aren't cached.
+func (c *ttlCache[T]) get(now time.Time, ttl time.Duration, compute func() (T, error)) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.computedAt.IsZero() && now.Sub(c.computedAt) < ttl {
+		return c.value, nil
+	}
+
+	value, err := compute()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	c.value = value
+	c.computedAt = now
+	return value, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+)
+
+func Test_ttlCache(t *testing.T) {
+	var cache ttlCache[[]leaderboardEntry]
+	now := time.Now()
+	ttl := 10 * time.Minute
+
+	calls := 0
+	compute := func() ([]leaderboardEntry, error) {
+		calls++
+		return []leaderboardEntry{{Name: "Ada", Count: calls}}, nil
+	}
+
+	first, err := cache.get(now, ttl, compute)
+	assert.NoError(t, err)
+
+	cached, err := cache.get(now.Add(time.Minute), ttl, compute)
+	assert.NoError(t, err)
+	assert.Equal(t, cached, first)
+	assert.Equal(t, calls, 1)
+
+	refreshed, err := cache.get(now.Add(ttl), ttl, compute)
+	assert.NoError(t, err)
+	assert.Equal(t, refreshed, []leaderboardEntry{{Name: "Ada", Count: 2}})
+
+	t.Run("errors aren't cached", func(t *testing.T) {
+		var cache ttlCache[pairingTotals]
+		_, err := cache.get(now, ttl, func() (pairingTotals, error) {
+			return pairingTotals{AllTime: 1}, errors.New("oops")
+		})
+		assert.Equal(t, err.Error(), "oops")
+
+		totals, err := cache.get(now, ttl, func() (pairingTotals, error) {
+			return pairingTotals{AllTime: 2}, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, totals.AllTime, 2)
+	})
+}
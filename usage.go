@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// The `trends` command shows this many days by default, and at most
+// maxTrendsDays.
+const (
+	defaultTrendsDays = 7
+	maxTrendsDays     = 90
+)
+
+// UsageRollup records how much Pairing Bot was used on the previous UTC day:
+// how many Recursers are subscribed, how many matches were made, and how many
+// commands were handled. Running it again for the same day replaces the
+// record, so a retried job is harmless.
+func (pl *PairingLogic) UsageRollup(ctx context.Context) error {
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	start := end.AddDate(0, 0, -1)
+	date := start.Format(time.DateOnly)
+
+	subscribers, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
+	if err != nil {
+		return fmt.Errorf("get subscribers: %w", err)
+	}
+
+	matches, err := store.Pairings(pl.db).GetMatchesSince(ctx, start.Add(-time.Second))
+	if err != nil {
+		return fmt.Errorf("get matches: %w", err)
+	}
+
+	commands, err := store.Usage(pl.db).CommandCount(ctx, date)
+	if err != nil {
+		return fmt.Errorf("get command count: %w", err)
+	}
+
+	day := store.UsageDay{
+		Date:        date,
+		Subscribers: len(subscribers),
+		Matches:     countMatchesBefore(matches, end),
+		Commands:    commands,
+		Timestamp:   time.Now().Unix(),
+	}
+	if err := store.Usage(pl.db).Insert(ctx, day); err != nil {
+		return fmt.Errorf("record usage: %w", err)
+	}
+
+	logger(ctx).Info("Recorded the day's usage",
+		slog.String("date", day.Date),
+		slog.Int("subscribers", day.Subscribers),
+		slog.Int("matches", day.Matches),
+		slog.Int("commands", day.Commands),
+	)
+	return nil
+}
+
+// countMatchesBefore counts the matches made before end.
+func countMatchesBefore(matches []store.Match, end time.Time) int {
+	var n int
+	for _, match := range matches {
+		if match.Timestamp < end.Unix() {
+			n++
+		}
+	}
+	return n
+}
+
+// Trends shows the usage records for the last few days.
+func (pl *PairingLogic) Trends(ctx context.Context, days int) (string, error) {
+	end := time.Now().UTC().AddDate(0, 0, -1)
+	start := end.AddDate(0, 0, 1-days)
+
+	usage, err := store.Usage(pl.db).GetRange(ctx, start.Format(time.DateOnly), end.Format(time.DateOnly))
+	if err != nil {
+		return "", readError(err)
+	}
+	return formatTrends(days, usage), nil
+}
+
+// formatTrends shows the usage records as a table, oldest first. Days without
+// a record (like before the rollup job started) are left out.
+func formatTrends(days int, usage []store.UsageDay) string {
+	if len(usage) == 0 {
+		return fmt.Sprintf("There's no usage recorded for the last %d day(s). The `/usage` job records each day after it's over.", days)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage for the last %d day(s):\n\n", days)
+	b.WriteString("| Day | Subscribers | Matches | Commands |\n")
+	b.WriteString("|---|---|---|---|")
+	for _, day := range usage {
+		fmt.Fprintf(&b, "\n| %s | %d | %d | %d |", day.Date, day.Subscribers, day.Matches, day.Commands)
+	}
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_countMatchesBefore(t *testing.T) {
+	end := time.Date(2024, time.March, 12, 0, 0, 0, 0, time.UTC)
+	matches := []store.Match{
+		{Timestamp: end.Add(-time.Hour).Unix()},
+		{Timestamp: end.Add(-23 * time.Hour).Unix()},
+		{Timestamp: end.Unix()},
+		{Timestamp: end.Add(time.Hour).Unix()},
+	}
+	assert.Equal(t, countMatchesBefore(matches, end), 2)
+}
+
+func Test_formatTrends(t *testing.T) {
+	msg := formatTrends(7, []store.UsageDay{
+		{Date: "2024-03-10", Subscribers: 39, Matches: 18, Commands: 12},
+		{Date: "2024-03-11", Subscribers: 40, Matches: 19, Commands: 25},
+	})
+	assert.Equal(t, msg, "Usage for the last 7 day(s):\n\n"+
+		"| Day | Subscribers | Matches | Commands |\n"+
+		"|---|---|---|---|\n"+
+		"| 2024-03-10 | 39 | 18 | 12 |\n"+
+		"| 2024-03-11 | 40 | 19 | 25 |")
+
+	t.Run("nothing recorded", func(t *testing.T) {
+		assert.Equal(t, formatTrends(3, nil), "There's no usage recorded for the last 3 day(s). The `/usage` job records each day after it's over.")
+	})
+}
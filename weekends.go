@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// isSkippedWeekend returns whether the match run at `now` pairs for a weekend
+// day that the config says to skip. The weekend is in the config's time zone,
+// or UTC if it doesn't have one (or has one that doesn't exist), and the day is
+// the one store.Recurser.MatchDay would give for someone there.
+func isSkippedWeekend(config store.WeekendConfig, now time.Time) bool {
+	if !config.Skip {
+		return false
+	}
+	loc, err := time.LoadLocation(config.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	day := store.MatchTimeIn(now, loc).Weekday()
+	return day == time.Saturday || day == time.Sunday
+}
+
+// skippingWeekend returns whether the match run at `now` should do nothing
+// because it's the weekend. If the setting can't be read, it matches anyway.
+func (pl *PairingLogic) skippingWeekend(ctx context.Context, now time.Time) bool {
+	config, err := store.WeekendConfigs(pl.db).Get(ctx)
+	if err != nil {
+		logger(ctx).Warn("Could not get the weekend setting, so matching anyway", slog.Any("error", err))
+		return false
+	}
+	return isSkippedWeekend(config, now)
+}
+
+// WeekendsStatus shows whether there's matching on weekends.
+func (pl *PairingLogic) WeekendsStatus(ctx context.Context, rec *store.Recurser) (string, error) {
+	config, err := store.WeekendConfigs(pl.db).Get(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+
+	if !config.Skip {
+		return "Weekends are matched like any other day, for people who have them in their schedule. Send `weekends off` to stop.", nil
+	}
+
+	changed := ""
+	if config.UpdatedBy != 0 {
+		changed = fmt.Sprintf(" (since @_**|%d** changed it at %s)", config.UpdatedBy, time.Unix(config.Timestamp, 0).In(rec.Location()).Format("2006-01-02 15:04"))
+	}
+	return fmt.Sprintf("There's no matching on Saturdays and Sundays in **%s**%s, whatever people's schedules say.", weekendZone(config), changed), nil
+}
+
+// SetWeekends turns weekend matching on or off. Off takes the time zone that
+// decides when it's the weekend.
+func (pl *PairingLogic) SetWeekends(ctx context.Context, rec *store.Recurser, on bool, timezone string) (string, error) {
+	config := store.WeekendConfig{
+		Skip:      !on,
+		UpdatedBy: rec.ID,
+		Timestamp: time.Now().Unix(),
+	}
+	if !on {
+		config.Timezone = timezone
+	}
+	if err := store.WeekendConfigs(pl.db).Set(ctx, config); err != nil {
+		return "", writeError(err)
+	}
+
+	if on {
+		return "Done. Weekends are matched like any other day again.", nil
+	}
+	return fmt.Sprintf("Done. Match runs on Saturdays and Sundays in **%s** won't match anyone. (Send `weekends on` to undo this.)", weekendZone(config)), nil
+}
+
+// weekendZone is the name of the time zone where it's the weekend.
+func weekendZone(config store.WeekendConfig) string {
+	if config.Timezone == "" {
+		return "UTC"
+	}
+	return config.Timezone
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_isSkippedWeekend(t *testing.T) {
+	// The match runs in March, when New York is on EDT, are at midnight or
+	// later there.
+	friday := time.Date(2024, time.March, 15, store.MatchRunHour, 0, 0, 0, time.UTC)
+	saturday := friday.AddDate(0, 0, 1)
+	sunday := friday.AddDate(0, 0, 2)
+	// Saturday in UTC, but still Friday evening in New York. It's pairing for
+	// Saturday in both.
+	lateFriday := time.Date(2024, time.March, 16, 3, 0, 0, 0, time.UTC)
+
+	// In January, New York is on EST, so each match run is the evening
+	// before there. It's still pairing for the same day as in UTC.
+	winterFriday := time.Date(2024, time.January, 12, store.MatchRunHour, 0, 0, 0, time.UTC)
+	winterSaturday := winterFriday.AddDate(0, 0, 1)
+	winterSunday := winterFriday.AddDate(0, 0, 2)
+	winterMonday := winterFriday.AddDate(0, 0, 3)
+
+	skip := store.WeekendConfig{Skip: true}
+	skipNY := store.WeekendConfig{Skip: true, Timezone: "America/New_York"}
+
+	for name, tc := range map[string]struct {
+		Config   store.WeekendConfig
+		Now      time.Time
+		Expected bool
+	}{
+		"enabled, saturday":         {skip, saturday, true},
+		"enabled, sunday":           {skip, sunday, true},
+		"enabled, friday":           {skip, friday, false},
+		"disabled, saturday":        {store.WeekendConfig{}, saturday, false},
+		"disabled, with a timezone": {store.WeekendConfig{Timezone: "America/New_York"}, sunday, false},
+		"utc, late friday":          {skip, lateFriday, true},
+		"new york, late friday":     {skipNY, lateFriday, true},
+		"new york, friday":          {skipNY, friday, false},
+		"new york, saturday":        {skipNY, saturday, true},
+		"new york, winter friday":   {skipNY, winterFriday, false},
+		"new york, winter saturday": {skipNY, winterSaturday, true},
+		"new york, winter sunday":   {skipNY, winterSunday, true},
+		"new york, winter monday":   {skipNY, winterMonday, false},
+		"utc, winter monday":        {skip, winterMonday, false},
+		"unknown timezone":          {store.WeekendConfig{Skip: true, Timezone: "Mars/Olympus_Mons"}, lateFriday, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, isSkippedWeekend(tc.Config, tc.Now), tc.Expected)
+		})
+	}
+}
+
+func TestPairingLogic_skippingWeekend(t *testing.T) {
+	ctx := context.Background()
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx)}
+
+	saturday := time.Date(2024, time.March, 16, 12, 0, 0, 0, time.UTC)
+	monday := saturday.AddDate(0, 0, 2)
+	admin := &store.Recurser{ID: 1}
+
+	// Weekends are matched until someone turns them off.
+	assert.Equal(t, pl.skippingWeekend(ctx, saturday), false)
+
+	_, err := pl.SetWeekends(ctx, admin, false, "America/New_York")
+	assert.NoError(t, err)
+	assert.Equal(t, pl.skippingWeekend(ctx, saturday), true)
+	assert.Equal(t, pl.skippingWeekend(ctx, monday), false)
+
+	_, err = pl.SetWeekends(ctx, admin, true, "")
+	assert.NoError(t, err)
+	assert.Equal(t, pl.skippingWeekend(ctx, saturday), false)
+}
+
+func TestPairingLogic_match_skippingWeekend(t *testing.T) {
+	ctx := context.Background()
+	fake := pbtest.NewFakeZulip(t)
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx), zulip: fake.Client}
+
+	saturday := time.Date(2024, time.March, 16, store.MatchRunHour, 0, 0, 0, time.UTC)
+	_, err := pl.SetWeekends(ctx, &store.Recurser{ID: 1}, false, "")
+	assert.NoError(t, err)
+
+	everyDay := store.NewSchedule([]string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"})
+	for _, rec := range []store.Recurser{
+		{ID: 1, Name: "One", Schedule: everyDay, IsSubscribed: true, IsSkippingTomorrow: true},
+		{ID: 2, Name: "Two", Schedule: everyDay, IsSubscribed: true},
+	} {
+		assert.NoError(t, store.Recursers(pl.db).Set(ctx, &rec))
+	}
+
+	assert.NoError(t, pl.matchOn(ctx, saturday))
+	assert.Equal(t, len(fake.Messages()), 0)
+
+	// Skipping tomorrow was only for this run, even though nobody was matched.
+	skippers, err := store.Recursers(pl.db).ListSkippingTomorrow(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(skippers), 0)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/recursecenter/pairing-bot/recurse"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+// Who shows someone's public profile, if they've opted in with `set
+// discoverable on`. Everyone else gets the same "not found" reply, whether or
+// not they're subscribed, so it doesn't give away who uses Pairing Bot.
+func (pl *PairingLogic) Who(ctx context.Context, rec *store.Recurser, args []string) (string, error) {
+	name := args[0]
+	notFound := fmt.Sprintf("I couldn't find a discoverable Recurser called %s. People only show up here after they `set discoverable on`.", name)
+
+	if len(args) > 1 {
+		// A mention with a broken ID can't belong to anyone.
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return notFound, nil
+		}
+		found, err := store.Recursers(pl.db).GetByUserID(ctx, rec.Realm, id, "", name)
+		if err != nil {
+			return "", readError(err)
+		}
+		if !found.IsSubscribed || !found.Discoverable {
+			return notFound, nil
+		}
+		return formatProfile(found), nil
+	}
+
+	subscribers, err := store.Recursers(pl.db).GetAllSubscribed(ctx)
+	if err != nil {
+		return "", readError(err)
+	}
+
+	// Stored names can be out of date, so the RC directory also gets a say in
+	// who the name belongs to.
+	var profiles []recurse.Profile
+	if rec.Realm == store.DefaultRealm && pl.recurse != nil {
+		profiles, err = pl.recurse.ActiveRecursers(ctx)
+		if err != nil {
+			logger(ctx).Warn("Could not look up the name in the RC directory", slog.Any("error", err))
+		}
+	}
+
+	found := findDiscoverable(subscribers, profiles, rec.Realm, name)
+	switch len(found) {
+	case 0:
+		return notFound, nil
+	case 1:
+		return formatProfile(&found[0]), nil
+	default:
+		return fmt.Sprintf("There's more than one %s! Mention the one you mean, picking them from Zulip's suggestions.", name), nil
+	}
+}
+
+// findDiscoverable returns the discoverable subscribers in the realm who go by
+// the name, either in their record or in the RC directory.
+func findDiscoverable(subscribers []store.Recurser, profiles []recurse.Profile, realm, name string) []store.Recurser {
+	directoryIDs := make(map[int64]bool)
+	for _, profile := range profiles {
+		if profile.ZulipID != 0 && strings.EqualFold(profile.Name, name) {
+			directoryIDs[profile.ZulipID] = true
+		}
+	}
+
+	var found []store.Recurser
+	for _, rec := range subscribers {
+		if rec.Realm != realm || !rec.Discoverable {
+			continue
+		}
+		if strings.EqualFold(rec.Name, name) || directoryIDs[rec.ID] {
+			found = append(found, rec)
+		}
+	}
+	return found
+}
+
+// formatProfile shows the parts of a Recurser's record that they've shared:
+// their bio, their topics, and whether they're at RC right now.
+func formatProfile(rec *store.Recurser) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "@_**%s|%d**", rec.Name, rec.ID)
+	if rec.CurrentlyAtRC {
+		b.WriteString(" is at RC right now.")
+	} else {
+		b.WriteString(" isn't at RC right now.")
+	}
+
+	if rec.Bio == "" && len(rec.Topics) == 0 {
+		b.WriteString("\n\nThey haven't shared a bio or any topics yet.")
+		return b.String()
+	}
+	if rec.Bio != "" {
+		fmt.Fprintf(&b, "\n\n**Bio:** %s", rec.Bio)
+	}
+	if len(rec.Topics) > 0 {
+		fmt.Fprintf(&b, "\n\n**Topics:** %s", strings.Join(rec.Topics, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/recursecenter/pairing-bot/internal/assert"
+	"github.com/recursecenter/pairing-bot/internal/pbtest"
+	"github.com/recursecenter/pairing-bot/recurse"
+	"github.com/recursecenter/pairing-bot/store"
+)
+
+func Test_findDiscoverable(t *testing.T) {
+	subscribers := []store.Recurser{
+		{ID: 1, Name: "Ada Lovelace", Discoverable: true},
+		{ID: 2, Name: "Grace Hopper", Discoverable: false},
+		{ID: 3, Name: "Old Name", Discoverable: true},
+		{ID: 4, Name: "Ada Lovelace", Discoverable: true, Realm: "sister"},
+	}
+	profiles := []recurse.Profile{{Name: "New Name", ZulipID: 3}}
+
+	ids := func(found []store.Recurser) []int64 {
+		var ids []int64
+		for _, rec := range found {
+			ids = append(ids, rec.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, ids(findDiscoverable(subscribers, profiles, store.DefaultRealm, "ada lovelace")), []int64{1})
+	assert.Equal(t, ids(findDiscoverable(subscribers, profiles, "sister", "Ada Lovelace")), []int64{4})
+	assert.Equal(t, ids(findDiscoverable(subscribers, profiles, store.DefaultRealm, "New Name")), []int64{3})
+
+	t.Run("not discoverable", func(t *testing.T) {
+		assert.Equal(t, len(findDiscoverable(subscribers, profiles, store.DefaultRealm, "Grace Hopper")), 0)
+	})
+}
+
+func Test_formatProfile(t *testing.T) {
+	rec := &store.Recurser{ID: 1, Name: "Ada", Bio: "Engines", Topics: []string{"math", "poetry"}, CurrentlyAtRC: true}
+	assert.Equal(t, formatProfile(rec), "@_**Ada|1** is at RC right now.\n\n**Bio:** Engines\n\n**Topics:** math, poetry")
+
+	rec = &store.Recurser{ID: 2, Name: "Grace"}
+	assert.Equal(t, formatProfile(rec), "@_**Grace|2** isn't at RC right now.\n\nThey haven't shared a bio or any topics yet.")
+}
+
+func TestPairingLogic_Who(t *testing.T) {
+	ctx := context.Background()
+	pl := &PairingLogic{db: pbtest.FirestoreClient(t, ctx)}
+
+	for _, rec := range []*store.Recurser{
+		{ID: 1, Name: "Ada", Bio: "Engines", Schedule: store.DefaultSchedule(), Discoverable: true},
+		{ID: 2, Name: "Grace", Bio: "Compilers", Schedule: store.DefaultSchedule()},
+	} {
+		assert.NoError(t, store.Recursers(pl.db).Set(ctx, rec))
+	}
+	asker := &store.Recurser{ID: 3, Name: "Alan"}
+
+	for _, args := range [][]string{{"Ada"}, {"Ada", "1"}} {
+		msg, err := pl.dispatch(ctx, "who", args, asker)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, "@_**Ada|1** isn't at RC right now.\n\n**Bio:** Engines")
+	}
+
+	t.Run("not discoverable", func(t *testing.T) {
+		notFound := "I couldn't find a discoverable Recurser called Grace. People only show up here after they `set discoverable on`."
+		for _, args := range [][]string{{"Grace"}, {"Grace", "2"}} {
+			msg, err := pl.dispatch(ctx, "who", args, asker)
+			assert.NoError(t, err)
+			assert.Equal(t, msg, notFound)
+		}
+
+		// Not being subscribed at all looks the same, and so does a mention
+		// that doesn't have a real ID.
+		for _, id := range []string{"4", "abc"} {
+			msg, err := pl.dispatch(ctx, "who", []string{"Grace", id}, asker)
+			assert.NoError(t, err)
+			assert.Equal(t, msg, notFound)
+		}
+	})
+}
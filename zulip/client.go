@@ -11,6 +11,9 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
 )
 
 var defaultBaseURL *url.URL = must(url.Parse("https://recurse.zulipchat.com/api/v1/"))
@@ -29,14 +32,24 @@ type Client struct {
 	http        *http.Client
 	baseURL     *url.URL
 	credentials CredentialsFunc
+
+	limiter    *rate.Limiter
+	maxRetries int
 }
 
+// DefaultRateLimit is the default number of requests per second that a Client
+// will send. Zulip allows 200 requests per minute, so this leaves some room.
+const DefaultRateLimit = 3
+
 // NewClient creates a new Zulip API client.
 func NewClient(credentials CredentialsFunc, opts ...ClientOpt) (*Client, error) {
 	client := Client{
 		http:        http.DefaultClient,
 		baseURL:     defaultBaseURL,
 		credentials: credentials,
+
+		limiter:    rate.NewLimiter(DefaultRateLimit, 1),
+		maxRetries: 3,
 	}
 
 	for i, opt := range opts {
@@ -79,6 +92,14 @@ func (c *Client) SendUserMessage(ctx context.Context, userIDs []int64, message s
 	return c.postForm(ctx, endpoint, form)
 }
 
+// Ping checks that the Zulip API is up and accepts the client's credentials, by
+// fetching the bot's own profile. It doesn't send anything.
+//
+// https://zulip.com/api/get-own-user
+func (c *Client) Ping(ctx context.Context) error {
+	return c.send(ctx, http.MethodGet, c.baseURL.JoinPath("users", "me"), nil)
+}
+
 // recipients returns the string-array-of-strings required by the Zulip
 // messaging API.
 //
@@ -96,46 +117,89 @@ func recipients(userIDs []int64) string {
 // postForm sends the POST request with authorization and encoded form values.
 // This returns a non-nil error if the response status code indicates an error
 // (400 or higher) or if the request could not be sent.
+//
+// Requests are throttled by the client's rate limiter. If Zulip still says
+// we're sending too fast (429), the request is retried after a delay.
 func (c *Client) postForm(ctx context.Context, endpoint *url.URL, form url.Values) error {
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		endpoint.String(),
-		strings.NewReader(form.Encode()),
-	)
-	if err != nil {
-		return fmt.Errorf("build request: %w", err)
-	}
-
-	req.Header.Set("content-type", "application/x-www-form-urlencoded")
+	return c.send(ctx, http.MethodPost, endpoint, form)
+}
 
+// send is postForm for any method. Requests without a form have no body.
+func (c *Client) send(ctx context.Context, method string, endpoint *url.URL, form url.Values) error {
 	creds, err := c.credentials(ctx)
 	if err != nil {
 		return fmt.Errorf("fetch credentials: %w", err)
 	}
-	req.SetBasicAuth(creds.Username, creds.Password)
 
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("wait for rate limiter: %w", err)
+		}
 
-	// This read will consume the body...
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("read response body: %w", err)
-	}
+		var reqBody io.Reader
+		if form != nil {
+			reqBody = strings.NewReader(form.Encode())
+		}
+
+		req, err := http.NewRequestWithContext(
+			ctx,
+			method,
+			endpoint.String(),
+			reqBody,
+		)
+		if err != nil {
+			return fmt.Errorf("build request: %w", err)
+		}
+
+		if form != nil {
+			req.Header.Set("content-type", "application/x-www-form-urlencoded")
+		}
+		req.SetBasicAuth(creds.Username, creds.Password)
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return err
+		}
+
+		// This read will consume the body...
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("read response body: %w", err)
+		}
 
-	// ... so replace the content afterward.
-	resp.Body = io.NopCloser(bytes.NewReader(body))
+		// ... so replace the content afterward.
+		resp.Body = io.NopCloser(bytes.NewReader(body))
 
-	log.Printf("zulip response: %d %s\n", resp.StatusCode, string(body))
+		log.Printf("zulip response: %d %s\n", resp.StatusCode, string(body))
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < c.maxRetries {
+			delay := retryDelay(resp, attempt)
+			log.Printf("Rate limited by Zulip, retrying in %s", delay)
+
+			select {
+			case <-time.After(delay):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
 
-	if resp.StatusCode >= 400 {
-		return &ResponseError{resp}
+		if resp.StatusCode >= 400 {
+			return &ResponseError{resp}
+		}
+		return nil
+	}
+}
+
+// retryDelay returns how long to wait before retrying a rate-limited request.
+// This uses the Retry-After header (in seconds) if there is one, and otherwise
+// backs off exponentially starting at one second.
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	if seconds, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second))
 	}
-	return nil
+	return time.Second << attempt
 }
 
 // A ClientOpt is used to configure a Client.
@@ -174,6 +238,32 @@ func WithBaseURL(baseURL string) ClientOpt {
 	}
 }
 
+// WithRateLimit sets the maximum number of requests per second, sending at most
+// `burst` requests at once.
+//
+// The default is DefaultRateLimit requests per second, one at a time.
+func WithRateLimit(perSecond float64, burst int) ClientOpt {
+	return func(c *Client) error {
+		if perSecond <= 0 || burst < 1 {
+			return fmt.Errorf("invalid rate limit: %v per second with burst %d", perSecond, burst)
+		}
+
+		c.limiter = rate.NewLimiter(rate.Limit(perSecond), burst)
+		return nil
+	}
+}
+
+// WithMaxRetries sets how many times a rate-limited (429) request is retried
+// before giving up.
+//
+// The default value is 3.
+func WithMaxRetries(maxRetries int) ClientOpt {
+	return func(c *Client) error {
+		c.maxRetries = maxRetries
+		return nil
+	}
+}
+
 // ResponseError is the type of error returned when the response status
 // indicates an error (400 or greater).
 type ResponseError struct {
@@ -6,8 +6,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/recursecenter/pairing-bot/internal/assert"
 	"github.com/recursecenter/pairing-bot/zulip"
@@ -161,6 +163,29 @@ func TestClient_SendUserMessage(t *testing.T) {
 	srv.AssertRequestCount(1)
 }
 
+func TestClient_Ping(t *testing.T) {
+	srv := mockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodGet)
+		assert.Equal(t, r.URL.Path, "/users/me")
+
+		// Base64-encoding of "fake-username:fake-password"
+		authz := "Basic ZmFrZS11c2VybmFtZTpmYWtlLXBhc3N3b3Jk"
+		assert.Equal(t, r.Header.Get("Authorization"), authz)
+	})
+
+	client, err := zulip.NewClient(
+		zulip.StaticCredentials("fake-username", "fake-password"),
+		zulip.WithHTTP(srv.Client()),
+		zulip.WithBaseURL(srv.URL()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, client.Ping(context.Background()))
+	srv.AssertRequestCount(1)
+}
+
 func TestClient_zulip_errors(t *testing.T) {
 	srv := mockServer(t, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusTeapot)
@@ -196,3 +221,114 @@ func TestClient_zulip_errors(t *testing.T) {
 
 	srv.AssertRequestCount(1)
 }
+
+// fakeTransport returns the canned responses in order, one per request.
+type fakeTransport struct {
+	responses []*http.Response
+	requests  []*http.Request
+}
+
+func (f *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp := f.responses[len(f.requests)]
+	f.requests = append(f.requests, req)
+	return resp, nil
+}
+
+func fakeResponse(status int, header http.Header) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader("{}")),
+	}
+}
+
+func TestClient_rateLimitRetry(t *testing.T) {
+	transport := &fakeTransport{
+		responses: []*http.Response{
+			fakeResponse(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"0.01"}}),
+			fakeResponse(http.StatusOK, nil),
+		},
+	}
+
+	client, err := zulip.NewClient(
+		zulip.StaticCredentials("fake-username", "fake-password"),
+		zulip.WithHTTP(&http.Client{Transport: transport}),
+		zulip.WithBaseURL("https://zulip.example.net/api/v1/"),
+		zulip.WithRateLimit(1000, 1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.SendUserMessage(context.Background(), []int64{0, 1}, "Okay, go!")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The retry sends the same message again.
+	assert.Equal(t, len(transport.requests), 2)
+	for _, req := range transport.requests {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, string(body), "content=Okay%2C+go%21&to=%5B0%2C1%5D&type=private")
+	}
+}
+
+func TestClient_rateLimitGiveUp(t *testing.T) {
+	tooMany := func() *http.Response {
+		return fakeResponse(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"0"}})
+	}
+	transport := &fakeTransport{
+		responses: []*http.Response{tooMany(), tooMany(), tooMany()},
+	}
+
+	client, err := zulip.NewClient(
+		zulip.StaticCredentials("fake-username", "fake-password"),
+		zulip.WithHTTP(&http.Client{Transport: transport}),
+		zulip.WithBaseURL("https://zulip.example.net/api/v1/"),
+		zulip.WithRateLimit(1000, 1),
+		zulip.WithMaxRetries(2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.SendUserMessage(context.Background(), []int64{0, 1}, "Okay, go!")
+	if respErr, ok := assert.ErrorAs[*zulip.ResponseError](t, err); ok {
+		assert.Equal(t, respErr.Response.StatusCode, http.StatusTooManyRequests)
+	}
+	assert.Equal(t, len(transport.requests), 3)
+}
+
+func TestClient_rateLimit(t *testing.T) {
+	srv := mockServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	client, err := zulip.NewClient(
+		zulip.StaticCredentials("fake-username", "fake-password"),
+		zulip.WithHTTP(srv.Client()),
+		zulip.WithBaseURL(srv.URL()),
+		zulip.WithRateLimit(20, 1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// After the first request, each one waits for the next token.
+	start := time.Now()
+	for range 3 {
+		if err := client.SendUserMessage(context.Background(), []int64{0}, "hi"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests at 20 per second took only %s", elapsed)
+	}
+
+	srv.AssertRequestCount(3)
+}
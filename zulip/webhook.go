@@ -2,6 +2,7 @@ package zulip
 
 import (
 	"bytes"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -23,6 +24,7 @@ type Webhook struct {
 //
 // https://zulip.com/api/outgoing-webhooks#fields-documentation
 type Message struct {
+	ID               int64            `json:"id"`
 	DisplayRecipient DisplayRecipient `json:"display_recipient"`
 	SenderID         int64            `json:"sender_id"`
 	SenderEmail      string           `json:"sender_email"`
@@ -61,6 +63,12 @@ func (d *DisplayRecipient) UnmarshalJSON(b []byte) error {
 	return errors.New("invalid value for DisplayRecipient")
 }
 
+// IsGroupDM returns whether the message is a direct message between more than
+// two people, like the ones that match messages start.
+func (d DisplayRecipient) IsGroupDM() bool {
+	return len(d.Users) > 2
+}
+
 type User struct {
 	ID int64 `json:"id"`
 }
@@ -91,13 +99,16 @@ var ErrWebhookUnauthorized = errors.New("unauthorized interaction attempt")
 
 // ParseWebhook decodes a Webhook and validates that it came from Zulip by
 // comparing against the shared secret token.
+//
+// An empty token never matches, so a missing secret can't let in requests that
+// don't have one either.
 func ParseWebhook(r io.Reader, token string) (*Webhook, error) {
 	w := new(Webhook)
 	if err := json.NewDecoder(r).Decode(w); err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrWebhookParse, err)
 	}
 
-	if w.Token != token {
+	if token == "" || subtle.ConstantTimeCompare([]byte(w.Token), []byte(token)) != 1 {
 		return nil, ErrWebhookUnauthorized
 	}
 	return w, nil
@@ -28,6 +28,23 @@ func TestWebhook(t *testing.T) {
 				SenderFullName: "Your Name",
 			},
 		},
+		"testdata/webhook_group_direct_message.json": {
+			Data:    "skip tomorrow",
+			Token:   "fake-zulip-token",
+			Trigger: "direct_message",
+			Message: zulip.Message{
+				DisplayRecipient: zulip.DisplayRecipient{
+					Users: []zulip.User{
+						{ID: 1000},
+						{ID: 2000},
+						{ID: 3000},
+					},
+				},
+				SenderID:       1000,
+				SenderEmail:    "fake-1000@recurse.example.net",
+				SenderFullName: "Your Name",
+			},
+		},
 		"testdata/webhook_mention.json": {
 			Data:    "Try messaging @**Pairing Bot!** to join in!",
 			Token:   "fake-zulip-token",
@@ -44,6 +61,8 @@ func TestWebhook(t *testing.T) {
 	badHooks := map[string]error{
 		"testdata/webhook_bad_data.json":  zulip.ErrWebhookParse,
 		"testdata/webhook_bad_token.json": zulip.ErrWebhookUnauthorized,
+
+		"testdata/webhook_missing_token.json": zulip.ErrWebhookUnauthorized,
 	}
 
 	for path, expected := range goodHooks {
@@ -77,4 +96,31 @@ func TestWebhook(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("no expected token", func(t *testing.T) {
+		b, err := os.ReadFile("testdata/webhook_missing_token.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = zulip.ParseWebhook(bytes.NewReader(b), "")
+		if !errors.Is(err, zulip.ErrWebhookUnauthorized) {
+			t.Errorf("expected %v, got %v", zulip.ErrWebhookUnauthorized, err)
+		}
+	})
+}
+
+func TestDisplayRecipient_IsGroupDM(t *testing.T) {
+	for _, tc := range []struct {
+		Recipient zulip.DisplayRecipient
+		Expected  bool
+	}{
+		{zulip.DisplayRecipient{Stream: "pairing"}, false},
+		{zulip.DisplayRecipient{Users: []zulip.User{{ID: 1}, {ID: 2}}}, false},
+		{zulip.DisplayRecipient{Users: []zulip.User{{ID: 1}, {ID: 2}, {ID: 3}}}, true},
+	} {
+		if got := tc.Recipient.IsGroupDM(); got != tc.Expected {
+			t.Errorf("IsGroupDM() for %+v = %v, wanted %v", tc.Recipient, got, tc.Expected)
+		}
+	}
 }